@@ -850,6 +850,12 @@ func reportCrash(c context.Context, build *Build, req *dashapi.Crash) (*Bug, err
 	if err != nil {
 		return nil, fmt.Errorf("failed to find bug for the crash: %w", err)
 	}
+	if bug == nil {
+		bug, err = findBugForCrashStack(c, ns, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find bug by the crash stack: %w", err)
+		}
+	}
 	if bug == nil {
 		bug, err = createBugForCrash(c, ns, req)
 		if err != nil {
@@ -911,6 +917,10 @@ func reportCrash(c context.Context, build *Build, req *dashapi.Crash) (*Bug, err
 		if len(req.Report) != 0 {
 			bug.HasReport = true
 		}
+		if len(bug.Frames) == 0 && len(req.Frames) != 0 && !req.Corrupted && !req.Suppressed {
+			bug.Frames = req.Frames
+			bug.TopFrames = topFrames(req.Frames)
+		}
 		if calculateSubsystems {
 			bug.SetAutoSubsystems(c, newSubsystems, now, getNsConfig(c, ns).Subsystems.Revision)
 		}
@@ -988,6 +998,8 @@ func saveCrash(c context.Context, ns string, req *dashapi.Crash, bug *Bug, bugKe
 			GuiltyFiles: req.GuiltyFiles,
 		},
 	}
+	crash.SyzReliability = makeReproReliability(req.ReproSyzReliability)
+	crash.CReliability = makeReproReliability(req.ReproCReliability)
 	var err error
 	if crash.Log, err = putText(c, ns, textCrashLog, req.Log); err != nil {
 		return err
@@ -995,6 +1007,18 @@ func saveCrash(c context.Context, ns string, req *dashapi.Crash, bug *Bug, bugKe
 	if crash.Report, err = putText(c, ns, textCrashReport, req.Report); err != nil {
 		return err
 	}
+	if len(req.Secondary) != 0 {
+		var secondary []byte
+		for _, rep := range req.Secondary {
+			crash.SecondaryTitles = append(crash.SecondaryTitles, rep.Title)
+			secondary = append(secondary, rep.Title+"\n\n"...)
+			secondary = append(secondary, rep.Report...)
+			secondary = append(secondary, "\n\n"...)
+		}
+		if crash.SecondaryReport, err = putText(c, ns, textCrashReport, secondary); err != nil {
+			return err
+		}
+	}
 	if crash.ReproSyz, err = putText(c, ns, textReproSyz, req.ReproSyz); err != nil {
 		return err
 	}
@@ -1072,6 +1096,9 @@ func purgeOldCrashes(c context.Context, bug *Bug, bugKey *db.Key) {
 		if crash.Report != 0 {
 			toDelete = append(toDelete, db.NewKey(c, textCrashReport, "", crash.Report, nil))
 		}
+		if crash.SecondaryReport != 0 {
+			toDelete = append(toDelete, db.NewKey(c, textCrashReport, "", crash.SecondaryReport, nil))
+		}
 		if crash.ReproSyz != 0 {
 			toDelete = append(toDelete, db.NewKey(c, textReproSyz, "", crash.ReproSyz, nil))
 		}
@@ -1501,6 +1528,32 @@ func findBugForCrash(c context.Context, ns string, titles []string) (*Bug, error
 	return best, nil
 }
 
+// findBugForCrashStack returns an active bug with a stack trace similar to the crash stack,
+// if such merging is enabled for the namespace (see Config.StackMergeThreshold).
+func findBugForCrashStack(c context.Context, ns string, req *dashapi.Crash) (*Bug, error) {
+	threshold := getNsConfig(c, ns).StackMergeThreshold
+	if threshold == 0 || len(req.Frames) == 0 || req.Corrupted || req.Suppressed {
+		return nil, nil
+	}
+	similar, err := loadBugsWithSimilarStack(c, ns, req.Frames)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range similar {
+		if item.score < threshold {
+			break
+		}
+		if active, err := isActiveBug(c, item.bug); err != nil {
+			return nil, err
+		} else if !active {
+			continue
+		}
+		log.Infof(c, "merging %q into %q: stack similarity %.2f", req.Title, item.bug.Title, item.score)
+		return item.bug, nil
+	}
+	return nil, nil
+}
+
 func createBugForCrash(c context.Context, ns string, req *dashapi.Crash) (*Bug, error) {
 	// Datastore limits the number of entities involved in a transaction to 25, so it's possible
 	// to iterate over them all only up to some point.
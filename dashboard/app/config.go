@@ -16,6 +16,7 @@ import (
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/syzkaller/dashboard/dashapi"
 	"github.com/google/syzkaller/pkg/email"
+	"github.com/google/syzkaller/pkg/report/crash"
 	"github.com/google/syzkaller/pkg/subsystem"
 	"github.com/google/syzkaller/pkg/validator"
 	"github.com/google/syzkaller/pkg/vcs"
@@ -83,6 +84,10 @@ type Config struct {
 	// Unique string that allows to show "similar bugs" across different namespaces.
 	// Similar bugs are shown only across namespaces with the same value of SimilarityDomain.
 	SimilarityDomain string
+	// If set, a crash that does not match any existing bug by title is merged into an open bug
+	// with a similar stack trace if the crash.StackSimilarity score is at least this value.
+	// Bugs with similar stacks are shown on the bug page regardless of this setting.
+	StackMergeThreshold float64
 	// Per-namespace clients that act only on a particular namespace.
 	// The keys are client identities (names), the values are their passwords.
 	Clients map[string]string
@@ -558,6 +563,10 @@ func checkNamespace(ns string, cfg *Config, namespaces, clientNames map[string]b
 	if cfg.SimilarityDomain == "" {
 		cfg.SimilarityDomain = ns
 	}
+	if cfg.StackMergeThreshold != 0 &&
+		(cfg.StackMergeThreshold < crash.MinStackSimilarity || cfg.StackMergeThreshold > 1) {
+		panic(fmt.Sprintf("%v: StackMergeThreshold must be in [%v, 1] range", ns, crash.MinStackSimilarity))
+	}
 	checkClients(clientNames, cfg.Clients)
 	for name, mgr := range cfg.Managers {
 		checkManager(ns, name, mgr)
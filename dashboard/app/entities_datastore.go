@@ -7,12 +7,14 @@ import (
 	"context"
 	"fmt"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/google/syzkaller/dashboard/dashapi"
 	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/report/crash"
 	"github.com/google/syzkaller/pkg/subsystem"
 	db "google.golang.org/appengine/v2/datastore"
 )
@@ -131,6 +133,10 @@ type Bug struct {
 	// FixCandidateJob holds the key of the latest successful cross-tree fix bisection job.
 	FixCandidateJob string
 	ReproAttempts   []BugReproAttempt
+	// Stack trace of the first crash that had one (see dashapi.Crash.Frames).
+	Frames []string `datastore:",noindex"`
+	// A few top frames of Frames, used to look up bugs with similar stacks.
+	TopFrames []string
 }
 
 type BugTreeTestInfo struct {
@@ -358,11 +364,15 @@ type Crash struct {
 	Flags           int64               // properties of the Crash
 	Report          int64               // reference to CrashReport text entity
 	ReportElements  CrashReportElements // parsed parts of the crash report
+	SecondaryTitles []string            `datastore:",noindex"` // titles of reports that followed the main one
+	SecondaryReport int64               // reference to CrashReport text entity with the secondary reports
 	ReproOpts       []byte              `datastore:",noindex"`
 	ReproSyz        int64               // reference to ReproSyz text entity
 	ReproC          int64               // reference to ReproC text entity
 	ReproIsRevoked  bool                // the repro no longer triggers the bug on HEAD
 	ReproLog        int64               // reference to ReproLog text entity
+	SyzReliability  ReproReliability    // how often the syz reproducer triggers the bug
+	CReliability    ReproReliability    // how often the C reproducer triggers the bug
 	LastReproRetest time.Time           // the last time when the repro was re-checked
 	MachineInfo     int64               // Reference to MachineInfo text entity.
 	// Custom crash priority for reporting (greater values are higher priority).
@@ -373,6 +383,31 @@ type Crash struct {
 	AssetsLastCheck time.Time // the last time we checked the assets for deprecation
 }
 
+// ReproReliability is zero if the reliability was not measured.
+type ReproReliability struct {
+	Runs        int
+	Crashes     int
+	TimeToCrash time.Duration
+}
+
+func makeReproReliability(r *dashapi.ReproReliability) ReproReliability {
+	if r == nil {
+		return ReproReliability{}
+	}
+	return ReproReliability{
+		Runs:        r.Runs,
+		Crashes:     r.Crashes,
+		TimeToCrash: r.TimeToCrash,
+	}
+}
+
+func (r ReproReliability) String() string {
+	if r.Runs == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%v/%v", r.Crashes, r.Runs)
+}
+
 type CrashReportElements struct {
 	GuiltyFiles []string // guilty files as determined during the crash report parsing
 }
@@ -938,6 +973,53 @@ func loadSimilarBugs(c context.Context, bug *Bug) ([]*Bug, error) {
 	return ret, nil
 }
 
+// Number of top stack frames used to look up bugs with similar stacks (see Bug.TopFrames).
+const numTopFrames = 3
+
+func topFrames(frames []string) []string {
+	return frames[:min(len(frames), numTopFrames)]
+}
+
+type stackSimilarBug struct {
+	bug   *Bug
+	score float64 // see crash.StackSimilarity
+}
+
+// loadBugsWithSimilarStack returns bugs in the namespace with stacks similar to frames,
+// the most similar first.
+func loadBugsWithSimilarStack(c context.Context, ns string, frames []string) ([]*stackSimilarBug, error) {
+	dedup := make(map[string]bool)
+	var ret []*stackSimilarBug
+	for _, frame := range topFrames(frames) {
+		var bugs []*Bug
+		_, err := db.NewQuery("Bug").
+			Filter("Namespace=", ns).
+			Filter("TopFrames=", frame).
+			GetAll(c, &bugs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query bugs: %w", err)
+		}
+		for _, bug := range bugs {
+			if dedup[bug.keyHash(c)] {
+				continue
+			}
+			dedup[bug.keyHash(c)] = true
+			score := crash.StackSimilarity(frames, bug.Frames)
+			if score < crash.MinStackSimilarity {
+				continue
+			}
+			ret = append(ret, &stackSimilarBug{bug: bug, score: score})
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].score != ret[j].score {
+			return ret[i].score > ret[j].score
+		}
+		return ret[i].bug.Title < ret[j].bug.Title
+	})
+	return ret, nil
+}
+
 // Since these IDs appear in Reported-by tags in commit, we slightly limit their size.
 const reportingHashLen = 20
 
@@ -982,6 +982,7 @@ func handleRetestedRepro(c context.Context, now time.Time, job *Job, jobKey *db.
 
 func gatherCrashTitles(req *dashapi.JobDoneReq) []string {
 	ret := append([]string{}, req.CrashAltTitles...)
+	ret = append(ret, req.CrashSecondaryTitles...)
 	if req.CrashTitle != "" {
 		ret = append(ret, req.CrashTitle)
 	}
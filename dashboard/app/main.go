@@ -283,6 +283,23 @@ type uiReproAttempt struct {
 	LogLink string
 }
 
+// uiSimilarStacks shows the bug stack and stacks of bugs similar to it side by side.
+type uiSimilarStacks struct {
+	Bugs []*uiSimilarStackBug
+	Rows [][]uiStackFrame
+}
+
+type uiSimilarStackBug struct {
+	Title string
+	Link  string
+	Score float64
+}
+
+type uiStackFrame struct {
+	Name   string
+	Common bool // the frame is present both in the bug stack and in the similar bug stack
+}
+
 type uiBugPage struct {
 	Header          *uiHeader
 	Now             time.Time
@@ -309,6 +326,7 @@ const (
 	sectionDiscussionList = "discussion_list"
 	sectionTestResults    = "test_results"
 	sectionReproAttempts  = "repro_attempts"
+	sectionSimilarStacks  = "similar_stacks"
 )
 
 type uiCollapsible struct {
@@ -401,10 +419,14 @@ type uiCrash struct {
 	LogLink         string
 	LogHasStrace    bool
 	ReportLink      string
+	SecondaryLink   string
+	SecondaryTitles []string
 	ReproSyzLink    string
 	ReproCLink      string
 	ReproIsRevoked  bool
 	ReproLogLink    string
+	SyzReliability  string
+	CReliability    string
 	MachineInfoLink string
 	Assets          []*uiAsset
 	*uiBuild
@@ -1147,6 +1169,17 @@ func handleBug(c context.Context, w http.ResponseWriter, r *http.Request) error
 			Value: similar,
 		})
 	}
+	similarStacks, err := loadSimilarStacksUI(c, r, bug)
+	if err != nil {
+		return err
+	}
+	if similarStacks != nil {
+		sections = append(sections, &uiCollapsible{
+			Title: fmt.Sprintf("Bugs with similar stack traces (%d)", len(similarStacks.Bugs)),
+			Type:  sectionSimilarStacks,
+			Value: similarStacks,
+		})
+	}
 	causeBisections, err := queryBugJobs(c, bug, JobBisectCause)
 	if err != nil {
 		return fmt.Errorf("failed to load cause bisections: %w", err)
@@ -1871,6 +1904,61 @@ func loadSimilarBugsUI(c context.Context, r *http.Request, bug *Bug, state *Repo
 	return group, nil
 }
 
+// Stacks of that many most similar bugs are shown on the bug page.
+const maxSimilarStacks = 4
+
+func loadSimilarStacksUI(c context.Context, r *http.Request, bug *Bug) (*uiSimilarStacks, error) {
+	if len(bug.Frames) == 0 {
+		return nil, nil
+	}
+	similar, err := loadBugsWithSimilarStack(c, bug.Namespace, bug.Frames)
+	if err != nil {
+		return nil, err
+	}
+	accessLevel := accessLevel(c, r)
+	ret := &uiSimilarStacks{}
+	stacks := [][]string{bug.Frames}
+	for _, item := range similar {
+		if item.bug.keyHash(c) == bug.keyHash(c) || accessLevel < item.bug.sanitizeAccess(c, accessLevel) {
+			continue
+		}
+		ret.Bugs = append(ret.Bugs, &uiSimilarStackBug{
+			Title: item.bug.displayTitle(),
+			Link:  bugLink(item.bug.keyHash(c)),
+			Score: item.score,
+		})
+		stacks = append(stacks, item.bug.Frames)
+		if len(ret.Bugs) == maxSimilarStacks {
+			break
+		}
+	}
+	if len(ret.Bugs) == 0 {
+		return nil, nil
+	}
+	for i := 0; ; i++ {
+		row := make([]uiStackFrame, len(stacks))
+		empty := true
+		for col, stack := range stacks {
+			if i >= len(stack) {
+				continue
+			}
+			empty = false
+			row[col].Name = stack[i]
+			for other, stack1 := range stacks {
+				// The bug stack is compared with all the others, and the others only with the bug stack.
+				if other != col && (col == 0 || other == 0) && stringInList(stack1, stack[i]) {
+					row[col].Common = true
+				}
+			}
+		}
+		if empty {
+			break
+		}
+		ret.Rows = append(ret.Rows, row)
+	}
+	return ret, nil
+}
+
 func closedBugStatus(bug *Bug, bugReporting *BugReporting) string {
 	status := ""
 	switch bug.Status {
@@ -2079,10 +2167,14 @@ func makeUICrash(c context.Context, crash *Crash, build *Build) *uiCrash {
 		LogLink:         textLink(textCrashLog, crash.Log),
 		LogHasStrace:    dashapi.CrashFlags(crash.Flags)&dashapi.CrashUnderStrace > 0,
 		ReportLink:      textLink(textCrashReport, crash.Report),
+		SecondaryLink:   textLink(textCrashReport, crash.SecondaryReport),
+		SecondaryTitles: crash.SecondaryTitles,
 		ReproSyzLink:    textLink(textReproSyz, crash.ReproSyz),
 		ReproCLink:      textLink(textReproC, crash.ReproC),
 		ReproLogLink:    textLink(textReproLog, crash.ReproLog),
 		ReproIsRevoked:  crash.ReproIsRevoked,
+		SyzReliability:  crash.SyzReliability.String(),
+		CReliability:    crash.CReliability.String(),
 		MachineInfoLink: textLink(textMachineInfo, crash.MachineInfo),
 		Assets:          makeUIAssets(c, build, crash, true),
 	}
@@ -616,6 +616,61 @@ func TestAltTitles1(t *testing.T) {
 	c.expectEQ(rep.Log, crash2.Log)
 }
 
+func TestStackMerge(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+
+	crash1 := testCrash(build, 1)
+	crash1.Frames = []string{"foo", "bar", "baz", "sys_ioctl"}
+	c.client.ReportCrash(crash1)
+	rep := c.client.pollBug()
+	c.expectEQ(rep.Title, crash1.Title)
+
+	// A different title, but the same stack with an additional helper frame.
+	crash2 := testCrash(build, 2)
+	crash2.Frames = []string{"foo_helper", "foo", "bar", "baz", "sys_ioctl"}
+	c.client.ReportCrash(crash2)
+	c.client.pollBugs(0)
+
+	// Now the bug is found by the merged title.
+	crash3 := testCrash(build, 2)
+	c.client.ReportCrash(crash3)
+	c.client.pollBugs(0)
+
+	// The same caller, but a different crashing function.
+	crash4 := testCrash(build, 4)
+	crash4.Frames = []string{"qux", "bar", "baz", "sys_ioctl"}
+	c.client.ReportCrash(crash4)
+	rep = c.client.pollBug()
+	c.expectEQ(rep.Title, crash4.Title)
+}
+
+func TestSecondaryReports(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+
+	crash := testCrash(build, 1)
+	crash.Secondary = []dashapi.SecondaryReport{
+		{Title: "WARNING in foo", Report: []byte("WARNING report")},
+		{Title: "BUG in bar", Report: []byte("BUG report")},
+	}
+	c.client.ReportCrash(crash)
+	rep := c.client.pollBug()
+	c.expectEQ(rep.Title, crash.Title)
+
+	_, dbCrash, _ := c.loadBug(rep.ID)
+	c.expectEQ(dbCrash.SecondaryTitles, []string{"WARNING in foo", "BUG in bar"})
+	text, _, err := getText(c.ctx, textCrashReport, dbCrash.SecondaryReport)
+	c.expectOK(err)
+	c.expectEQ(string(text), "WARNING in foo\n\nWARNING report\n\nBUG in bar\n\nBUG report\n\n")
+}
+
 func TestAltTitles2(t *testing.T) {
 	c := NewCtx(t)
 	defer c.Close()
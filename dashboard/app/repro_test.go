@@ -516,3 +516,22 @@ func TestReproTask(t *testing.T) {
 	c.expectOK(err)
 	c.expectEQ(resp.CrashLog, []byte(nil))
 }
+
+func TestReproReliability(t *testing.T) {
+	c := NewCtx(t)
+	defer c.Close()
+
+	build := testBuild(1)
+	c.client.UploadBuild(build)
+
+	crash := testCrashWithRepro(build, 1)
+	crash.ReproSyzReliability = &dashapi.ReproReliability{Runs: 5, Crashes: 5, TimeToCrash: time.Minute}
+	crash.ReproCReliability = &dashapi.ReproReliability{Runs: 5, Crashes: 1, TimeToCrash: time.Hour}
+	c.client.ReportCrash(crash)
+	rep := c.client.pollBug()
+
+	_, dbCrash, _ := c.loadBug(rep.ID)
+	c.expectEQ(dbCrash.SyzReliability, ReproReliability{Runs: 5, Crashes: 5, TimeToCrash: time.Minute})
+	c.expectEQ(dbCrash.CReliability.String(), "1/5")
+	c.expectEQ(ReproReliability{}.String(), "")
+}
@@ -207,8 +207,10 @@ type JobDoneReq struct {
 	Log            []byte // bisection log
 	CrashTitle     string
 	CrashAltTitles []string
-	CrashLog       []byte
-	CrashReport    []byte
+	// Titles of the reports that followed the main one (see report.Report.Secondary).
+	CrashSecondaryTitles []string
+	CrashLog             []byte
+	CrashReport          []byte
 	// Bisection results:
 	// If there is 0 commits:
 	//  - still happens on HEAD for fix bisection
@@ -332,12 +334,30 @@ type Crash struct {
 	MachineInfo []byte
 	Assets      []NewAsset
 	GuiltyFiles []string
+	// Function names from the first stack trace in the report, used for deduplication by stack similarity.
+	Frames []string
+	// Other distinct reports that followed the main one in the log (likely its consequences).
+	Secondary []SecondaryReport
 	// The following is optional and is filled only after repro.
 	ReproOpts     []byte
 	ReproSyz      []byte
 	ReproC        []byte
 	ReproLog      []byte
 	OriginalTitle string // Title before we began bug reproduction.
+	// How often the reproducers trigger the bug (nil if not measured).
+	ReproSyzReliability *ReproReliability
+	ReproCReliability   *ReproReliability
+}
+
+type SecondaryReport struct {
+	Title  string
+	Report []byte
+}
+
+type ReproReliability struct {
+	Runs        int
+	Crashes     int
+	TimeToCrash time.Duration // average time to trigger the bug
 }
 
 type ReportCrashResp struct {
@@ -333,6 +333,18 @@ static int fault_injected(int fail_fd)
 #endif
 #endif
 
+#if !GOOS_linux
+#if SYZ_EXECUTOR || SYZ_PIN_CPU
+static void pin_cpu(int cpu)
+{
+}
+
+static void unpin_cpu()
+{
+}
+#endif
+#endif
+
 #if !GOOS_windows
 #if SYZ_EXECUTOR || SYZ_THREADED
 #include <errno.h>
@@ -4749,6 +4749,40 @@ static int inject_fault(int nth)
 }
 #endif
 
+#if SYZ_EXECUTOR || SYZ_PIN_CPU
+#include <sched.h>
+#include <stdbool.h>
+#include <stdbool.h>
+
+// Threads are reused for subsequent calls, so the original affinity is saved
+// by pin_cpu and must be restored with unpin_cpu after the call.
+static __thread cpu_set_t pinned_cpu_saved;
+static __thread bool pinned_cpu_active;
+
+static void pin_cpu(int cpu)
+{
+	// Pinning only makes races more likely, so it's best-effort:
+	// the CPU may be offline or not allowed by the cpuset.
+	long ncpu = sysconf(_SC_NPROCESSORS_ONLN);
+	if (ncpu <= 0)
+		return;
+	if (sched_getaffinity(0, sizeof(pinned_cpu_saved), &pinned_cpu_saved))
+		return;
+	cpu_set_t set;
+	CPU_ZERO(&set);
+	CPU_SET(cpu % ncpu, &set);
+	pinned_cpu_active = sched_setaffinity(0, sizeof(set), &set) == 0;
+}
+
+static void unpin_cpu()
+{
+	if (!pinned_cpu_active)
+		return;
+	pinned_cpu_active = false;
+	sched_setaffinity(0, sizeof(pinned_cpu_saved), &pinned_cpu_saved);
+}
+#endif
+
 #if SYZ_EXECUTOR
 static int fault_injected(int fail_fd)
 {
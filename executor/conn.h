@@ -13,15 +13,22 @@
 
 #include <vector>
 
+// Must match flatrpc.MinKeySize.
+constexpr size_t kMinKeySize = 16;
+
 // Connection represents a client TCP connection.
 // It connects to the given addr:port and allows to send/receive
 // flatbuffers-encoded messages.
+// If the pre-shared key is given, the connection is authenticated and encrypted
+// (see pkg/flatrpc/secure.go for the protocol description).
 class Connection
 {
 public:
-	Connection(const char* addr, const char* port)
-	    : fd_(Connect(addr, port))
+	Connection(const char* addr, const char* port, const std::vector<uint8>& key = {})
+	    : fd_(Connect(addr, port)), secure_(!key.empty())
 	{
+		if (secure_)
+			Handshake(key);
 	}
 
 	int FD() const
@@ -54,6 +61,144 @@ public:
 	}
 
 	void Send(const void* data, size_t size)
+	{
+		if (!secure_) {
+			SendRaw(data, size);
+			return;
+		}
+		const uint8* ptr = static_cast<const uint8*>(data);
+		while (size) {
+			uint32 n = std::min<size_t>(size, kMaxRecord);
+			send_buf_.resize(kRecordHdr + n + Sha256::kSize);
+			uint32 hdr = htole32(n);
+			memcpy(send_buf_.data(), &hdr, kRecordHdr);
+			memcpy(send_buf_.data() + kRecordHdr, ptr, n);
+			chacha20_xor(write_key_, RecordNonce(write_seq_).data(), send_buf_.data() + kRecordHdr, n);
+			RecordMAC(write_mac_, write_seq_, send_buf_.data(), kRecordHdr + n, send_buf_.data() + kRecordHdr + n);
+			write_seq_++;
+			// The whole record is sent at once, the manager side expects it.
+			SendRaw(send_buf_.data(), send_buf_.size());
+			ptr += n;
+			size -= n;
+		}
+	}
+
+private:
+	static constexpr size_t kRecordHdr = 4;
+	static constexpr size_t kMaxRecord = 1 << 20;
+	static constexpr size_t kNonceSize = 16;
+	static constexpr char kMagic[] = "SYZPSK01";
+
+	const int fd_;
+	const bool secure_;
+	std::vector<char> recv_buf_;
+	flatbuffers::FlatBufferBuilder fbb_;
+	uint8 write_key_[Sha256::kSize];
+	uint8 write_mac_[Sha256::kSize];
+	uint8 read_key_[Sha256::kSize];
+	uint8 read_mac_[Sha256::kSize];
+	uint64 write_seq_ = 0;
+	uint64 read_seq_ = 0;
+	std::vector<uint8> send_buf_;
+	// Decrypted, but not yet consumed data.
+	std::vector<uint8> plain_;
+	size_t plain_pos_ = 0;
+
+	void Handshake(const std::vector<uint8>& key)
+	{
+		uint8 client_nonce[kNonceSize];
+		RandomBytes(client_nonce, sizeof(client_nonce));
+		uint8 hello[sizeof(kMagic) - 1 + kNonceSize];
+		memcpy(hello, kMagic, sizeof(kMagic) - 1);
+		memcpy(hello + sizeof(kMagic) - 1, client_nonce, kNonceSize);
+		SendRaw(hello, sizeof(hello));
+		uint8 reply[kNonceSize + Sha256::kSize];
+		RecvRaw(reply, sizeof(reply));
+		const uint8* server_nonce = reply;
+		uint8 mac[Sha256::kSize];
+		auto derive = [&](const char* label, uint8* out) {
+			HmacSha256(key.data(), key.size())
+			    .Write(label, strlen(label))
+			    .Write(client_nonce, kNonceSize)
+			    .Write(server_nonce, kNonceSize)
+			    .Sum(out);
+		};
+		derive("syz server", mac);
+		// The manager must prove that it knows the key before we accept any requests from it.
+		if (!equal_macs(mac, reply + kNonceSize, sizeof(mac)))
+			fail("manager failed authentication with the pre-shared key");
+		derive("syz client", mac);
+		SendRaw(mac, sizeof(mac));
+		derive("c2s enc", write_key_);
+		derive("c2s mac", write_mac_);
+		derive("s2c enc", read_key_);
+		derive("s2c mac", read_mac_);
+	}
+
+	static void RandomBytes(void* data, size_t size)
+	{
+		int fd = open("/dev/urandom", O_RDONLY);
+		if (fd == -1)
+			fail("failed to open /dev/urandom");
+		if (read(fd, data, size) != static_cast<ssize_t>(size))
+			fail("failed to read /dev/urandom");
+		close(fd);
+	}
+
+	static std::array<uint8, 12> RecordNonce(uint64 seq)
+	{
+		std::array<uint8, 12> nonce = {};
+		for (int i = 0; i < 8; i++)
+			nonce[4 + i] = seq >> (8 * i);
+		return nonce;
+	}
+
+	static void RecordMAC(const uint8* key, uint64 seq, const uint8* data, size_t size, uint8* out)
+	{
+		uint64 seq_le = htole64(seq);
+		HmacSha256(key, Sha256::kSize).Write(&seq_le, sizeof(seq_le)).Write(data, size).Sum(out);
+	}
+
+	void RecvRecord()
+	{
+		uint32 size;
+		RecvRaw(&size, sizeof(size));
+		size = le32toh(size);
+		if (size == 0 || size > kMaxRecord)
+			failmsg("bad rpc record size", "size=%u", size);
+		plain_.resize(kRecordHdr + size + Sha256::kSize);
+		uint32 hdr = htole32(size);
+		memcpy(plain_.data(), &hdr, kRecordHdr);
+		RecvRaw(plain_.data() + kRecordHdr, size + Sha256::kSize);
+		uint8 mac[Sha256::kSize];
+		RecordMAC(read_mac_, read_seq_, plain_.data(), kRecordHdr + size, mac);
+		if (!equal_macs(mac, plain_.data() + kRecordHdr + size, sizeof(mac)))
+			fail("rpc record authentication failed");
+		chacha20_xor(read_key_, RecordNonce(read_seq_).data(), plain_.data() + kRecordHdr, size);
+		read_seq_++;
+		plain_.resize(kRecordHdr + size);
+		plain_pos_ = kRecordHdr;
+	}
+
+	// The manager sends every message in separate records, so once a message is received,
+	// there is no buffered plaintext left, and select on FD() correctly reports new messages.
+	void Recv(void* data, size_t size)
+	{
+		if (!secure_) {
+			RecvRaw(data, size);
+			return;
+		}
+		for (size_t recv = 0; recv < size;) {
+			if (plain_pos_ == plain_.size())
+				RecvRecord();
+			size_t n = std::min(size - recv, plain_.size() - plain_pos_);
+			memcpy(static_cast<char*>(data) + recv, plain_.data() + plain_pos_, n);
+			plain_pos_ += n;
+			recv += n;
+		}
+	}
+
+	void SendRaw(const void* data, size_t size)
 	{
 		for (size_t sent = 0; sent < size;) {
 			ssize_t n = write(fd_, static_cast<const char*>(data) + sent, size - sent);
@@ -71,12 +216,7 @@ public:
 		}
 	}
 
-private:
-	const int fd_;
-	std::vector<char> recv_buf_;
-	flatbuffers::FlatBufferBuilder fbb_;
-
-	void Recv(void* data, size_t size)
+	void RecvRaw(void* data, size_t size)
 	{
 		for (size_t recv = 0; recv < size;) {
 			ssize_t n = read(fd_, static_cast<char*>(data) + recv, size - recv);
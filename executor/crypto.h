@@ -0,0 +1,198 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Minimal SHA-256, HMAC-SHA256 and ChaCha20 implementations for the pre-shared key
+// connection to the manager (see pkg/flatrpc/secure.go). The executor is not linked
+// with any crypto libraries, and these are enough to implement the protocol.
+
+#include <string.h>
+
+class Sha256
+{
+public:
+	static constexpr size_t kSize = 32;
+	static constexpr size_t kBlockSize = 64;
+
+	Sha256()
+	{
+		static const uint32 init[8] = {0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+					       0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19};
+		memcpy(state_, init, sizeof(state_));
+	}
+
+	void Write(const void* data, size_t size)
+	{
+		const uint8* ptr = static_cast<const uint8*>(data);
+		total_ += size;
+		while (size) {
+			size_t n = std::min(size, kBlockSize - buffered_);
+			memcpy(block_ + buffered_, ptr, n);
+			buffered_ += n;
+			ptr += n;
+			size -= n;
+			if (buffered_ == kBlockSize) {
+				Block(block_);
+				buffered_ = 0;
+			}
+		}
+	}
+
+	void Sum(uint8* out)
+	{
+		uint64 bits = total_ * 8;
+		uint8 pad = 0x80;
+		Write(&pad, 1);
+		pad = 0;
+		while (buffered_ != kBlockSize - 8)
+			Write(&pad, 1);
+		uint8 len[8];
+		for (int i = 0; i < 8; i++)
+			len[i] = bits >> (56 - 8 * i);
+		Write(len, sizeof(len));
+		for (int i = 0; i < 8; i++) {
+			for (int j = 0; j < 4; j++)
+				out[4 * i + j] = state_[i] >> (24 - 8 * j);
+		}
+	}
+
+private:
+	uint32 state_[8];
+	uint8 block_[kBlockSize];
+	size_t buffered_ = 0;
+	uint64 total_ = 0;
+
+	static uint32 Rotr(uint32 v, int n)
+	{
+		return (v >> n) | (v << (32 - n));
+	}
+
+	void Block(const uint8* data)
+	{
+		static const uint32 k[64] = {
+		    0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+		    0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+		    0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+		    0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+		    0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+		    0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+		    0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+		    0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+		};
+		uint32 w[64];
+		for (int i = 0; i < 16; i++)
+			w[i] = (uint32)data[4 * i] << 24 | (uint32)data[4 * i + 1] << 16 |
+			       (uint32)data[4 * i + 2] << 8 | (uint32)data[4 * i + 3];
+		for (int i = 16; i < 64; i++) {
+			uint32 s0 = Rotr(w[i - 15], 7) ^ Rotr(w[i - 15], 18) ^ (w[i - 15] >> 3);
+			uint32 s1 = Rotr(w[i - 2], 17) ^ Rotr(w[i - 2], 19) ^ (w[i - 2] >> 10);
+			w[i] = w[i - 16] + s0 + w[i - 7] + s1;
+		}
+		uint32 v[8];
+		memcpy(v, state_, sizeof(v));
+		for (int i = 0; i < 64; i++) {
+			uint32 s1 = Rotr(v[4], 6) ^ Rotr(v[4], 11) ^ Rotr(v[4], 25);
+			uint32 ch = (v[4] & v[5]) ^ (~v[4] & v[6]);
+			uint32 t1 = v[7] + s1 + ch + k[i] + w[i];
+			uint32 s0 = Rotr(v[0], 2) ^ Rotr(v[0], 13) ^ Rotr(v[0], 22);
+			uint32 maj = (v[0] & v[1]) ^ (v[0] & v[2]) ^ (v[1] & v[2]);
+			uint32 t2 = s0 + maj;
+			memmove(v + 1, v, 7 * sizeof(v[0]));
+			v[4] += t1;
+			v[0] = t1 + t2;
+		}
+		for (int i = 0; i < 8; i++)
+			state_[i] += v[i];
+	}
+};
+
+class HmacSha256
+{
+public:
+	HmacSha256(const void* key, size_t size)
+	{
+		uint8 k[Sha256::kBlockSize] = {};
+		if (size > sizeof(k)) {
+			Sha256 h;
+			h.Write(key, size);
+			h.Sum(k);
+		} else {
+			memcpy(k, key, size);
+		}
+		uint8 pad[Sha256::kBlockSize];
+		for (size_t i = 0; i < sizeof(pad); i++)
+			pad[i] = k[i] ^ 0x36;
+		inner_.Write(pad, sizeof(pad));
+		for (size_t i = 0; i < sizeof(pad); i++)
+			pad[i] = k[i] ^ 0x5c;
+		outer_.Write(pad, sizeof(pad));
+	}
+
+	HmacSha256& Write(const void* data, size_t size)
+	{
+		inner_.Write(data, size);
+		return *this;
+	}
+
+	void Sum(uint8* out)
+	{
+		uint8 inner[Sha256::kSize];
+		inner_.Sum(inner);
+		outer_.Write(inner, sizeof(inner));
+		outer_.Sum(out);
+	}
+
+private:
+	Sha256 inner_;
+	Sha256 outer_;
+};
+
+// ChaCha20 as in RFC 8439 with 96-bit nonce and 32-bit block counter starting at 0.
+static void chacha20_xor(const uint8* key, const uint8* nonce, uint8* data, size_t size)
+{
+	auto load32 = [](const uint8* p) {
+		return (uint32)p[0] | (uint32)p[1] << 8 | (uint32)p[2] << 16 | (uint32)p[3] << 24;
+	};
+	uint32 input[16] = {0x61707865, 0x3320646e, 0x79622d32, 0x6b206574};
+	for (int i = 0; i < 8; i++)
+		input[4 + i] = load32(key + 4 * i);
+	input[12] = 0;
+	for (int i = 0; i < 3; i++)
+		input[13 + i] = load32(nonce + 4 * i);
+	auto rotl = [](uint32 v, int n) { return (v << n) | (v >> (32 - n)); };
+	for (size_t pos = 0; pos < size; pos += 64, input[12]++) {
+		uint32 x[16];
+		memcpy(x, input, sizeof(x));
+		for (int i = 0; i < 10; i++) {
+			static const int qr[8][4] = {{0, 4, 8, 12}, {1, 5, 9, 13}, {2, 6, 10, 14}, {3, 7, 11, 15},
+						     {0, 5, 10, 15}, {1, 6, 11, 12}, {2, 7, 8, 13}, {3, 4, 9, 14}};
+			for (const auto& q : qr) {
+				uint32 &a = x[q[0]], &b = x[q[1]], &c = x[q[2]], &d = x[q[3]];
+				a += b;
+				d = rotl(d ^ a, 16);
+				c += d;
+				b = rotl(b ^ c, 12);
+				a += b;
+				d = rotl(d ^ a, 8);
+				c += d;
+				b = rotl(b ^ c, 7);
+			}
+		}
+		uint8 stream[64];
+		for (int i = 0; i < 16; i++) {
+			uint32 v = x[i] + input[i];
+			for (int j = 0; j < 4; j++)
+				stream[4 * i + j] = v >> (8 * j);
+		}
+		for (size_t i = 0; i < 64 && pos + i < size; i++)
+			data[pos + i] ^= stream[i];
+	}
+}
+
+// Constant-time comparison to not leak the expected MAC.
+static bool equal_macs(const uint8* a, const uint8* b, size_t size)
+{
+	uint8 diff = 0;
+	for (size_t i = 0; i < size; i++)
+		diff |= a[i] ^ b[i];
+	return diff == 0;
+}
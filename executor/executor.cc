@@ -272,10 +272,17 @@ static bool flag_nic_vf;
 static bool flag_vhci_injection;
 static bool flag_wifi;
 static bool flag_delay_kcov_mmap;
+// If set, signal/cover are sent in the compact packed form (see write_packed).
+static bool flag_pack_cover;
+// Scratch buffers for write_packed. Output is written only by the main thread.
+static std::vector<uint64> packed_pcs;
+static std::vector<uint8> packed_data;
 
 static bool flag_collect_cover;
 static bool flag_collect_signal;
 static bool flag_dedup_cover;
+// If set, collect the additional feedback metrics (see feedback_read).
+static bool flag_feedback;
 static bool flag_threaded;
 
 // If true, then executor should write the comparisons data to fuzzer.
@@ -369,6 +376,8 @@ struct cover_t {
 	bool overflow;
 };
 
+const int kMaxFeedback = static_cast<int>(rpc::FeedbackKind::MAX) + 1;
+
 struct thread_t {
 	int id;
 	bool created;
@@ -387,6 +396,8 @@ struct thread_t {
 	bool fault_injected;
 	cover_t cov;
 	bool soft_fail_state;
+	bool has_feedback;
+	uint64 feedback[kMaxFeedback];
 };
 
 static thread_t threads[kMaxThreads];
@@ -415,6 +426,8 @@ struct handshake_req {
 	uint64 syscall_timeout_ms;
 	uint64 program_timeout_ms;
 	uint64 slowdown_scale;
+	bool pack_cover;
+	bool feedback;
 };
 
 struct execute_req {
@@ -480,6 +493,7 @@ static flatbuffers::span<uint8_t> finish_output(OutputData* output, int proc_id,
 						const std::vector<uint8_t>* process_output);
 static void parse_execute(const execute_req& req);
 static void parse_handshake(const handshake_req& req);
+static uint32 write_packed(flatbuffers::FlatBufferBuilder& fbb);
 
 #include "syscalls.h"
 
@@ -540,8 +554,17 @@ bool CoverAccessScope::used_;
 static feature_t features[] = {};
 #endif
 
+#if !SYZ_HAVE_FEEDBACK
+// Reads per-thread counters for the additional feedback metrics indexed by rpc::FeedbackKind.
+static bool feedback_read(uint64* counters)
+{
+	return false;
+}
+#endif
+
 #include "shmem.h"
 
+#include "crypto.h"
 #include "conn.h"
 #include "cover_filter.h"
 #include "files.h"
@@ -787,6 +810,8 @@ void parse_handshake(const handshake_req& req)
 	flag_wifi = (bool)(req.flags & rpc::ExecEnv::EnableWifi);
 	flag_delay_kcov_mmap = (bool)(req.flags & rpc::ExecEnv::DelayKcovMmap);
 	flag_nic_vf = (bool)(req.flags & rpc::ExecEnv::EnableNicVF);
+	flag_pack_cover = req.pack_cover;
+	flag_feedback = req.feedback;
 }
 
 void receive_execute()
@@ -1161,12 +1186,32 @@ thread_t* schedule_call(int call_index, int call_num, uint64 copyout_index, uint
 	return th;
 }
 
+// Writes packed_pcs as zigzag-encoded varint deltas between subsequent values (see flatrpc.PackPCs).
+// Subsequent PCs are usually close to each other, so this takes 1-3 bytes per PC instead of 8.
+static uint32 write_packed(flatbuffers::FlatBufferBuilder& fbb)
+{
+	packed_data.clear();
+	uint64 prev = 0;
+	for (uint64 pc : packed_pcs) {
+		uint64 delta = pc - prev;
+		prev = pc;
+		uint64 v = (delta << 1) ^ static_cast<uint64>(static_cast<int64_t>(delta) >> 63);
+		for (; v >= 0x80; v >>= 7)
+			packed_data.push_back(static_cast<uint8>(v) | 0x80);
+		packed_data.push_back(static_cast<uint8>(v));
+	}
+	return fbb.CreateVector(packed_data.data(), packed_data.size()).o;
+}
+
 template <typename cover_data_t>
 uint32 write_signal(flatbuffers::FlatBufferBuilder& fbb, int index, cover_t* cov, bool all)
 {
 	// Write out feedback signals.
 	// Currently it is code edges computed as xor of two subsequent basic block PCs.
-	fbb.StartVector(0, sizeof(uint64));
+	if (flag_pack_cover)
+		packed_pcs.clear();
+	else
+		fbb.StartVector(0, sizeof(uint64));
 	cover_data_t* cover_data = (cover_data_t*)(cov->data + cov->data_offset);
 	if ((char*)(cover_data + cov->size) > cov->data_end)
 		failmsg("too much cover", "cov=%u", cov->size);
@@ -1191,9 +1236,17 @@ uint32 write_signal(flatbuffers::FlatBufferBuilder& fbb, int index, cover_t* cov
 			continue;
 		if (!all && max_signal && max_signal->Contains(sig))
 			continue;
-		fbb.PushElement(uint64(sig));
+		if (flag_pack_cover)
+			packed_pcs.push_back(sig);
+		else
+			fbb.PushElement(uint64(sig));
 		nsig++;
 	}
+	if (flag_pack_cover) {
+		// Signal order does not matter, and sorted values have smaller deltas.
+		std::sort(packed_pcs.begin(), packed_pcs.end());
+		return write_packed(fbb);
+	}
 	return fbb.EndVector(nsig);
 }
 
@@ -1207,6 +1260,12 @@ uint32 write_cover(flatbuffers::FlatBufferBuilder& fbb, cover_t* cov)
 		std::sort(cover_data, end);
 		cover_size = std::unique(cover_data, end) - cover_data;
 	}
+	if (flag_pack_cover) {
+		packed_pcs.clear();
+		for (uint32 i = 0; i < cover_size; i++)
+			packed_pcs.push_back(uint64(cover_data[i] + cov->pc_offset));
+		return write_packed(fbb);
+	}
 	fbb.StartVector(cover_size, sizeof(uint64));
 	// Flatbuffer arrays are written backwards, so reverse the order on our side as well.
 	for (uint32 i = 0; i < cover_size; i++)
@@ -1313,7 +1372,7 @@ void copyout_call_results(thread_t* th)
 	}
 }
 
-void write_output(int index, cover_t* cov, rpc::CallFlag flags, uint32 error, bool all_signal)
+void write_output(int index, cover_t* cov, rpc::CallFlag flags, uint32 error, bool all_signal, const uint64* feedback)
 {
 	CoverAccessScope scope(cov);
 	auto& fbb = *output_builder;
@@ -1338,18 +1397,31 @@ void write_output(int index, cover_t* cov, rpc::CallFlag flags, uint32 error, bo
 				cover_off = write_cover<uint32>(fbb, cov);
 		}
 	}
+	uint32 feedback_off = 0;
+	if (feedback)
+		feedback_off = fbb.CreateVector(feedback, kMaxFeedback).o;
 
 	rpc::CallInfoRawBuilder builder(*output_builder);
 	if (cov->overflow)
 		flags |= rpc::CallFlag::CoverageOverflow;
 	builder.add_flags(flags);
 	builder.add_error(error);
-	if (signal_off)
-		builder.add_signal(signal_off);
-	if (cover_off)
-		builder.add_cover(cover_off);
+	if (signal_off) {
+		if (flag_pack_cover)
+			builder.add_packed_signal(signal_off);
+		else
+			builder.add_signal(signal_off);
+	}
+	if (cover_off) {
+		if (flag_pack_cover)
+			builder.add_packed_cover(cover_off);
+		else
+			builder.add_cover(cover_off);
+	}
 	if (comps_off)
 		builder.add_comps(comps_off);
+	if (feedback_off)
+		builder.add_feedback(feedback_off);
 	auto off = builder.Finish();
 	uint32 slot = output_data->completed.load(std::memory_order_relaxed);
 	if (slot >= kMaxCalls)
@@ -1376,7 +1448,7 @@ void write_call_output(thread_t* th, bool finished)
 			flags |= rpc::CallFlag::FaultInjected;
 	}
 	bool all_signal = th->call_index < 64 ? (all_call_signal & (1ull << th->call_index)) : false;
-	write_output(th->call_index, &th->cov, flags, reserrno, all_signal);
+	write_output(th->call_index, &th->cov, flags, reserrno, all_signal, th->has_feedback ? th->feedback : nullptr);
 }
 
 void write_extra_output()
@@ -1386,7 +1458,7 @@ void write_extra_output()
 	cover_collect(&extra_cov);
 	if (!extra_cov.size)
 		return;
-	write_output(-1, &extra_cov, rpc::CallFlag::NONE, 997, all_extra_signal);
+	write_output(-1, &extra_cov, rpc::CallFlag::NONE, 997, all_extra_signal, nullptr);
 	cover_reset(&extra_cov);
 }
 
@@ -1496,9 +1568,14 @@ void execute_call(thread_t* th)
 		fail_fd = inject_fault(th->call_props.fail_nth);
 		th->soft_fail_state = true;
 	}
+	if (th->call_props.pin_cpu > 0)
+		pin_cpu(th->call_props.pin_cpu - 1);
 
 	if (flag_coverage)
 		cover_reset(&th->cov);
+	uint64 feedback_start[kMaxFeedback];
+	bool collect_feedback = flag_feedback && flag_collect_signal && feedback_read(feedback_start);
+	th->has_feedback = false;
 	// For pseudo-syscalls and user-space functions NONFAILING can abort before assigning to th->res.
 	// Arrange for res = -1 and errno = EFAULT result for such case.
 	th->res = -1;
@@ -1510,6 +1587,11 @@ void execute_call(thread_t* th)
 		th->reserrno = EINVAL;
 	// Reset the flag before the first possible fail().
 	th->soft_fail_state = false;
+	if (collect_feedback && feedback_read(th->feedback)) {
+		for (int i = 0; i < kMaxFeedback; i++)
+			th->feedback[i] -= feedback_start[i];
+		th->has_feedback = true;
+	}
 
 	if (flag_coverage)
 		cover_collect(&th->cov);
@@ -1522,6 +1604,8 @@ void execute_call(thread_t* th)
 	// But let's still return res, errno and coverage from the first execution.
 	for (int i = 0; i < th->call_props.rerun; i++)
 		NONFAILING(execute_syscall(call, th->args));
+	if (th->call_props.pin_cpu > 0)
+		unpin_cpu();
 
 	debug("#%d [%llums] <- %s=0x%llx",
 	      th->id, current_time_ms() - start_time_ms, call->name, (uint64)th->res);
@@ -1533,6 +1617,8 @@ void execute_call(thread_t* th)
 		debug(" fault=%d", th->fault_injected);
 	if (th->call_props.rerun > 0)
 		debug(" rerun=%d", th->call_props.rerun);
+	if (th->call_props.pin_cpu > 0)
+		debug(" pin_cpu=%d", th->call_props.pin_cpu);
 	debug("\n");
 }
 
@@ -8,6 +8,7 @@
 #include <sys/ioctl.h>
 #include <sys/mman.h>
 #include <sys/prctl.h>
+#include <sys/resource.h>
 #include <sys/syscall.h>
 #include <unistd.h>
 
@@ -235,6 +236,17 @@ NORETURN void doexit_thread(int status)
 	}
 }
 
+#define SYZ_HAVE_FEEDBACK 1
+static bool feedback_read(uint64* counters)
+{
+	struct rusage ru;
+	if (getrusage(RUSAGE_THREAD, &ru))
+		return false;
+	counters[static_cast<int>(rpc::FeedbackKind::ContextSwitches)] = ru.ru_nvcsw;
+	counters[static_cast<int>(rpc::FeedbackKind::PageFaults)] = ru.ru_minflt + ru.ru_majflt;
+	return true;
+}
+
 #define SYZ_HAVE_KCSAN 1
 static void setup_kcsan_filter(const std::vector<std::string>& frames)
 {
@@ -82,14 +82,77 @@ private:
 	ProcIDPool& operator=(const ProcIDPool&) = delete;
 };
 
+// ResultBatch accumulates exec results and sends them to the host in a single message
+// to reduce per-message overhead when lots of small programs are executed.
+class ResultBatch
+{
+public:
+	ResultBatch(Connection& conn)
+	    : conn_(conn)
+	{
+	}
+
+	// Add takes a size-prefixed ExecutorMessageRaw with ExecResult produced by finish_output.
+	void Add(flatbuffers::span<uint8_t> data)
+	{
+		if (count_ == results_.size())
+			results_.emplace_back();
+		results_[count_++].assign(data.begin(), data.end());
+		size_ += data.size();
+		if (size_ >= kMaxSize)
+			Flush();
+	}
+
+	void Flush()
+	{
+		if (count_ == 1) {
+			// Don't bother wrapping a single result.
+			conn_.Send(results_[0].data(), results_[0].size());
+		} else if (count_ > 1) {
+			std::vector<flatbuffers::Offset<rpc::ExecResultDataRaw>> offsets;
+			for (size_t i = 0; i < count_; i++) {
+				// Batched results are not size-prefixed.
+				const auto& res = results_[i];
+				const size_t prefix = sizeof(flatbuffers::uoffset_t);
+				auto data = fbb_.CreateVector(res.data() + prefix, res.size() - prefix);
+				offsets.push_back(rpc::CreateExecResultDataRaw(fbb_, data));
+			}
+			auto batch = rpc::CreateExecResultBatchRawDirect(fbb_, &offsets);
+			auto msg = rpc::CreateExecutorMessageRaw(fbb_, rpc::ExecutorMessagesRaw::ExecResultBatch, batch.Union());
+			fbb_.FinishSizePrefixed(msg);
+			auto data = fbb_.GetBufferSpan();
+			debug("sending exec result batch: results=%zu size=%zu\n", count_, data.size());
+			conn_.Send(data.data(), data.size());
+			fbb_.Reset();
+		}
+		count_ = 0;
+		size_ = 0;
+	}
+
+private:
+	static constexpr size_t kMaxSize = 4 << 20;
+
+	Connection& conn_;
+	flatbuffers::FlatBufferBuilder fbb_;
+	// The first count_ elements are used, the rest are kept to reuse memory.
+	std::vector<std::vector<uint8_t>> results_;
+	size_t count_ = 0;
+	size_t size_ = 0;
+
+	ResultBatch(const ResultBatch&) = delete;
+	ResultBatch& operator=(const ResultBatch&) = delete;
+};
+
 // Proc represents one subprocess that runs tests (re-execed syz-executor with 'exec' argument).
 // The object is persistent and re-starts subprocess when it crashes.
 class Proc
 {
 public:
-	Proc(Connection& conn, const char* bin, ProcIDPool& proc_id_pool, int& restarting, const bool& corpus_triaged, int max_signal_fd, int cover_filter_fd,
-	     bool use_cover_edges, bool is_kernel_64_bit, uint32 slowdown, uint32 syscall_timeout_ms, uint32 program_timeout_ms)
+	Proc(Connection& conn, ResultBatch& results, const char* bin, ProcIDPool& proc_id_pool, int& restarting, const bool& corpus_triaged,
+	     int max_signal_fd, int cover_filter_fd, bool use_cover_edges, bool is_kernel_64_bit, uint32 slowdown, uint32 syscall_timeout_ms,
+	     uint32 program_timeout_ms, bool feedback)
 	    : conn_(conn),
+	      results_(results),
 	      bin_(bin),
 	      proc_id_pool_(proc_id_pool),
 	      id_(proc_id_pool.Alloc()),
@@ -102,6 +165,7 @@ public:
 	      slowdown_(slowdown),
 	      syscall_timeout_ms_(syscall_timeout_ms),
 	      program_timeout_ms_(program_timeout_ms),
+	      feedback_(feedback),
 	      req_shmem_(kMaxInput),
 	      resp_shmem_(kMaxOutput),
 	      resp_mem_(static_cast<OutputData*>(resp_shmem_.Mem()))
@@ -193,6 +257,7 @@ private:
 	};
 
 	Connection& conn_;
+	ResultBatch& results_;
 	const char* const bin_;
 	ProcIDPool& proc_id_pool_;
 	int id_;
@@ -205,6 +270,7 @@ private:
 	const uint32 slowdown_;
 	const uint32 syscall_timeout_ms_;
 	const uint32 program_timeout_ms_;
+	const bool feedback_;
 	State state_ = State::Started;
 	std::optional<Subprocess> process_;
 	ShmemFile req_shmem_;
@@ -365,6 +431,8 @@ private:
 		    .syscall_timeout_ms = syscall_timeout_ms_,
 		    .program_timeout_ms = ProgramTimeoutMs(),
 		    .slowdown_scale = slowdown_,
+		    .pack_cover = true,
+		    .feedback = feedback_,
 		};
 		if (write(req_pipe_, &req, sizeof(req)) != sizeof(req)) {
 			debug("request pipe write failed (errno=%d)\n", errno);
@@ -445,7 +513,7 @@ private:
 		if (msg_->type == rpc::RequestType::Program)
 			num_calls = read_input(&prog_data);
 		auto data = finish_output(resp_mem_, id_, msg_->id, num_calls, elapsed, freshness_++, status, hanged, output);
-		conn_.Send(data.data(), data.size());
+		results_.Add(data);
 
 		resp_mem_->Reset();
 		msg_.reset();
@@ -537,16 +605,17 @@ class Runner
 public:
 	Runner(Connection& conn, int vm_index, const char* bin)
 	    : conn_(conn),
-	      vm_index_(vm_index)
+	      vm_index_(vm_index),
+	      results_(conn)
 	{
 		int num_procs = Handshake();
 		proc_id_pool_.emplace(num_procs);
 		int max_signal_fd = max_signal_ ? max_signal_->FD() : -1;
 		int cover_filter_fd = cover_filter_ ? cover_filter_->FD() : -1;
 		for (int i = 0; i < num_procs; i++)
-			procs_.emplace_back(new Proc(conn, bin, *proc_id_pool_, restarting_, corpus_triaged_,
+			procs_.emplace_back(new Proc(conn, results_, bin, *proc_id_pool_, restarting_, corpus_triaged_,
 						     max_signal_fd, cover_filter_fd, use_cover_edges_, is_kernel_64_bit_, slowdown_,
-						     syscall_timeout_ms_, program_timeout_ms_));
+						     syscall_timeout_ms_, program_timeout_ms_, feedback_));
 
 		for (;;)
 			Loop();
@@ -555,6 +624,7 @@ public:
 private:
 	Connection& conn_;
 	const int vm_index_;
+	ResultBatch results_;
 	std::optional<CoverFilter> max_signal_;
 	std::optional<CoverFilter> cover_filter_;
 	std::optional<ProcIDPool> proc_id_pool_;
@@ -568,6 +638,7 @@ private:
 	uint32 slowdown_ = 0;
 	uint32 syscall_timeout_ms_ = 0;
 	uint32 program_timeout_ms_ = 0;
+	bool feedback_ = false;
 
 	friend std::ostream& operator<<(std::ostream& ss, const Runner& runner)
 	{
@@ -581,6 +652,7 @@ private:
 		   << " slowdown=" << runner.slowdown_
 		   << " syscall_timeout_ms=" << runner.syscall_timeout_ms_
 		   << " program_timeout_ms=" << runner.program_timeout_ms_
+		   << " feedback=" << runner.feedback_
 		   << "\n";
 		ss << "procs:\n";
 		for (const auto& proc : runner.procs_)
@@ -607,6 +679,8 @@ private:
 			conn_.Recv(raw);
 			if (auto* msg = raw.msg.AsExecRequest())
 				Handle(*msg);
+			else if (auto* msg = raw.msg.AsExecRequestBatch())
+				Handle(*msg);
 			else if (auto* msg = raw.msg.AsSignalUpdate())
 				Handle(*msg);
 			else if (auto* msg = raw.msg.AsCorpusTriaged())
@@ -625,6 +699,9 @@ private:
 			}
 		}
 
+		// Send all results produced during this iteration.
+		results_.Flush();
+
 		if (restarting_ < 0 || restarting_ > static_cast<int>(procs_.size()))
 			failmsg("bad restarting", "restarting=%d", restarting_);
 	}
@@ -653,6 +730,7 @@ private:
 		slowdown_ = conn_reply.slowdown;
 		syscall_timeout_ms_ = conn_reply.syscall_timeout_ms;
 		program_timeout_ms_ = conn_reply.program_timeout_ms;
+		feedback_ = conn_reply.feedback;
 		if (conn_reply.cover)
 			max_signal_.emplace();
 
@@ -732,6 +810,16 @@ private:
 		requests_.push_back(std::move(msg));
 	}
 
+	void Handle(rpc::ExecRequestBatchRawT& msg)
+	{
+		debug("recv exec request batch: requests=%zu\n", msg.requests.size());
+		for (auto& req : msg.requests) {
+			if (!req)
+				fail("missing request in exec request batch");
+			Handle(*req);
+		}
+	}
+
 	void Handle(const rpc::SignalUpdateRawT& msg)
 	{
 		debug("recv signal update: new=%zu\n", msg.new_max.size());
@@ -754,6 +842,7 @@ private:
 		std::ostringstream ss;
 		ss << *this;
 		const std::string& str = ss.str();
+		results_.Flush();
 		rpc::StateResultRawT res;
 		res.data.insert(res.data.begin(), str.data(), str.data() + str.size());
 		rpc::ExecutorMessageRawT raw;
@@ -763,6 +852,8 @@ private:
 
 	void ExecuteBinary(rpc::ExecRequestRawT& msg)
 	{
+		// Binary requests block everything for a while, so send pending results first.
+		results_.Flush();
 		rpc::ExecutingMessageRawT exec;
 		exec.id = msg.id;
 		rpc::ExecutorMessageRawT raw;
@@ -886,14 +977,24 @@ static void FatalHandler(int sig, siginfo_t* info, void* ucontext)
 
 static void runner(char** argv, int argc)
 {
-	if (argc != 5)
-		fail("usage: syz-executor runner <index> <manager-addr> <manager-port>");
+	if (argc != 5 && argc != 6)
+		fail("usage: syz-executor runner <index> <manager-addr> <manager-port> [<key-file>]");
 	char* endptr = nullptr;
 	int vm_index = strtol(argv[2], &endptr, 10);
 	if (vm_index < 0 || *endptr != 0)
 		failmsg("failed to parse VM index", "str='%s'", argv[2]);
 	const char* const manager_addr = argv[3];
 	const char* const manager_port = argv[4];
+	std::vector<uint8> key;
+	if (argc == 6) {
+		auto file = ReadFile(argv[5]);
+		if (!file->error.empty() || file->data.size() < kMinKeySize)
+			failmsg("failed to read the pre-shared key", "file=%s error=%s size=%zu",
+				argv[5], file->error.c_str(), file->data.size());
+		key = std::move(file->data);
+		// Test programs should not be able to read the key.
+		unlink(argv[5]);
+	}
 
 	struct rlimit rlim;
 	rlim.rlim_cur = rlim.rlim_max = kFdLimit;
@@ -920,7 +1021,7 @@ static void runner(char** argv, int argc)
 			failmsg("sigaction failed", "sig=%d", sig);
 	}
 
-	Connection conn(manager_addr, manager_port);
+	Connection conn(manager_addr, manager_port, key);
 
 	// This is required to make Subprocess fd remapping logic work.
 	// kCoverFilterFd is the largest fd we set in the child processes.
@@ -22,8 +22,9 @@
 // To reduce size of the snapshot it's recommended to use smaller kernel and setup fewer devices.
 // For example the following cmdline arguments:
 // "loop.max_loop=1 dummy_hcd.num=1 vivid.n_devs=2 vivid.multiplanar=1,2 netrom.nr_ndevs=1 rose.rose_ndevs=1"
-// and CONFIG_USBIP_VHCI_NR_HCS=1 help to reduce snapshot by about 20 MB. Note: we have only 1 proc
-// in snapshot mode, so we don't need lots of devices. However, our descriptions rely on vivid.n_devs=16
+// and CONFIG_USBIP_VHCI_NR_HCS=1 help to reduce snapshot by about 20 MB. Note: we have only few procs
+// in snapshot mode (see snapshot_procs config), so we don't need lots of devices (but loop.max_loop
+// needs to be at least the number of procs). However, our descriptions rely on vivid.n_devs=16
 // since they hardcode names like /dev/video36 which follow after these 16 pre-created devices.
 //
 // Additionally we could try to use executor as init process, this should remove dhcpd/sshd/udevd/klogd/etc.
@@ -32,10 +33,39 @@
 static struct {
 	// Ivshmem interrupt doorbell register.
 	volatile uint32* doorbell;
+	// Global header used for host/target synchronization.
 	volatile rpc::SnapshotHeaderT* hdr;
+	// Header of the output area of this proc.
+	volatile rpc::SnapshotHeaderT* proc_hdr;
 	void* input;
+	char* output;
+	int procs;
 } ivs;
 
+// Snapshot shared memory layout, see flatrpc.SnapshotInputSlot and flatrpc.SnapshotOutputArea for details.
+constexpr size_t kSnapshotOutputRegionSize = static_cast<uint64>(rpc::Const::SnapshotShmemSize) -
+					     static_cast<uint64>(rpc::Const::MaxInputSize);
+constexpr size_t kSnapshotGlobalHeaderSize = 4 << 10;
+
+static size_t SnapshotInputSlot(int proc, size_t* size)
+{
+	*size = static_cast<uint64>(rpc::Const::MaxInputSize) / ivs.procs & ~size_t(7);
+	return proc * *size;
+}
+
+static size_t SnapshotOutputArea(int proc, size_t* size)
+{
+	*size = (kSnapshotOutputRegionSize - kSnapshotGlobalHeaderSize) / ivs.procs & ~size_t((4 << 10) - 1);
+	*size = std::min<size_t>(*size, static_cast<uint64>(rpc::Const::MaxOutputSize));
+	return kSnapshotGlobalHeaderSize + proc * *size;
+}
+
+static volatile rpc::SnapshotHeaderT* SnapshotProcHeader(int proc)
+{
+	size_t size = 0;
+	return reinterpret_cast<volatile rpc::SnapshotHeaderT*>(ivs.output + SnapshotOutputArea(proc, &size));
+}
+
 // Finds qemu ivshmem device, see:
 // https://www.qemu.org/docs/master/specs/ivshmem-spec.html
 static void FindIvshmemDevices()
@@ -78,7 +108,7 @@ static void FindIvshmemDevices()
 		} else if (statbuf.st_size == static_cast<uint64>(rpc::Const::SnapshotShmemSize)) {
 			input = mmap(nullptr, static_cast<uint64>(rpc::Const::MaxInputSize),
 				     PROT_READ, MAP_SHARED, res2, 0);
-			output = mmap(nullptr, static_cast<uint64>(rpc::Const::MaxOutputSize),
+			output = mmap(nullptr, kSnapshotOutputRegionSize,
 				      PROT_READ | PROT_WRITE, MAP_SHARED, res2,
 				      static_cast<uint64>(rpc::Const::MaxInputSize));
 			if (input == MAP_FAILED || output == MAP_FAILED)
@@ -86,9 +116,9 @@ static void FindIvshmemDevices()
 			debug("mapped shmem input at at %p/%llu\n",
 			      input, static_cast<uint64>(rpc::Const::MaxInputSize));
 			debug("mapped shmem output at at %p/%llu\n",
-			      output, static_cast<uint64>(rpc::Const::MaxOutputSize));
+			      output, static_cast<uint64>(kSnapshotOutputRegionSize));
 #if GOOS_linux
-			if (pkeys_enabled && pkey_mprotect(output, static_cast<uint64>(rpc::Const::MaxOutputSize),
+			if (pkeys_enabled && pkey_mprotect(output, kSnapshotOutputRegionSize,
 							   PROT_READ | PROT_WRITE, RESERVED_PKEY))
 				exitf("failed to pkey_mprotect output buffer");
 #endif
@@ -101,8 +131,19 @@ static void FindIvshmemDevices()
 	ivs.doorbell = static_cast<uint32*>(regs) + 3;
 	ivs.hdr = static_cast<rpc::SnapshotHeaderT*>(output);
 	ivs.input = input;
-	output_data = reinterpret_cast<OutputData*>(static_cast<char*>(output) + sizeof(rpc::SnapshotHeaderT));
-	output_size = static_cast<uint64>(rpc::Const::MaxOutputSize) - sizeof(rpc::SnapshotHeaderT);
+	ivs.output = static_cast<char*>(output);
+}
+
+// SnapshotSetupProc sets up input/output of the proc, the output area of the proc is laid out the same way
+// as the whole output region in the non-snapshot mode, but starts with the proc header.
+static void SnapshotSetupProc(int proc)
+{
+	procid = proc;
+	size_t size = 0;
+	size_t offset = SnapshotOutputArea(proc, &size);
+	ivs.proc_hdr = SnapshotProcHeader(proc);
+	output_data = reinterpret_cast<OutputData*>(ivs.output + offset + sizeof(rpc::SnapshotHeaderT));
+	output_size = size - sizeof(rpc::SnapshotHeaderT);
 }
 
 static void SnapshotSetup(char** argv, int argc)
@@ -133,6 +174,9 @@ static void SnapshotSetup(char** argv, int argc)
 	    .slowdown_scale = static_cast<uint64>(msg->slowdown()),
 	};
 	parse_handshake(req);
+	ivs.procs = msg->procs() ? msg->procs() : 1;
+	if (ivs.procs < 0 || ivs.procs > static_cast<int>(rpc::Const::MaxSnapshotProcs))
+		failmsg("bad snapshot procs", "procs=%d", ivs.procs);
 #if SYZ_HAVE_FEATURES
 	setup_sysctl();
 	setup_cgroups();
@@ -149,6 +193,20 @@ static void SnapshotSetup(char** argv, int argc)
 		if (reason)
 			failmsg("feature setup failed", "reason: %s", reason);
 	}
+	// Fork the rest of the procs after the global setup, each proc then continues
+	// with its own sandbox and fork server, just like a separate executor process.
+	int proc = 0;
+	for (int i = 1; i < ivs.procs; i++) {
+		int pid = fork();
+		if (pid < 0)
+			fail("snapshot proc fork failed");
+		if (pid == 0) {
+			proc = i;
+			use_temporary_dir();
+			break;
+		}
+	}
+	SnapshotSetupProc(proc);
 }
 
 constexpr size_t kOutputPopulate = 256 << 10;
@@ -158,6 +216,15 @@ constexpr size_t kDataPopulate = 8 << 10;
 constexpr size_t kCoveragePopulate = 64 << 10;
 constexpr size_t kThreadsPopulate = 2;
 
+// SnapshotWaitProcs waits until all procs leave the given state.
+static void SnapshotWaitProcs(rpc::SnapshotState state)
+{
+	for (int proc = 0; proc < ivs.procs; proc++) {
+		while (SnapshotProcHeader(proc)->state == state)
+			sleep_ms(1);
+	}
+}
+
 static void SnapshotSetState(rpc::SnapshotState state)
 {
 	debug("changing stapshot state %s -> %s\n",
@@ -223,25 +290,40 @@ static void SnapshotStart()
 	// Wait for the parent process to prefault as well.
 	while (!output_data->completed)
 		sleep_ms(1);
-	// Notify host that we are ready to be snapshotted.
-	SnapshotSetState(rpc::SnapshotState::Ready);
+	// Notify host that we are ready to be snapshotted once all procs are ready.
+	std::atomic_signal_fence(std::memory_order_seq_cst);
+	ivs.proc_hdr->state = rpc::SnapshotState::Ready;
+	if (procid == 0) {
+		for (int proc = 0; proc < ivs.procs; proc++) {
+			while (SnapshotProcHeader(proc)->state != rpc::SnapshotState::Ready)
+				sleep_ms(1);
+		}
+		SnapshotSetState(rpc::SnapshotState::Ready);
+	}
 	// Snapshot is restored here.
 	// First time we may loop here while the snapshot is taken,
 	// but afterwards we should be restored when the state is already Execute.
 	// Note: we don't use sleep in the loop because we may be snapshotted while in the sleep syscall.
 	// As the result each execution after snapshot restore will be slower as it will need to finish
 	// the sleep and return from the syscall.
-	while (ivs.hdr->state == rpc::SnapshotState::Ready)
+	while (ivs.hdr->state == rpc::SnapshotState::Handshake || ivs.hdr->state == rpc::SnapshotState::Ready)
 		;
 	if (ivs.hdr->state == rpc::SnapshotState::Snapshotted) {
 		// First time around, just acknowledge and wait for snapshot restart.
-		SnapshotSetState(rpc::SnapshotState::Executed);
+		if (procid == 0)
+			SnapshotSetState(rpc::SnapshotState::Executed);
 		for (;;)
 			sleep(1000);
 	}
 	// Resumed for program execution.
 	output_data->Reset();
-	auto msg = flatbuffers::GetRoot<rpc::SnapshotRequest>(ivs.input);
+	size_t slot_size = 0;
+	size_t slot_offset = SnapshotInputSlot(procid, &slot_size);
+	auto msg = flatbuffers::GetRoot<rpc::SnapshotRequest>(static_cast<char*>(ivs.input) + slot_offset);
+	if (!msg->prog_data()) {
+		// The slot is unused in this execution.
+		doexit(0);
+	}
 	execute_req req = {
 	    .magic = kInMagic,
 	    .id = 0,
@@ -260,10 +342,16 @@ NORETURN static void SnapshotDone(bool failed)
 	debug("SnapshotDone\n");
 	CoverAccessScope scope(nullptr);
 	uint32 num_calls = output_data->num_calls.load(std::memory_order_relaxed);
-	auto data = finish_output(output_data, 0, 0, num_calls, 0, 0, failed ? kFailStatus : 0, false, nullptr);
-	ivs.hdr->output_offset = data.data() - reinterpret_cast<volatile uint8_t*>(ivs.hdr);
-	ivs.hdr->output_size = data.size();
-	SnapshotSetState(failed ? rpc::SnapshotState::Failed : rpc::SnapshotState::Executed);
+	auto data = finish_output(output_data, procid, 0, num_calls, 0, 0, failed ? kFailStatus : 0, false, nullptr);
+	ivs.proc_hdr->output_offset = data.data() - reinterpret_cast<volatile uint8_t*>(ivs.hdr);
+	ivs.proc_hdr->output_size = data.size();
+	std::atomic_signal_fence(std::memory_order_seq_cst);
+	ivs.proc_hdr->state = failed ? rpc::SnapshotState::Failed : rpc::SnapshotState::Executed;
+	// Proc 0 notifies host once all procs have finished.
+	if (procid == 0) {
+		SnapshotWaitProcs(rpc::SnapshotState::Execute);
+		SnapshotSetState(rpc::SnapshotState::Executed);
+	}
 	// Wait to be restarted from the snapshot.
 	for (;;)
 		sleep(1000);
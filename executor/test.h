@@ -365,6 +365,57 @@ static int test_glob()
 	return 0;
 }
 
+static bool check_hex(const char* what, const uint8* data, size_t size, const char* want)
+{
+	char got[1024] = {};
+	for (size_t i = 0; i < size && 2 * i + 2 < sizeof(got); i++)
+		snprintf(got + 2 * i, 3, "%02x", data[i]);
+	if (!strcmp(got, want))
+		return true;
+	printf("%s mismatch\nwant: %s\ngot:  %s\n", what, want, got);
+	return false;
+}
+
+static int test_crypto()
+{
+	uint8 sum[Sha256::kSize];
+	Sha256 sha;
+	sha.Write("abc", 3);
+	sha.Sum(sum);
+	if (!check_hex("sha256", sum, sizeof(sum), "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"))
+		return 1;
+	// RFC 4231 test case 2.
+	HmacSha256("Jefe", 4).Write("what do ya want ", 16).Write("for nothing?", 12).Sum(sum);
+	if (!check_hex("hmac-sha256", sum, sizeof(sum), "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"))
+		return 1;
+	// RFC 8439 section 2.4.2 test vector, but with the initial block counter 0.
+	uint8 key[32];
+	for (size_t i = 0; i < sizeof(key); i++)
+		key[i] = i;
+	const uint8 nonce[12] = {0, 0, 0, 0x9, 0, 0, 0, 0x4a, 0, 0, 0, 0};
+	char data[] = "Ladies and Gentlemen of the class of '99: If I could offer you only one tip "
+		      "for the future, sunscreen would be it.";
+	chacha20_xor(key, nonce, reinterpret_cast<uint8*>(data), sizeof(data) - 1);
+	if (!check_hex("chacha20", reinterpret_cast<uint8*>(data), sizeof(data) - 1,
+		       "c6bdf594fa87d094756b8d179a7ba25b816398cc26a334e7f7cf2720335074f1beb85c505d2d6dec471cd7ffaf00"
+		       "2e85f3d6207bd9865fc130f6e554067f15bb7e9d9ec4be553c352466ad3fc54f03e4b3b991e755b51c76764786ba"
+		       "b0a1023db1f0012369bfdd6661aeb325bbee22cbc13c"))
+		return 1;
+	return 0;
+}
+
+static int test_pack_cover()
+{
+	// Must match flatrpc.PackPCs.
+	packed_pcs = {0xffffffff81000000, 0xffffffff81000010, 0xffffffff81000008, 0};
+	flatbuffers::FlatBufferBuilder fbb;
+	fbb.Finish(flatbuffers::Offset<flatbuffers::Vector<uint8_t>>(write_packed(fbb)));
+	auto vec = flatbuffers::GetRoot<flatbuffers::Vector<uint8_t>>(fbb.GetBufferPointer());
+	if (!check_hex("packed cover", vec->data(), vec->size(), "ffffffef0f200ff0ffffef0f"))
+		return 1;
+	return 0;
+}
+
 static struct {
 	const char* name;
 	int (*f)();
@@ -380,6 +431,8 @@ static struct {
 #endif
     {"test_cover_filter", test_cover_filter},
     {"test_glob", test_glob},
+    {"test_crypto", test_crypto},
+    {"test_pack_cover", test_pack_cover},
 };
 
 static int run_tests(const char* test)
@@ -0,0 +1,410 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package consolelog stores console output of VMs in rotating files and allows to search them later.
+//
+// Each line of the output is stored with the time it was received and the name of the source
+// it came from (e.g. console or ssh) in the format:
+//
+//	2006-01-02T15:04:05.000000Z source: text
+//
+// Lines are addressed by offsets that grow monotonically across rotations and manager restarts,
+// each file is named after the offset of its first byte. Only the last few files are kept.
+package consolelog
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+type Log struct {
+	mu       sync.Mutex
+	dir      string
+	fileSize int64
+	files    int
+	file     *os.File
+	w        *bufio.Writer
+	start    int64 // offset of the current file
+	size     int64 // size of the current file
+	now      func() time.Time
+	// Set after write errors and after Close.
+	stopped bool
+}
+
+const (
+	// Files are rotated once they reach this size.
+	DefaultFileSize = 16 << 20
+	// Number of files to keep.
+	DefaultFiles = 4
+
+	timeFormat = "2006-01-02T15:04:05.000000Z"
+	fileSuffix = ".log"
+	// Buffered output is flushed at least this often, so that it can be searched.
+	flushPeriod = time.Second
+)
+
+// Open opens the log in the directory, new output is appended after the output stored previously.
+func Open(dir string) (*Log, error) {
+	return open(dir, DefaultFileSize, DefaultFiles, time.Now)
+}
+
+func open(dir string, fileSize int64, files int, now func() time.Time) (*Log, error) {
+	if err := osutil.MkdirAll(dir); err != nil {
+		return nil, err
+	}
+	l := &Log{
+		dir:      dir,
+		fileSize: fileSize,
+		files:    files,
+		now:      now,
+	}
+	chunks, err := listFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(chunks) != 0 {
+		last := chunks[len(chunks)-1]
+		l.start, l.size = last.start, last.size
+	}
+	if err := l.openFile(); err != nil {
+		return nil, err
+	}
+	go l.flushLoop()
+	return l, nil
+}
+
+// Append stores the output received from the source.
+// Data is split into lines, an incomplete last line is terminated.
+func (l *Log) Append(source string, data []byte) {
+	if l == nil || len(data) == 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return
+	}
+	prefix := l.now().UTC().Format(timeFormat) + " " + source + ": "
+	for len(data) != 0 {
+		line := data
+		if pos := bytes.IndexByte(data, '\n'); pos != -1 {
+			line, data = data[:pos], data[pos+1:]
+		} else {
+			data = nil
+		}
+		if l.size >= l.fileSize {
+			if err := l.rotate(); err != nil {
+				l.fail(err)
+				return
+			}
+		}
+		l.w.WriteString(prefix)
+		l.w.Write(line)
+		if err := l.w.WriteByte('\n'); err != nil {
+			l.fail(err)
+			return
+		}
+		l.size += int64(len(prefix) + len(line) + 1)
+	}
+}
+
+// Offset returns the offset of the next line.
+func (l *Log) Offset() int64 {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.start + l.size
+}
+
+func (l *Log) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stopped {
+		return nil
+	}
+	l.stopped = true
+	return l.closeFile()
+}
+
+func (l *Log) flushLoop() {
+	ticker := time.NewTicker(flushPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		l.mu.Lock()
+		if l.stopped {
+			l.mu.Unlock()
+			return
+		}
+		if err := l.w.Flush(); err != nil {
+			l.fail(err)
+		}
+		l.mu.Unlock()
+	}
+}
+
+func (l *Log) fail(err error) {
+	// Don't disturb fuzzing, the log is only a debugging aid.
+	log.Logf(0, "failed to write console log %v: %v", l.dir, err)
+	l.stopped = true
+	l.closeFile()
+}
+
+func (l *Log) rotate() error {
+	if err := l.closeFile(); err != nil {
+		return err
+	}
+	l.start += l.size
+	l.size = 0
+	if err := l.openFile(); err != nil {
+		return err
+	}
+	chunks, err := listFiles(l.dir)
+	if err != nil {
+		return err
+	}
+	for len(chunks) > l.files {
+		os.Remove(chunks[0].name)
+		chunks = chunks[1:]
+	}
+	return nil
+}
+
+func (l *Log) openFile() error {
+	f, err := os.OpenFile(fileName(l.dir, l.start), os.O_WRONLY|os.O_CREATE|os.O_APPEND, osutil.DefaultFilePerm)
+	if err != nil {
+		return err
+	}
+	l.file = f
+	l.w = bufio.NewWriterSize(f, 64<<10)
+	return nil
+}
+
+func (l *Log) closeFile() error {
+	if l.file == nil {
+		return nil
+	}
+	err := l.w.Flush()
+	if closeErr := l.file.Close(); err == nil {
+		err = closeErr
+	}
+	l.file, l.w = nil, nil
+	return err
+}
+
+func fileName(dir string, start int64) string {
+	// Zero padding makes lexicographical order of the files match the offset order.
+	return filepath.Join(dir, fmt.Sprintf("%016d%v", start, fileSuffix))
+}
+
+type chunk struct {
+	name  string
+	start int64
+	size  int64
+}
+
+func listFiles(dir string) ([]chunk, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var chunks []chunk
+	for _, entry := range entries {
+		start, err := strconv.ParseInt(strings.TrimSuffix(entry.Name(), fileSuffix), 10, 64)
+		if err != nil || !strings.HasSuffix(entry.Name(), fileSuffix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		chunks = append(chunks, chunk{
+			name:  filepath.Join(dir, entry.Name()),
+			start: start,
+			size:  info.Size(),
+		})
+	}
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].start < chunks[j].start
+	})
+	return chunks, nil
+}
+
+type Line struct {
+	Offset int64
+	Time   time.Time
+	Source string
+	Text   string
+}
+
+func (line *Line) String() string {
+	return fmt.Sprintf("%v %v: %v", line.Time.UTC().Format(timeFormat), line.Source, line.Text)
+}
+
+// Query selects lines for Read.
+type Query struct {
+	// Time window, zero values mean no bound.
+	From time.Time
+	To   time.Time
+	// If set, only the lines matching the regexp are returned.
+	Grep *regexp.Regexp
+	// If set, only up to Limit last lines are returned.
+	Limit int
+}
+
+// Read returns the lines stored in the directory that match the query.
+// It returns true if some lines were dropped due to the limit.
+func Read(dir string, q Query) ([]*Line, bool, error) {
+	chunks, err := listFiles(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	var lines []*Line
+	truncated := false
+	for i, c := range chunks {
+		if !q.From.IsZero() && i+1 < len(chunks) {
+			// Skip the file if the next file starts before the window.
+			if next, err := firstLine(chunks[i+1]); err == nil && next.Time.Before(q.From) {
+				continue
+			}
+		}
+		stop := false
+		err := readFile(c, func(line *Line) bool {
+			if !q.To.IsZero() && line.Time.After(q.To) {
+				stop = true
+				return false
+			}
+			if !q.From.IsZero() && line.Time.Before(q.From) ||
+				q.Grep != nil && !q.Grep.MatchString(line.Text) {
+				return true
+			}
+			lines = append(lines, line)
+			if q.Limit != 0 && len(lines) > 2*q.Limit {
+				lines = append(lines[:0], lines[len(lines)-q.Limit:]...)
+				truncated = true
+			}
+			return true
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if stop {
+			break
+		}
+	}
+	if q.Limit != 0 && len(lines) > q.Limit {
+		lines = lines[len(lines)-q.Limit:]
+		truncated = true
+	}
+	return lines, truncated, nil
+}
+
+// TimeAt returns the time of the first line at or after the offset.
+// If there are no such lines yet, it returns the current time.
+func TimeAt(dir string, offset int64) (time.Time, error) {
+	chunks, err := listFiles(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if len(chunks) == 0 || offset < chunks[0].start {
+		return time.Time{}, fmt.Errorf("offset %v is not present in the log", offset)
+	}
+	var res time.Time
+	for _, c := range chunks {
+		if offset >= c.start+c.size {
+			continue
+		}
+		err := readFile(c, func(line *Line) bool {
+			if line.Offset < offset {
+				return true
+			}
+			res = line.Time
+			return false
+		})
+		if err != nil || !res.IsZero() {
+			return res, err
+		}
+	}
+	return time.Now(), nil
+}
+
+func firstLine(c chunk) (*Line, error) {
+	var res *Line
+	err := readFile(c, func(line *Line) bool {
+		res = line
+		return false
+	})
+	if err == nil && res == nil {
+		err = fmt.Errorf("empty file %v", c.name)
+	}
+	return res, err
+}
+
+// readFile calls fn for each line in the file until it returns false.
+func readFile(c chunk, fn func(*Line) bool) error {
+	f, err := os.Open(c.name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// The file was rotated away concurrently.
+			return nil
+		}
+		return err
+	}
+	defer f.Close()
+	r := bufio.NewReaderSize(f, 64<<10)
+	offset := c.start
+	for {
+		data, err := r.ReadString('\n')
+		if err != nil {
+			// The last line may be incomplete while it's being written.
+			return nil
+		}
+		line := parseLine(data)
+		size := int64(len(data))
+		if line != nil {
+			line.Offset = offset
+			if !fn(line) {
+				return nil
+			}
+		}
+		offset += size
+	}
+}
+
+func parseLine(data string) *Line {
+	if len(data) < len(timeFormat)+1 {
+		return nil
+	}
+	t, err := time.Parse(timeFormat, data[:len(timeFormat)])
+	if err != nil {
+		return nil
+	}
+	source, text, ok := strings.Cut(data[len(timeFormat)+1:], ": ")
+	if !ok {
+		return nil
+	}
+	return &Line{
+		Time:   t,
+		Source: source,
+		Text:   strings.TrimSuffix(text, "\n"),
+	}
+}
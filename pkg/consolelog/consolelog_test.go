@@ -0,0 +1,90 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package consolelog
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLog(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	clock := func() time.Time { return now }
+	l, err := open(dir, 200, 3, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		now = start.Add(time.Duration(i) * time.Minute)
+		l.Append("console", []byte(fmt.Sprintf("line %v\nline %va", i, i)))
+		l.Append("ssh", []byte(fmt.Sprintf("ssh %v\n", i)))
+	}
+	offset := l.Offset()
+	assert.NoError(t, l.Close())
+
+	chunks, err := listFiles(dir)
+	assert.NoError(t, err)
+	assert.Len(t, chunks, 3)
+
+	// The oldest lines were rotated away.
+	lines, truncated, err := Read(dir, Query{})
+	assert.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, "2024-01-01T12:19:00.000000Z ssh: ssh 19", lines[len(lines)-1].String())
+	first := lines[0]
+	assert.Equal(t, chunks[0].start, first.Offset)
+	assert.True(t, first.Time.After(start))
+
+	// Time window.
+	lines, _, err = Read(dir, Query{
+		From: start.Add(17 * time.Minute),
+		To:   start.Add(18 * time.Minute),
+	})
+	assert.NoError(t, err)
+	var texts []string
+	for _, line := range lines {
+		texts = append(texts, line.Source+": "+line.Text)
+	}
+	assert.Equal(t, []string{
+		"console: line 17", "console: line 17a", "ssh: ssh 17",
+		"console: line 18", "console: line 18a", "ssh: ssh 18",
+	}, texts)
+
+	// Grep with a limit.
+	lines, truncated, err = Read(dir, Query{
+		Grep:  regexp.MustCompile(`^line \d+a$`),
+		Limit: 2,
+	})
+	assert.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "line 19a", lines[1].Text)
+
+	// Offsets.
+	tm, err := TimeAt(dir, lines[0].Offset)
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(18*time.Minute), tm)
+	tm, err = TimeAt(dir, lines[0].Offset-1)
+	assert.NoError(t, err)
+	assert.Equal(t, start.Add(18*time.Minute), tm)
+	_, err = TimeAt(dir, 0)
+	assert.Error(t, err)
+
+	// The log continues after reopening.
+	l, err = open(dir, 200, 3, clock)
+	assert.NoError(t, err)
+	assert.Equal(t, offset, l.Offset())
+	l.Append("console", []byte("after restart\n"))
+	assert.NoError(t, l.Close())
+	lines, _, err = Read(dir, Query{Grep: regexp.MustCompile("restart")})
+	assert.NoError(t, err)
+	assert.Len(t, lines, 1)
+	assert.Equal(t, offset, lines[0].Offset)
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"maps"
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/syzkaller/pkg/cover"
 	"github.com/google/syzkaller/pkg/hash"
@@ -32,6 +33,11 @@ type Corpus struct {
 	StatCover  *stat.Val
 
 	focusAreas []*focusAreaState
+
+	schedule     PowerSchedule
+	seeds        map[*prog.Prog]*seedStats
+	mutations    atomic.Int64
+	avgMutations float64 // as of the last energy recalculation
 }
 
 type focusAreaState struct {
@@ -57,6 +63,7 @@ func NewFocusedCorpus(ctx context.Context, updates chan<- NewItemEvent, areas []
 	corpus := &Corpus{
 		ctx:          ctx,
 		progsMap:     make(map[string]*Item),
+		seeds:        make(map[*prog.Prog]*seedStats),
 		updates:      updates,
 		ProgramsList: &ProgramsList{},
 	}
@@ -103,6 +110,7 @@ type Item struct {
 	Updates []ItemUpdate
 
 	areas map[*focusAreaState]struct{}
+	stats *seedStats
 }
 
 func (item Item) StringCall() string {
@@ -152,6 +160,7 @@ func (corpus *Corpus) Save(inp NewInput) {
 			Cover:   newCover.Serialize(),
 			Updates: append([]ItemUpdate{}, old.Updates...),
 			areas:   maps.Clone(old.areas),
+			stats:   old.stats,
 		}
 		const maxUpdates = 32
 		if len(newItem.Updates) < maxUpdates {
@@ -168,10 +177,12 @@ func (corpus *Corpus) Save(inp NewInput) {
 			Signal:  inp.Signal,
 			Cover:   inp.Cover,
 			Updates: []ItemUpdate{update},
+			stats:   new(seedStats),
 		}
 		corpus.progsMap[sig] = item
+		corpus.seeds[item.Prog] = item.stats
 		corpus.applyFocusAreas(item, inp.Cover)
-		corpus.saveProgram(inp.Prog, inp.Signal)
+		corpus.saveProgram(item, corpus.energy(item))
 	}
 	corpus.signal.Merge(inp.Signal)
 	newCover := corpus.cover.MergeDiff(inp.Cover)
@@ -200,7 +211,7 @@ func (corpus *Corpus) applyFocusAreas(item *Item, coverDelta []uint64) {
 		if !matches {
 			continue
 		}
-		area.saveProgram(item.Prog, item.Signal)
+		area.saveProgram(item, corpus.energy(item))
 		if item.areas == nil {
 			item.areas = make(map[*focusAreaState]struct{})
 			item.areas[area] = struct{}{}
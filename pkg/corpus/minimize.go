@@ -7,6 +7,7 @@ import (
 	"sort"
 
 	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
 )
 
 func (corpus *Corpus) Minimize(cover bool) {
@@ -38,6 +39,7 @@ func (corpus *Corpus) Minimize(cover bool) {
 	})
 
 	corpus.progsMap = make(map[string]*Item)
+	corpus.seeds = make(map[*prog.Prog]*seedStats)
 
 	// Overwrite the program lists.
 	corpus.ProgramsList = &ProgramsList{}
@@ -47,9 +49,11 @@ func (corpus *Corpus) Minimize(cover bool) {
 	for _, ctx := range signal.Minimize(inputs) {
 		inp := ctx.(*Item)
 		corpus.progsMap[inp.Sig] = inp
-		corpus.saveProgram(inp.Prog, inp.Signal)
+		corpus.seeds[inp.Prog] = inp.stats
+		energy := corpus.energy(inp)
+		corpus.saveProgram(inp, energy)
 		for area := range inp.areas {
-			area.saveProgram(inp.Prog, inp.Signal)
+			area.saveProgram(inp, energy)
 		}
 	}
 }
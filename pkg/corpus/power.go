@@ -0,0 +1,177 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package corpus
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// PowerSchedule determines how much energy (the probability to be chosen for mutation)
+// each corpus program gets. The schedules are modelled after the AFL ones.
+// All of them start from the signal size of the program and then adjust it based on
+// how many times the program was already mutated and how many of its mutants gave new signal.
+type PowerSchedule int
+
+const (
+	// Energy is proportional to the signal size (it's the default behavior).
+	PowerExploit PowerSchedule = iota
+	// Programs that were mutated less than others get more energy.
+	PowerExplore
+	// Programs whose mutants keep finding new signal get exponentially more energy,
+	// heavily mined programs get less energy.
+	PowerFast
+	// Same as FAST, but programs that were mutated more than average and never
+	// yielded new signal are cut off (get the minimal energy).
+	PowerCOE
+)
+
+var powerScheduleNames = [...]string{
+	PowerExploit: "exploit",
+	PowerExplore: "explore",
+	PowerFast:    "fast",
+	PowerCOE:     "coe",
+}
+
+func (s PowerSchedule) String() string {
+	return powerScheduleNames[s]
+}
+
+func ParsePowerSchedule(name string) (PowerSchedule, error) {
+	if name == "" {
+		return PowerExploit, nil
+	}
+	for s, str := range powerScheduleNames {
+		if str == name {
+			return PowerSchedule(s), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown power schedule %q", name)
+}
+
+const (
+	// Bounds for the energy multiplier relative to the signal size.
+	powerMinFactor = 1.0 / 16
+	powerMaxFactor = 16
+	// Energy values are integers, so we scale them to not lose the fractional multipliers.
+	powerEnergyScale = 16
+	// Energy of all programs is recalculated after that many mutations.
+	powerRecalcPeriod = 1000
+)
+
+// seedStats are mutable statistics shared by all versions of an Item.
+type seedStats struct {
+	mutations  atomic.Int64 // the number of times the program was mutated
+	productive atomic.Int64 // the number of mutants that gave new signal
+}
+
+func (item Item) Mutations() int {
+	if item.stats == nil {
+		return 0
+	}
+	return int(item.stats.mutations.Load())
+}
+
+func (item Item) ProductiveMutations() int {
+	if item.stats == nil {
+		return 0
+	}
+	return int(item.stats.productive.Load())
+}
+
+// energy returns the selection weight of a program with the given signal size.
+// avgMutations is the average number of mutations per corpus program.
+func (s PowerSchedule) energy(signalLen int, stats *seedStats, avgMutations float64) int64 {
+	base := int64(max(signalLen, 1))
+	if s == PowerExploit || stats == nil {
+		return base
+	}
+	productive := stats.productive.Load()
+	// How much the program was mined compared to an average one.
+	mined := float64(stats.mutations.Load()) / max(avgMutations, 1)
+	factor := 1.0
+	switch s {
+	case PowerExplore:
+		factor = 1 / (1 + mined)
+	case PowerCOE:
+		if mined > 1 && productive == 0 {
+			factor = powerMinFactor
+			break
+		}
+		fallthrough
+	case PowerFast:
+		factor = math.Exp2(float64(min(productive, 8))) / (1 + mined)
+	}
+	factor = min(max(factor, powerMinFactor), powerMaxFactor)
+	return max(1, int64(float64(base)*factor*powerEnergyScale))
+}
+
+// SetPowerSchedule changes the power schedule used to choose programs for mutation.
+func (corpus *Corpus) SetPowerSchedule(s PowerSchedule) {
+	corpus.mu.Lock()
+	defer corpus.mu.Unlock()
+	corpus.schedule = s
+	corpus.recalculateEnergyLocked()
+}
+
+func (corpus *Corpus) PowerSchedule() PowerSchedule {
+	corpus.mu.RLock()
+	defer corpus.mu.RUnlock()
+	return corpus.schedule
+}
+
+// SaveMutation records that p (previously returned by ChooseProgram) was mutated
+// and whether the resulting program gave new signal.
+func (corpus *Corpus) SaveMutation(p *prog.Prog, newSignal bool) {
+	corpus.mu.RLock()
+	stats := corpus.seeds[p]
+	schedule := corpus.schedule
+	corpus.mu.RUnlock()
+	if stats == nil {
+		// The program was removed from the corpus by minimization.
+		return
+	}
+	stats.mutations.Add(1)
+	if newSignal {
+		stats.productive.Add(1)
+	}
+	if corpus.mutations.Add(1)%powerRecalcPeriod == 0 && schedule != PowerExploit {
+		corpus.mu.Lock()
+		corpus.recalculateEnergyLocked()
+		corpus.mu.Unlock()
+	}
+}
+
+// Energy returns the share of the total energy of each corpus item (indexed by Item.Sig).
+// Focus areas are not taken into account.
+func (corpus *Corpus) Energy() map[string]float64 {
+	corpus.mu.RLock()
+	defer corpus.mu.RUnlock()
+	ret := make(map[string]float64, len(corpus.progsMap))
+	total := 0.0
+	for sig, item := range corpus.progsMap {
+		energy := float64(corpus.schedule.energy(len(item.Signal), item.stats, corpus.avgMutations))
+		ret[sig] = energy
+		total += energy
+	}
+	for sig := range ret {
+		ret[sig] /= total
+	}
+	return ret
+}
+
+func (corpus *Corpus) recalculateEnergyLocked() {
+	total := 0
+	for _, item := range corpus.progsMap {
+		total += item.Mutations()
+	}
+	corpus.avgMutations = float64(total) / float64(max(len(corpus.progsMap), 1))
+	corpus.ProgramsList.recalculate(corpus.schedule, corpus.avgMutations)
+	for _, area := range corpus.focusAreas {
+		area.recalculate(corpus.schedule, corpus.avgMutations)
+	}
+}
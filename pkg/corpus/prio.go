@@ -7,7 +7,6 @@ import (
 	"math/rand"
 	"sort"
 
-	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 )
 
@@ -15,6 +14,9 @@ type ProgramsList struct {
 	progs    []*prog.Prog
 	sumPrios int64
 	accPrios []int64
+	// Inputs for the energy recalculation.
+	signalLens []int
+	stats      []*seedStats
 }
 
 func (pl *ProgramsList) chooseProgram(r *rand.Rand) *prog.Prog {
@@ -28,14 +30,24 @@ func (pl *ProgramsList) chooseProgram(r *rand.Rand) *prog.Prog {
 	return pl.progs[idx]
 }
 
-func (pl *ProgramsList) saveProgram(p *prog.Prog, signal signal.Signal) {
-	prio := int64(len(signal))
-	if prio == 0 {
-		prio = 1
-	}
+func (pl *ProgramsList) saveProgram(item *Item, prio int64) {
 	pl.sumPrios += prio
 	pl.accPrios = append(pl.accPrios, pl.sumPrios)
-	pl.progs = append(pl.progs, p)
+	pl.progs = append(pl.progs, item.Prog)
+	pl.signalLens = append(pl.signalLens, len(item.Signal))
+	pl.stats = append(pl.stats, item.stats)
+}
+
+func (pl *ProgramsList) recalculate(schedule PowerSchedule, avgMutations float64) {
+	pl.sumPrios = 0
+	for i := range pl.progs {
+		pl.sumPrios += schedule.energy(pl.signalLens[i], pl.stats[i], avgMutations)
+		pl.accPrios[i] = pl.sumPrios
+	}
+}
+
+func (corpus *Corpus) energy(item *Item) int64 {
+	return corpus.schedule.energy(len(item.Signal), item.stats, corpus.avgMutations)
 }
 
 func (corpus *Corpus) ChooseProgram(r *rand.Rand) *prog.Prog {
@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/google/syzkaller/pkg/hash"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 	"github.com/stretchr/testify/assert"
@@ -117,3 +118,78 @@ func TestFocusAreas(t *testing.T) {
 	assert.InDelta(t, secondCount, TOTAL*0.3, TOTAL/25)
 	assert.InDelta(t, thirdCount, TOTAL*0.6, TOTAL/25)
 }
+
+func TestPowerSchedule(t *testing.T) {
+	target := getTarget(t, targets.TestOS, targets.TestArch64)
+	corpus := NewCorpus(context.Background())
+	rs := rand.NewSource(0)
+	// All programs have the same signal size, so only the mutation statistics matter.
+	var productive, mined, fresh []*prog.Prog
+	for i := 0; i < 30; i++ {
+		inp := generateRangedInput(target, rs, i*10, i*10+9)
+		corpus.Save(inp)
+		switch i % 3 {
+		case 0:
+			productive = append(productive, inp.Prog)
+			for j := 0; j < 100; j++ {
+				corpus.SaveMutation(inp.Prog, j%20 == 0)
+			}
+		case 1:
+			mined = append(mined, inp.Prog)
+			for j := 0; j < 300; j++ {
+				corpus.SaveMutation(inp.Prog, false)
+			}
+		case 2:
+			fresh = append(fresh, inp.Prog)
+		}
+	}
+	item := corpus.Item(hash.String(productive[0].Serialize()))
+	assert.Equal(t, 100, item.Mutations())
+	assert.Equal(t, 5, item.ProductiveMutations())
+
+	r := rand.New(rs)
+	choose := func(schedule PowerSchedule) (int, int, int) {
+		corpus.SetPowerSchedule(schedule)
+		sum := 0.0
+		for _, share := range corpus.Energy() {
+			sum += share
+		}
+		assert.InDelta(t, 1.0, sum, 1e-6)
+		counts := map[*prog.Prog]int{}
+		for i := 0; i < 10000; i++ {
+			counts[corpus.ChooseProgram(r)]++
+		}
+		total := func(progs []*prog.Prog) int {
+			ret := 0
+			for _, p := range progs {
+				ret += counts[p]
+			}
+			return ret
+		}
+		return total(productive), total(mined), total(fresh)
+	}
+
+	a, b, c := choose(PowerExploit)
+	assert.InDelta(t, a, 10000/3, 500)
+	assert.InDelta(t, b, 10000/3, 500)
+	assert.InDelta(t, c, 10000/3, 500)
+
+	a, b, c = choose(PowerExplore)
+	assert.Greater(t, c, a)
+	assert.Greater(t, a, b)
+
+	a, b, c = choose(PowerFast)
+	assert.Greater(t, a, c)
+	assert.Greater(t, c, b)
+
+	_, b2, _ := choose(PowerCOE)
+	assert.Less(t, b2, b)
+
+	for s := PowerExploit; s <= PowerCOE; s++ {
+		parsed, err := ParsePowerSchedule(s.String())
+		assert.NoError(t, err)
+		assert.Equal(t, s, parsed)
+	}
+	_, err := ParsePowerSchedule("foo")
+	assert.Error(t, err)
+}
@@ -18,6 +18,8 @@ type Impl struct {
 	Symbolize       func(pcs map[*vminfo.KernelModule][]uint64) ([]Frame, error)
 	CallbackPoints  []uint64
 	PreciseCoverage bool
+	// Direct calls between functions (currently only for the core kernel on amd64/arm64).
+	Calls []Call
 }
 
 type CompileUnit struct {
@@ -42,6 +44,12 @@ type ObjectUnit struct {
 	CMPs []uint64 // PCs we can get in comparison interception callbacks for this unit.
 }
 
+// Call is a direct call instruction at PC that calls the function that starts at Target.
+type Call struct {
+	PC     uint64
+	Target uint64
+}
+
 type Frame struct {
 	Module   *vminfo.KernelModule
 	PC       uint64
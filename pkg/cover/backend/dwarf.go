@@ -104,6 +104,7 @@ func makeDWARF(params *dwarfParams) (impl *Impl, err error) {
 type Result struct {
 	CoverPoints [2][]uint64
 	Symbols     []*Symbol
+	Calls       []Call
 }
 
 func processModule(params *dwarfParams, module *vminfo.KernelModule, info *symbolInfo,
@@ -115,6 +116,7 @@ func processModule(params *dwarfParams, module *vminfo.KernelModule, info *symbo
 
 	var data []byte
 	var coverPoints [2][]uint64
+	var calls []Call
 	if target.Arch != targets.AMD64 && target.Arch != targets.ARM64 {
 		coverPoints, err = objdump(target, module)
 	} else if module.Name == "" {
@@ -122,7 +124,7 @@ func processModule(params *dwarfParams, module *vminfo.KernelModule, info *symbo
 		if err != nil {
 			return nil, err
 		}
-		coverPoints, err = readCoverPoints(target, info, data)
+		coverPoints, calls, err = readCoverPoints(target, info, data)
 	} else {
 		coverPoints, err = params.readModuleCoverPoints(target, module, info)
 	}
@@ -133,6 +135,7 @@ func processModule(params *dwarfParams, module *vminfo.KernelModule, info *symbo
 	result := &Result{
 		Symbols:     symbols,
 		CoverPoints: coverPoints,
+		Calls:       calls,
 	}
 	return result, nil
 }
@@ -149,12 +152,14 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 	// and index 1 refers to comparison callbacks (__sanitizer_cov_trace_cmp*).
 	var allCoverPoints [2][]uint64
 	var allSymbols []*Symbol
+	var allCalls []Call
 	var allRanges []pcRange
 	var allUnits []*CompileUnit
 	preciseCoverage := true
 	type binResult struct {
 		symbols     []*Symbol
 		coverPoints [2][]uint64
+		calls       []Call
 		ranges      []pcRange
 		units       []*CompileUnit
 		err         error
@@ -185,7 +190,8 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 				binC <- binResult{err: err}
 				return
 			}
-			binC <- binResult{symbols: result.Symbols, coverPoints: result.CoverPoints, ranges: ranges, units: units}
+			binC <- binResult{symbols: result.Symbols, coverPoints: result.CoverPoints, calls: result.Calls,
+				ranges: ranges, units: units}
 		}()
 		if isKcovBrokenInCompiler(params.getCompilerVersion(module.Path)) {
 			preciseCoverage = false
@@ -199,6 +205,7 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 		allSymbols = append(allSymbols, result.symbols...)
 		allCoverPoints[0] = append(allCoverPoints[0], result.coverPoints[0]...)
 		allCoverPoints[1] = append(allCoverPoints[1], result.coverPoints[1]...)
+		allCalls = append(allCalls, result.calls...)
 		allRanges = append(allRanges, result.ranges...)
 		allUnits = append(allUnits, result.units...)
 	}
@@ -214,6 +221,15 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 	for _, sym := range uniqSymbs {
 		allSymbols = append(allSymbols, sym)
 	}
+	// Byte-wise instruction scanning produces some false calls, most of them don't point to a function start.
+	ncall := 0
+	for _, call := range allCalls {
+		if _, ok := uniqSymbs[call.Target]; ok {
+			allCalls[ncall] = call
+			ncall++
+		}
+	}
+	allCalls = allCalls[:ncall]
 	sort.Slice(allSymbols, func(i, j int) bool {
 		return allSymbols[i].Start < allSymbols[j].Start
 	})
@@ -250,6 +266,7 @@ func makeDWARFUnsafe(params *dwarfParams) (*Impl, error) {
 		},
 		CallbackPoints:  allCoverPoints[0],
 		PreciseCoverage: preciseCoverage,
+		Calls:           allCalls,
 	}
 	return impl, nil
 }
@@ -528,12 +545,14 @@ func nextCallTarget(arch *Arch, textAddr uint64, data []byte, pos *int) (uint64,
 }
 
 // readCoverPoints finds all coverage points (calls of __sanitizer_cov_trace_*) in the object file.
+// All other call instructions are returned as calls.
 // Currently it is [amd64|arm64]-specific: looks for opcode and correct offset.
 // Running objdump on the whole object file is too slow.
-func readCoverPoints(target *targets.Target, info *symbolInfo, data []byte) ([2][]uint64, error) {
+func readCoverPoints(target *targets.Target, info *symbolInfo, data []byte) ([2][]uint64, []Call, error) {
 	var pcs [2][]uint64
+	var calls []Call
 	if len(info.tracePC) == 0 {
-		return pcs, fmt.Errorf("no __sanitizer_cov_trace_pc symbol in the object file")
+		return pcs, nil, fmt.Errorf("no __sanitizer_cov_trace_pc symbol in the object file")
 	}
 
 	i := 0
@@ -547,9 +566,11 @@ func readCoverPoints(target *targets.Target, info *symbolInfo, data []byte) ([2]
 			pcs[0] = append(pcs[0], pc)
 		} else if info.traceCmp[callTarget] {
 			pcs[1] = append(pcs[1], pc)
+		} else {
+			calls = append(calls, Call{PC: pc, Target: callTarget})
 		}
 	}
-	return pcs, nil
+	return pcs, calls, nil
 }
 
 // Source files for Android may be split between two subdirectories: the common AOSP kernel
@@ -6,6 +6,7 @@ package cover
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/google/syzkaller/pkg/cover/backend"
 	"github.com/google/syzkaller/pkg/mgrconfig"
@@ -207,7 +208,7 @@ func (rg *ReportGenerator) symbolizePCs(PCs []uint64) error {
 	symbolize := make(map[*backend.Symbol]bool)
 	pcs := make(map[*vminfo.KernelModule][]uint64)
 	for _, pc := range PCs {
-		sym := rg.findSymbol(pc)
+		sym := rg.FindSymbol(pc)
 		if sym == nil || sym.Symbolized || symbolize[sym] {
 			continue
 		}
@@ -225,6 +226,30 @@ func (rg *ReportGenerator) symbolizePCs(PCs []uint64) error {
 	return nil
 }
 
+// LinePCs returns coverage callback PCs that correspond to the given source line.
+// The file is matched as a suffix of the source file names.
+func (rg *ReportGenerator) LinePCs(file string, line int) ([]uint64, error) {
+	var pcs []uint64
+	for _, unit := range rg.Units {
+		if strings.HasSuffix(unit.Name, file) {
+			pcs = append(pcs, unit.PCs...)
+		}
+	}
+	if len(pcs) == 0 {
+		return nil, fmt.Errorf("no coverage points in %v", file)
+	}
+	if err := rg.symbolizePCs(pcs); err != nil {
+		return nil, err
+	}
+	var ret []uint64
+	for _, frame := range rg.Frames {
+		if frame.StartLine == line && strings.HasSuffix(frame.Name, file) {
+			ret = append(ret, frame.PC)
+		}
+	}
+	return ret, nil
+}
+
 func fileByFrame(files map[string]*file, frame *backend.Frame) *file {
 	f := files[frame.Name]
 	if f == nil {
@@ -241,7 +266,8 @@ func fileByFrame(files map[string]*file, frame *backend.Frame) *file {
 	return f
 }
 
-func (rg *ReportGenerator) findSymbol(pc uint64) *backend.Symbol {
+// FindSymbol returns the symbol that contains pc, or nil.
+func (rg *ReportGenerator) FindSymbol(pc uint64) *backend.Symbol {
 	idx := sort.Search(len(rg.Symbols), func(i int) bool {
 		return pc < rg.Symbols[i].End
 	})
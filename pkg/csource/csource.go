@@ -262,6 +262,9 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 		if call.Props.FailNth > 0 {
 			fmt.Fprintf(w, "\tinject_fault(%v);\n", call.Props.FailNth)
 		}
+		if call.Props.PinCPU > 0 {
+			fmt.Fprintf(w, "\tpin_cpu(%v);\n", call.Props.PinCPU-1)
+		}
 		// Call itself.
 		resCopyout := call.Index != prog.ExecNoCopyout
 		argCopyout := len(call.Copyout) != 0
@@ -274,6 +277,9 @@ func (ctx *context) generateCalls(p prog.ExecProg, trace bool) ([]string, []uint
 			ctx.emitCall(w, call, ci, false, false)
 			fmt.Fprintf(w, "\t}\n")
 		}
+		if call.Props.PinCPU > 0 {
+			fmt.Fprintf(w, "\tunpin_cpu();\n")
+		}
 		// Copyout.
 		if resCopyout || argCopyout {
 			ctx.copyout(w, call, resCopyout)
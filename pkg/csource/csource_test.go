@@ -107,6 +107,9 @@ func testTarget(t *testing.T, target *prog.Target, full bool) {
 	if len(p.Calls) > 2 {
 		p.Calls[2].Props.Rerun = 4
 	}
+	if len(p.Calls) > 3 {
+		p.Calls[3].Props.PinCPU = 2
+	}
 	for opti, opts := range opts {
 		if testing.Short() && opts.HandleSegv {
 			// HandleSegv can radically increase compilation time/memory consumption on large programs.
@@ -240,6 +243,20 @@ syscall(SYS_csource7, /*flag=BIT_1*/2ul);
 syscall(SYS_csource7, /*flag=BIT_0_AND_1*/3ul);
 syscall(SYS_csource7, /*flag=*/4ul);
 syscall(SYS_csource7, /*flag=BIT_0|0x4*/5ul);
+`,
+		},
+		{
+			input: `
+csource7(0x0) (async, pin_cpu: 1)
+csource7(0x1) (pin_cpu: 2)
+`,
+			output: `
+pin_cpu(0);
+syscall(SYS_csource7, /*flag=*/0ul);
+unpin_cpu();
+pin_cpu(1);
+syscall(SYS_csource7, /*flag=BIT_0*/1ul);
+unpin_cpu();
 `,
 		},
 	}
@@ -30,9 +30,19 @@ var (
 type Serv struct {
 	Addr *net.TCPAddr
 	ln   net.Listener
+	key  []byte
 }
 
 func Listen(addr string) (*Serv, error) {
+	return ListenSecure(addr, nil)
+}
+
+// ListenSecure is like Listen, but if key is not empty, it accepts only the clients that know the key
+// and encrypts all traffic (see ServerHandshake).
+func ListenSecure(addr string, key []byte) (*Serv, error) {
+	if len(key) != 0 && len(key) < MinKeySize {
+		return nil, fmt.Errorf("the key is too short (%v bytes, need at least %v)", len(key), MinKeySize)
+	}
 	ln, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
@@ -40,6 +50,7 @@ func Listen(addr string) (*Serv, error) {
 	return &Serv{
 		Addr: ln.Addr().(*net.TCPAddr),
 		ln:   ln,
+		key:  key,
 	}, nil
 }
 
@@ -69,6 +80,17 @@ func (s *Serv) Serve(baseCtx context.Context, handler func(context.Context, *Con
 			connCtx, cancel := context.WithCancel(ctx)
 			defer cancel()
 
+			if len(s.key) != 0 {
+				secure, err := ServerHandshake(conn, s.key)
+				if err != nil {
+					// Don't abort the server: anybody can connect to it.
+					log.Logf(0, "flatrpc: rejected connection from %v: %v", conn.RemoteAddr(), err)
+					conn.Close()
+					return nil
+				}
+				conn = secure
+			}
+
 			c := NewConn(conn)
 			// Closing the server does not automatically close all the connections.
 			go func() {
@@ -148,10 +170,7 @@ func Recv[Raw RecvType[T], T any](c *Conn) (res *T, err0 error) {
 	}
 	c.hasData -= c.lastMsg
 	c.lastMsg = 0
-	const (
-		sizePrefixSize = flatbuffers.SizeUint32
-		maxMessageSize = 64 << 20
-	)
+	const sizePrefixSize = flatbuffers.SizeUint32
 	// Then, receive at least the size prefix (4 bytes).
 	// And then the full message, if we have not got it yet.
 	if err := c.recv(sizePrefixSize); err != nil {
@@ -186,12 +205,16 @@ func (c *Conn) recv(size int) error {
 }
 
 func Parse[Raw RecvType[T], T any](data []byte) (res *T, err0 error) {
+	statRecv.Add(len(data))
+	return parse[Raw](data)
+}
+
+func parse[Raw RecvType[T], T any](data []byte) (res *T, err0 error) {
 	defer func() {
 		if err := recover(); err != nil {
 			err0 = fmt.Errorf("%v", err)
 		}
 	}()
-	statRecv.Add(len(data))
 	// This probably can be expressed w/o reflect as "new U" where U is *T,
 	// but I failed to express that as generic constraints.
 	var msg Raw
@@ -225,7 +248,8 @@ func verifyExecutorMessage(raw *ExecutorMessageRaw, rawSize int) error {
 	switch typ := raw.MsgType(); typ {
 	case ExecutorMessagesRawExecResult,
 		ExecutorMessagesRawExecuting,
-		ExecutorMessagesRawState:
+		ExecutorMessagesRawState,
+		ExecutorMessagesRawExecResultBatch:
 	default:
 		return fmt.Errorf("bad executor message type %v", typ)
 	}
@@ -233,15 +257,30 @@ func verifyExecutorMessage(raw *ExecutorMessageRaw, rawSize int) error {
 	if !raw.Msg(&tab) {
 		return errors.New("received no message")
 	}
-	// Only ExecResult has arrays.
-	if raw.MsgType() == ExecutorMessagesRawExecResult {
+	switch raw.MsgType() {
+	case ExecutorMessagesRawExecResult:
 		var res ExecResultRaw
 		res.Init(tab.Bytes, tab.Pos)
 		return verifyExecResult(&res, rawSize)
+	case ExecutorMessagesRawExecResultBatch:
+		// Individual results are serialized messages on their own,
+		// they are verified when parsed by ExecResultBatch.Unpack.
+		var batch ExecResultBatchRaw
+		batch.Init(tab.Bytes, tab.Pos)
+		if size := batch.ResultsLength() * flatbuffers.SizeUOffsetT; size > rawSize {
+			return fmt.Errorf("corrupted message: total size %v, size of elements %v",
+				rawSize, size)
+		}
 	}
 	return nil
 }
 
+const (
+	maxMessageSize = 64 << 20
+	// Unpacked signal/cover of a single exec result must fit into this limit.
+	maxUnpackedSize = maxMessageSize
+)
+
 func verifyExecResult(res *ExecResultRaw, rawSize int) error {
 	info := res.Info(nil)
 	if info == nil {
@@ -250,11 +289,22 @@ func verifyExecResult(res *ExecResultRaw, rawSize int) error {
 	var tmp ComparisonRaw
 	// It's hard to impose good limit on each individual signal/cover/comps array,
 	// so instead we count total memory size for all calls and check that it's not
-	// larger than the total message size.
+	// larger than the total message size. Packed signal/cover are expanded by UnpackPCs
+	// up to 8 times (that's the point of packing), so their unpacked size is counted
+	// separately and is limited by the max message size.
+	unpacked := 0
 	callSize := func(call *CallInfoRaw) int {
 		// Cap array size at 1G to prevent overflows during multiplication by size and addition.
 		const maxSize = 1 << 30
 		size := 0
+		if call.PackedSignalLength() != 0 {
+			size += min(maxSize, call.PackedSignalLength())
+			unpacked += min(maxSize, numPackedPCs(call.PackedSignalBytes())) * int(unsafe.Sizeof(uint64(0)))
+		}
+		if call.PackedCoverLength() != 0 {
+			size += min(maxSize, call.PackedCoverLength())
+			unpacked += min(maxSize, numPackedPCs(call.PackedCoverBytes())) * int(unsafe.Sizeof(uint64(0)))
+		}
 		if call.SignalLength() != 0 {
 			size += min(maxSize, call.SignalLength()) * int(unsafe.Sizeof(call.Signal(0)))
 		}
@@ -285,5 +335,9 @@ func verifyExecResult(res *ExecResultRaw, rawSize int) error {
 		return fmt.Errorf("corrupted message: total size %v, size of elements %v",
 			rawSize, size)
 	}
+	if unpacked > maxUnpackedSize {
+		return fmt.Errorf("corrupted message: total size %v, size of unpacked signal/cover %v",
+			rawSize, unpacked)
+	}
 	return nil
 }
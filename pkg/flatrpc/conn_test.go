@@ -214,10 +214,20 @@ func FuzzRecv(f *testing.F) {
 		}
 		c := NewConn(n)
 		for {
-			_, err := Recv[*ExecutorMessageRaw](c)
+			msg, err := Recv[*ExecutorMessageRaw](c)
 			if err != nil {
 				break
 			}
+			var results []*ExecResult
+			switch val := msg.Msg.Value.(type) {
+			case *ExecResult:
+				results = append(results, val)
+			case *ExecResultBatch:
+				results, _ = val.Unpack()
+			}
+			for _, res := range results {
+				res.Info.UnpackCover()
+			}
 		}
 	})
 }
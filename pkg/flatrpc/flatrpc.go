@@ -11,6 +11,7 @@ import (
 type Const uint64
 
 const (
+	ConstMaxSnapshotProcs     Const = 4
 	ConstSnapshotDoorbellSize Const = 4096
 	ConstMaxInputSize         Const = 4198400
 	ConstMaxOutputSize        Const = 14680064
@@ -18,6 +19,7 @@ const (
 )
 
 var EnumNamesConst = map[Const]string{
+	ConstMaxSnapshotProcs:     "MaxSnapshotProcs",
 	ConstSnapshotDoorbellSize: "SnapshotDoorbellSize",
 	ConstMaxInputSize:         "MaxInputSize",
 	ConstMaxOutputSize:        "MaxOutputSize",
@@ -25,6 +27,7 @@ var EnumNamesConst = map[Const]string{
 }
 
 var EnumValuesConst = map[string]Const{
+	"MaxSnapshotProcs":     ConstMaxSnapshotProcs,
 	"SnapshotDoorbellSize": ConstSnapshotDoorbellSize,
 	"MaxInputSize":         ConstMaxInputSize,
 	"MaxOutputSize":        ConstMaxOutputSize,
@@ -122,27 +125,30 @@ func (v Feature) String() string {
 type HostMessagesRaw byte
 
 const (
-	HostMessagesRawNONE          HostMessagesRaw = 0
-	HostMessagesRawExecRequest   HostMessagesRaw = 1
-	HostMessagesRawSignalUpdate  HostMessagesRaw = 2
-	HostMessagesRawCorpusTriaged HostMessagesRaw = 3
-	HostMessagesRawStateRequest  HostMessagesRaw = 4
+	HostMessagesRawNONE             HostMessagesRaw = 0
+	HostMessagesRawExecRequest      HostMessagesRaw = 1
+	HostMessagesRawSignalUpdate     HostMessagesRaw = 2
+	HostMessagesRawCorpusTriaged    HostMessagesRaw = 3
+	HostMessagesRawStateRequest     HostMessagesRaw = 4
+	HostMessagesRawExecRequestBatch HostMessagesRaw = 5
 )
 
 var EnumNamesHostMessagesRaw = map[HostMessagesRaw]string{
-	HostMessagesRawNONE:          "NONE",
-	HostMessagesRawExecRequest:   "ExecRequest",
-	HostMessagesRawSignalUpdate:  "SignalUpdate",
-	HostMessagesRawCorpusTriaged: "CorpusTriaged",
-	HostMessagesRawStateRequest:  "StateRequest",
+	HostMessagesRawNONE:             "NONE",
+	HostMessagesRawExecRequest:      "ExecRequest",
+	HostMessagesRawSignalUpdate:     "SignalUpdate",
+	HostMessagesRawCorpusTriaged:    "CorpusTriaged",
+	HostMessagesRawStateRequest:     "StateRequest",
+	HostMessagesRawExecRequestBatch: "ExecRequestBatch",
 }
 
 var EnumValuesHostMessagesRaw = map[string]HostMessagesRaw{
-	"NONE":          HostMessagesRawNONE,
-	"ExecRequest":   HostMessagesRawExecRequest,
-	"SignalUpdate":  HostMessagesRawSignalUpdate,
-	"CorpusTriaged": HostMessagesRawCorpusTriaged,
-	"StateRequest":  HostMessagesRawStateRequest,
+	"NONE":             HostMessagesRawNONE,
+	"ExecRequest":      HostMessagesRawExecRequest,
+	"SignalUpdate":     HostMessagesRawSignalUpdate,
+	"CorpusTriaged":    HostMessagesRawCorpusTriaged,
+	"StateRequest":     HostMessagesRawStateRequest,
+	"ExecRequestBatch": HostMessagesRawExecRequestBatch,
 }
 
 func (v HostMessagesRaw) String() string {
@@ -170,6 +176,8 @@ func (t *HostMessagesRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffse
 		return t.Value.(*CorpusTriagedRawT).Pack(builder)
 	case HostMessagesRawStateRequest:
 		return t.Value.(*StateRequestRawT).Pack(builder)
+	case HostMessagesRawExecRequestBatch:
+		return t.Value.(*ExecRequestBatchRawT).Pack(builder)
 	}
 	return 0
 }
@@ -188,6 +196,9 @@ func (rcv HostMessagesRaw) UnPack(table flatbuffers.Table) *HostMessagesRawT {
 	case HostMessagesRawStateRequest:
 		x := StateRequestRaw{_tab: table}
 		return &HostMessagesRawT{Type: HostMessagesRawStateRequest, Value: x.UnPack()}
+	case HostMessagesRawExecRequestBatch:
+		x := ExecRequestBatchRaw{_tab: table}
+		return &HostMessagesRawT{Type: HostMessagesRawExecRequestBatch, Value: x.UnPack()}
 	}
 	return nil
 }
@@ -195,24 +206,27 @@ func (rcv HostMessagesRaw) UnPack(table flatbuffers.Table) *HostMessagesRawT {
 type ExecutorMessagesRaw byte
 
 const (
-	ExecutorMessagesRawNONE       ExecutorMessagesRaw = 0
-	ExecutorMessagesRawExecResult ExecutorMessagesRaw = 1
-	ExecutorMessagesRawExecuting  ExecutorMessagesRaw = 2
-	ExecutorMessagesRawState      ExecutorMessagesRaw = 3
+	ExecutorMessagesRawNONE            ExecutorMessagesRaw = 0
+	ExecutorMessagesRawExecResult      ExecutorMessagesRaw = 1
+	ExecutorMessagesRawExecuting       ExecutorMessagesRaw = 2
+	ExecutorMessagesRawState           ExecutorMessagesRaw = 3
+	ExecutorMessagesRawExecResultBatch ExecutorMessagesRaw = 4
 )
 
 var EnumNamesExecutorMessagesRaw = map[ExecutorMessagesRaw]string{
-	ExecutorMessagesRawNONE:       "NONE",
-	ExecutorMessagesRawExecResult: "ExecResult",
-	ExecutorMessagesRawExecuting:  "Executing",
-	ExecutorMessagesRawState:      "State",
+	ExecutorMessagesRawNONE:            "NONE",
+	ExecutorMessagesRawExecResult:      "ExecResult",
+	ExecutorMessagesRawExecuting:       "Executing",
+	ExecutorMessagesRawState:           "State",
+	ExecutorMessagesRawExecResultBatch: "ExecResultBatch",
 }
 
 var EnumValuesExecutorMessagesRaw = map[string]ExecutorMessagesRaw{
-	"NONE":       ExecutorMessagesRawNONE,
-	"ExecResult": ExecutorMessagesRawExecResult,
-	"Executing":  ExecutorMessagesRawExecuting,
-	"State":      ExecutorMessagesRawState,
+	"NONE":            ExecutorMessagesRawNONE,
+	"ExecResult":      ExecutorMessagesRawExecResult,
+	"Executing":       ExecutorMessagesRawExecuting,
+	"State":           ExecutorMessagesRawState,
+	"ExecResultBatch": ExecutorMessagesRawExecResultBatch,
 }
 
 func (v ExecutorMessagesRaw) String() string {
@@ -238,6 +252,8 @@ func (t *ExecutorMessagesRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UO
 		return t.Value.(*ExecutingMessageRawT).Pack(builder)
 	case ExecutorMessagesRawState:
 		return t.Value.(*StateResultRawT).Pack(builder)
+	case ExecutorMessagesRawExecResultBatch:
+		return t.Value.(*ExecResultBatchRawT).Pack(builder)
 	}
 	return 0
 }
@@ -253,6 +269,9 @@ func (rcv ExecutorMessagesRaw) UnPack(table flatbuffers.Table) *ExecutorMessages
 	case ExecutorMessagesRawState:
 		x := StateResultRaw{_tab: table}
 		return &ExecutorMessagesRawT{Type: ExecutorMessagesRawState, Value: x.UnPack()}
+	case ExecutorMessagesRawExecResultBatch:
+		x := ExecResultBatchRaw{_tab: table}
+		return &ExecutorMessagesRawT{Type: ExecutorMessagesRawExecResultBatch, Value: x.UnPack()}
 	}
 	return nil
 }
@@ -446,6 +465,30 @@ func (v CallFlag) String() string {
 	return "CallFlag(" + strconv.FormatInt(int64(v), 10) + ")"
 }
 
+type FeedbackKind uint64
+
+const (
+	FeedbackKindContextSwitches FeedbackKind = 0
+	FeedbackKindPageFaults      FeedbackKind = 1
+)
+
+var EnumNamesFeedbackKind = map[FeedbackKind]string{
+	FeedbackKindContextSwitches: "ContextSwitches",
+	FeedbackKindPageFaults:      "PageFaults",
+}
+
+var EnumValuesFeedbackKind = map[string]FeedbackKind{
+	"ContextSwitches": FeedbackKindContextSwitches,
+	"PageFaults":      FeedbackKindPageFaults,
+}
+
+func (v FeedbackKind) String() string {
+	if s, ok := EnumNamesFeedbackKind[v]; ok {
+		return s
+	}
+	return "FeedbackKind(" + strconv.FormatInt(int64(v), 10) + ")"
+}
+
 type SnapshotState uint64
 
 const (
@@ -618,6 +661,7 @@ type ConnectReplyRawT struct {
 	RaceFrames       []string `json:"race_frames"`
 	Features         Feature  `json:"features"`
 	Files            []string `json:"files"`
+	Feedback         bool     `json:"feedback"`
 }
 
 func (t *ConnectReplyRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
@@ -676,6 +720,7 @@ func (t *ConnectReplyRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffse
 	ConnectReplyRawAddRaceFrames(builder, raceFramesOffset)
 	ConnectReplyRawAddFeatures(builder, t.Features)
 	ConnectReplyRawAddFiles(builder, filesOffset)
+	ConnectReplyRawAddFeedback(builder, t.Feedback)
 	return ConnectReplyRawEnd(builder)
 }
 
@@ -704,6 +749,7 @@ func (rcv *ConnectReplyRaw) UnPackTo(t *ConnectReplyRawT) {
 	for j := 0; j < filesLength; j++ {
 		t.Files[j] = string(rcv.Files(j))
 	}
+	t.Feedback = rcv.Feedback()
 }
 
 func (rcv *ConnectReplyRaw) UnPack() *ConnectReplyRawT {
@@ -901,8 +947,20 @@ func (rcv *ConnectReplyRaw) FilesLength() int {
 	return 0
 }
 
+func (rcv *ConnectReplyRaw) Feedback() bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(28))
+	if o != 0 {
+		return rcv._tab.GetBool(o + rcv._tab.Pos)
+	}
+	return false
+}
+
+func (rcv *ConnectReplyRaw) MutateFeedback(n bool) bool {
+	return rcv._tab.MutateBoolSlot(28, n)
+}
+
 func ConnectReplyRawStart(builder *flatbuffers.Builder) {
-	builder.StartObject(12)
+	builder.StartObject(13)
 }
 func ConnectReplyRawAddDebug(builder *flatbuffers.Builder, debug bool) {
 	builder.PrependBoolSlot(0, debug, false)
@@ -949,6 +1007,9 @@ func ConnectReplyRawAddFiles(builder *flatbuffers.Builder, files flatbuffers.UOf
 func ConnectReplyRawStartFilesVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(4, numElems, 4)
 }
+func ConnectReplyRawAddFeedback(builder *flatbuffers.Builder, feedback bool) {
+	builder.PrependBoolSlot(12, feedback, false)
+}
 func ConnectReplyRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -2104,6 +2165,111 @@ func ExecRequestRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
 
+type ExecRequestBatchRawT struct {
+	Requests []*ExecRequestRawT `json:"requests"`
+}
+
+func (t *ExecRequestBatchRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	if t == nil {
+		return 0
+	}
+	requestsOffset := flatbuffers.UOffsetT(0)
+	if t.Requests != nil {
+		requestsLength := len(t.Requests)
+		requestsOffsets := make([]flatbuffers.UOffsetT, requestsLength)
+		for j := 0; j < requestsLength; j++ {
+			requestsOffsets[j] = t.Requests[j].Pack(builder)
+		}
+		ExecRequestBatchRawStartRequestsVector(builder, requestsLength)
+		for j := requestsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(requestsOffsets[j])
+		}
+		requestsOffset = builder.EndVector(requestsLength)
+	}
+	ExecRequestBatchRawStart(builder)
+	ExecRequestBatchRawAddRequests(builder, requestsOffset)
+	return ExecRequestBatchRawEnd(builder)
+}
+
+func (rcv *ExecRequestBatchRaw) UnPackTo(t *ExecRequestBatchRawT) {
+	requestsLength := rcv.RequestsLength()
+	t.Requests = make([]*ExecRequestRawT, requestsLength)
+	for j := 0; j < requestsLength; j++ {
+		x := ExecRequestRaw{}
+		rcv.Requests(&x, j)
+		t.Requests[j] = x.UnPack()
+	}
+}
+
+func (rcv *ExecRequestBatchRaw) UnPack() *ExecRequestBatchRawT {
+	if rcv == nil {
+		return nil
+	}
+	t := &ExecRequestBatchRawT{}
+	rcv.UnPackTo(t)
+	return t
+}
+
+type ExecRequestBatchRaw struct {
+	_tab flatbuffers.Table
+}
+
+func GetRootAsExecRequestBatchRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecRequestBatchRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset:])
+	x := &ExecRequestBatchRaw{}
+	x.Init(buf, n+offset)
+	return x
+}
+
+func GetSizePrefixedRootAsExecRequestBatchRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecRequestBatchRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset+flatbuffers.SizeUint32:])
+	x := &ExecRequestBatchRaw{}
+	x.Init(buf, n+offset+flatbuffers.SizeUint32)
+	return x
+}
+
+func (rcv *ExecRequestBatchRaw) Init(buf []byte, i flatbuffers.UOffsetT) {
+	rcv._tab.Bytes = buf
+	rcv._tab.Pos = i
+}
+
+func (rcv *ExecRequestBatchRaw) Table() flatbuffers.Table {
+	return rcv._tab
+}
+
+func (rcv *ExecRequestBatchRaw) Requests(obj *ExecRequestRaw, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ExecRequestBatchRaw) RequestsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func ExecRequestBatchRawStart(builder *flatbuffers.Builder) {
+	builder.StartObject(1)
+}
+func ExecRequestBatchRawAddRequests(builder *flatbuffers.Builder, requests flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(requests), 0)
+}
+func ExecRequestBatchRawStartRequestsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ExecRequestBatchRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	return builder.EndObject()
+}
+
 type SignalUpdateRawT struct {
 	NewMax []uint64 `json:"new_max"`
 }
@@ -2453,11 +2619,14 @@ func ExecutingMessageRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 }
 
 type CallInfoRawT struct {
-	Flags  CallFlag          `json:"flags"`
-	Error  int32             `json:"error"`
-	Signal []uint64          `json:"signal"`
-	Cover  []uint64          `json:"cover"`
-	Comps  []*ComparisonRawT `json:"comps"`
+	Flags        CallFlag          `json:"flags"`
+	Error        int32             `json:"error"`
+	Signal       []uint64          `json:"signal"`
+	Cover        []uint64          `json:"cover"`
+	Comps        []*ComparisonRawT `json:"comps"`
+	Feedback     []uint64          `json:"feedback"`
+	PackedSignal []byte            `json:"packed_signal"`
+	PackedCover  []byte            `json:"packed_cover"`
 }
 
 func (t *CallInfoRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
@@ -2491,12 +2660,32 @@ func (t *CallInfoRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 		}
 		compsOffset = builder.EndVector(compsLength)
 	}
+	feedbackOffset := flatbuffers.UOffsetT(0)
+	if t.Feedback != nil {
+		feedbackLength := len(t.Feedback)
+		CallInfoRawStartFeedbackVector(builder, feedbackLength)
+		for j := feedbackLength - 1; j >= 0; j-- {
+			builder.PrependUint64(t.Feedback[j])
+		}
+		feedbackOffset = builder.EndVector(feedbackLength)
+	}
+	packedSignalOffset := flatbuffers.UOffsetT(0)
+	if t.PackedSignal != nil {
+		packedSignalOffset = builder.CreateByteString(t.PackedSignal)
+	}
+	packedCoverOffset := flatbuffers.UOffsetT(0)
+	if t.PackedCover != nil {
+		packedCoverOffset = builder.CreateByteString(t.PackedCover)
+	}
 	CallInfoRawStart(builder)
 	CallInfoRawAddFlags(builder, t.Flags)
 	CallInfoRawAddError(builder, t.Error)
 	CallInfoRawAddSignal(builder, signalOffset)
 	CallInfoRawAddCover(builder, coverOffset)
 	CallInfoRawAddComps(builder, compsOffset)
+	CallInfoRawAddFeedback(builder, feedbackOffset)
+	CallInfoRawAddPackedSignal(builder, packedSignalOffset)
+	CallInfoRawAddPackedCover(builder, packedCoverOffset)
 	return CallInfoRawEnd(builder)
 }
 
@@ -2520,6 +2709,13 @@ func (rcv *CallInfoRaw) UnPackTo(t *CallInfoRawT) {
 		rcv.Comps(&x, j)
 		t.Comps[j] = x.UnPack()
 	}
+	feedbackLength := rcv.FeedbackLength()
+	t.Feedback = make([]uint64, feedbackLength)
+	for j := 0; j < feedbackLength; j++ {
+		t.Feedback[j] = rcv.Feedback(j)
+	}
+	t.PackedSignal = rcv.PackedSignalBytes()
+	t.PackedCover = rcv.PackedCoverBytes()
 }
 
 func (rcv *CallInfoRaw) UnPack() *CallInfoRawT {
@@ -2653,8 +2849,102 @@ func (rcv *CallInfoRaw) CompsLength() int {
 	return 0
 }
 
+func (rcv *CallInfoRaw) Feedback(j int) uint64 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.GetUint64(a + flatbuffers.UOffsetT(j*8))
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) FeedbackLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) MutateFeedback(j int, n uint64) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(14))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.MutateUint64(a+flatbuffers.UOffsetT(j*8), n)
+	}
+	return false
+}
+
+func (rcv *CallInfoRaw) PackedSignal(j int) byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.GetByte(a + flatbuffers.UOffsetT(j*1))
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) PackedSignalLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) PackedSignalBytes() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *CallInfoRaw) MutatePackedSignal(j int, n byte) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(16))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.MutateByte(a+flatbuffers.UOffsetT(j*1), n)
+	}
+	return false
+}
+
+func (rcv *CallInfoRaw) PackedCover(j int) byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.GetByte(a + flatbuffers.UOffsetT(j*1))
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) PackedCoverLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *CallInfoRaw) PackedCoverBytes() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *CallInfoRaw) MutatePackedCover(j int, n byte) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(18))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.MutateByte(a+flatbuffers.UOffsetT(j*1), n)
+	}
+	return false
+}
+
 func CallInfoRawStart(builder *flatbuffers.Builder) {
-	builder.StartObject(5)
+	builder.StartObject(8)
 }
 func CallInfoRawAddFlags(builder *flatbuffers.Builder, flags CallFlag) {
 	builder.PrependByteSlot(0, byte(flags), 0)
@@ -2680,6 +2970,24 @@ func CallInfoRawAddComps(builder *flatbuffers.Builder, comps flatbuffers.UOffset
 func CallInfoRawStartCompsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
 	return builder.StartVector(32, numElems, 8)
 }
+func CallInfoRawAddFeedback(builder *flatbuffers.Builder, feedback flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(5, flatbuffers.UOffsetT(feedback), 0)
+}
+func CallInfoRawStartFeedbackVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(8, numElems, 8)
+}
+func CallInfoRawAddPackedSignal(builder *flatbuffers.Builder, packedSignal flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(6, flatbuffers.UOffsetT(packedSignal), 0)
+}
+func CallInfoRawStartPackedSignalVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(1, numElems, 1)
+}
+func CallInfoRawAddPackedCover(builder *flatbuffers.Builder, packedCover flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(7, flatbuffers.UOffsetT(packedCover), 0)
+}
+func CallInfoRawStartPackedCoverVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(1, numElems, 1)
+}
 func CallInfoRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
@@ -3166,6 +3474,215 @@ func ExecResultRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
 
+type ExecResultBatchRawT struct {
+	Results []*ExecResultDataRawT `json:"results"`
+}
+
+func (t *ExecResultBatchRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	if t == nil {
+		return 0
+	}
+	resultsOffset := flatbuffers.UOffsetT(0)
+	if t.Results != nil {
+		resultsLength := len(t.Results)
+		resultsOffsets := make([]flatbuffers.UOffsetT, resultsLength)
+		for j := 0; j < resultsLength; j++ {
+			resultsOffsets[j] = t.Results[j].Pack(builder)
+		}
+		ExecResultBatchRawStartResultsVector(builder, resultsLength)
+		for j := resultsLength - 1; j >= 0; j-- {
+			builder.PrependUOffsetT(resultsOffsets[j])
+		}
+		resultsOffset = builder.EndVector(resultsLength)
+	}
+	ExecResultBatchRawStart(builder)
+	ExecResultBatchRawAddResults(builder, resultsOffset)
+	return ExecResultBatchRawEnd(builder)
+}
+
+func (rcv *ExecResultBatchRaw) UnPackTo(t *ExecResultBatchRawT) {
+	resultsLength := rcv.ResultsLength()
+	t.Results = make([]*ExecResultDataRawT, resultsLength)
+	for j := 0; j < resultsLength; j++ {
+		x := ExecResultDataRaw{}
+		rcv.Results(&x, j)
+		t.Results[j] = x.UnPack()
+	}
+}
+
+func (rcv *ExecResultBatchRaw) UnPack() *ExecResultBatchRawT {
+	if rcv == nil {
+		return nil
+	}
+	t := &ExecResultBatchRawT{}
+	rcv.UnPackTo(t)
+	return t
+}
+
+type ExecResultBatchRaw struct {
+	_tab flatbuffers.Table
+}
+
+func GetRootAsExecResultBatchRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecResultBatchRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset:])
+	x := &ExecResultBatchRaw{}
+	x.Init(buf, n+offset)
+	return x
+}
+
+func GetSizePrefixedRootAsExecResultBatchRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecResultBatchRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset+flatbuffers.SizeUint32:])
+	x := &ExecResultBatchRaw{}
+	x.Init(buf, n+offset+flatbuffers.SizeUint32)
+	return x
+}
+
+func (rcv *ExecResultBatchRaw) Init(buf []byte, i flatbuffers.UOffsetT) {
+	rcv._tab.Bytes = buf
+	rcv._tab.Pos = i
+}
+
+func (rcv *ExecResultBatchRaw) Table() flatbuffers.Table {
+	return rcv._tab
+}
+
+func (rcv *ExecResultBatchRaw) Results(obj *ExecResultDataRaw, j int) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		x := rcv._tab.Vector(o)
+		x += flatbuffers.UOffsetT(j) * 4
+		x = rcv._tab.Indirect(x)
+		obj.Init(rcv._tab.Bytes, x)
+		return true
+	}
+	return false
+}
+
+func (rcv *ExecResultBatchRaw) ResultsLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func ExecResultBatchRawStart(builder *flatbuffers.Builder) {
+	builder.StartObject(1)
+}
+func ExecResultBatchRawAddResults(builder *flatbuffers.Builder, results flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(results), 0)
+}
+func ExecResultBatchRawStartResultsVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(4, numElems, 4)
+}
+func ExecResultBatchRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	return builder.EndObject()
+}
+
+type ExecResultDataRawT struct {
+	Data []byte `json:"data"`
+}
+
+func (t *ExecResultDataRawT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	if t == nil {
+		return 0
+	}
+	dataOffset := flatbuffers.UOffsetT(0)
+	if t.Data != nil {
+		dataOffset = builder.CreateByteString(t.Data)
+	}
+	ExecResultDataRawStart(builder)
+	ExecResultDataRawAddData(builder, dataOffset)
+	return ExecResultDataRawEnd(builder)
+}
+
+func (rcv *ExecResultDataRaw) UnPackTo(t *ExecResultDataRawT) {
+	t.Data = rcv.DataBytes()
+}
+
+func (rcv *ExecResultDataRaw) UnPack() *ExecResultDataRawT {
+	if rcv == nil {
+		return nil
+	}
+	t := &ExecResultDataRawT{}
+	rcv.UnPackTo(t)
+	return t
+}
+
+type ExecResultDataRaw struct {
+	_tab flatbuffers.Table
+}
+
+func GetRootAsExecResultDataRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecResultDataRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset:])
+	x := &ExecResultDataRaw{}
+	x.Init(buf, n+offset)
+	return x
+}
+
+func GetSizePrefixedRootAsExecResultDataRaw(buf []byte, offset flatbuffers.UOffsetT) *ExecResultDataRaw {
+	n := flatbuffers.GetUOffsetT(buf[offset+flatbuffers.SizeUint32:])
+	x := &ExecResultDataRaw{}
+	x.Init(buf, n+offset+flatbuffers.SizeUint32)
+	return x
+}
+
+func (rcv *ExecResultDataRaw) Init(buf []byte, i flatbuffers.UOffsetT) {
+	rcv._tab.Bytes = buf
+	rcv._tab.Pos = i
+}
+
+func (rcv *ExecResultDataRaw) Table() flatbuffers.Table {
+	return rcv._tab
+}
+
+func (rcv *ExecResultDataRaw) Data(j int) byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.GetByte(a + flatbuffers.UOffsetT(j*1))
+	}
+	return 0
+}
+
+func (rcv *ExecResultDataRaw) DataLength() int {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.VectorLen(o)
+	}
+	return 0
+}
+
+func (rcv *ExecResultDataRaw) DataBytes() []byte {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		return rcv._tab.ByteVector(o + rcv._tab.Pos)
+	}
+	return nil
+}
+
+func (rcv *ExecResultDataRaw) MutateData(j int, n byte) bool {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(4))
+	if o != 0 {
+		a := rcv._tab.Vector(o)
+		return rcv._tab.MutateByte(a+flatbuffers.UOffsetT(j*1), n)
+	}
+	return false
+}
+
+func ExecResultDataRawStart(builder *flatbuffers.Builder) {
+	builder.StartObject(1)
+}
+func ExecResultDataRawAddData(builder *flatbuffers.Builder, data flatbuffers.UOffsetT) {
+	builder.PrependUOffsetTSlot(0, flatbuffers.UOffsetT(data), 0)
+}
+func ExecResultDataRawStartDataVector(builder *flatbuffers.Builder, numElems int) flatbuffers.UOffsetT {
+	return builder.StartVector(1, numElems, 1)
+}
+func ExecResultDataRawEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
+	return builder.EndObject()
+}
+
 type StateResultRawT struct {
 	Data []byte `json:"data"`
 }
@@ -3390,6 +3907,7 @@ type SnapshotHandshakeT struct {
 	Features         Feature `json:"features"`
 	EnvFlags         ExecEnv `json:"env_flags"`
 	SandboxArg       int64   `json:"sandbox_arg"`
+	Procs            int32   `json:"procs"`
 }
 
 func (t *SnapshotHandshakeT) Pack(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
@@ -3405,6 +3923,7 @@ func (t *SnapshotHandshakeT) Pack(builder *flatbuffers.Builder) flatbuffers.UOff
 	SnapshotHandshakeAddFeatures(builder, t.Features)
 	SnapshotHandshakeAddEnvFlags(builder, t.EnvFlags)
 	SnapshotHandshakeAddSandboxArg(builder, t.SandboxArg)
+	SnapshotHandshakeAddProcs(builder, t.Procs)
 	return SnapshotHandshakeEnd(builder)
 }
 
@@ -3417,6 +3936,7 @@ func (rcv *SnapshotHandshake) UnPackTo(t *SnapshotHandshakeT) {
 	t.Features = rcv.Features()
 	t.EnvFlags = rcv.EnvFlags()
 	t.SandboxArg = rcv.SandboxArg()
+	t.Procs = rcv.Procs()
 }
 
 func (rcv *SnapshotHandshake) UnPack() *SnapshotHandshakeT {
@@ -3551,8 +4071,20 @@ func (rcv *SnapshotHandshake) MutateSandboxArg(n int64) bool {
 	return rcv._tab.MutateInt64Slot(18, n)
 }
 
+func (rcv *SnapshotHandshake) Procs() int32 {
+	o := flatbuffers.UOffsetT(rcv._tab.Offset(20))
+	if o != 0 {
+		return rcv._tab.GetInt32(o + rcv._tab.Pos)
+	}
+	return 0
+}
+
+func (rcv *SnapshotHandshake) MutateProcs(n int32) bool {
+	return rcv._tab.MutateInt32Slot(20, n)
+}
+
 func SnapshotHandshakeStart(builder *flatbuffers.Builder) {
-	builder.StartObject(8)
+	builder.StartObject(9)
 }
 func SnapshotHandshakeAddCoverEdges(builder *flatbuffers.Builder, coverEdges bool) {
 	builder.PrependBoolSlot(0, coverEdges, false)
@@ -3578,6 +4110,9 @@ func SnapshotHandshakeAddEnvFlags(builder *flatbuffers.Builder, envFlags ExecEnv
 func SnapshotHandshakeAddSandboxArg(builder *flatbuffers.Builder, sandboxArg int64) {
 	builder.PrependInt64Slot(7, sandboxArg, 0)
 }
+func SnapshotHandshakeAddProcs(builder *flatbuffers.Builder, procs int32) {
+	builder.PrependInt32Slot(8, procs, 0)
+}
 func SnapshotHandshakeEnd(builder *flatbuffers.Builder) flatbuffers.UOffsetT {
 	return builder.EndObject()
 }
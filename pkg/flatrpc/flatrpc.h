@@ -57,6 +57,10 @@ struct ExecRequestRaw;
 struct ExecRequestRawBuilder;
 struct ExecRequestRawT;
 
+struct ExecRequestBatchRaw;
+struct ExecRequestBatchRawBuilder;
+struct ExecRequestBatchRawT;
+
 struct SignalUpdateRaw;
 struct SignalUpdateRawBuilder;
 struct SignalUpdateRawT;
@@ -87,6 +91,14 @@ struct ExecResultRaw;
 struct ExecResultRawBuilder;
 struct ExecResultRawT;
 
+struct ExecResultDataRaw;
+struct ExecResultDataRawBuilder;
+struct ExecResultDataRawT;
+
+struct ExecResultBatchRaw;
+struct ExecResultBatchRawBuilder;
+struct ExecResultBatchRawT;
+
 struct StateResultRaw;
 struct StateResultRawBuilder;
 struct StateResultRawT;
@@ -104,16 +116,18 @@ struct SnapshotRequestBuilder;
 struct SnapshotRequestT;
 
 enum class Const : uint64_t {
+  MaxSnapshotProcs = 4ULL,
   SnapshotDoorbellSize = 4096ULL,
   MaxInputSize = 4198400ULL,
   MaxOutputSize = 14680064ULL,
   SnapshotShmemSize = 33554432ULL,
-  MIN = SnapshotDoorbellSize,
+  MIN = MaxSnapshotProcs,
   MAX = SnapshotShmemSize
 };
 
-inline const Const (&EnumValuesConst())[4] {
+inline const Const (&EnumValuesConst())[5] {
   static const Const values[] = {
+    Const::MaxSnapshotProcs,
     Const::SnapshotDoorbellSize,
     Const::MaxInputSize,
     Const::MaxOutputSize,
@@ -124,6 +138,7 @@ inline const Const (&EnumValuesConst())[4] {
 
 inline const char *EnumNameConst(Const e) {
   switch (e) {
+    case Const::MaxSnapshotProcs: return "MaxSnapshotProcs";
     case Const::SnapshotDoorbellSize: return "SnapshotDoorbellSize";
     case Const::MaxInputSize: return "MaxInputSize";
     case Const::MaxOutputSize: return "MaxOutputSize";
@@ -219,35 +234,38 @@ enum class HostMessagesRaw : uint8_t {
   SignalUpdate = 2,
   CorpusTriaged = 3,
   StateRequest = 4,
+  ExecRequestBatch = 5,
   MIN = NONE,
-  MAX = StateRequest
+  MAX = ExecRequestBatch
 };
 
-inline const HostMessagesRaw (&EnumValuesHostMessagesRaw())[5] {
+inline const HostMessagesRaw (&EnumValuesHostMessagesRaw())[6] {
   static const HostMessagesRaw values[] = {
     HostMessagesRaw::NONE,
     HostMessagesRaw::ExecRequest,
     HostMessagesRaw::SignalUpdate,
     HostMessagesRaw::CorpusTriaged,
-    HostMessagesRaw::StateRequest
+    HostMessagesRaw::StateRequest,
+    HostMessagesRaw::ExecRequestBatch
   };
   return values;
 }
 
 inline const char * const *EnumNamesHostMessagesRaw() {
-  static const char * const names[6] = {
+  static const char * const names[7] = {
     "NONE",
     "ExecRequest",
     "SignalUpdate",
     "CorpusTriaged",
     "StateRequest",
+    "ExecRequestBatch",
     nullptr
   };
   return names;
 }
 
 inline const char *EnumNameHostMessagesRaw(HostMessagesRaw e) {
-  if (flatbuffers::IsOutRange(e, HostMessagesRaw::NONE, HostMessagesRaw::StateRequest)) return "";
+  if (flatbuffers::IsOutRange(e, HostMessagesRaw::NONE, HostMessagesRaw::ExecRequestBatch)) return "";
   const size_t index = static_cast<size_t>(e);
   return EnumNamesHostMessagesRaw()[index];
 }
@@ -272,6 +290,10 @@ template<> struct HostMessagesRawTraits<rpc::StateRequestRaw> {
   static const HostMessagesRaw enum_value = HostMessagesRaw::StateRequest;
 };
 
+template<> struct HostMessagesRawTraits<rpc::ExecRequestBatchRaw> {
+  static const HostMessagesRaw enum_value = HostMessagesRaw::ExecRequestBatch;
+};
+
 template<typename T> struct HostMessagesRawUnionTraits {
   static const HostMessagesRaw enum_value = HostMessagesRaw::NONE;
 };
@@ -292,6 +314,10 @@ template<> struct HostMessagesRawUnionTraits<rpc::StateRequestRawT> {
   static const HostMessagesRaw enum_value = HostMessagesRaw::StateRequest;
 };
 
+template<> struct HostMessagesRawUnionTraits<rpc::ExecRequestBatchRawT> {
+  static const HostMessagesRaw enum_value = HostMessagesRaw::ExecRequestBatch;
+};
+
 struct HostMessagesRawUnion {
   HostMessagesRaw type;
   void *value;
@@ -354,6 +380,14 @@ struct HostMessagesRawUnion {
     return type == HostMessagesRaw::StateRequest ?
       reinterpret_cast<const rpc::StateRequestRawT *>(value) : nullptr;
   }
+  rpc::ExecRequestBatchRawT *AsExecRequestBatch() {
+    return type == HostMessagesRaw::ExecRequestBatch ?
+      reinterpret_cast<rpc::ExecRequestBatchRawT *>(value) : nullptr;
+  }
+  const rpc::ExecRequestBatchRawT *AsExecRequestBatch() const {
+    return type == HostMessagesRaw::ExecRequestBatch ?
+      reinterpret_cast<const rpc::ExecRequestBatchRawT *>(value) : nullptr;
+  }
 };
 
 bool VerifyHostMessagesRaw(flatbuffers::Verifier &verifier, const void *obj, HostMessagesRaw type);
@@ -364,33 +398,36 @@ enum class ExecutorMessagesRaw : uint8_t {
   ExecResult = 1,
   Executing = 2,
   State = 3,
+  ExecResultBatch = 4,
   MIN = NONE,
-  MAX = State
+  MAX = ExecResultBatch
 };
 
-inline const ExecutorMessagesRaw (&EnumValuesExecutorMessagesRaw())[4] {
+inline const ExecutorMessagesRaw (&EnumValuesExecutorMessagesRaw())[5] {
   static const ExecutorMessagesRaw values[] = {
     ExecutorMessagesRaw::NONE,
     ExecutorMessagesRaw::ExecResult,
     ExecutorMessagesRaw::Executing,
-    ExecutorMessagesRaw::State
+    ExecutorMessagesRaw::State,
+    ExecutorMessagesRaw::ExecResultBatch
   };
   return values;
 }
 
 inline const char * const *EnumNamesExecutorMessagesRaw() {
-  static const char * const names[5] = {
+  static const char * const names[6] = {
     "NONE",
     "ExecResult",
     "Executing",
     "State",
+    "ExecResultBatch",
     nullptr
   };
   return names;
 }
 
 inline const char *EnumNameExecutorMessagesRaw(ExecutorMessagesRaw e) {
-  if (flatbuffers::IsOutRange(e, ExecutorMessagesRaw::NONE, ExecutorMessagesRaw::State)) return "";
+  if (flatbuffers::IsOutRange(e, ExecutorMessagesRaw::NONE, ExecutorMessagesRaw::ExecResultBatch)) return "";
   const size_t index = static_cast<size_t>(e);
   return EnumNamesExecutorMessagesRaw()[index];
 }
@@ -411,6 +448,10 @@ template<> struct ExecutorMessagesRawTraits<rpc::StateResultRaw> {
   static const ExecutorMessagesRaw enum_value = ExecutorMessagesRaw::State;
 };
 
+template<> struct ExecutorMessagesRawTraits<rpc::ExecResultBatchRaw> {
+  static const ExecutorMessagesRaw enum_value = ExecutorMessagesRaw::ExecResultBatch;
+};
+
 template<typename T> struct ExecutorMessagesRawUnionTraits {
   static const ExecutorMessagesRaw enum_value = ExecutorMessagesRaw::NONE;
 };
@@ -427,6 +468,10 @@ template<> struct ExecutorMessagesRawUnionTraits<rpc::StateResultRawT> {
   static const ExecutorMessagesRaw enum_value = ExecutorMessagesRaw::State;
 };
 
+template<> struct ExecutorMessagesRawUnionTraits<rpc::ExecResultBatchRawT> {
+  static const ExecutorMessagesRaw enum_value = ExecutorMessagesRaw::ExecResultBatch;
+};
+
 struct ExecutorMessagesRawUnion {
   ExecutorMessagesRaw type;
   void *value;
@@ -481,6 +526,14 @@ struct ExecutorMessagesRawUnion {
     return type == ExecutorMessagesRaw::State ?
       reinterpret_cast<const rpc::StateResultRawT *>(value) : nullptr;
   }
+  rpc::ExecResultBatchRawT *AsExecResultBatch() {
+    return type == ExecutorMessagesRaw::ExecResultBatch ?
+      reinterpret_cast<rpc::ExecResultBatchRawT *>(value) : nullptr;
+  }
+  const rpc::ExecResultBatchRawT *AsExecResultBatch() const {
+    return type == ExecutorMessagesRaw::ExecResultBatch ?
+      reinterpret_cast<const rpc::ExecResultBatchRawT *>(value) : nullptr;
+  }
 };
 
 bool VerifyExecutorMessagesRaw(flatbuffers::Verifier &verifier, const void *obj, ExecutorMessagesRaw type);
@@ -724,6 +777,36 @@ inline const char *EnumNameCallFlag(CallFlag e) {
   return EnumNamesCallFlag()[index];
 }
 
+enum class FeedbackKind : uint64_t {
+  ContextSwitches = 0,
+  PageFaults = 1ULL,
+  MIN = ContextSwitches,
+  MAX = PageFaults
+};
+
+inline const FeedbackKind (&EnumValuesFeedbackKind())[2] {
+  static const FeedbackKind values[] = {
+    FeedbackKind::ContextSwitches,
+    FeedbackKind::PageFaults
+  };
+  return values;
+}
+
+inline const char * const *EnumNamesFeedbackKind() {
+  static const char * const names[3] = {
+    "ContextSwitches",
+    "PageFaults",
+    nullptr
+  };
+  return names;
+}
+
+inline const char *EnumNameFeedbackKind(FeedbackKind e) {
+  if (flatbuffers::IsOutRange(e, FeedbackKind::ContextSwitches, FeedbackKind::PageFaults)) return "";
+  const size_t index = static_cast<size_t>(e);
+  return EnumNamesFeedbackKind()[index];
+}
+
 enum class SnapshotState : uint64_t {
   Initial = 0,
   Handshake = 1ULL,
@@ -965,6 +1048,7 @@ struct ConnectReplyRawT : public flatbuffers::NativeTable {
   std::vector<std::string> race_frames{};
   rpc::Feature features = static_cast<rpc::Feature>(0);
   std::vector<std::string> files{};
+  bool feedback = false;
 };
 
 struct ConnectReplyRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
@@ -982,7 +1066,8 @@ struct ConnectReplyRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
     VT_LEAK_FRAMES = 20,
     VT_RACE_FRAMES = 22,
     VT_FEATURES = 24,
-    VT_FILES = 26
+    VT_FILES = 26,
+    VT_FEEDBACK = 28
   };
   bool debug() const {
     return GetField<uint8_t>(VT_DEBUG, 0) != 0;
@@ -1020,6 +1105,9 @@ struct ConnectReplyRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
   const flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>> *files() const {
     return GetPointer<const flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>> *>(VT_FILES);
   }
+  bool feedback() const {
+    return GetField<uint8_t>(VT_FEEDBACK, 0) != 0;
+  }
   bool Verify(flatbuffers::Verifier &verifier) const {
     return VerifyTableStart(verifier) &&
            VerifyField<uint8_t>(verifier, VT_DEBUG, 1) &&
@@ -1040,6 +1128,7 @@ struct ConnectReplyRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
            VerifyOffset(verifier, VT_FILES) &&
            verifier.VerifyVector(files()) &&
            verifier.VerifyVectorOfStrings(files()) &&
+           VerifyField<uint8_t>(verifier, VT_FEEDBACK, 1) &&
            verifier.EndTable();
   }
   ConnectReplyRawT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
@@ -1087,6 +1176,9 @@ struct ConnectReplyRawBuilder {
   void add_files(flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>>> files) {
     fbb_.AddOffset(ConnectReplyRaw::VT_FILES, files);
   }
+  void add_feedback(bool feedback) {
+    fbb_.AddElement<uint8_t>(ConnectReplyRaw::VT_FEEDBACK, static_cast<uint8_t>(feedback), 0);
+  }
   explicit ConnectReplyRawBuilder(flatbuffers::FlatBufferBuilder &_fbb)
         : fbb_(_fbb) {
     start_ = fbb_.StartTable();
@@ -1111,7 +1203,8 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRaw(
     flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>>> leak_frames = 0,
     flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>>> race_frames = 0,
     rpc::Feature features = static_cast<rpc::Feature>(0),
-    flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>>> files = 0) {
+    flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<flatbuffers::String>>> files = 0,
+    bool feedback = false) {
   ConnectReplyRawBuilder builder_(_fbb);
   builder_.add_features(features);
   builder_.add_files(files);
@@ -1121,6 +1214,7 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRaw(
   builder_.add_syscall_timeout_ms(syscall_timeout_ms);
   builder_.add_slowdown(slowdown);
   builder_.add_procs(procs);
+  builder_.add_feedback(feedback);
   builder_.add_kernel_64_bit(kernel_64_bit);
   builder_.add_cover_edges(cover_edges);
   builder_.add_cover(cover);
@@ -1141,7 +1235,8 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRawDirect(
     const std::vector<flatbuffers::Offset<flatbuffers::String>> *leak_frames = nullptr,
     const std::vector<flatbuffers::Offset<flatbuffers::String>> *race_frames = nullptr,
     rpc::Feature features = static_cast<rpc::Feature>(0),
-    const std::vector<flatbuffers::Offset<flatbuffers::String>> *files = nullptr) {
+    const std::vector<flatbuffers::Offset<flatbuffers::String>> *files = nullptr,
+    bool feedback = false) {
   auto leak_frames__ = leak_frames ? _fbb.CreateVector<flatbuffers::Offset<flatbuffers::String>>(*leak_frames) : 0;
   auto race_frames__ = race_frames ? _fbb.CreateVector<flatbuffers::Offset<flatbuffers::String>>(*race_frames) : 0;
   auto files__ = files ? _fbb.CreateVector<flatbuffers::Offset<flatbuffers::String>>(*files) : 0;
@@ -1158,7 +1253,8 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRawDirect(
       leak_frames__,
       race_frames__,
       features,
-      files__);
+      files__,
+      feedback);
 }
 
 flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRaw(flatbuffers::FlatBufferBuilder &_fbb, const ConnectReplyRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
@@ -1625,6 +1721,9 @@ struct HostMessageRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
   const rpc::StateRequestRaw *msg_as_StateRequest() const {
     return msg_type() == rpc::HostMessagesRaw::StateRequest ? static_cast<const rpc::StateRequestRaw *>(msg()) : nullptr;
   }
+  const rpc::ExecRequestBatchRaw *msg_as_ExecRequestBatch() const {
+    return msg_type() == rpc::HostMessagesRaw::ExecRequestBatch ? static_cast<const rpc::ExecRequestBatchRaw *>(msg()) : nullptr;
+  }
   bool Verify(flatbuffers::Verifier &verifier) const {
     return VerifyTableStart(verifier) &&
            VerifyField<uint8_t>(verifier, VT_MSG_TYPE, 1) &&
@@ -1653,6 +1752,10 @@ template<> inline const rpc::StateRequestRaw *HostMessageRaw::msg_as<rpc::StateR
   return msg_as_StateRequest();
 }
 
+template<> inline const rpc::ExecRequestBatchRaw *HostMessageRaw::msg_as<rpc::ExecRequestBatchRaw>() const {
+  return msg_as_ExecRequestBatch();
+}
+
 struct HostMessageRawBuilder {
   typedef HostMessageRaw Table;
   flatbuffers::FlatBufferBuilder &fbb_;
@@ -1714,6 +1817,9 @@ struct ExecutorMessageRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
   const rpc::StateResultRaw *msg_as_State() const {
     return msg_type() == rpc::ExecutorMessagesRaw::State ? static_cast<const rpc::StateResultRaw *>(msg()) : nullptr;
   }
+  const rpc::ExecResultBatchRaw *msg_as_ExecResultBatch() const {
+    return msg_type() == rpc::ExecutorMessagesRaw::ExecResultBatch ? static_cast<const rpc::ExecResultBatchRaw *>(msg()) : nullptr;
+  }
   bool Verify(flatbuffers::Verifier &verifier) const {
     return VerifyTableStart(verifier) &&
            VerifyField<uint8_t>(verifier, VT_MSG_TYPE, 1) &&
@@ -1738,6 +1844,10 @@ template<> inline const rpc::StateResultRaw *ExecutorMessageRaw::msg_as<rpc::Sta
   return msg_as_State();
 }
 
+template<> inline const rpc::ExecResultBatchRaw *ExecutorMessageRaw::msg_as<rpc::ExecResultBatchRaw>() const {
+  return msg_as_ExecResultBatch();
+}
+
 struct ExecutorMessageRawBuilder {
   typedef ExecutorMessageRaw Table;
   flatbuffers::FlatBufferBuilder &fbb_;
@@ -1917,6 +2027,73 @@ inline flatbuffers::Offset<ExecRequestRaw> CreateExecRequestRawDirect(
 
 flatbuffers::Offset<ExecRequestRaw> CreateExecRequestRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecRequestRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
 
+struct ExecRequestBatchRawT : public flatbuffers::NativeTable {
+  typedef ExecRequestBatchRaw TableType;
+  std::vector<std::unique_ptr<rpc::ExecRequestRawT>> requests{};
+  ExecRequestBatchRawT() = default;
+  ExecRequestBatchRawT(const ExecRequestBatchRawT &o);
+  ExecRequestBatchRawT(ExecRequestBatchRawT&&) FLATBUFFERS_NOEXCEPT = default;
+  ExecRequestBatchRawT &operator=(ExecRequestBatchRawT o) FLATBUFFERS_NOEXCEPT;
+};
+
+struct ExecRequestBatchRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
+  typedef ExecRequestBatchRawT NativeTableType;
+  typedef ExecRequestBatchRawBuilder Builder;
+  enum FlatBuffersVTableOffset FLATBUFFERS_VTABLE_UNDERLYING_TYPE {
+    VT_REQUESTS = 4
+  };
+  const flatbuffers::Vector<flatbuffers::Offset<rpc::ExecRequestRaw>> *requests() const {
+    return GetPointer<const flatbuffers::Vector<flatbuffers::Offset<rpc::ExecRequestRaw>> *>(VT_REQUESTS);
+  }
+  bool Verify(flatbuffers::Verifier &verifier) const {
+    return VerifyTableStart(verifier) &&
+           VerifyOffset(verifier, VT_REQUESTS) &&
+           verifier.VerifyVector(requests()) &&
+           verifier.VerifyVectorOfTables(requests()) &&
+           verifier.EndTable();
+  }
+  ExecRequestBatchRawT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  void UnPackTo(ExecRequestBatchRawT *_o, const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  static flatbuffers::Offset<ExecRequestBatchRaw> Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecRequestBatchRawT* _o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+};
+
+struct ExecRequestBatchRawBuilder {
+  typedef ExecRequestBatchRaw Table;
+  flatbuffers::FlatBufferBuilder &fbb_;
+  flatbuffers::uoffset_t start_;
+  void add_requests(flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<rpc::ExecRequestRaw>>> requests) {
+    fbb_.AddOffset(ExecRequestBatchRaw::VT_REQUESTS, requests);
+  }
+  explicit ExecRequestBatchRawBuilder(flatbuffers::FlatBufferBuilder &_fbb)
+        : fbb_(_fbb) {
+    start_ = fbb_.StartTable();
+  }
+  flatbuffers::Offset<ExecRequestBatchRaw> Finish() {
+    const auto end = fbb_.EndTable(start_);
+    auto o = flatbuffers::Offset<ExecRequestBatchRaw>(end);
+    return o;
+  }
+};
+
+inline flatbuffers::Offset<ExecRequestBatchRaw> CreateExecRequestBatchRaw(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<rpc::ExecRequestRaw>>> requests = 0) {
+  ExecRequestBatchRawBuilder builder_(_fbb);
+  builder_.add_requests(requests);
+  return builder_.Finish();
+}
+
+inline flatbuffers::Offset<ExecRequestBatchRaw> CreateExecRequestBatchRawDirect(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    const std::vector<flatbuffers::Offset<rpc::ExecRequestRaw>> *requests = nullptr) {
+  auto requests__ = requests ? _fbb.CreateVector<flatbuffers::Offset<rpc::ExecRequestRaw>>(*requests) : 0;
+  return rpc::CreateExecRequestBatchRaw(
+      _fbb,
+      requests__);
+}
+
+flatbuffers::Offset<ExecRequestBatchRaw> CreateExecRequestBatchRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecRequestBatchRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+
 struct SignalUpdateRawT : public flatbuffers::NativeTable {
   typedef SignalUpdateRaw TableType;
   std::vector<uint64_t> new_max{};
@@ -2149,6 +2326,9 @@ struct CallInfoRawT : public flatbuffers::NativeTable {
   std::vector<uint64_t> signal{};
   std::vector<uint64_t> cover{};
   std::vector<rpc::ComparisonRaw> comps{};
+  std::vector<uint64_t> feedback{};
+  std::vector<uint8_t> packed_signal{};
+  std::vector<uint8_t> packed_cover{};
 };
 
 struct CallInfoRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
@@ -2159,7 +2339,10 @@ struct CallInfoRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
     VT_ERROR = 6,
     VT_SIGNAL = 8,
     VT_COVER = 10,
-    VT_COMPS = 12
+    VT_COMPS = 12,
+    VT_FEEDBACK = 14,
+    VT_PACKED_SIGNAL = 16,
+    VT_PACKED_COVER = 18
   };
   rpc::CallFlag flags() const {
     return static_cast<rpc::CallFlag>(GetField<uint8_t>(VT_FLAGS, 0));
@@ -2176,6 +2359,15 @@ struct CallInfoRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
   const flatbuffers::Vector<const rpc::ComparisonRaw *> *comps() const {
     return GetPointer<const flatbuffers::Vector<const rpc::ComparisonRaw *> *>(VT_COMPS);
   }
+  const flatbuffers::Vector<uint64_t> *feedback() const {
+    return GetPointer<const flatbuffers::Vector<uint64_t> *>(VT_FEEDBACK);
+  }
+  const flatbuffers::Vector<uint8_t> *packed_signal() const {
+    return GetPointer<const flatbuffers::Vector<uint8_t> *>(VT_PACKED_SIGNAL);
+  }
+  const flatbuffers::Vector<uint8_t> *packed_cover() const {
+    return GetPointer<const flatbuffers::Vector<uint8_t> *>(VT_PACKED_COVER);
+  }
   bool Verify(flatbuffers::Verifier &verifier) const {
     return VerifyTableStart(verifier) &&
            VerifyField<uint8_t>(verifier, VT_FLAGS, 1) &&
@@ -2186,6 +2378,12 @@ struct CallInfoRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
            verifier.VerifyVector(cover()) &&
            VerifyOffset(verifier, VT_COMPS) &&
            verifier.VerifyVector(comps()) &&
+           VerifyOffset(verifier, VT_FEEDBACK) &&
+           verifier.VerifyVector(feedback()) &&
+           VerifyOffset(verifier, VT_PACKED_SIGNAL) &&
+           verifier.VerifyVector(packed_signal()) &&
+           VerifyOffset(verifier, VT_PACKED_COVER) &&
+           verifier.VerifyVector(packed_cover()) &&
            verifier.EndTable();
   }
   CallInfoRawT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
@@ -2212,6 +2410,15 @@ struct CallInfoRawBuilder {
   void add_comps(flatbuffers::Offset<flatbuffers::Vector<const rpc::ComparisonRaw *>> comps) {
     fbb_.AddOffset(CallInfoRaw::VT_COMPS, comps);
   }
+  void add_feedback(flatbuffers::Offset<flatbuffers::Vector<uint64_t>> feedback) {
+    fbb_.AddOffset(CallInfoRaw::VT_FEEDBACK, feedback);
+  }
+  void add_packed_signal(flatbuffers::Offset<flatbuffers::Vector<uint8_t>> packed_signal) {
+    fbb_.AddOffset(CallInfoRaw::VT_PACKED_SIGNAL, packed_signal);
+  }
+  void add_packed_cover(flatbuffers::Offset<flatbuffers::Vector<uint8_t>> packed_cover) {
+    fbb_.AddOffset(CallInfoRaw::VT_PACKED_COVER, packed_cover);
+  }
   explicit CallInfoRawBuilder(flatbuffers::FlatBufferBuilder &_fbb)
         : fbb_(_fbb) {
     start_ = fbb_.StartTable();
@@ -2229,8 +2436,14 @@ inline flatbuffers::Offset<CallInfoRaw> CreateCallInfoRaw(
     int32_t error = 0,
     flatbuffers::Offset<flatbuffers::Vector<uint64_t>> signal = 0,
     flatbuffers::Offset<flatbuffers::Vector<uint64_t>> cover = 0,
-    flatbuffers::Offset<flatbuffers::Vector<const rpc::ComparisonRaw *>> comps = 0) {
+    flatbuffers::Offset<flatbuffers::Vector<const rpc::ComparisonRaw *>> comps = 0,
+    flatbuffers::Offset<flatbuffers::Vector<uint64_t>> feedback = 0,
+    flatbuffers::Offset<flatbuffers::Vector<uint8_t>> packed_signal = 0,
+    flatbuffers::Offset<flatbuffers::Vector<uint8_t>> packed_cover = 0) {
   CallInfoRawBuilder builder_(_fbb);
+  builder_.add_packed_cover(packed_cover);
+  builder_.add_packed_signal(packed_signal);
+  builder_.add_feedback(feedback);
   builder_.add_comps(comps);
   builder_.add_cover(cover);
   builder_.add_signal(signal);
@@ -2245,17 +2458,26 @@ inline flatbuffers::Offset<CallInfoRaw> CreateCallInfoRawDirect(
     int32_t error = 0,
     const std::vector<uint64_t> *signal = nullptr,
     const std::vector<uint64_t> *cover = nullptr,
-    const std::vector<rpc::ComparisonRaw> *comps = nullptr) {
+    const std::vector<rpc::ComparisonRaw> *comps = nullptr,
+    const std::vector<uint64_t> *feedback = nullptr,
+    const std::vector<uint8_t> *packed_signal = nullptr,
+    const std::vector<uint8_t> *packed_cover = nullptr) {
   auto signal__ = signal ? _fbb.CreateVector<uint64_t>(*signal) : 0;
   auto cover__ = cover ? _fbb.CreateVector<uint64_t>(*cover) : 0;
   auto comps__ = comps ? _fbb.CreateVectorOfStructs<rpc::ComparisonRaw>(*comps) : 0;
+  auto feedback__ = feedback ? _fbb.CreateVector<uint64_t>(*feedback) : 0;
+  auto packed_signal__ = packed_signal ? _fbb.CreateVector<uint8_t>(*packed_signal) : 0;
+  auto packed_cover__ = packed_cover ? _fbb.CreateVector<uint8_t>(*packed_cover) : 0;
   return rpc::CreateCallInfoRaw(
       _fbb,
       flags,
       error,
       signal__,
       cover__,
-      comps__);
+      comps__,
+      feedback__,
+      packed_signal__,
+      packed_cover__);
 }
 
 flatbuffers::Offset<CallInfoRaw> CreateCallInfoRaw(flatbuffers::FlatBufferBuilder &_fbb, const CallInfoRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
@@ -2517,6 +2739,135 @@ inline flatbuffers::Offset<ExecResultRaw> CreateExecResultRawDirect(
 
 flatbuffers::Offset<ExecResultRaw> CreateExecResultRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
 
+struct ExecResultDataRawT : public flatbuffers::NativeTable {
+  typedef ExecResultDataRaw TableType;
+  std::vector<uint8_t> data{};
+};
+
+struct ExecResultDataRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
+  typedef ExecResultDataRawT NativeTableType;
+  typedef ExecResultDataRawBuilder Builder;
+  enum FlatBuffersVTableOffset FLATBUFFERS_VTABLE_UNDERLYING_TYPE {
+    VT_DATA = 4
+  };
+  const flatbuffers::Vector<uint8_t> *data() const {
+    return GetPointer<const flatbuffers::Vector<uint8_t> *>(VT_DATA);
+  }
+  bool Verify(flatbuffers::Verifier &verifier) const {
+    return VerifyTableStart(verifier) &&
+           VerifyOffset(verifier, VT_DATA) &&
+           verifier.VerifyVector(data()) &&
+           verifier.EndTable();
+  }
+  ExecResultDataRawT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  void UnPackTo(ExecResultDataRawT *_o, const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  static flatbuffers::Offset<ExecResultDataRaw> Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultDataRawT* _o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+};
+
+struct ExecResultDataRawBuilder {
+  typedef ExecResultDataRaw Table;
+  flatbuffers::FlatBufferBuilder &fbb_;
+  flatbuffers::uoffset_t start_;
+  void add_data(flatbuffers::Offset<flatbuffers::Vector<uint8_t>> data) {
+    fbb_.AddOffset(ExecResultDataRaw::VT_DATA, data);
+  }
+  explicit ExecResultDataRawBuilder(flatbuffers::FlatBufferBuilder &_fbb)
+        : fbb_(_fbb) {
+    start_ = fbb_.StartTable();
+  }
+  flatbuffers::Offset<ExecResultDataRaw> Finish() {
+    const auto end = fbb_.EndTable(start_);
+    auto o = flatbuffers::Offset<ExecResultDataRaw>(end);
+    return o;
+  }
+};
+
+inline flatbuffers::Offset<ExecResultDataRaw> CreateExecResultDataRaw(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    flatbuffers::Offset<flatbuffers::Vector<uint8_t>> data = 0) {
+  ExecResultDataRawBuilder builder_(_fbb);
+  builder_.add_data(data);
+  return builder_.Finish();
+}
+
+inline flatbuffers::Offset<ExecResultDataRaw> CreateExecResultDataRawDirect(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    const std::vector<uint8_t> *data = nullptr) {
+  auto data__ = data ? _fbb.CreateVector<uint8_t>(*data) : 0;
+  return rpc::CreateExecResultDataRaw(
+      _fbb,
+      data__);
+}
+
+flatbuffers::Offset<ExecResultDataRaw> CreateExecResultDataRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultDataRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+
+struct ExecResultBatchRawT : public flatbuffers::NativeTable {
+  typedef ExecResultBatchRaw TableType;
+  std::vector<std::unique_ptr<rpc::ExecResultDataRawT>> results{};
+  ExecResultBatchRawT() = default;
+  ExecResultBatchRawT(const ExecResultBatchRawT &o);
+  ExecResultBatchRawT(ExecResultBatchRawT&&) FLATBUFFERS_NOEXCEPT = default;
+  ExecResultBatchRawT &operator=(ExecResultBatchRawT o) FLATBUFFERS_NOEXCEPT;
+};
+
+struct ExecResultBatchRaw FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
+  typedef ExecResultBatchRawT NativeTableType;
+  typedef ExecResultBatchRawBuilder Builder;
+  enum FlatBuffersVTableOffset FLATBUFFERS_VTABLE_UNDERLYING_TYPE {
+    VT_RESULTS = 4
+  };
+  const flatbuffers::Vector<flatbuffers::Offset<rpc::ExecResultDataRaw>> *results() const {
+    return GetPointer<const flatbuffers::Vector<flatbuffers::Offset<rpc::ExecResultDataRaw>> *>(VT_RESULTS);
+  }
+  bool Verify(flatbuffers::Verifier &verifier) const {
+    return VerifyTableStart(verifier) &&
+           VerifyOffset(verifier, VT_RESULTS) &&
+           verifier.VerifyVector(results()) &&
+           verifier.VerifyVectorOfTables(results()) &&
+           verifier.EndTable();
+  }
+  ExecResultBatchRawT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  void UnPackTo(ExecResultBatchRawT *_o, const flatbuffers::resolver_function_t *_resolver = nullptr) const;
+  static flatbuffers::Offset<ExecResultBatchRaw> Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultBatchRawT* _o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+};
+
+struct ExecResultBatchRawBuilder {
+  typedef ExecResultBatchRaw Table;
+  flatbuffers::FlatBufferBuilder &fbb_;
+  flatbuffers::uoffset_t start_;
+  void add_results(flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<rpc::ExecResultDataRaw>>> results) {
+    fbb_.AddOffset(ExecResultBatchRaw::VT_RESULTS, results);
+  }
+  explicit ExecResultBatchRawBuilder(flatbuffers::FlatBufferBuilder &_fbb)
+        : fbb_(_fbb) {
+    start_ = fbb_.StartTable();
+  }
+  flatbuffers::Offset<ExecResultBatchRaw> Finish() {
+    const auto end = fbb_.EndTable(start_);
+    auto o = flatbuffers::Offset<ExecResultBatchRaw>(end);
+    return o;
+  }
+};
+
+inline flatbuffers::Offset<ExecResultBatchRaw> CreateExecResultBatchRaw(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    flatbuffers::Offset<flatbuffers::Vector<flatbuffers::Offset<rpc::ExecResultDataRaw>>> results = 0) {
+  ExecResultBatchRawBuilder builder_(_fbb);
+  builder_.add_results(results);
+  return builder_.Finish();
+}
+
+inline flatbuffers::Offset<ExecResultBatchRaw> CreateExecResultBatchRawDirect(
+    flatbuffers::FlatBufferBuilder &_fbb,
+    const std::vector<flatbuffers::Offset<rpc::ExecResultDataRaw>> *results = nullptr) {
+  auto results__ = results ? _fbb.CreateVector<flatbuffers::Offset<rpc::ExecResultDataRaw>>(*results) : 0;
+  return rpc::CreateExecResultBatchRaw(
+      _fbb,
+      results__);
+}
+
+flatbuffers::Offset<ExecResultBatchRaw> CreateExecResultBatchRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultBatchRawT *_o, const flatbuffers::rehasher_function_t *_rehasher = nullptr);
+
 struct StateResultRawT : public flatbuffers::NativeTable {
   typedef StateResultRaw TableType;
   std::vector<uint8_t> data{};
@@ -2663,6 +3014,7 @@ struct SnapshotHandshakeT : public flatbuffers::NativeTable {
   rpc::Feature features = static_cast<rpc::Feature>(0);
   rpc::ExecEnv env_flags = static_cast<rpc::ExecEnv>(0);
   int64_t sandbox_arg = 0;
+  int32_t procs = 0;
 };
 
 struct SnapshotHandshake FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
@@ -2676,7 +3028,8 @@ struct SnapshotHandshake FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
     VT_PROGRAM_TIMEOUT_MS = 12,
     VT_FEATURES = 14,
     VT_ENV_FLAGS = 16,
-    VT_SANDBOX_ARG = 18
+    VT_SANDBOX_ARG = 18,
+    VT_PROCS = 20
   };
   bool cover_edges() const {
     return GetField<uint8_t>(VT_COVER_EDGES, 0) != 0;
@@ -2702,6 +3055,9 @@ struct SnapshotHandshake FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
   int64_t sandbox_arg() const {
     return GetField<int64_t>(VT_SANDBOX_ARG, 0);
   }
+  int32_t procs() const {
+    return GetField<int32_t>(VT_PROCS, 0);
+  }
   bool Verify(flatbuffers::Verifier &verifier) const {
     return VerifyTableStart(verifier) &&
            VerifyField<uint8_t>(verifier, VT_COVER_EDGES, 1) &&
@@ -2712,6 +3068,7 @@ struct SnapshotHandshake FLATBUFFERS_FINAL_CLASS : private flatbuffers::Table {
            VerifyField<uint64_t>(verifier, VT_FEATURES, 8) &&
            VerifyField<uint64_t>(verifier, VT_ENV_FLAGS, 8) &&
            VerifyField<int64_t>(verifier, VT_SANDBOX_ARG, 8) &&
+           VerifyField<int32_t>(verifier, VT_PROCS, 4) &&
            verifier.EndTable();
   }
   SnapshotHandshakeT *UnPack(const flatbuffers::resolver_function_t *_resolver = nullptr) const;
@@ -2747,6 +3104,9 @@ struct SnapshotHandshakeBuilder {
   void add_sandbox_arg(int64_t sandbox_arg) {
     fbb_.AddElement<int64_t>(SnapshotHandshake::VT_SANDBOX_ARG, sandbox_arg, 0);
   }
+  void add_procs(int32_t procs) {
+    fbb_.AddElement<int32_t>(SnapshotHandshake::VT_PROCS, procs, 0);
+  }
   explicit SnapshotHandshakeBuilder(flatbuffers::FlatBufferBuilder &_fbb)
         : fbb_(_fbb) {
     start_ = fbb_.StartTable();
@@ -2767,11 +3127,13 @@ inline flatbuffers::Offset<SnapshotHandshake> CreateSnapshotHandshake(
     int32_t program_timeout_ms = 0,
     rpc::Feature features = static_cast<rpc::Feature>(0),
     rpc::ExecEnv env_flags = static_cast<rpc::ExecEnv>(0),
-    int64_t sandbox_arg = 0) {
+    int64_t sandbox_arg = 0,
+    int32_t procs = 0) {
   SnapshotHandshakeBuilder builder_(_fbb);
   builder_.add_sandbox_arg(sandbox_arg);
   builder_.add_env_flags(env_flags);
   builder_.add_features(features);
+  builder_.add_procs(procs);
   builder_.add_program_timeout_ms(program_timeout_ms);
   builder_.add_syscall_timeout_ms(syscall_timeout_ms);
   builder_.add_slowdown(slowdown);
@@ -2952,6 +3314,7 @@ inline void ConnectReplyRaw::UnPackTo(ConnectReplyRawT *_o, const flatbuffers::r
   { auto _e = race_frames(); if (_e) { _o->race_frames.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->race_frames[_i] = _e->Get(_i)->str(); } } }
   { auto _e = features(); _o->features = _e; }
   { auto _e = files(); if (_e) { _o->files.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->files[_i] = _e->Get(_i)->str(); } } }
+  { auto _e = feedback(); _o->feedback = _e; }
 }
 
 inline flatbuffers::Offset<ConnectReplyRaw> ConnectReplyRaw::Pack(flatbuffers::FlatBufferBuilder &_fbb, const ConnectReplyRawT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
@@ -2974,6 +3337,7 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRaw(flatbuffers::F
   auto _race_frames = _o->race_frames.size() ? _fbb.CreateVectorOfStrings(_o->race_frames) : 0;
   auto _features = _o->features;
   auto _files = _o->files.size() ? _fbb.CreateVectorOfStrings(_o->files) : 0;
+  auto _feedback = _o->feedback;
   return rpc::CreateConnectReplyRaw(
       _fbb,
       _debug,
@@ -2987,7 +3351,8 @@ inline flatbuffers::Offset<ConnectReplyRaw> CreateConnectReplyRaw(flatbuffers::F
       _leak_frames,
       _race_frames,
       _features,
-      _files);
+      _files,
+      _feedback);
 }
 
 inline InfoRequestRawT::InfoRequestRawT(const InfoRequestRawT &o)
@@ -3282,6 +3647,42 @@ inline flatbuffers::Offset<ExecRequestRaw> CreateExecRequestRaw(flatbuffers::Fla
       _all_signal);
 }
 
+inline ExecRequestBatchRawT::ExecRequestBatchRawT(const ExecRequestBatchRawT &o) {
+  requests.reserve(o.requests.size());
+  for (const auto &requests_ : o.requests) { requests.emplace_back((requests_) ? new rpc::ExecRequestRawT(*requests_) : nullptr); }
+}
+
+inline ExecRequestBatchRawT &ExecRequestBatchRawT::operator=(ExecRequestBatchRawT o) FLATBUFFERS_NOEXCEPT {
+  std::swap(requests, o.requests);
+  return *this;
+}
+
+inline ExecRequestBatchRawT *ExecRequestBatchRaw::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
+  auto _o = std::unique_ptr<ExecRequestBatchRawT>(new ExecRequestBatchRawT());
+  UnPackTo(_o.get(), _resolver);
+  return _o.release();
+}
+
+inline void ExecRequestBatchRaw::UnPackTo(ExecRequestBatchRawT *_o, const flatbuffers::resolver_function_t *_resolver) const {
+  (void)_o;
+  (void)_resolver;
+  { auto _e = requests(); if (_e) { _o->requests.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->requests[_i] = std::unique_ptr<rpc::ExecRequestRawT>(_e->Get(_i)->UnPack(_resolver)); } } }
+}
+
+inline flatbuffers::Offset<ExecRequestBatchRaw> ExecRequestBatchRaw::Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecRequestBatchRawT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
+  return CreateExecRequestBatchRaw(_fbb, _o, _rehasher);
+}
+
+inline flatbuffers::Offset<ExecRequestBatchRaw> CreateExecRequestBatchRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecRequestBatchRawT *_o, const flatbuffers::rehasher_function_t *_rehasher) {
+  (void)_rehasher;
+  (void)_o;
+  struct _VectorArgs { flatbuffers::FlatBufferBuilder *__fbb; const ExecRequestBatchRawT* __o; const flatbuffers::rehasher_function_t *__rehasher; } _va = { &_fbb, _o, _rehasher}; (void)_va;
+  auto _requests = _o->requests.size() ? _fbb.CreateVector<flatbuffers::Offset<rpc::ExecRequestRaw>> (_o->requests.size(), [](size_t i, _VectorArgs *__va) { return CreateExecRequestRaw(*__va->__fbb, __va->__o->requests[i].get(), __va->__rehasher); }, &_va ) : 0;
+  return rpc::CreateExecRequestBatchRaw(
+      _fbb,
+      _requests);
+}
+
 inline SignalUpdateRawT *SignalUpdateRaw::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
   auto _o = std::unique_ptr<SignalUpdateRawT>(new SignalUpdateRawT());
   UnPackTo(_o.get(), _resolver);
@@ -3403,6 +3804,9 @@ inline void CallInfoRaw::UnPackTo(CallInfoRawT *_o, const flatbuffers::resolver_
   { auto _e = signal(); if (_e) { _o->signal.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->signal[_i] = _e->Get(_i); } } }
   { auto _e = cover(); if (_e) { _o->cover.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->cover[_i] = _e->Get(_i); } } }
   { auto _e = comps(); if (_e) { _o->comps.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->comps[_i] = *_e->Get(_i); } } }
+  { auto _e = feedback(); if (_e) { _o->feedback.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->feedback[_i] = _e->Get(_i); } } }
+  { auto _e = packed_signal(); if (_e) { _o->packed_signal.resize(_e->size()); std::copy(_e->begin(), _e->end(), _o->packed_signal.begin()); } }
+  { auto _e = packed_cover(); if (_e) { _o->packed_cover.resize(_e->size()); std::copy(_e->begin(), _e->end(), _o->packed_cover.begin()); } }
 }
 
 inline flatbuffers::Offset<CallInfoRaw> CallInfoRaw::Pack(flatbuffers::FlatBufferBuilder &_fbb, const CallInfoRawT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
@@ -3418,13 +3822,19 @@ inline flatbuffers::Offset<CallInfoRaw> CreateCallInfoRaw(flatbuffers::FlatBuffe
   auto _signal = _o->signal.size() ? _fbb.CreateVector(_o->signal) : 0;
   auto _cover = _o->cover.size() ? _fbb.CreateVector(_o->cover) : 0;
   auto _comps = _o->comps.size() ? _fbb.CreateVectorOfStructs(_o->comps) : 0;
+  auto _feedback = _o->feedback.size() ? _fbb.CreateVector(_o->feedback) : 0;
+  auto _packed_signal = _o->packed_signal.size() ? _fbb.CreateVector(_o->packed_signal) : 0;
+  auto _packed_cover = _o->packed_cover.size() ? _fbb.CreateVector(_o->packed_cover) : 0;
   return rpc::CreateCallInfoRaw(
       _fbb,
       _flags,
       _error,
       _signal,
       _cover,
-      _comps);
+      _comps,
+      _feedback,
+      _packed_signal,
+      _packed_cover);
 }
 
 inline ProgInfoRawT::ProgInfoRawT(const ProgInfoRawT &o)
@@ -3544,6 +3954,68 @@ inline flatbuffers::Offset<ExecResultRaw> CreateExecResultRaw(flatbuffers::FlatB
       _info);
 }
 
+inline ExecResultDataRawT *ExecResultDataRaw::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
+  auto _o = std::unique_ptr<ExecResultDataRawT>(new ExecResultDataRawT());
+  UnPackTo(_o.get(), _resolver);
+  return _o.release();
+}
+
+inline void ExecResultDataRaw::UnPackTo(ExecResultDataRawT *_o, const flatbuffers::resolver_function_t *_resolver) const {
+  (void)_o;
+  (void)_resolver;
+  { auto _e = data(); if (_e) { _o->data.resize(_e->size()); std::copy(_e->begin(), _e->end(), _o->data.begin()); } }
+}
+
+inline flatbuffers::Offset<ExecResultDataRaw> ExecResultDataRaw::Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultDataRawT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
+  return CreateExecResultDataRaw(_fbb, _o, _rehasher);
+}
+
+inline flatbuffers::Offset<ExecResultDataRaw> CreateExecResultDataRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultDataRawT *_o, const flatbuffers::rehasher_function_t *_rehasher) {
+  (void)_rehasher;
+  (void)_o;
+  struct _VectorArgs { flatbuffers::FlatBufferBuilder *__fbb; const ExecResultDataRawT* __o; const flatbuffers::rehasher_function_t *__rehasher; } _va = { &_fbb, _o, _rehasher}; (void)_va;
+  auto _data = _o->data.size() ? _fbb.CreateVector(_o->data) : 0;
+  return rpc::CreateExecResultDataRaw(
+      _fbb,
+      _data);
+}
+
+inline ExecResultBatchRawT::ExecResultBatchRawT(const ExecResultBatchRawT &o) {
+  results.reserve(o.results.size());
+  for (const auto &results_ : o.results) { results.emplace_back((results_) ? new rpc::ExecResultDataRawT(*results_) : nullptr); }
+}
+
+inline ExecResultBatchRawT &ExecResultBatchRawT::operator=(ExecResultBatchRawT o) FLATBUFFERS_NOEXCEPT {
+  std::swap(results, o.results);
+  return *this;
+}
+
+inline ExecResultBatchRawT *ExecResultBatchRaw::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
+  auto _o = std::unique_ptr<ExecResultBatchRawT>(new ExecResultBatchRawT());
+  UnPackTo(_o.get(), _resolver);
+  return _o.release();
+}
+
+inline void ExecResultBatchRaw::UnPackTo(ExecResultBatchRawT *_o, const flatbuffers::resolver_function_t *_resolver) const {
+  (void)_o;
+  (void)_resolver;
+  { auto _e = results(); if (_e) { _o->results.resize(_e->size()); for (flatbuffers::uoffset_t _i = 0; _i < _e->size(); _i++) { _o->results[_i] = std::unique_ptr<rpc::ExecResultDataRawT>(_e->Get(_i)->UnPack(_resolver)); } } }
+}
+
+inline flatbuffers::Offset<ExecResultBatchRaw> ExecResultBatchRaw::Pack(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultBatchRawT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
+  return CreateExecResultBatchRaw(_fbb, _o, _rehasher);
+}
+
+inline flatbuffers::Offset<ExecResultBatchRaw> CreateExecResultBatchRaw(flatbuffers::FlatBufferBuilder &_fbb, const ExecResultBatchRawT *_o, const flatbuffers::rehasher_function_t *_rehasher) {
+  (void)_rehasher;
+  (void)_o;
+  struct _VectorArgs { flatbuffers::FlatBufferBuilder *__fbb; const ExecResultBatchRawT* __o; const flatbuffers::rehasher_function_t *__rehasher; } _va = { &_fbb, _o, _rehasher}; (void)_va;
+  auto _results = _o->results.size() ? _fbb.CreateVector<flatbuffers::Offset<rpc::ExecResultDataRaw>> (_o->results.size(), [](size_t i, _VectorArgs *__va) { return CreateExecResultDataRaw(*__va->__fbb, __va->__o->results[i].get(), __va->__rehasher); }, &_va ) : 0;
+  return rpc::CreateExecResultBatchRaw(
+      _fbb,
+      _results);
+}
+
 inline StateResultRawT *StateResultRaw::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
   auto _o = std::unique_ptr<StateResultRawT>(new StateResultRawT());
   UnPackTo(_o.get(), _resolver);
@@ -3619,6 +4091,7 @@ inline void SnapshotHandshake::UnPackTo(SnapshotHandshakeT *_o, const flatbuffer
   { auto _e = features(); _o->features = _e; }
   { auto _e = env_flags(); _o->env_flags = _e; }
   { auto _e = sandbox_arg(); _o->sandbox_arg = _e; }
+  { auto _e = procs(); _o->procs = _e; }
 }
 
 inline flatbuffers::Offset<SnapshotHandshake> SnapshotHandshake::Pack(flatbuffers::FlatBufferBuilder &_fbb, const SnapshotHandshakeT* _o, const flatbuffers::rehasher_function_t *_rehasher) {
@@ -3637,6 +4110,7 @@ inline flatbuffers::Offset<SnapshotHandshake> CreateSnapshotHandshake(flatbuffer
   auto _features = _o->features;
   auto _env_flags = _o->env_flags;
   auto _sandbox_arg = _o->sandbox_arg;
+  auto _procs = _o->procs;
   return rpc::CreateSnapshotHandshake(
       _fbb,
       _cover_edges,
@@ -3646,7 +4120,8 @@ inline flatbuffers::Offset<SnapshotHandshake> CreateSnapshotHandshake(flatbuffer
       _program_timeout_ms,
       _features,
       _env_flags,
-      _sandbox_arg);
+      _sandbox_arg,
+      _procs);
 }
 
 inline SnapshotRequestT *SnapshotRequest::UnPack(const flatbuffers::resolver_function_t *_resolver) const {
@@ -3708,6 +4183,10 @@ inline bool VerifyHostMessagesRaw(flatbuffers::Verifier &verifier, const void *o
       auto ptr = reinterpret_cast<const rpc::StateRequestRaw *>(obj);
       return verifier.VerifyTable(ptr);
     }
+    case HostMessagesRaw::ExecRequestBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecRequestBatchRaw *>(obj);
+      return verifier.VerifyTable(ptr);
+    }
     default: return true;
   }
 }
@@ -3743,6 +4222,10 @@ inline void *HostMessagesRawUnion::UnPack(const void *obj, HostMessagesRaw type,
       auto ptr = reinterpret_cast<const rpc::StateRequestRaw *>(obj);
       return ptr->UnPack(resolver);
     }
+    case HostMessagesRaw::ExecRequestBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecRequestBatchRaw *>(obj);
+      return ptr->UnPack(resolver);
+    }
     default: return nullptr;
   }
 }
@@ -3766,6 +4249,10 @@ inline flatbuffers::Offset<void> HostMessagesRawUnion::Pack(flatbuffers::FlatBuf
       auto ptr = reinterpret_cast<const rpc::StateRequestRawT *>(value);
       return CreateStateRequestRaw(_fbb, ptr, _rehasher).Union();
     }
+    case HostMessagesRaw::ExecRequestBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecRequestBatchRawT *>(value);
+      return CreateExecRequestBatchRaw(_fbb, ptr, _rehasher).Union();
+    }
     default: return 0;
   }
 }
@@ -3788,6 +4275,10 @@ inline HostMessagesRawUnion::HostMessagesRawUnion(const HostMessagesRawUnion &u)
       value = new rpc::StateRequestRawT(*reinterpret_cast<rpc::StateRequestRawT *>(u.value));
       break;
     }
+    case HostMessagesRaw::ExecRequestBatch: {
+      value = new rpc::ExecRequestBatchRawT(*reinterpret_cast<rpc::ExecRequestBatchRawT *>(u.value));
+      break;
+    }
     default:
       break;
   }
@@ -3815,6 +4306,11 @@ inline void HostMessagesRawUnion::Reset() {
       delete ptr;
       break;
     }
+    case HostMessagesRaw::ExecRequestBatch: {
+      auto ptr = reinterpret_cast<rpc::ExecRequestBatchRawT *>(value);
+      delete ptr;
+      break;
+    }
     default: break;
   }
   value = nullptr;
@@ -3838,6 +4334,10 @@ inline bool VerifyExecutorMessagesRaw(flatbuffers::Verifier &verifier, const voi
       auto ptr = reinterpret_cast<const rpc::StateResultRaw *>(obj);
       return verifier.VerifyTable(ptr);
     }
+    case ExecutorMessagesRaw::ExecResultBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecResultBatchRaw *>(obj);
+      return verifier.VerifyTable(ptr);
+    }
     default: return true;
   }
 }
@@ -3869,6 +4369,10 @@ inline void *ExecutorMessagesRawUnion::UnPack(const void *obj, ExecutorMessagesR
       auto ptr = reinterpret_cast<const rpc::StateResultRaw *>(obj);
       return ptr->UnPack(resolver);
     }
+    case ExecutorMessagesRaw::ExecResultBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecResultBatchRaw *>(obj);
+      return ptr->UnPack(resolver);
+    }
     default: return nullptr;
   }
 }
@@ -3888,6 +4392,10 @@ inline flatbuffers::Offset<void> ExecutorMessagesRawUnion::Pack(flatbuffers::Fla
       auto ptr = reinterpret_cast<const rpc::StateResultRawT *>(value);
       return CreateStateResultRaw(_fbb, ptr, _rehasher).Union();
     }
+    case ExecutorMessagesRaw::ExecResultBatch: {
+      auto ptr = reinterpret_cast<const rpc::ExecResultBatchRawT *>(value);
+      return CreateExecResultBatchRaw(_fbb, ptr, _rehasher).Union();
+    }
     default: return 0;
   }
 }
@@ -3906,6 +4414,10 @@ inline ExecutorMessagesRawUnion::ExecutorMessagesRawUnion(const ExecutorMessages
       value = new rpc::StateResultRawT(*reinterpret_cast<rpc::StateResultRawT *>(u.value));
       break;
     }
+    case ExecutorMessagesRaw::ExecResultBatch: {
+      value = new rpc::ExecResultBatchRawT(*reinterpret_cast<rpc::ExecResultBatchRawT *>(u.value));
+      break;
+    }
     default:
       break;
   }
@@ -3928,6 +4440,11 @@ inline void ExecutorMessagesRawUnion::Reset() {
       delete ptr;
       break;
     }
+    case ExecutorMessagesRaw::ExecResultBatch: {
+      auto ptr = reinterpret_cast<rpc::ExecResultBatchRawT *>(value);
+      delete ptr;
+      break;
+    }
     default: break;
   }
   value = nullptr;
@@ -4,6 +4,8 @@
 package flatrpc
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"slices"
 	"sync/atomic"
@@ -15,6 +17,22 @@ import (
 
 const AllFeatures = ^Feature(0)
 
+// FeedbackChannels maps names of the additional feedback channels used in configs
+// to the corresponding metrics reported by executor.
+var FeedbackChannels = map[string]FeedbackKind{
+	"context_switches": FeedbackKindContextSwitches,
+	"page_faults":      FeedbackKindPageFaults,
+}
+
+// FeedbackKinds converts names of the feedback channels into the corresponding metrics.
+func FeedbackKinds(channels []string) []FeedbackKind {
+	var ret []FeedbackKind
+	for _, channel := range channels {
+		ret = append(ret, FeedbackChannels[channel])
+	}
+	return ret
+}
+
 // Flatbuffers compiler adds T suffix to object API types, which are actual structs representing types.
 // This leads to non-idiomatic Go code, e.g. we would have to use []FileInfoT in Go code.
 // So we use Raw suffix for all flatbuffers tables and rename object API types here to idiomatic names.
@@ -30,6 +48,7 @@ type HostMessage = HostMessageRawT
 type ExecutorMessages = ExecutorMessagesRawT
 type ExecutorMessage = ExecutorMessageRawT
 type ExecRequest = ExecRequestRawT
+type ExecRequestBatch = ExecRequestBatchRawT
 type StateRequest = StateRequestRawT
 type SignalUpdate = SignalUpdateRawT
 type CorpusTriaged = CorpusTriagedRawT
@@ -39,6 +58,8 @@ type Comparison = ComparisonRawT
 type ExecOpts = ExecOptsRawT
 type ProgInfo = ProgInfoRawT
 type ExecResult = ExecResultRawT
+type ExecResultBatch = ExecResultBatchRawT
+type ExecResultData = ExecResultDataRawT
 type StateResult = StateResultRawT
 
 func init() {
@@ -69,9 +90,112 @@ func (ci *CallInfo) clone() *CallInfo {
 	ret.Signal = slices.Clone(ret.Signal)
 	ret.Cover = slices.Clone(ret.Cover)
 	ret.Comps = slices.Clone(ret.Comps)
+	ret.Feedback = slices.Clone(ret.Feedback)
+	ret.PackedSignal = slices.Clone(ret.PackedSignal)
+	ret.PackedCover = slices.Clone(ret.PackedCover)
 	return &ret
 }
 
+// UnpackCover converts packed signal/cover sent by the executor into the normal Signal/Cover form.
+func (pi *ProgInfo) UnpackCover() error {
+	if pi == nil {
+		return nil
+	}
+	for _, call := range pi.Calls {
+		if err := call.unpackCover(); err != nil {
+			return err
+		}
+	}
+	for _, call := range pi.ExtraRaw {
+		if err := call.unpackCover(); err != nil {
+			return err
+		}
+	}
+	return pi.Extra.unpackCover()
+}
+
+func (ci *CallInfo) unpackCover() error {
+	if ci == nil {
+		return nil
+	}
+	if ci.PackedSignal != nil {
+		signal, err := UnpackPCs(ci.PackedSignal)
+		if err != nil {
+			return fmt.Errorf("bad packed signal: %w", err)
+		}
+		ci.Signal, ci.PackedSignal = signal, nil
+	}
+	if ci.PackedCover != nil {
+		cover, err := UnpackPCs(ci.PackedCover)
+		if err != nil {
+			return fmt.Errorf("bad packed cover: %w", err)
+		}
+		ci.Cover, ci.PackedCover = cover, nil
+	}
+	return nil
+}
+
+// PackPCs encodes signal/cover in the form used for CallInfo.PackedSignal/PackedCover:
+// zigzag-encoded varint deltas between subsequent values.
+// Subsequent PCs tend to be close to each other, so most deltas take 1-3 bytes.
+func PackPCs(pcs []uint64) []byte {
+	var res []byte
+	prev := uint64(0)
+	for _, pc := range pcs {
+		res = binary.AppendVarint(res, int64(pc-prev))
+		prev = pc
+	}
+	return res
+}
+
+// numPackedPCs returns the number of PCs encoded in the PackPCs output:
+// the last byte of each varint has the high bit clear.
+func numPackedPCs(data []byte) int {
+	n := 0
+	for _, b := range data {
+		if b < 0x80 {
+			n++
+		}
+	}
+	return n
+}
+
+// UnpackPCs is the reverse of PackPCs.
+func UnpackPCs(data []byte) ([]uint64, error) {
+	res := make([]uint64, 0, numPackedPCs(data))
+	prev := uint64(0)
+	for len(data) != 0 {
+		delta, n := binary.Varint(data)
+		if n <= 0 {
+			return nil, errors.New("corrupted varint")
+		}
+		prev += uint64(delta)
+		res = append(res, prev)
+		data = data[n:]
+	}
+	return res, nil
+}
+
+// Unpack parses results contained in the batch.
+// Similar to Recv, the results reference the batch data.
+func (batch *ExecResultBatch) Unpack() ([]*ExecResult, error) {
+	var res []*ExecResult
+	for _, data := range batch.Results {
+		if data == nil {
+			return nil, errors.New("missing result in exec result batch")
+		}
+		msg, err := parse[*ExecutorMessageRaw](data.Data)
+		if err != nil {
+			return nil, err
+		}
+		if msg.Msg == nil || msg.Msg.Type != ExecutorMessagesRawExecResult {
+			return nil, fmt.Errorf("bad message in exec result batch: %v", msg.Msg)
+		}
+		res = append(res, msg.Msg.Value.(*ExecResult))
+	}
+	return res, nil
+}
+
 func EmptyProgInfo(calls int) *ProgInfo {
 	info := &ProgInfo{}
 	for i := 0; i < calls; i++ {
@@ -120,3 +244,28 @@ func (hdr *SnapshotHeaderT) UpdateState(state SnapshotState) {
 func (hdr *SnapshotHeaderT) LoadState() SnapshotState {
 	return SnapshotState(atomic.LoadUint64((*uint64)(unsafe.Pointer(&hdr.State))))
 }
+
+// Snapshot shared memory region starts with MaxInputSize bytes of input split into equal slots
+// for each proc. The rest of the region is the output region: the first page holds the global SnapshotHeader
+// used for host/target synchronization, the remaining pages are split into equal page-aligned areas
+// for each proc (but not larger than MaxOutputSize). Each proc area starts with the proc SnapshotHeader
+// followed by the proc output.
+const (
+	SnapshotOutputRegionSize = int(ConstSnapshotShmemSize - ConstMaxInputSize)
+	snapshotGlobalHeaderSize = 4 << 10
+)
+
+// SnapshotInputSlot returns offset and size of the input slot for the proc
+// relative to the start of the shared memory region.
+func SnapshotInputSlot(procs, proc int) (offset, size int) {
+	size = int(ConstMaxInputSize) / max(procs, 1) &^ 7
+	return proc * size, size
+}
+
+// SnapshotOutputArea returns offset and size of the output area for the proc
+// relative to the start of the output region.
+func SnapshotOutputArea(procs, proc int) (offset, size int) {
+	size = (SnapshotOutputRegionSize - snapshotGlobalHeaderSize) / max(procs, 1) &^ (4<<10 - 1)
+	size = min(size, int(ConstMaxOutputSize))
+	return snapshotGlobalHeaderSize + proc*size, size
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package flatrpc
+
+import (
+	"bytes"
+	"testing"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotLayout(t *testing.T) {
+	for procs := 1; procs <= int(ConstMaxSnapshotProcs); procs++ {
+		inputEnd, outputEnd := 0, snapshotGlobalHeaderSize
+		for proc := 0; proc < procs; proc++ {
+			offset, size := SnapshotInputSlot(procs, proc)
+			assert.Equal(t, 0, offset%8)
+			assert.GreaterOrEqual(t, offset, inputEnd)
+			assert.Greater(t, size, 1<<20)
+			inputEnd = offset + size
+
+			offset, size = SnapshotOutputArea(procs, proc)
+			assert.Equal(t, 0, offset%(4<<10))
+			assert.GreaterOrEqual(t, offset, outputEnd)
+			assert.Greater(t, size, 4<<20)
+			assert.LessOrEqual(t, size, int(ConstMaxOutputSize))
+			outputEnd = offset + size
+		}
+		assert.LessOrEqual(t, inputEnd, int(ConstMaxInputSize))
+		assert.LessOrEqual(t, outputEnd, SnapshotOutputRegionSize)
+	}
+}
+
+func TestPackPCs(t *testing.T) {
+	tests := [][]uint64{
+		{},
+		{0},
+		{1, 2, 3},
+		{0xffffffff81000000, 0xffffffff81000010, 0xffffffff80ffff00, 0x10, ^uint64(0), 0},
+	}
+	for _, pcs := range tests {
+		packed := PackPCs(pcs)
+		got, err := UnpackPCs(packed)
+		assert.NoError(t, err)
+		assert.Equal(t, pcs, got)
+	}
+	// Close PCs take few bytes.
+	assert.Len(t, PackPCs([]uint64{0xffffffff81000000, 0xffffffff81000010, 0xffffffff81000008}), 7)
+	_, err := UnpackPCs([]byte{0x80})
+	assert.Error(t, err)
+}
+
+func TestExecResultBatch(t *testing.T) {
+	var results []*ExecResult
+	batch := &ExecResultBatch{}
+	for i := 0; i < 3; i++ {
+		res := &ExecResult{
+			Id:     int64(i),
+			Output: []byte{byte(i)},
+			Info: &ProgInfo{
+				Calls: []*CallInfo{{
+					Signal:       []uint64{},
+					Cover:        []uint64{},
+					Comps:        []*Comparison{},
+					Feedback:     []uint64{},
+					PackedSignal: PackPCs([]uint64{1, 2, uint64(i)}),
+				}},
+				ExtraRaw: []*CallInfo{},
+			},
+		}
+		results = append(results, res)
+		builder := flatbuffers.NewBuilder(0)
+		builder.Finish((&ExecutorMessage{
+			Msg: &ExecutorMessages{
+				Type:  ExecutorMessagesRawExecResult,
+				Value: res,
+			},
+		}).Pack(builder))
+		batch.Results = append(batch.Results, &ExecResultData{Data: builder.FinishedBytes()})
+	}
+	builder := flatbuffers.NewBuilder(0)
+	builder.Finish((&ExecutorMessage{
+		Msg: &ExecutorMessages{
+			Type:  ExecutorMessagesRawExecResultBatch,
+			Value: batch,
+		},
+	}).Pack(builder))
+	msg, err := Parse[*ExecutorMessageRaw](builder.FinishedBytes())
+	assert.NoError(t, err)
+	got, err := msg.Msg.Value.(*ExecResultBatch).Unpack()
+	assert.NoError(t, err)
+	assert.Equal(t, results, got)
+	for i, res := range got {
+		assert.NoError(t, res.Info.UnpackCover())
+		assert.Equal(t, []uint64{1, 2, uint64(i)}, res.Info.Calls[0].Signal)
+		assert.Nil(t, res.Info.Calls[0].PackedSignal)
+	}
+}
+
+func TestExecResultPackedSize(t *testing.T) {
+	parse := func(packed []byte) error {
+		builder := flatbuffers.NewBuilder(0)
+		builder.Finish((&ExecutorMessage{
+			Msg: &ExecutorMessages{
+				Type: ExecutorMessagesRawExecResult,
+				Value: &ExecResult{
+					Info: &ProgInfo{
+						Calls: []*CallInfo{{PackedCover: packed}},
+					},
+				},
+			},
+		}).Pack(builder))
+		_, err := Parse[*ExecutorMessageRaw](builder.FinishedBytes())
+		return err
+	}
+	// Each zero byte unpacks into an 8-byte PC.
+	assert.NoError(t, parse(make([]byte, 1<<20)))
+	assert.Error(t, parse(make([]byte, maxUnpackedSize/8+1)))
+	// Long varints unpack into fewer PCs.
+	long := bytes.Repeat([]byte{0x80, 0x80, 0x80, 0x01}, (maxUnpackedSize/8+1)/4+1)
+	assert.NoError(t, parse(long))
+}
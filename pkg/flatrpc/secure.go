@@ -0,0 +1,216 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package flatrpc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/chacha20"
+)
+
+// Pre-shared key (PSK) transport.
+//
+// We can't use TLS since the executor is not linked with any crypto libraries,
+// so the protocol is intentionally simple to be implemented in executor/conn.h:
+//
+//	client -> server: pskMagic, client nonce
+//	server -> client: server nonce, HMAC(key, "syz server" || client nonce || server nonce)
+//	client -> server: HMAC(key, "syz client" || client nonce || server nonce)
+//
+// Both sides prove that they know the key, and derive per-connection keys
+// as HMAC(key, label || client nonce || server nonce). After the handshake all data is sent
+// in records: length (u32), ChaCha20 ciphertext, HMAC(mac key, seq (u64) || length || ciphertext).
+// The ChaCha20 nonce is the record sequence number, so every record uses a unique key stream.
+
+const (
+	pskMagic      = "SYZPSK01"
+	pskNonceSize  = 16
+	pskMACSize    = sha256.Size
+	pskRecordHdr  = 4
+	pskMaxRecord  = 1 << 20
+	pskHandshakeT = time.Minute
+)
+
+// MinKeySize is the min size of the pre-shared key.
+const MinKeySize = 16
+
+// secureConn encrypts and authenticates all data sent over the underlying connection.
+type secureConn struct {
+	net.Conn
+
+	writeMu  sync.Mutex
+	writeKey []byte
+	writeMAC []byte
+	writeSeq uint64
+	writeBuf []byte
+
+	readKey []byte
+	readMAC []byte
+	readSeq uint64
+	readBuf []byte // received, but not yet consumed plaintext
+	readErr error
+}
+
+// ServerHandshake authenticates the client connection with the key and returns
+// the connection that encrypts all data.
+func ServerHandshake(conn net.Conn, key []byte) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(pskHandshakeT))
+	defer conn.SetDeadline(time.Time{})
+	hello := make([]byte, len(pskMagic)+pskNonceSize)
+	if _, err := io.ReadFull(conn, hello); err != nil {
+		return nil, fmt.Errorf("failed to read client hello: %w", err)
+	}
+	if string(hello[:len(pskMagic)]) != pskMagic {
+		return nil, errors.New("client does not use pre-shared key")
+	}
+	clientNonce := hello[len(pskMagic):]
+	serverNonce := make([]byte, pskNonceSize)
+	if _, err := rand.Read(serverNonce); err != nil {
+		return nil, err
+	}
+	reply := append(serverNonce, pskHMAC(key, "syz server", clientNonce, serverNonce)...)
+	if _, err := conn.Write(reply); err != nil {
+		return nil, fmt.Errorf("failed to send server hello: %w", err)
+	}
+	proof := make([]byte, pskMACSize)
+	if _, err := io.ReadFull(conn, proof); err != nil {
+		return nil, fmt.Errorf("failed to read client proof: %w", err)
+	}
+	if !hmac.Equal(proof, pskHMAC(key, "syz client", clientNonce, serverNonce)) {
+		return nil, errors.New("client used wrong key")
+	}
+	return newSecureConn(conn, key, clientNonce, serverNonce, "s2c", "c2s"), nil
+}
+
+// ClientHandshake is the client counterpart of ServerHandshake (the executor implements the same).
+func ClientHandshake(conn net.Conn, key []byte) (net.Conn, error) {
+	conn.SetDeadline(time.Now().Add(pskHandshakeT))
+	defer conn.SetDeadline(time.Time{})
+	clientNonce := make([]byte, pskNonceSize)
+	if _, err := rand.Read(clientNonce); err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write(append([]byte(pskMagic), clientNonce...)); err != nil {
+		return nil, fmt.Errorf("failed to send client hello: %w", err)
+	}
+	reply := make([]byte, pskNonceSize+pskMACSize)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, fmt.Errorf("failed to read server hello: %w", err)
+	}
+	serverNonce := reply[:pskNonceSize]
+	if !hmac.Equal(reply[pskNonceSize:], pskHMAC(key, "syz server", clientNonce, serverNonce)) {
+		return nil, errors.New("server used wrong key")
+	}
+	if _, err := conn.Write(pskHMAC(key, "syz client", clientNonce, serverNonce)); err != nil {
+		return nil, fmt.Errorf("failed to send client proof: %w", err)
+	}
+	return newSecureConn(conn, key, clientNonce, serverNonce, "c2s", "s2c"), nil
+}
+
+func newSecureConn(conn net.Conn, key, clientNonce, serverNonce []byte, write, read string) *secureConn {
+	return &secureConn{
+		Conn:     conn,
+		writeKey: pskHMAC(key, write+" enc", clientNonce, serverNonce),
+		writeMAC: pskHMAC(key, write+" mac", clientNonce, serverNonce),
+		readKey:  pskHMAC(key, read+" enc", clientNonce, serverNonce),
+		readMAC:  pskHMAC(key, read+" mac", clientNonce, serverNonce),
+	}
+}
+
+func pskHMAC(key []byte, label string, data ...[]byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(label))
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+func recordMAC(key []byte, seq uint64, record []byte) []byte {
+	var seqData [8]byte
+	binary.LittleEndian.PutUint64(seqData[:], seq)
+	return pskHMAC(key, "", seqData[:], record)
+}
+
+func recordCipher(key []byte, seq uint64) *chacha20.Cipher {
+	var nonce [chacha20.NonceSize]byte
+	binary.LittleEndian.PutUint64(nonce[4:], seq)
+	cipher, err := chacha20.NewUnauthenticatedCipher(key, nonce[:])
+	if err != nil {
+		panic(err)
+	}
+	return cipher
+}
+
+// Write sends data in records. Records are written in a single Write call
+// of the underlying connection, so that a flatrpc message is received by the executor
+// at once when it fits into one record.
+func (c *secureConn) Write(data []byte) (int, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	written := 0
+	for len(data) != 0 {
+		n := min(len(data), pskMaxRecord)
+		buf := c.writeBuf[:0]
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(n))
+		buf = append(buf, data[:n]...)
+		recordCipher(c.writeKey, c.writeSeq).XORKeyStream(buf[pskRecordHdr:], buf[pskRecordHdr:])
+		buf = append(buf, recordMAC(c.writeMAC, c.writeSeq, buf)...)
+		c.writeSeq++
+		c.writeBuf = buf
+		if _, err := c.Conn.Write(buf); err != nil {
+			return written, err
+		}
+		written += n
+		data = data[n:]
+	}
+	return written, nil
+}
+
+func (c *secureConn) Read(data []byte) (int, error) {
+	if len(c.readBuf) == 0 {
+		if c.readErr != nil {
+			return 0, c.readErr
+		}
+		if c.readErr = c.readRecord(); c.readErr != nil {
+			return 0, c.readErr
+		}
+	}
+	n := copy(data, c.readBuf)
+	c.readBuf = c.readBuf[n:]
+	return n, nil
+}
+
+func (c *secureConn) readRecord() error {
+	var hdr [pskRecordHdr]byte
+	if _, err := io.ReadFull(c.Conn, hdr[:]); err != nil {
+		return err
+	}
+	size := binary.LittleEndian.Uint32(hdr[:])
+	if size == 0 || size > pskMaxRecord {
+		return fmt.Errorf("bad record size %v", size)
+	}
+	record := make([]byte, pskRecordHdr+int(size)+pskMACSize)
+	copy(record, hdr[:])
+	if _, err := io.ReadFull(c.Conn, record[pskRecordHdr:]); err != nil {
+		return err
+	}
+	body := record[:pskRecordHdr+size]
+	if !hmac.Equal(record[len(body):], recordMAC(c.readMAC, c.readSeq, body)) {
+		return errors.New("record authentication failed")
+	}
+	recordCipher(c.readKey, c.readSeq).XORKeyStream(body[pskRecordHdr:], body[pskRecordHdr:])
+	c.readSeq++
+	c.readBuf = body[pskRecordHdr:]
+	return nil
+}
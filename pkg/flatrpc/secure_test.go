@@ -0,0 +1,121 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package flatrpc
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecureConn(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	serv, err := ListenSecure(":0", key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer serv.Close()
+	// Larger than a single record.
+	bigReply := &ConnectReply{
+		LeakFrames: []string{},
+		RaceFrames: []string{},
+		Files:      []string{string(bytes.Repeat([]byte{'a'}, 3*pskMaxRecord+10))},
+	}
+	go serv.Serve(context.Background(), func(_ context.Context, c *Conn) error {
+		for {
+			if _, err := Recv[*ConnectRequestRaw](c); err != nil {
+				return nil
+			}
+			if err := Send(c, bigReply); err != nil {
+				return nil
+			}
+		}
+	})
+
+	conn := dialSecure(t, serv.Addr.String(), key)
+	c := NewConn(conn)
+	defer c.Close()
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, Send(c, &ConnectRequest{Id: int64(i)}))
+		reply, err := Recv[*ConnectReplyRaw](c)
+		assert.NoError(t, err)
+		assert.Equal(t, bigReply, reply)
+	}
+
+	// Clients with a wrong key are rejected, and the server does not prove that it knows the key.
+	raw, err := net.Dial("tcp", serv.Addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ClientHandshake(raw, []byte("wrong key 0123456"))
+	assert.ErrorContains(t, err, "server used wrong key")
+	raw.Close()
+
+	// Plain text clients are rejected.
+	raw, err = net.Dial("tcp", serv.Addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Write(bytes.Repeat([]byte{0}, 64))
+	raw.SetReadDeadline(time.Now().Add(time.Minute))
+	n, _ := raw.Read(make([]byte, 1))
+	assert.Equal(t, 0, n)
+	raw.Close()
+
+	_, err = ListenSecure(":0", []byte("short"))
+	assert.Error(t, err)
+}
+
+func TestSecureConnTampering(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	done := make(chan net.Conn)
+	go func() {
+		conn, err := ServerHandshake(server, key)
+		assert.NoError(t, err)
+		done <- conn
+	}()
+	conn, err := ClientHandshake(client, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sconn := <-done
+
+	// Flip a bit in the ciphertext of the record.
+	go func() {
+		sc := conn.(*secureConn)
+		sc.Conn = &corruptingConn{sc.Conn}
+		conn.Write([]byte("hello"))
+	}()
+	_, err = sconn.Read(make([]byte, 10))
+	assert.ErrorContains(t, err, "record authentication failed")
+}
+
+type corruptingConn struct {
+	net.Conn
+}
+
+func (c *corruptingConn) Write(data []byte) (int, error) {
+	data = bytes.Clone(data)
+	data[pskRecordHdr] ^= 1
+	return c.Conn.Write(data)
+}
+
+func dialSecure(t *testing.T, addr string, key []byte) net.Conn {
+	conn, err := net.DialTimeout("tcp", addr, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	secure, err := ClientHandshake(conn, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return secure
+}
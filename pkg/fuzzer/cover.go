@@ -44,6 +44,12 @@ func (cover *Cover) addRawMaxSignal(signal []uint64, prio uint8) signal.Signal {
 	return diff
 }
 
+func (cover *Cover) hasNewRawSignal(signal []uint64, prio uint8) bool {
+	cover.mu.RLock()
+	defer cover.mu.RUnlock()
+	return !cover.maxSignal.DiffRaw(signal, prio).Empty()
+}
+
 func (cover *Cover) CopyMaxSignal() signal.Signal {
 	cover.mu.RLock()
 	defer cover.mu.RUnlock()
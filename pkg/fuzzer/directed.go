@@ -0,0 +1,146 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/stat"
+	"github.com/google/syzkaller/prog"
+)
+
+// directedSelector chooses corpus programs for mutation in the directed fuzzing mode.
+// The energy of a program is calculated like in AFLGo: the distance of a program is the average
+// distance of its covered PCs to the targets, and programs closer to the targets get exponentially
+// more energy. Simulated annealing gradually moves the focus from exploration (all programs
+// get similar energy) to exploitation (the closest programs get most of the energy).
+type directedSelector struct {
+	distances map[uint64]uint32
+	start     time.Time
+
+	mu        sync.RWMutex
+	progs     []*prog.Prog
+	accEnergy []float64
+	updated   time.Time
+	minDist   float64 // minimal program distance, or -1 if no program reaches the targets
+	reached   int     // the number of programs that cover the targets
+}
+
+const (
+	// The time after which the temperature drops to 5%, i.e. we mostly exploit the closest programs.
+	directedExploreTime = time.Hour
+	// How often the energy is recalculated.
+	directedUpdatePeriod = 30 * time.Second
+)
+
+func newDirectedSelector(distances map[uint64]uint32) *directedSelector {
+	ds := &directedSelector{
+		distances: distances,
+		start:     time.Now(),
+		minDist:   -1,
+	}
+	stat.New("target distance", "Minimal distance to the directed fuzzing targets among corpus programs",
+		stat.Graph("directed"), func() int {
+			ds.mu.RLock()
+			defer ds.mu.RUnlock()
+			return int(ds.minDist)
+		})
+	stat.New("target reached", "Corpus programs that cover the directed fuzzing targets",
+		stat.Graph("directed"), stat.Console, func() int {
+			ds.mu.RLock()
+			defer ds.mu.RUnlock()
+			return ds.reached
+		})
+	return ds
+}
+
+func (ds *directedSelector) chooseProgram(r *rand.Rand, corpusObj *corpus.Corpus) *prog.Prog {
+	ds.mu.RLock()
+	stale := ds.staleLocked()
+	ds.mu.RUnlock()
+	if stale {
+		ds.mu.Lock()
+		if ds.staleLocked() {
+			ds.updateLocked(corpusObj.Items(), time.Since(ds.start))
+		}
+		ds.mu.Unlock()
+	}
+	ds.mu.RLock()
+	defer ds.mu.RUnlock()
+	if len(ds.progs) == 0 {
+		return nil
+	}
+	val := r.Float64() * ds.accEnergy[len(ds.accEnergy)-1]
+	idx := sort.SearchFloat64s(ds.accEnergy, val)
+	return ds.progs[min(idx, len(ds.progs)-1)]
+}
+
+func (ds *directedSelector) staleLocked() bool {
+	return time.Since(ds.updated) > directedUpdatePeriod || len(ds.progs) == 0
+}
+
+// distance returns the average distance of the covered PCs, or -1 if the targets are not reachable.
+func (ds *directedSelector) distance(cover []uint64) float64 {
+	total, count := 0.0, 0
+	for _, pc := range cover {
+		if dist, ok := ds.distances[pc]; ok {
+			total += float64(dist)
+			count++
+		}
+	}
+	if count == 0 {
+		return -1
+	}
+	return total / float64(count)
+}
+
+func (ds *directedSelector) updateLocked(items []*corpus.Item, elapsed time.Duration) {
+	dists := make([]float64, len(items))
+	minDist, maxDist := math.Inf(1), math.Inf(-1)
+	reached := 0
+	for i, item := range items {
+		dists[i] = ds.distance(item.Cover)
+		if dists[i] < 0 {
+			continue
+		}
+		minDist = min(minDist, dists[i])
+		maxDist = max(maxDist, dists[i])
+		for _, pc := range item.Cover {
+			if dist, ok := ds.distances[pc]; ok && dist == 0 {
+				reached++
+				break
+			}
+		}
+	}
+	temperature := math.Pow(20, -elapsed.Seconds()/directedExploreTime.Seconds())
+	progs := make([]*prog.Prog, len(items))
+	accEnergy := make([]float64, len(items))
+	sum := 0.0
+	for i, item := range items {
+		// Normalized distance: 0 for the closest program, 1 for the farthest or unreachable ones.
+		norm := 1.0
+		if dists[i] >= 0 && maxDist > minDist {
+			norm = (dists[i] - minDist) / (maxDist - minDist)
+		} else if dists[i] >= 0 {
+			norm = 0
+		}
+		power := (1-norm)*(1-temperature) + 0.5*temperature
+		sum += float64(max(len(item.Signal), 1)) * math.Exp2(10*power-5)
+		progs[i] = item.Prog
+		accEnergy[i] = sum
+	}
+	ds.progs = progs
+	ds.accEnergy = accEnergy
+	ds.updated = time.Now()
+	ds.reached = reached
+	ds.minDist = -1
+	if !math.IsInf(minDist, 1) {
+		ds.minDist = minDist
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectedSelector(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(testutil.RandSource(t))
+	corpusObj := corpus.NewCorpus(context.Background())
+	var progs []*prog.Prog
+	// The programs cover the target, a PC close to it, and an unrelated PC.
+	for _, pc := range []uint64{1, 2, 3} {
+		p := target.Generate(rnd, 5, target.DefaultChoiceTable())
+		corpusObj.Save(corpus.NewInput{
+			Prog:   p,
+			Signal: signal.FromRaw([]uint64{pc}, 0),
+			Cover:  []uint64{pc},
+		})
+		progs = append(progs, p)
+	}
+	ds := newDirectedSelector(map[uint64]uint32{1: 0, 2: 10})
+	choose := func(elapsed time.Duration) map[*prog.Prog]int {
+		ds.updateLocked(corpusObj.Items(), elapsed)
+		ret := map[*prog.Prog]int{}
+		for i := 0; i < 10000; i++ {
+			ret[ds.chooseProgram(rnd, corpusObj)]++
+		}
+		return ret
+	}
+	// In the beginning, all programs get the same energy.
+	counts := choose(0)
+	assert.Equal(t, 0.0, ds.minDist)
+	assert.Equal(t, 1, ds.reached)
+	for _, p := range progs {
+		assert.InDelta(t, 10000/3, counts[p], 500)
+	}
+	// Later, the closest program is chosen most of the time.
+	counts = choose(10 * time.Hour)
+	assert.Greater(t, counts[progs[0]], 9000)
+	assert.Less(t, counts[progs[2]], 100)
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"math/bits"
+	"math/rand"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/google/syzkaller/pkg/fuzzer/queue"
+	"github.com/google/syzkaller/pkg/stat"
+	"github.com/google/syzkaller/prog"
+)
+
+// feedbackSeeds keeps programs that reach the maximum of the additional feedback metrics
+// (see Config.Feedback) for each syscall. The metrics are noisy (they depend on scheduling,
+// the state of the caches, etc), so they are kept out of the coverage signal and the corpus:
+// a new maximum is accepted only if it's reached by all re-executions of the program,
+// and the programs are used only as additional seeds for mutation.
+// Values are bucketed logarithmically, so a maximum is new only if it's about twice as large.
+type feedbackSeeds struct {
+	kinds []flatrpc.FeedbackKind
+
+	mu    sync.RWMutex
+	best  map[feedbackKey]*feedbackSeed
+	keys  []feedbackKey // for random choice
+	execs *stat.Val
+	jobs  *stat.Val
+}
+
+type feedbackKey struct {
+	kind flatrpc.FeedbackKind
+	id   int // syscall ID
+}
+
+type feedbackSeed struct {
+	p      *prog.Prog
+	bucket int
+}
+
+type feedbackCandidate struct {
+	call   int
+	kind   flatrpc.FeedbackKind
+	bucket int
+}
+
+const (
+	// The number of re-executions that must reach the new maximum.
+	feedbackDeflakeRuns = 3
+	// One in that many mutated programs is derived from the feedback seeds.
+	feedbackSeedRate = 10
+)
+
+func newFeedbackSeeds(kinds []flatrpc.FeedbackKind) *feedbackSeeds {
+	fs := &feedbackSeeds{
+		kinds: kinds,
+		best:  make(map[feedbackKey]*feedbackSeed),
+	}
+	stat.New("feedback seeds", "Programs that reach the maximum of the additional feedback metrics",
+		stat.Graph("corpus"), func() int {
+			fs.mu.RLock()
+			defer fs.mu.RUnlock()
+			return len(fs.keys)
+		})
+	fs.jobs = stat.New("feedback jobs", "Running feedback triage jobs", stat.StackedGraph("jobs"))
+	fs.execs = stat.New("exec feedback", "Executions of feedback triage programs",
+		stat.Rate{}, stat.StackedGraph("exec"))
+	return fs
+}
+
+// candidates returns the calls of the program that may have reached a new maximum.
+func (fs *feedbackSeeds) candidates(p *prog.Prog, info *flatrpc.ProgInfo) []feedbackCandidate {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	var ret []feedbackCandidate
+	for call, ci := range info.Calls {
+		for _, kind := range fs.kinds {
+			if ci == nil || int(kind) >= len(ci.Feedback) {
+				continue
+			}
+			bucket := bits.Len64(ci.Feedback[kind])
+			if bucket > fs.bucketLocked(kind, p.Calls[call].Meta.ID) {
+				ret = append(ret, feedbackCandidate{call, kind, bucket})
+			}
+		}
+	}
+	return ret
+}
+
+// update saves the program if it still has a new maximum for the candidate.
+func (fs *feedbackSeeds) update(p *prog.Prog, cand feedbackCandidate) bool {
+	key := feedbackKey{cand.kind, p.Calls[cand.call].Meta.ID}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if cand.bucket <= fs.bucketLocked(key.kind, key.id) {
+		return false
+	}
+	if fs.best[key] == nil {
+		fs.keys = append(fs.keys, key)
+	}
+	fs.best[key] = &feedbackSeed{p, cand.bucket}
+	return true
+}
+
+func (fs *feedbackSeeds) bucketLocked(kind flatrpc.FeedbackKind, id int) int {
+	if seed := fs.best[feedbackKey{kind, id}]; seed != nil {
+		return seed.bucket
+	}
+	return 0
+}
+
+func (fs *feedbackSeeds) chooseProgram(r *rand.Rand) *prog.Prog {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if len(fs.keys) == 0 {
+		return nil
+	}
+	return fs.best[fs.keys[r.Intn(len(fs.keys))]].p
+}
+
+// feedbackJob re-executes a program that gave a new maximum of a feedback metric
+// and saves it as a seed if the maximum is stable.
+type feedbackJob struct {
+	p     *prog.Prog
+	exec  queue.Executor
+	cands []feedbackCandidate
+}
+
+func (job *feedbackJob) run(fuzzer *Fuzzer) {
+	fs := fuzzer.feedback
+	for run := 0; run < feedbackDeflakeRuns && len(job.cands) != 0; run++ {
+		result := fuzzer.executeWithFlags(job.exec, &queue.Request{
+			Prog:     job.p,
+			ExecOpts: setFlags(flatrpc.ExecFlagCollectSignal),
+			Stat:     fs.execs,
+		}, progInTriage)
+		if result.Stop() || result.Info == nil {
+			return
+		}
+		// The stable value is the minimum over all runs.
+		stable := job.cands[:0]
+		for _, cand := range job.cands {
+			ci := result.Info.Calls[cand.call]
+			if ci == nil || int(cand.kind) >= len(ci.Feedback) {
+				continue
+			}
+			cand.bucket = min(cand.bucket, bits.Len64(ci.Feedback[cand.kind]))
+			if cand.bucket != 0 {
+				stable = append(stable, cand)
+			}
+		}
+		job.cands = stable
+	}
+	for _, cand := range job.cands {
+		if fs.update(job.p, cand) {
+			fuzzer.Logf(2, "new %v maximum (bucket %v) in call %v", cand.kind,
+				cand.bucket, job.p.CallName(cand.call))
+		}
+	}
+}
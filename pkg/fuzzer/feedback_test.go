@@ -0,0 +1,47 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedbackSeeds(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte("test()\ntest()\n"), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := newFeedbackSeeds([]flatrpc.FeedbackKind{flatrpc.FeedbackKindPageFaults})
+	info := func(faults ...uint64) *flatrpc.ProgInfo {
+		info := flatrpc.EmptyProgInfo(len(p.Calls))
+		for i, call := range info.Calls {
+			call.Feedback = []uint64{100, faults[i]}
+		}
+		return info
+	}
+	// Context switches are not enabled, and zero values are never a maximum.
+	assert.Empty(t, fs.candidates(p, info(0, 0)))
+	cands := fs.candidates(p, info(0, 5))
+	assert.Equal(t, []feedbackCandidate{{1, flatrpc.FeedbackKindPageFaults, 3}}, cands)
+	assert.Nil(t, fs.chooseProgram(rand.New(testutil.RandSource(t))))
+
+	assert.True(t, fs.update(p, cands[0]))
+	assert.False(t, fs.update(p, cands[0]))
+	assert.Equal(t, p, fs.chooseProgram(rand.New(testutil.RandSource(t))))
+	// Both calls are the same syscall, so they share the maximum.
+	// Values in the same logarithmic bucket are not new.
+	assert.Empty(t, fs.candidates(p, info(4, 7)))
+	assert.Len(t, fs.candidates(p, info(8, 0)), 1)
+}
@@ -32,7 +32,15 @@ type Fuzzer struct {
 	rnd          *rand.Rand
 	target       *prog.Target
 	hintsLimiter prog.HintsLimiter
-	runningJobs  map[jobIntrospector]struct{}
+	runningJobs  map[job]struct{}
+	mutateOpts   prog.MutateOpts
+	mutations    *mutationScheduler    // nil if adaptive mutation is disabled
+	directed     *directedSelector     // nil if directed fuzzing is disabled
+	transitions  *prog.CallTransitions // nil if learned priorities are disabled
+	feedback     *feedbackSeeds        // nil if no additional feedback is configured
+
+	// Candidate requests that are not finished yet.
+	pendingCandidates map[*queue.Request]ProgFlags
 
 	ct           *prog.ChoiceTable
 	ctProgs      int
@@ -57,12 +65,30 @@ func NewFuzzer(ctx context.Context, cfg *Config, rnd *rand.Rand,
 		ctx:         ctx,
 		rnd:         rnd,
 		target:      target,
-		runningJobs: map[jobIntrospector]struct{}{},
+		runningJobs: map[job]struct{}{},
+		mutateOpts:  prog.DefaultMutateOpts,
+
+		pendingCandidates: map[*queue.Request]ProgFlags{},
 
 		// We're okay to lose some of the messages -- if we are already
 		// regenerating the table, we don't want to repeat it right away.
 		ctRegenerate: make(chan struct{}),
 	}
+	if cfg.Crossover {
+		f.mutateOpts = f.mutateOpts.WithCrossover()
+	}
+	if cfg.AdaptiveMutation {
+		f.mutations = newMutationScheduler(f.mutateOpts)
+	}
+	if cfg.LearnTransitions {
+		f.transitions = prog.NewCallTransitions()
+	}
+	if len(cfg.TargetDistances) != 0 {
+		f.directed = newDirectedSelector(cfg.TargetDistances)
+	}
+	if len(cfg.Feedback) != 0 {
+		f.feedback = newFeedbackSeeds(cfg.Feedback)
+	}
 	f.execQueues = newExecQueues(f)
 	f.updateChoiceTable(nil)
 	go f.choiceTableUpdater()
@@ -78,6 +104,27 @@ type execQueues struct {
 	triageQueue          *queue.DynamicOrderer
 	smashQueue           *queue.PlainQueue
 	source               queue.Source
+	snapshotSource       queue.Source        // nil if Config.SnapshotJobs is not set
+	fair                 *queue.WeightedFair // nil if exec shares are not configured
+}
+
+// Fuzzer stages that can be given shares of executor time (see Config.ExecShares).
+const (
+	StageTriageCandidate = "triage candidate"
+	StageCandidate       = "candidate"
+	StageTriage          = "triage"
+	StageSmash           = "smash"
+	StageFuzz            = "fuzz"
+)
+
+// Default shares for the stages that are not mentioned in Config.ExecShares.
+// Triage is cheap relative to the value it brings, so it gets most of the time.
+var defaultExecShares = map[string]queue.Share{
+	StageTriageCandidate: {Weight: 4},
+	StageCandidate:       {Weight: 4},
+	StageTriage:          {Weight: 4},
+	StageSmash:           {Weight: 2},
+	StageFuzz:            {Weight: 1, Min: 0.05},
 }
 
 func newExecQueues(fuzzer *Fuzzer) execQueues {
@@ -95,6 +142,60 @@ func newExecQueues(fuzzer *Fuzzer) execQueues {
 		// mutating various corpus programs.
 		skipQueue = 2
 	}
+	if fuzzer.Config.SnapshotJobs {
+		// Jobs are executed in snapshot mode, fuzzing is done in both modes
+		// so that snapshot VMs are not idle when there are no jobs.
+		ret.snapshotSource = queue.Order(
+			ret.triageCandidateQueue,
+			ret.triageQueue,
+			queue.Alternate(ret.smashQueue, skipQueue),
+			queue.Callback(fuzzer.genFuzz),
+		)
+	}
+	if len(fuzzer.Config.ExecShares) != 0 {
+		var stages []queue.FairStage
+		for _, stage := range []struct {
+			name   string
+			source queue.Source
+			job    bool
+		}{
+			{StageTriageCandidate, ret.triageCandidateQueue, true},
+			{StageCandidate, ret.candidateQueue, false},
+			{StageTriage, ret.triageQueue, true},
+			{StageSmash, ret.smashQueue, true},
+			{StageFuzz, queue.Callback(fuzzer.genFuzz), false},
+		} {
+			if stage.job && fuzzer.Config.SnapshotJobs {
+				continue
+			}
+			share, ok := fuzzer.Config.ExecShares[stage.name]
+			if !ok {
+				share = defaultExecShares[stage.name]
+			}
+			stages = append(stages, queue.FairStage{
+				Name:   stage.name,
+				Source: stage.source,
+				Share:  share,
+			})
+		}
+		ret.fair = queue.Fair(stages...)
+		ret.source = ret.fair
+		for i, stage := range stages {
+			stat.New(fmt.Sprintf("exec share %v", stage.Name),
+				fmt.Sprintf("Recent share (%%) of executor time consumed by the %v stage", stage.Name),
+				stat.StackedGraph("exec shares"), stat.Link("/queues"), func() int {
+					return int(100 * ret.fair.Stages()[i].Actual)
+				})
+		}
+		return ret
+	}
+	if fuzzer.Config.SnapshotJobs {
+		ret.source = queue.Order(
+			ret.candidateQueue,
+			queue.Callback(fuzzer.genFuzz),
+		)
+		return ret
+	}
 	// Sources are listed in the order, in which they will be polled.
 	ret.source = queue.Order(
 		ret.triageCandidateQueue,
@@ -107,7 +208,12 @@ func newExecQueues(fuzzer *Fuzzer) execQueues {
 }
 
 func (fuzzer *Fuzzer) CandidateTriageFinished() bool {
-	return fuzzer.statCandidates.Val()+fuzzer.statJobsTriageCandidate.Val() == 0
+	return fuzzer.CandidateBacklog() == 0
+}
+
+// CandidateBacklog returns the number of candidates that are not yet executed or triaged.
+func (fuzzer *Fuzzer) CandidateBacklog() int {
+	return fuzzer.statCandidates.Val() + fuzzer.statJobsTriageCandidate.Val()
 }
 
 func (fuzzer *Fuzzer) execute(executor queue.Executor, req *queue.Request) *queue.Result {
@@ -127,6 +233,7 @@ func (fuzzer *Fuzzer) prepare(req *queue.Request, flags ProgFlags, attempt int)
 
 func (fuzzer *Fuzzer) enqueue(executor queue.Executor, req *queue.Request, flags ProgFlags, attempt int) {
 	fuzzer.prepare(req, flags, attempt)
+	fuzzer.Config.Tracer.Start(req)
 	executor.Submit(req)
 }
 
@@ -166,6 +273,15 @@ func (fuzzer *Fuzzer) processResult(req *queue.Request, res *queue.Result, flags
 			sort.Strings(job.info.Calls)
 			fuzzer.startJob(stat, job)
 		}
+		if fuzzer.feedback != nil {
+			if cands := fuzzer.feedback.candidates(req.Prog, res.Info); len(cands) != 0 {
+				fuzzer.startJob(fuzzer.feedback.jobs, &feedbackJob{
+					p:     req.Prog.Clone(),
+					exec:  fuzzer.smashQueue,
+					cands: cands,
+				})
+			}
+		}
 	}
 
 	if res.Info != nil {
@@ -192,15 +308,21 @@ func (fuzzer *Fuzzer) processResult(req *queue.Request, res *queue.Result, flags
 	}
 	if flags&progCandidate != 0 {
 		fuzzer.statCandidates.Add(-1)
+		fuzzer.mu.Lock()
+		delete(fuzzer.pendingCandidates, req)
+		fuzzer.mu.Unlock()
 	}
 	return true
 }
 
 type Config struct {
-	Debug          bool
-	Corpus         *corpus.Corpus
-	Logf           func(level int, msg string, args ...interface{})
-	Snapshot       bool
+	Debug    bool
+	Corpus   *corpus.Corpus
+	Logf     func(level int, msg string, args ...interface{})
+	Snapshot bool
+	// Execute triage, minimization, hints and smash jobs in snapshot mode (see SnapshotSource),
+	// while the rest of the fuzzing happens in the normal mode.
+	SnapshotJobs   bool
 	Coverage       bool
 	FaultInjection bool
 	Comparisons    bool
@@ -210,13 +332,32 @@ type Config struct {
 	FetchRawCover  bool
 	NewInputFilter func(call string) bool
 	PatchTest      bool
+	// Adapt weights of mutation operators based on the new signal they produce.
+	AdaptiveMutation bool
+	// Distances from coverage PCs to the directed fuzzing targets.
+	// If set, programs closer to the targets are mutated more often.
+	TargetDistances map[uint64]uint32
+	// If set, a sample of the requests is traced through the execution pipeline.
+	Tracer *queue.Tracer
+	// Learn call-to-call transitions from programs that give new signal
+	// and use them for the choice table.
+	LearnTransitions bool
+	// Enable the resource-aware crossover mutation operator.
+	Crossover bool
+	// If set, executor time is split between the fuzzer stages (Stage* constants)
+	// according to the shares, stages that are not mentioned get default shares.
+	// Otherwise the stages are strictly prioritized.
+	ExecShares map[string]queue.Share
+	// Additional per-call feedback metrics to maximize besides coverage.
+	// Programs that stably reach a new maximum of a metric for a syscall are used as mutation seeds.
+	Feedback []flatrpc.FeedbackKind
 }
 
 func (fuzzer *Fuzzer) triageProgCall(p *prog.Prog, info *flatrpc.CallInfo, call int, triage *map[int]*triageCall) {
 	if info == nil {
 		return
 	}
-	prio := signalPrio(p, info, call)
+	prio := SignalPrio(p, info, call)
 	newMaxSignal := fuzzer.Cover.addRawMaxSignal(info.Signal, prio)
 	if newMaxSignal.Empty() {
 		return
@@ -251,7 +392,9 @@ func (fuzzer *Fuzzer) handleCallInfo(req *queue.Request, info *flatrpc.CallInfo,
 	}
 }
 
-func signalPrio(p *prog.Prog, info *flatrpc.CallInfo, call int) (prio uint8) {
+// SignalPrio returns the priority of the signal of the call (or of the extra signal if call is -1).
+// Signal of successful calls and calls without ANY arguments has higher priority.
+func SignalPrio(p *prog.Prog, info *flatrpc.CallInfo, call int) (prio uint8) {
 	if call == -1 {
 		return 0
 	}
@@ -273,9 +416,11 @@ func (fuzzer *Fuzzer) genFuzz() *queue.Request {
 		mutateRate = 0.5
 	}
 	var req *queue.Request
+	var seed *prog.Prog
+	var ops []prog.MutationOp
 	rnd := fuzzer.rand()
 	if rnd.Float64() < mutateRate {
-		req = mutateProgRequest(fuzzer, rnd)
+		req, seed, ops = mutateProgRequest(fuzzer, rnd)
 	}
 	if req == nil {
 		req = genProgRequest(fuzzer, rnd)
@@ -285,11 +430,44 @@ func (fuzzer *Fuzzer) genFuzz() *queue.Request {
 			Prog: randomCollide(req.Prog, rnd),
 			Stat: fuzzer.statExecCollide,
 		}
+		seed, ops = nil, nil
 	}
 	fuzzer.prepare(req, 0, 0)
+	if seed != nil {
+		fuzzer.mutationFeedback(req, seed, ops)
+	}
+	fuzzer.Config.Tracer.Start(req)
 	return req
 }
 
+// mutationFeedback reports to the mutation scheduler and to the corpus power schedule
+// whether the mutated program gave new signal.
+// The callback is installed after processResult, so it runs before it (callbacks are called
+// in the LIFO order) and sees the max signal before it's updated with the program's signal.
+func (fuzzer *Fuzzer) mutationFeedback(req *queue.Request, seed *prog.Prog, ops []prog.MutationOp) {
+	req.OnDone(func(req *queue.Request, res *queue.Result) bool {
+		newSignal := false
+		if res.Info != nil && res.Status != queue.Hanged {
+			for call, info := range res.Info.Calls {
+				newSignal = newSignal || fuzzer.hasNewSignal(req.Prog, info, call)
+			}
+			newSignal = newSignal || fuzzer.hasNewSignal(req.Prog, res.Info.Extra, -1)
+		}
+		if fuzzer.mutations != nil {
+			fuzzer.mutations.feedback(ops, newSignal)
+		}
+		fuzzer.Config.Corpus.SaveMutation(seed, newSignal)
+		return true
+	})
+}
+
+func (fuzzer *Fuzzer) hasNewSignal(p *prog.Prog, info *flatrpc.CallInfo, call int) bool {
+	if info == nil {
+		return false
+	}
+	return fuzzer.Cover.hasNewRawSignal(info.Signal, SignalPrio(p, info, call))
+}
+
 func (fuzzer *Fuzzer) startJob(stat *stat.Val, newJob job) {
 	fuzzer.Logf(2, "started %T", newJob)
 	go func() {
@@ -299,17 +477,15 @@ func (fuzzer *Fuzzer) startJob(stat *stat.Val, newJob job) {
 		fuzzer.statJobs.Add(1)
 		defer fuzzer.statJobs.Add(-1)
 
-		if obj, ok := newJob.(jobIntrospector); ok {
+		fuzzer.mu.Lock()
+		fuzzer.runningJobs[newJob] = struct{}{}
+		fuzzer.mu.Unlock()
+
+		defer func() {
 			fuzzer.mu.Lock()
-			fuzzer.runningJobs[obj] = struct{}{}
+			delete(fuzzer.runningJobs, newJob)
 			fuzzer.mu.Unlock()
-
-			defer func() {
-				fuzzer.mu.Lock()
-				delete(fuzzer.runningJobs, obj)
-				fuzzer.mu.Unlock()
-			}()
-		}
+		}()
 
 		newJob.run(fuzzer)
 	}()
@@ -324,6 +500,17 @@ func (fuzzer *Fuzzer) Next() *queue.Request {
 	return req
 }
 
+// SnapshotSource returns the source of requests for VMs in snapshot mode (nil if Config.SnapshotJobs is not set).
+// All jobs are executed only via this source.
+func (fuzzer *Fuzzer) SnapshotSource() queue.Source {
+	return fuzzer.snapshotSource
+}
+
+// snapshotJobs says if jobs are executed in snapshot mode.
+func (fuzzer *Fuzzer) snapshotJobs() bool {
+	return fuzzer.Config.Snapshot || fuzzer.Config.SnapshotJobs
+}
+
 func (fuzzer *Fuzzer) Logf(level int, msg string, args ...interface{}) {
 	if fuzzer.Config.Logf == nil {
 		return
@@ -346,18 +533,29 @@ const (
 type Candidate struct {
 	Prog  *prog.Prog
 	Flags ProgFlags
+	// If set, the program is added to the corpus right away with the given signal
+	// and coverage instead of being triaged again.
+	Triaged *TriagedInput
 }
 
 func (fuzzer *Fuzzer) AddCandidates(candidates []Candidate) {
-	fuzzer.statCandidates.Add(len(candidates))
 	for _, candidate := range candidates {
+		if candidate.Triaged != nil {
+			fuzzer.addTriaged(candidate)
+			continue
+		}
+		fuzzer.statCandidates.Add(1)
 		req := &queue.Request{
 			Prog:      candidate.Prog,
 			ExecOpts:  setFlags(flatrpc.ExecFlagCollectSignal),
 			Stat:      fuzzer.statExecCandidate,
 			Important: true,
 		}
-		fuzzer.enqueue(fuzzer.candidateQueue, req, candidate.Flags|progCandidate, 0)
+		flags := candidate.Flags | progCandidate
+		fuzzer.mu.Lock()
+		fuzzer.pendingCandidates[req] = candidate.Flags
+		fuzzer.mu.Unlock()
+		fuzzer.enqueue(fuzzer.candidateQueue, req, flags, 0)
 	}
 }
 
@@ -368,7 +566,8 @@ func (fuzzer *Fuzzer) rand() *rand.Rand {
 }
 
 func (fuzzer *Fuzzer) updateChoiceTable(programs []*prog.Prog) {
-	newCt := fuzzer.target.BuildChoiceTable(programs, fuzzer.Config.EnabledCalls)
+	newCt := fuzzer.target.BuildChoiceTableWithTransitions(programs, fuzzer.Config.EnabledCalls,
+		fuzzer.transitions)
 
 	fuzzer.ctMu.Lock()
 	defer fuzzer.ctMu.Unlock()
@@ -411,13 +610,26 @@ func (fuzzer *Fuzzer) ChoiceTable() *prog.ChoiceTable {
 	return fuzzer.ct
 }
 
+// ExecShares returns the queue that splits executor time between the fuzzer stages
+// (nil if Config.ExecShares is not set). It can be used to adjust the shares at runtime.
+func (fuzzer *Fuzzer) ExecShares() *queue.WeightedFair {
+	return fuzzer.fair
+}
+
+// CallTransitions returns the learned call transitions model (nil if it's disabled).
+func (fuzzer *Fuzzer) CallTransitions() *prog.CallTransitions {
+	return fuzzer.transitions
+}
+
 func (fuzzer *Fuzzer) RunningJobs() []*JobInfo {
 	fuzzer.mu.Lock()
 	defer fuzzer.mu.Unlock()
 
 	var ret []*JobInfo
 	for item := range fuzzer.runningJobs {
-		ret = append(ret, item.getInfo())
+		if obj, ok := item.(jobIntrospector); ok {
+			ret = append(ret, obj.getInfo())
+		}
 	}
 	return ret
 }
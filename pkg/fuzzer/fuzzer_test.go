@@ -92,6 +92,60 @@ func TestFuzz(t *testing.T) {
 	}
 }
 
+func TestSnapshotJobs(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64Fuzz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	calls := map[*prog.Syscall]bool{}
+	for _, c := range target.Syscalls {
+		calls[c] = true
+	}
+	fuzzer := NewFuzzer(ctx, &Config{
+		Corpus:       corpus.NewCorpus(ctx),
+		Coverage:     true,
+		SnapshotJobs: true,
+		EnabledCalls: calls,
+	}, rand.New(testutil.RandSource(t)), target)
+	rnd := rand.New(testutil.RandSource(t))
+	fuzzer.AddCandidates([]Candidate{{
+		Prog:  target.Generate(rnd, 5, fuzzer.ChoiceTable()),
+		Flags: ProgFromCorpus,
+	}})
+
+	// Candidates are executed in the normal mode.
+	req := fuzzer.Next()
+	assert.Equal(t, fuzzer.statExecCandidate, req.Stat)
+	res, _, _ := emulateExec(req)
+	req.Done(res)
+
+	// The candidate gives new signal, but the triage job is executed only in snapshot mode.
+	var triageReq *queue.Request
+	assert.Eventually(t, func() bool {
+		req := fuzzer.SnapshotSource().Next()
+		if req.Stat == fuzzer.statExecTriage {
+			triageReq = req
+		}
+		return triageReq != nil
+	}, time.Minute, time.Millisecond)
+	for i := 0; i < 100; i++ {
+		req := fuzzer.Next()
+		assert.NotEqual(t, fuzzer.statExecTriage, req.Stat)
+		assert.NotEqual(t, fuzzer.statExecCandidate, req.Stat)
+	}
+	res, _, _ = emulateExec(triageReq)
+	triageReq.Done(res)
+
+	fuzzer = NewFuzzer(ctx, &Config{
+		Corpus:       corpus.NewCorpus(ctx),
+		Coverage:     true,
+		EnabledCalls: calls,
+	}, rand.New(testutil.RandSource(t)), target)
+	assert.Nil(t, fuzzer.SnapshotSource())
+}
+
 func BenchmarkFuzzer(b *testing.B) {
 	b.ReportAllocs()
 	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64Fuzz)
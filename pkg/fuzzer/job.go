@@ -52,23 +52,44 @@ func genProgRequest(fuzzer *Fuzzer, rnd *rand.Rand) *queue.Request {
 	}
 }
 
-func mutateProgRequest(fuzzer *Fuzzer, rnd *rand.Rand) *queue.Request {
-	p := fuzzer.Config.Corpus.ChooseProgram(rnd)
+// mutateProgRequest returns the request, the corpus program it was derived from
+// and the applied mutation operators (only if adaptive mutation is enabled).
+func mutateProgRequest(fuzzer *Fuzzer, rnd *rand.Rand) (*queue.Request, *prog.Prog, []prog.MutationOp) {
+	var p *prog.Prog
+	if fuzzer.directed != nil {
+		p = fuzzer.directed.chooseProgram(rnd, fuzzer.Config.Corpus)
+	}
+	if p == nil && fuzzer.feedback != nil && rnd.Intn(feedbackSeedRate) == 0 {
+		p = fuzzer.feedback.chooseProgram(rnd)
+	}
 	if p == nil {
-		return nil
+		p = fuzzer.Config.Corpus.ChooseProgram(rnd)
+	}
+	if p == nil {
+		return nil, nil, nil
 	}
 	newP := p.Clone()
-	newP.Mutate(rnd,
-		prog.RecommendedCalls,
-		fuzzer.ChoiceTable(),
-		fuzzer.Config.NoMutateCalls,
-		fuzzer.Config.Corpus.Programs(),
-	)
+	var ops []prog.MutationOp
+	if fuzzer.mutations != nil {
+		ops = fuzzer.mutations.mutate(newP, rnd,
+			fuzzer.ChoiceTable(),
+			fuzzer.Config.NoMutateCalls,
+			fuzzer.Config.Corpus.Programs(),
+		)
+	} else {
+		newP.MutateWithOpts(rnd,
+			prog.RecommendedCalls,
+			fuzzer.ChoiceTable(),
+			fuzzer.Config.NoMutateCalls,
+			fuzzer.Config.Corpus.Programs(),
+			fuzzer.mutateOpts,
+		)
+	}
 	return &queue.Request{
 		Prog:     newP,
 		ExecOpts: setFlags(flatrpc.ExecFlagCollectSignal),
 		Stat:     fuzzer.statExecFuzz,
-	}
+	}, p, ops
 }
 
 // triageJob are programs for which we noticed potential new coverage during
@@ -178,10 +199,14 @@ func (job *triageJob) handleCall(call int, info *triageCall) {
 	if !job.fuzzer.Config.NewInputFilter(callName) {
 		return
 	}
+	if job.fuzzer.transitions != nil {
+		job.fuzzer.transitions.Record(p, call)
+	}
 	if job.flags&ProgSmashed == 0 {
 		job.fuzzer.startJob(job.fuzzer.statJobsSmash, &smashJob{
 			exec: job.fuzzer.smashQueue,
 			p:    p.Clone(),
+			call: call,
 			info: &JobInfo{
 				Name:  p.String(),
 				Type:  "smash",
@@ -224,7 +249,7 @@ func (job *triageJob) deflake(exec func(*queue.Request, ProgFlags) *queue.Result
 
 	avoid := []queue.ExecutorID{job.executor}
 	needRuns := deflakeNeedCorpusRuns
-	if job.fuzzer.Config.Snapshot {
+	if job.fuzzer.snapshotJobs() {
 		needRuns = deflakeNeedSnapshotRuns
 	} else if job.flags&ProgFromCorpus == 0 {
 		needRuns = deflakeNeedRuns
@@ -274,7 +299,7 @@ func (job *triageJob) deflake(exec func(*queue.Request, ProgFlags) *queue.Result
 			// it won't be stable. However, it's still possible if we do more than needRuns runs.
 			// But also we already observed it and we know it's flaky, so at least doing
 			// cover.addRawMaxSignal for it looks useful.
-			prio := signalPrio(job.p, res, call)
+			prio := SignalPrio(job.p, res, call)
 			newMaxSignal := job.fuzzer.Cover.addRawMaxSignal(res.Signal, prio)
 			info.newSignal.Merge(newMaxSignal)
 			info.cover.Merge(res.Cover)
@@ -302,7 +327,7 @@ func (job *triageJob) deflake(exec func(*queue.Request, ProgFlags) *queue.Result
 }
 
 func (job *triageJob) stopDeflake(run, needRuns int, noNewSignal bool) bool {
-	if job.fuzzer.Config.Snapshot {
+	if job.fuzzer.snapshotJobs() {
 		return run >= needRuns+1
 	}
 	haveSignal := true
@@ -344,7 +369,7 @@ func (job *triageJob) stopDeflake(run, needRuns int, noNewSignal bool) bool {
 func (job *triageJob) minimize(call int, info *triageCall) (*prog.Prog, int) {
 	job.info.Logf("[call #%d] minimize started", call)
 	minimizeAttempts := 3
-	if job.fuzzer.Config.Snapshot {
+	if job.fuzzer.snapshotJobs() {
 		minimizeAttempts = 2
 	}
 	stop := false
@@ -416,7 +441,7 @@ func getSignalAndCover(p *prog.Prog, info *flatrpc.ProgInfo, call int) signal.Si
 	if inf == nil {
 		return nil
 	}
-	return signal.FromRaw(inf.Signal, signalPrio(p, inf, call))
+	return signal.FromRaw(inf.Signal, SignalPrio(p, inf, call))
 }
 
 func signalPreview(s signal.Signal) string {
@@ -442,6 +467,8 @@ func (job *triageJob) getInfo() *JobInfo {
 type smashJob struct {
 	exec queue.Executor
 	p    *prog.Prog
+	call int
+	skip int // the number of iterations done before the restart
 	info *JobInfo
 }
 
@@ -451,12 +478,13 @@ func (job *smashJob) run(fuzzer *Fuzzer) {
 
 	const iters = 25
 	rnd := fuzzer.rand()
-	for i := 0; i < iters; i++ {
+	for i := job.skip; i < iters; i++ {
 		p := job.p.Clone()
-		p.Mutate(rnd, prog.RecommendedCalls,
+		p.MutateWithOpts(rnd, prog.RecommendedCalls,
 			fuzzer.ChoiceTable(),
 			fuzzer.Config.NoMutateCalls,
-			fuzzer.Config.Corpus.Programs())
+			fuzzer.Config.Corpus.Programs(),
+			fuzzer.mutateOpts)
 		result := fuzzer.execute(job.exec, &queue.Request{
 			Prog:     p,
 			ExecOpts: setFlags(flatrpc.ExecFlagCollectSignal),
@@ -473,6 +501,15 @@ func (job *smashJob) getInfo() *JobInfo {
 	return job.info
 }
 
+func (job *smashJob) state() StateJob {
+	return StateJob{
+		Type:     stateJobSmash,
+		Prog:     string(job.p.Serialize()),
+		Call:     job.call,
+		Progress: job.skip + int(job.info.Execs.Load()),
+	}
+}
+
 func randomCollide(origP *prog.Prog, rnd *rand.Rand) *prog.Prog {
 	if rnd.Intn(5) == 0 {
 		// Old-style collide with a 20% probability.
@@ -499,10 +536,12 @@ type faultInjectionJob struct {
 	exec queue.Executor
 	p    *prog.Prog
 	call int
+	skip int // the number of iterations done before the restart
+	done atomic.Int32
 }
 
 func (job *faultInjectionJob) run(fuzzer *Fuzzer) {
-	for nth := 1; nth <= 100; nth++ {
+	for nth := job.skip + 1; nth <= 100; nth++ {
 		fuzzer.Logf(2, "injecting fault into call %v, step %v",
 			job.call, nth)
 		newProg := job.p.Clone()
@@ -514,6 +553,7 @@ func (job *faultInjectionJob) run(fuzzer *Fuzzer) {
 		if result.Stop() {
 			return
 		}
+		job.done.Store(int32(nth))
 		info := result.Info
 		if info != nil && len(info.Calls) > job.call &&
 			info.Calls[job.call].Flags&flatrpc.CallFlagFaultInjected == 0 {
@@ -522,6 +562,15 @@ func (job *faultInjectionJob) run(fuzzer *Fuzzer) {
 	}
 }
 
+func (job *faultInjectionJob) state() StateJob {
+	return StateJob{
+		Type:     stateJobFault,
+		Prog:     string(job.p.Serialize()),
+		Call:     job.call,
+		Progress: max(job.skip, int(job.done.Load())),
+	}
+}
+
 type hintsJob struct {
 	exec queue.Executor
 	p    *prog.Prog
@@ -583,6 +632,15 @@ func (job *hintsJob) getInfo() *JobInfo {
 	return job.info
 }
 
+func (job *hintsJob) state() StateJob {
+	// Hints jobs are always restarted from scratch.
+	return StateJob{
+		Type: stateJobHints,
+		Prog: string(job.p.Serialize()),
+		Call: job.call,
+	}
+}
+
 type syncBuffer struct {
 	mu  sync.Mutex
 	buf bytes.Buffer
@@ -0,0 +1,172 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/stat"
+	"github.com/google/syzkaller/prog"
+)
+
+// mutationScheduler is a multi-armed bandit over prog mutation operators.
+// Every operator starts with the static weight from prog.DefaultMutateOpts and then
+// its weight is scaled by how productive (in terms of new signal) the operator
+// turned out to be compared to the average productivity of all operators.
+// Statistics are periodically decayed, so that the scheduler keeps adapting
+// as the campaign progresses and different operators become more/less useful.
+type mutationScheduler struct {
+	opts  prog.MutateOpts
+	prior [prog.MutationOpCount]float64
+
+	mu        sync.RWMutex
+	arms      [prog.MutationOpCount]mutationArm
+	weights   [prog.MutationOpCount]float64 // cumulative weights used for selection
+	feedbacks int
+
+	statApplied [prog.MutationOpCount]*stat.Val
+	statNew     [prog.MutationOpCount]*stat.Val
+	statShare   [prog.MutationOpCount]*stat.Val
+}
+
+type mutationArm struct {
+	applied float64
+	success float64
+}
+
+const (
+	// The number of pseudo-applications that every operator starts with (with the average success rate).
+	// This prevents wild swings in weights while we have little data.
+	mutationPriorRuns = 100
+	// Weights are recalculated after that many feedbacks.
+	mutationRecalcPeriod = 100
+	// Statistics are halved after that many feedbacks.
+	mutationDecayPeriod = 50000
+	// Bounds for the weight multiplier of each operator relative to the static weight.
+	// The lower bound ensures that we still explore all operators.
+	mutationMinScale = 0.1
+	mutationMaxScale = 10
+)
+
+func newMutationScheduler(opts prog.MutateOpts) *mutationScheduler {
+	sched := &mutationScheduler{
+		opts: opts,
+	}
+	for op, weight := range opts.Weights() {
+		sched.prior[op] = float64(weight)
+	}
+	sched.recalculate()
+	for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+		sched.statApplied[op] = stat.New(fmt.Sprintf("mutation %v", op),
+			fmt.Sprintf("Successful applications of the %v mutation operator", op),
+			stat.Rate{}, stat.StackedGraph("mutations"))
+		sched.statNew[op] = stat.New(fmt.Sprintf("mutation %v new", op),
+			fmt.Sprintf("Programs with new signal produced by the %v mutation operator", op),
+			stat.Rate{}, stat.StackedGraph("mutations new"))
+		sched.statShare[op] = stat.New(fmt.Sprintf("mutation %v share", op),
+			fmt.Sprintf("Current probability (%%) of choosing the %v mutation operator", op),
+			stat.StackedGraph("mutations share"), sched.shareFunc(op))
+	}
+	return sched
+}
+
+func (sched *mutationScheduler) ChooseMutation(r *rand.Rand) prog.MutationOp {
+	sched.mu.RLock()
+	defer sched.mu.RUnlock()
+	val := r.Float64() * sched.weights[prog.MutationOpCount-1]
+	for op, weight := range sched.weights {
+		if val < weight {
+			return prog.MutationOp(op)
+		}
+	}
+	return prog.MutationOpCount - 1
+}
+
+// mutate mutates p with the adaptive weights and returns the applied operators
+// that must be passed to feedback once the program is executed.
+func (sched *mutationScheduler) mutate(p *prog.Prog, rnd *rand.Rand, ct *prog.ChoiceTable,
+	noMutate map[int]bool, corpus []*prog.Prog) []prog.MutationOp {
+	opts := sched.opts
+	opts.Scheduler = sched
+	ops := p.MutateWithOpts(rnd, prog.RecommendedCalls, ct, noMutate, corpus, opts)
+	for _, op := range ops {
+		sched.statApplied[op].Add(1)
+	}
+	return ops
+}
+
+// feedback credits the operators that produced the program with the execution outcome.
+func (sched *mutationScheduler) feedback(ops []prog.MutationOp, newSignal bool) {
+	if len(ops) == 0 {
+		return
+	}
+	if newSignal {
+		for _, op := range ops {
+			sched.statNew[op].Add(1)
+		}
+	}
+	// Split the credit between all applied operators.
+	credit := 1 / float64(len(ops))
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	for _, op := range ops {
+		arm := &sched.arms[op]
+		arm.applied += credit
+		if newSignal {
+			arm.success += credit
+		}
+	}
+	sched.feedbacks++
+	if sched.feedbacks%mutationDecayPeriod == 0 {
+		for op := range sched.arms {
+			sched.arms[op].applied /= 2
+			sched.arms[op].success /= 2
+		}
+	}
+	if sched.feedbacks%mutationRecalcPeriod == 0 {
+		sched.recalculateLocked()
+	}
+}
+
+func (sched *mutationScheduler) recalculate() {
+	sched.mu.Lock()
+	defer sched.mu.Unlock()
+	sched.recalculateLocked()
+}
+
+func (sched *mutationScheduler) recalculateLocked() {
+	var totalApplied, totalSuccess float64
+	for _, arm := range sched.arms {
+		totalApplied += arm.applied
+		totalSuccess += arm.success
+	}
+	// The +1 keeps the average rate positive before we get any feedback.
+	avgRate := (totalSuccess + 1) / (totalApplied + 1)
+	var sum float64
+	for op, arm := range sched.arms {
+		// Smooth the estimate towards the average rate while we have few runs.
+		rate := (arm.success + avgRate*mutationPriorRuns) / (arm.applied + mutationPriorRuns)
+		scale := min(max(rate/avgRate, mutationMinScale), mutationMaxScale)
+		sum += sched.prior[op] * scale
+		sched.weights[op] = sum
+	}
+}
+
+func (sched *mutationScheduler) shareFunc(op prog.MutationOp) func() int {
+	return func() int {
+		sched.mu.RLock()
+		defer sched.mu.RUnlock()
+		prev := 0.0
+		if op > 0 {
+			prev = sched.weights[op-1]
+		}
+		total := sched.weights[prog.MutationOpCount-1]
+		if total == 0 {
+			return 0
+		}
+		return int(100 * (sched.weights[op] - prev) / total)
+	}
+}
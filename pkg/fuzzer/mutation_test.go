@@ -0,0 +1,53 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMutationScheduler(t *testing.T) {
+	sched := newMutationScheduler(prog.DefaultMutateOpts.WithCrossover())
+	r := rand.New(testutil.RandSource(t))
+	initial := chosenMutations(sched, r)
+	for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+		assert.NotZero(t, initial[op], "%v was never chosen", op)
+	}
+
+	// Only insertCall gives new signal.
+	for i := 0; i < 10000; i++ {
+		for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+			sched.feedback([]prog.MutationOp{op}, op == prog.MutationInsert)
+		}
+	}
+	adapted := chosenMutations(sched, r)
+	assert.Greater(t, adapted[prog.MutationInsert], 2*initial[prog.MutationInsert])
+	for op := prog.MutationOp(0); op < prog.MutationOpCount; op++ {
+		// All operators must still be explored.
+		assert.NotZero(t, adapted[op], "%v was never chosen", op)
+		if op != prog.MutationInsert {
+			assert.Less(t, adapted[op], initial[op])
+		}
+	}
+}
+
+func TestMutationSchedulerNoCrossover(t *testing.T) {
+	sched := newMutationScheduler(prog.DefaultMutateOpts)
+	r := rand.New(testutil.RandSource(t))
+	// Crossover is disabled by default.
+	assert.Zero(t, chosenMutations(sched, r)[prog.MutationCrossover])
+}
+
+func chosenMutations(sched *mutationScheduler, r *rand.Rand) [prog.MutationOpCount]int {
+	var ret [prog.MutationOpCount]int
+	for i := 0; i < 100000; i++ {
+		ret[sched.ChooseMutation(r)]++
+	}
+	return ret
+}
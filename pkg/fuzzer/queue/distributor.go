@@ -55,6 +55,7 @@ func (dist *Distributor) delay(req *Request) {
 	dist.mu.Lock()
 	defer dist.mu.Unlock()
 	req.delayedSince = dist.seq.Load()
+	req.TraceEvent("distributor delay")
 	dist.queue = append(dist.queue, req)
 	dist.statDelayed.Add(1)
 	dist.empty.Store(false)
@@ -80,6 +81,7 @@ func (dist *Distributor) delayed(vm int) *Request {
 		if violation {
 			dist.statViolated.Add(1)
 		}
+		req.TraceEvent("distributor undelay", "vm", vm, "violated", violation)
 		last := len(dist.queue) - 1
 		dist.queue[i] = dist.queue[last]
 		dist.queue[last] = nil
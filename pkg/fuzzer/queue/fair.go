@@ -0,0 +1,224 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Share is the share of executor time given to a source by WeightedFair.
+type Share struct {
+	// Relative weight of the source.
+	Weight float64
+	// Minimal fraction (0..1) of the total execution time the source gets
+	// while it has requests, regardless of the weights.
+	Min float64
+}
+
+// FairStage is a named source for WeightedFair.
+type FairStage struct {
+	Name   string
+	Source Source
+	Share  Share
+}
+
+// FairStageInfo describes the current state of a WeightedFair stage.
+type FairStageInfo struct {
+	Name  string
+	Share Share
+	// Fraction of the recent execution time consumed by the stage.
+	Actual   float64
+	Requests int
+	ExecTime time.Duration
+}
+
+const (
+	// Recent execution time is halved with this period, so that the past does not dominate.
+	fairDecayPeriod = 10 * time.Second
+	// Execution time estimate for requests of a stage that did not finish any requests yet.
+	fairDefaultEstimate = 10 * time.Millisecond
+)
+
+// WeightedFair is a weighted fair queue Source: each stage gets a share of executor time
+// proportional to its weight (and not less than its minimal share) while it has requests.
+// The shares are enforced against the execution time measured by the executor
+// (or wall time, if it's not available) rather than against the number of requests.
+// Shares can be changed at runtime with SetShare.
+type WeightedFair struct {
+	mu        sync.Mutex
+	stages    []*fairStage
+	lastDecay time.Time
+}
+
+type fairStage struct {
+	FairStage
+	// Recent execution time (seconds), includes estimates for in-flight requests.
+	used float64
+	// Moving average of the request execution time (seconds).
+	estimate float64
+	requests int
+	execTime time.Duration
+}
+
+func Fair(stages ...FairStage) *WeightedFair {
+	wf := &WeightedFair{
+		lastDecay: time.Now(),
+	}
+	for _, stage := range stages {
+		wf.stages = append(wf.stages, &fairStage{
+			FairStage: stage,
+			estimate:  fairDefaultEstimate.Seconds(),
+		})
+	}
+	return wf
+}
+
+func (wf *WeightedFair) Next() *Request {
+	for _, stage := range wf.order() {
+		req := stage.Source.Next()
+		if req == nil {
+			continue
+		}
+		wf.dispatched(stage, req)
+		return req
+	}
+	return nil
+}
+
+// order returns the stages in the order they should be polled: first the stages that got
+// less than their minimal share, then the rest in the order of consumed time relative to the weight.
+func (wf *WeightedFair) order() []*fairStage {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	if since := time.Since(wf.lastDecay); since > fairDecayPeriod {
+		for _, stage := range wf.stages {
+			stage.used /= 2
+		}
+		wf.lastDecay = time.Now()
+	}
+	total := 0.0
+	for _, stage := range wf.stages {
+		total += stage.used
+	}
+	type stageKey struct {
+		stage      *fairStage
+		belowMin   bool
+		deficit    float64
+		normalized float64
+	}
+	keys := make([]stageKey, len(wf.stages))
+	for i, stage := range wf.stages {
+		key := stageKey{stage: stage}
+		actual := 0.0
+		if total != 0 {
+			actual = stage.used / total
+		}
+		if actual < stage.Share.Min {
+			key.belowMin = true
+			key.deficit = actual - stage.Share.Min
+		}
+		// Stages with zero weight are polled only if nothing else has requests.
+		key.normalized = math.Inf(1)
+		if stage.Share.Weight > 0 {
+			key.normalized = stage.used / stage.Share.Weight
+		}
+		keys[i] = key
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.belowMin != b.belowMin {
+			return a.belowMin
+		}
+		if a.belowMin {
+			return a.deficit < b.deficit
+		}
+		return a.normalized < b.normalized
+	})
+	ret := make([]*fairStage, len(keys))
+	for i, key := range keys {
+		ret[i] = key.stage
+	}
+	return ret
+}
+
+func (wf *WeightedFair) dispatched(stage *fairStage, req *Request) {
+	wf.mu.Lock()
+	estimate := stage.estimate
+	stage.used += estimate
+	wf.mu.Unlock()
+	start := time.Now()
+	req.OnDone(func(_ *Request, res *Result) bool {
+		elapsed := time.Since(start)
+		if res.Info != nil && res.Info.Elapsed != 0 {
+			elapsed = time.Duration(res.Info.Elapsed)
+		}
+		wf.mu.Lock()
+		defer wf.mu.Unlock()
+		stage.used = max(0, stage.used+elapsed.Seconds()-estimate)
+		stage.estimate = stage.estimate*0.9 + elapsed.Seconds()*0.1
+		stage.requests++
+		stage.execTime += elapsed
+		return true
+	})
+}
+
+// SetShare changes the share of the stage.
+// The resulting shares must have a positive total weight and the total min share must not exceed 1.
+func (wf *WeightedFair) SetShare(name string, share Share) error {
+	if !(share.Weight >= 0) || math.IsInf(share.Weight, 0) || !(share.Min >= 0 && share.Min <= 1) {
+		return fmt.Errorf("bad share %+v: weight must be finite and non-negative and min share in [0, 1]", share)
+	}
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	var target *fairStage
+	totalWeight, totalMin := 0.0, 0.0
+	for _, stage := range wf.stages {
+		current := stage.Share
+		if stage.Name == name {
+			target = stage
+			current = share
+		}
+		totalWeight += current.Weight
+		totalMin += current.Min
+	}
+	if target == nil {
+		return fmt.Errorf("unknown stage %q", name)
+	}
+	if totalWeight == 0 {
+		return fmt.Errorf("bad share %+v: total weight of all stages would be 0", share)
+	}
+	if totalMin > 1 {
+		return fmt.Errorf("bad share %+v: total min share of all stages would be %v", share, totalMin)
+	}
+	target.Share = share
+	return nil
+}
+
+// Stages returns the current state of all stages.
+func (wf *WeightedFair) Stages() []FairStageInfo {
+	wf.mu.Lock()
+	defer wf.mu.Unlock()
+	total := 0.0
+	for _, stage := range wf.stages {
+		total += stage.used
+	}
+	var ret []FairStageInfo
+	for _, stage := range wf.stages {
+		info := FairStageInfo{
+			Name:     stage.Name,
+			Share:    stage.Share,
+			Requests: stage.requests,
+			ExecTime: stage.execTime,
+		}
+		if total != 0 {
+			info.Actual = stage.used / total
+		}
+		ret = append(ret, info)
+	}
+	return ret
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeightedFair(t *testing.T) {
+	// Stage requests take different time to execute.
+	costs := map[string]time.Duration{
+		"fast":  time.Millisecond,
+		"slow":  10 * time.Millisecond,
+		"other": 5 * time.Millisecond,
+	}
+	var stages []FairStage
+	for _, name := range []string{"fast", "slow", "other"} {
+		name := name
+		stages = append(stages, FairStage{
+			Name: name,
+			Source: Callback(func() *Request {
+				return &Request{GlobPattern: name}
+			}),
+		})
+	}
+	stages[0].Share = Share{Weight: 1}
+	stages[1].Share = Share{Weight: 3}
+	stages[2].Share = Share{Weight: 0, Min: 0.2}
+	wf := Fair(stages...)
+	run := func() map[string]time.Duration {
+		used := make(map[string]time.Duration)
+		for i := 0; i < 10000; i++ {
+			req := wf.Next()
+			cost := costs[req.GlobPattern]
+			used[req.GlobPattern] += cost
+			req.Done(&Result{Info: &flatrpc.ProgInfo{Elapsed: uint64(cost)}})
+		}
+		return used
+	}
+	share := func(used map[string]time.Duration, name string) float64 {
+		total := time.Duration(0)
+		for _, cost := range used {
+			total += cost
+		}
+		return float64(used[name]) / float64(total)
+	}
+	used := run()
+	// The zero-weight stage gets only its min share, the rest is split 1:3 by execution time.
+	assert.InDelta(t, 0.2, share(used, "other"), 0.02)
+	assert.InDelta(t, 0.2, share(used, "fast"), 0.02)
+	assert.InDelta(t, 0.6, share(used, "slow"), 0.02)
+
+	assert.NoError(t, wf.SetShare("fast", Share{Weight: 1}))
+	assert.NoError(t, wf.SetShare("slow", Share{Weight: 1}))
+	assert.NoError(t, wf.SetShare("other", Share{Weight: 2}))
+	assert.Error(t, wf.SetShare("unknown", Share{Weight: 1}))
+	assert.Error(t, wf.SetShare("fast", Share{Weight: 1, Min: 2}))
+	assert.Error(t, wf.SetShare("fast", Share{Weight: math.NaN()}))
+	assert.Error(t, wf.SetShare("fast", Share{Weight: math.Inf(1)}))
+	assert.Error(t, wf.SetShare("fast", Share{Weight: 1, Min: math.NaN()}))
+	// Let the history decay, otherwise the new shares are skewed by the old usage.
+	for i := 0; i < 30; i++ {
+		wf.lastDecay = time.Time{}
+		wf.order()
+	}
+	used = run()
+	assert.InDelta(t, 0.25, share(used, "fast"), 0.05)
+	assert.InDelta(t, 0.25, share(used, "slow"), 0.05)
+	assert.InDelta(t, 0.5, share(used, "other"), 0.05)
+
+	infos := wf.Stages()
+	assert.Len(t, infos, 3)
+	assert.Equal(t, "slow", infos[1].Name)
+	assert.Equal(t, Share{Weight: 1}, infos[1].Share)
+	assert.Greater(t, infos[1].Requests, 0)
+}
+
+func TestWeightedFairSetShare(t *testing.T) {
+	wf := Fair(
+		FairStage{Name: "a", Source: Plain(), Share: Share{Weight: 1, Min: 0.5}},
+		FairStage{Name: "b", Source: Plain()},
+	)
+	// The total min share must not exceed 1.
+	assert.Error(t, wf.SetShare("b", Share{Weight: 1, Min: 0.6}))
+	assert.NoError(t, wf.SetShare("b", Share{Weight: 1, Min: 0.5}))
+	// The total weight must stay positive.
+	assert.NoError(t, wf.SetShare("a", Share{}))
+	assert.Error(t, wf.SetShare("b", Share{}))
+	assert.Equal(t, Share{Weight: 1, Min: 0.5}, wf.Stages()[1].Share)
+}
+
+func TestWeightedFairEmptyStages(t *testing.T) {
+	pq := Plain()
+	wf := Fair(
+		FairStage{Name: "plain", Source: pq, Share: Share{Weight: 1}},
+		FairStage{Name: "empty", Source: Plain(), Share: Share{Weight: 100}},
+	)
+	assert.Nil(t, wf.Next())
+	req := &Request{}
+	pq.Submit(req)
+	// The stage with a larger weight has no requests.
+	assert.Equal(t, req, wf.Next())
+	assert.Nil(t, wf.Next())
+}
@@ -59,6 +59,7 @@ type Request struct {
 
 	onceCrashed  bool
 	delayedSince uint64
+	trace        *Trace
 
 	mu     sync.Mutex
 	result *Result
@@ -87,6 +88,7 @@ func (r *Request) OnDone(cb DoneCallback) {
 }
 
 func (r *Request) Done(res *Result) {
+	r.traceResult(res)
 	if r.callback != nil {
 		if !r.callback(r, res) {
 			return
@@ -95,6 +97,7 @@ func (r *Request) Done(res *Result) {
 	if r.Stat != nil {
 		r.Stat.Add(1)
 	}
+	r.traceDone(res)
 	r.initChannel()
 	r.result = res
 	close(r.done)
@@ -230,6 +233,23 @@ const (
 	Hanged             // The program has hanged (can't be killed/waited).
 )
 
+func (s Status) String() string {
+	switch s {
+	case Success:
+		return "success"
+	case ExecFailure:
+		return "exec failure"
+	case Crashed:
+		return "crashed"
+	case Restarted:
+		return "restarted"
+	case Hanged:
+		return "hanged"
+	default:
+		return fmt.Sprintf("status %d", int(s))
+	}
+}
+
 // Executor describes the interface wanted by the producers of requests.
 // After a Request is submitted, it's expected that the consumer will eventually
 // take it and report the execution result via Done().
@@ -298,6 +318,7 @@ func (pq *PlainQueue) nextLocked() *Request {
 	ret := pq.queue[pq.pos]
 	pq.queue[pq.pos] = nil
 	pq.pos++
+	ret.TraceEvent("dequeue")
 	return ret
 }
 
@@ -391,7 +412,11 @@ func (do *DynamicOrderer) submit(req *Request, prio int) {
 func (do *DynamicOrderer) Next() *Request {
 	do.mu.Lock()
 	defer do.mu.Unlock()
-	return do.ops.Pop()
+	req := do.ops.Pop()
+	if req != nil {
+		req.TraceEvent("dequeue")
+	}
+	return req
 }
 
 type dynamicOrdererItem struct {
@@ -454,9 +479,11 @@ func (d *Deduplicator) Next() *Request {
 			d.mm[hash] = &duplicateState{}
 		} else if entry.res == nil {
 			// There's no result yet, put the request to the queue.
+			req.TraceEvent("duplicate wait")
 			entry.queued = append(entry.queued, req)
 		} else {
 			// We already know the result.
+			req.TraceEvent("duplicate")
 			req.Done(entry.res.clone())
 		}
 		d.mu.Unlock()
@@ -532,6 +559,7 @@ func (rq *RandomQueue) Next() *Request {
 	}
 	pos := rq.rnd.Intn(len(rq.queue))
 	item := rq.queue[pos]
+	item.TraceEvent("dequeue")
 
 	last := len(rq.queue) - 1
 	rq.queue[pos] = rq.queue[last]
@@ -37,12 +37,14 @@ func (r *retryer) done(req *Request, res *Result) bool {
 		return true
 	case Restarted:
 		// The input was on a restarted VM.
+		req.TraceEvent("retry")
 		r.pq.Submit(req)
 		return false
 	case Crashed:
 		// Retry important requests from crashed VMs once.
 		if req.Important && !req.onceCrashed {
 			req.onceCrashed = true
+			req.TraceEvent("retry")
 			r.pq.Submit(req)
 			return false
 		}
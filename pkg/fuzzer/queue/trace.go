@@ -0,0 +1,457 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/syzkaller/pkg/stat"
+)
+
+// Tracer records the lifecycle of a sample of requests as they travel through the pipeline.
+// A traced request collects a sequence of events (enqueue, dequeue, distributor delays,
+// VM assignment, execution, retries, the result). The time between two consecutive events
+// is attributed to a phase named after the first of them, which lets us see where requests
+// spend their time and why they were retried or dropped.
+type Tracer struct {
+	every   uint64
+	service string
+	seq     atomic.Uint64
+
+	mu       sync.Mutex
+	pending  []*Trace
+	recent   []*Trace
+	dropped  int
+	finished int
+	phases   map[string]*TracePhase
+	sources  map[string]*TracePhase
+	statuses map[string]int
+}
+
+// Trace is the recorded lifecycle of a single request.
+type Trace struct {
+	tracer *Tracer
+	id     [16]byte
+	source string
+
+	mu     sync.Mutex
+	events []TraceEvent
+	status Status
+	err    error
+}
+
+type TraceEvent struct {
+	Name  string
+	Time  time.Time
+	Attrs []TraceAttr
+}
+
+type TraceAttr struct {
+	Key   string
+	Value any
+}
+
+// TracePhase aggregates the time requests spent in a particular phase.
+type TracePhase struct {
+	Name  string
+	Count int
+	Total time.Duration
+	Max   time.Duration
+}
+
+func (tp *TracePhase) Avg() time.Duration {
+	if tp.Count == 0 {
+		return 0
+	}
+	return tp.Total / time.Duration(tp.Count)
+}
+
+type TraceSummary struct {
+	Every    int
+	Finished int
+	Dropped  int
+	Phases   []*TracePhase
+	Sources  []*TracePhase // end-to-end latency per request source
+	Statuses map[string]int
+	Recent   []*TraceRecord
+}
+
+// TraceRecord is a snapshot of a finished trace.
+type TraceRecord struct {
+	Source   string
+	Status   string
+	Err      error
+	Start    time.Time
+	Duration time.Duration
+	Events   []TraceEvent
+}
+
+const (
+	// The number of finished traces that can wait for the export.
+	maxPendingTraces = 10000
+	// The number of the most recent finished traces that are kept for the summary.
+	maxRecentTraces = 50
+)
+
+// NewTracer creates a tracer that traces every N-th request.
+func NewTracer(every int, service string) *Tracer {
+	t := &Tracer{
+		every:    uint64(max(every, 1)),
+		service:  service,
+		phases:   make(map[string]*TracePhase),
+		sources:  make(map[string]*TracePhase),
+		statuses: make(map[string]int),
+	}
+	stat.New("traced requests", "Number of finished traced requests",
+		stat.NoGraph, stat.Link("/traces"), func() int {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			return t.finished
+		})
+	return t
+}
+
+// Start records that the request was submitted for execution.
+// If the request is not traced yet, Start decides whether to trace it.
+// The request source is derived from the request stat.
+// It's fine to call Start on a nil Tracer.
+func (t *Tracer) Start(req *Request) {
+	if t == nil {
+		return
+	}
+	source := "unknown"
+	if req.Stat != nil {
+		source = req.Stat.Name()
+	}
+	if req.trace == nil {
+		if t.seq.Add(1)%t.every != 0 {
+			return
+		}
+		req.trace = &Trace{
+			tracer: t,
+			source: source,
+		}
+		binary.LittleEndian.PutUint64(req.trace.id[:8], rand.Uint64())
+		binary.LittleEndian.PutUint64(req.trace.id[8:], rand.Uint64())
+	}
+	req.TraceEvent("enqueue", "source", source)
+}
+
+// TraceEvent records an event in the request lifecycle if the request is traced.
+// Attributes are passed as key-value pairs.
+func (r *Request) TraceEvent(name string, attrs ...any) {
+	if r.trace == nil {
+		return
+	}
+	if len(attrs)%2 != 0 {
+		panic("odd number of trace attributes")
+	}
+	ev := TraceEvent{
+		Name: name,
+		Time: time.Now(),
+	}
+	for i := 0; i < len(attrs); i += 2 {
+		ev.Attrs = append(ev.Attrs, TraceAttr{Key: attrs[i].(string), Value: attrs[i+1]})
+	}
+	r.trace.mu.Lock()
+	r.trace.events = append(r.trace.events, ev)
+	r.trace.mu.Unlock()
+}
+
+func (r *Request) traceResult(res *Result) {
+	if r.trace == nil {
+		return
+	}
+	attrs := []any{
+		"status", res.Status.String(),
+		"vm", res.Executor.VM,
+		"proc", res.Executor.Proc,
+	}
+	if res.Info != nil {
+		attrs = append(attrs, "exec_ns", int64(res.Info.Elapsed))
+	}
+	if res.Err != nil {
+		attrs = append(attrs, "error", res.Err.Error())
+	}
+	r.TraceEvent("result", attrs...)
+}
+
+func (r *Request) traceDone(res *Result) {
+	tr := r.trace
+	if tr == nil {
+		return
+	}
+	r.trace = nil
+	tr.mu.Lock()
+	tr.events = append(tr.events, TraceEvent{Name: "done", Time: time.Now()})
+	tr.status = res.Status
+	tr.err = res.Err
+	tr.mu.Unlock()
+	tr.tracer.finish(tr)
+}
+
+func (t *Tracer) finish(tr *Trace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.finished++
+	t.statuses[tr.status.String()]++
+	for i := 0; i+1 < len(tr.events); i++ {
+		addPhase(t.phases, tr.events[i].Name, tr.events[i+1].Time.Sub(tr.events[i].Time))
+	}
+	addPhase(t.sources, tr.source, tr.duration())
+	if len(t.pending) < maxPendingTraces {
+		t.pending = append(t.pending, tr)
+	} else {
+		t.dropped++
+	}
+	if len(t.recent) == maxRecentTraces {
+		copy(t.recent, t.recent[1:])
+		t.recent = t.recent[:len(t.recent)-1]
+	}
+	t.recent = append(t.recent, tr)
+}
+
+func addPhase(m map[string]*TracePhase, name string, d time.Duration) {
+	phase := m[name]
+	if phase == nil {
+		phase = &TracePhase{Name: name}
+		m[name] = phase
+	}
+	phase.Count++
+	phase.Total += d
+	phase.Max = max(phase.Max, d)
+}
+
+func (tr *Trace) duration() time.Duration {
+	return tr.events[len(tr.events)-1].Time.Sub(tr.events[0].Time)
+}
+
+// Summary returns the aggregated statistics of the finished traces.
+func (t *Tracer) Summary() *TraceSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	ret := &TraceSummary{
+		Every:    int(t.every),
+		Finished: t.finished,
+		Dropped:  t.dropped,
+		Statuses: make(map[string]int),
+	}
+	for _, phase := range t.phases {
+		tmp := *phase
+		ret.Phases = append(ret.Phases, &tmp)
+	}
+	for _, source := range t.sources {
+		tmp := *source
+		ret.Sources = append(ret.Sources, &tmp)
+	}
+	for _, list := range [][]*TracePhase{ret.Phases, ret.Sources} {
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Total != list[j].Total {
+				return list[i].Total > list[j].Total
+			}
+			return list[i].Name < list[j].Name
+		})
+	}
+	for status, count := range t.statuses {
+		ret.Statuses[status] = count
+	}
+	for i := len(t.recent) - 1; i >= 0; i-- {
+		tr := t.recent[i]
+		ret.Recent = append(ret.Recent, &TraceRecord{
+			Source:   tr.source,
+			Status:   tr.status.String(),
+			Err:      tr.err,
+			Start:    tr.events[0].Time,
+			Duration: tr.duration(),
+			Events:   tr.events,
+		})
+	}
+	return ret
+}
+
+// Export writes the traces finished since the previous export to w
+// in the OpenTelemetry (OTLP) JSON format, and returns the number of exported traces.
+// Each trace is represented by a root span that covers the whole request lifecycle
+// and a child span per phase.
+func (t *Tracer) Export(w io.Writer) (int, error) {
+	t.mu.Lock()
+	traces := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+	if len(traces) == 0 {
+		return 0, nil
+	}
+	var spans []otlpSpan
+	for _, tr := range traces {
+		spans = append(spans, tr.spans()...)
+	}
+	data := otlpData{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: otlpAttrs([]TraceAttr{{Key: "service.name", Value: t.service}}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: "github.com/google/syzkaller/pkg/fuzzer/queue"},
+				Spans: spans,
+			}},
+		}},
+	}
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		return 0, err
+	}
+	return len(traces), nil
+}
+
+func (tr *Trace) spans() []otlpSpan {
+	traceID := hex.EncodeToString(tr.id[:])
+	spanID := func(idx int) string {
+		var id [8]byte
+		copy(id[:], tr.id[8:])
+		id[0] ^= byte(idx + 1)
+		return hex.EncodeToString(id[:])
+	}
+	status := otlpStatus{Code: otlpStatusOk}
+	if tr.err != nil || tr.status != Success {
+		status = otlpStatus{Code: otlpStatusError, Message: tr.status.String()}
+		if tr.err != nil {
+			status.Message = fmt.Sprintf("%v: %v", tr.status, tr.err)
+		}
+	}
+	ret := []otlpSpan{{
+		TraceID: traceID,
+		SpanID:  spanID(0),
+		Name:    "request",
+		Kind:    otlpSpanKindInternal,
+		Start:   otlpTime(tr.events[0].Time),
+		End:     otlpTime(tr.events[len(tr.events)-1].Time),
+		Attributes: otlpAttrs([]TraceAttr{
+			{Key: "source", Value: tr.source},
+			{Key: "status", Value: tr.status.String()},
+		}),
+		Status: status,
+	}}
+	for i := 0; i+1 < len(tr.events); i++ {
+		ev := tr.events[i]
+		ret[0].Events = append(ret[0].Events, otlpEvent{
+			Time:       otlpTime(ev.Time),
+			Name:       ev.Name,
+			Attributes: otlpAttrs(ev.Attrs),
+		})
+		ret = append(ret, otlpSpan{
+			TraceID:      traceID,
+			SpanID:       spanID(i + 1),
+			ParentSpanID: ret[0].SpanID,
+			Name:         ev.Name,
+			Kind:         otlpSpanKindInternal,
+			Start:        otlpTime(ev.Time),
+			End:          otlpTime(tr.events[i+1].Time),
+			Attributes:   otlpAttrs(ev.Attrs),
+		})
+	}
+	return ret
+}
+
+// The types below represent a subset of the OTLP JSON encoding.
+// See https://opentelemetry.io/docs/specs/otlp/#json-protobuf-encoding.
+type otlpData struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttr `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID      string      `json:"traceId"`
+	SpanID       string      `json:"spanId"`
+	ParentSpanID string      `json:"parentSpanId,omitempty"`
+	Name         string      `json:"name"`
+	Kind         int         `json:"kind"`
+	Start        string      `json:"startTimeUnixNano"`
+	End          string      `json:"endTimeUnixNano"`
+	Attributes   []otlpAttr  `json:"attributes,omitempty"`
+	Events       []otlpEvent `json:"events,omitempty"`
+	Status       otlpStatus  `json:"status"`
+}
+
+type otlpEvent struct {
+	Time       string     `json:"timeUnixNano"`
+	Name       string     `json:"name"`
+	Attributes []otlpAttr `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttr struct {
+	Key   string        `json:"key"`
+	Value otlpAttrValue `json:"value"`
+}
+
+type otlpAttrValue struct {
+	String *string `json:"stringValue,omitempty"`
+	Int    *string `json:"intValue,omitempty"`
+	Bool   *bool   `json:"boolValue,omitempty"`
+}
+
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusOk         = 1
+	otlpStatusError      = 2
+)
+
+func otlpTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func otlpAttrs(attrs []TraceAttr) []otlpAttr {
+	var ret []otlpAttr
+	for _, attr := range attrs {
+		var val otlpAttrValue
+		switch v := attr.Value.(type) {
+		case int:
+			str := strconv.FormatInt(int64(v), 10)
+			val.Int = &str
+		case int64:
+			str := strconv.FormatInt(v, 10)
+			val.Int = &str
+		case uint64:
+			str := strconv.FormatUint(v, 10)
+			val.Int = &str
+		case bool:
+			val.Bool = &v
+		default:
+			str := fmt.Sprint(v)
+			val.String = &str
+		}
+		ret = append(ret, otlpAttr{Key: attr.Key, Value: val})
+	}
+	return ret
+}
@@ -0,0 +1,82 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package queue
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTracer(t *testing.T) {
+	tracer := NewTracer(2, "test")
+	q := Plain()
+	source := Retry(q)
+	reqStat := stat.New("exec test", "test requests")
+	var reqs []*Request
+	for i := 0; i < 4; i++ {
+		req := &Request{Stat: reqStat}
+		tracer.Start(req)
+		q.Submit(req)
+		reqs = append(reqs, req)
+	}
+	// Every second request is traced.
+	for i, req := range reqs {
+		assert.Equal(t, i%2 == 1, req.trace != nil, "request %v", i)
+	}
+	for i := range reqs {
+		req := source.Next()
+		assert.Equal(t, reqs[i], req)
+		req.TraceEvent("send", "vm", 1)
+		if i == 1 {
+			req.Done(&Result{Status: Restarted})
+			assert.Equal(t, req, source.Next())
+			req.TraceEvent("send", "vm", 2)
+		}
+		req.Done(&Result{Status: Success})
+	}
+	summary := tracer.Summary()
+	assert.Equal(t, 2, summary.Finished)
+	assert.Equal(t, map[string]int{"success": 2}, summary.Statuses)
+	assert.Len(t, summary.Sources, 1)
+	assert.Equal(t, "exec test", summary.Sources[0].Name)
+	phases := make(map[string]int)
+	for _, phase := range summary.Phases {
+		phases[phase.Name] = phase.Count
+	}
+	assert.Equal(t, map[string]int{
+		"enqueue": 2,
+		"dequeue": 3,
+		"send":    3,
+		"result":  3,
+		"retry":   1,
+	}, phases)
+	var events []string
+	for _, ev := range summary.Recent[1].Events {
+		events = append(events, ev.Name)
+	}
+	assert.Equal(t, []string{"enqueue", "dequeue", "send", "result", "retry",
+		"dequeue", "send", "result", "done"}, events)
+
+	buf := new(bytes.Buffer)
+	n, err := tracer.Export(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	var data otlpData
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &data))
+	spans := data.ResourceSpans[0].ScopeSpans[0].Spans
+	// The root span and a span per phase.
+	assert.Len(t, spans, 2+4+8)
+	assert.Equal(t, "request", spans[0].Name)
+	assert.Equal(t, spans[0].SpanID, spans[1].ParentSpanID)
+	assert.Equal(t, spans[0].TraceID, spans[1].TraceID)
+
+	// Traces are exported only once.
+	n, err = tracer.Export(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+}
@@ -0,0 +1,168 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/prog"
+)
+
+// State is a snapshot of the in-memory fuzzer work that would otherwise be lost on restart:
+// candidates that were not triaged yet and the unfinished smash/hints/fault injection jobs.
+// It's obtained with Fuzzer.State() and can be passed to Fuzzer.Resume() after a restart.
+type State struct {
+	Candidates []StateCandidate `json:"candidates,omitempty"`
+	Jobs       []StateJob       `json:"jobs,omitempty"`
+}
+
+type StateCandidate struct {
+	Prog  string    `json:"prog"`
+	Flags ProgFlags `json:"flags"`
+}
+
+type StateJob struct {
+	Type string `json:"type"` // smash, hints or fault
+	Prog string `json:"prog"`
+	Call int    `json:"call"`
+	// The number of iterations that were already done (for smash and fault jobs).
+	Progress int `json:"progress,omitempty"`
+}
+
+const (
+	stateJobSmash = "smash"
+	stateJobHints = "hints"
+	stateJobFault = "fault"
+)
+
+// TriagedInput is the result of a previous triage of a candidate program.
+type TriagedInput struct {
+	Call   int
+	Signal signal.Signal
+	Cover  []uint64
+}
+
+// Jobs that can be persisted in State.
+type statefulJob interface {
+	state() StateJob
+}
+
+// State returns the current snapshot of the unfinished fuzzer work.
+func (fuzzer *Fuzzer) State() *State {
+	fuzzer.mu.Lock()
+	defer fuzzer.mu.Unlock()
+	state := &State{}
+	dedup := make(map[string]bool)
+	addCandidate := func(p *prog.Prog, flags ProgFlags) {
+		data := string(p.Serialize())
+		if dedup[data] {
+			return
+		}
+		dedup[data] = true
+		state.Candidates = append(state.Candidates, StateCandidate{
+			Prog:  data,
+			Flags: flags & (ProgFromCorpus | ProgMinimized | ProgSmashed),
+		})
+	}
+	for req, flags := range fuzzer.pendingCandidates {
+		addCandidate(req.Prog, flags)
+	}
+	for item := range fuzzer.runningJobs {
+		switch job := item.(type) {
+		case *triageJob:
+			// Programs with potential new signal that are not triaged yet
+			// (including candidates whose first execution has already finished)
+			// will be triaged again as candidates.
+			addCandidate(job.p, job.flags)
+		case statefulJob:
+			state.Jobs = append(state.Jobs, job.state())
+		}
+	}
+	return state
+}
+
+// Resume restarts the work persisted in the state.
+// Programs that fail to deserialize (e.g. because descriptions have changed) are skipped.
+func (fuzzer *Fuzzer) Resume(state *State) {
+	var candidates []Candidate
+	for _, item := range state.Candidates {
+		p, err := fuzzer.target.Deserialize([]byte(item.Prog), prog.NonStrict)
+		if err != nil || !p.OnlyContains(fuzzer.Config.EnabledCalls) {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Prog:  p,
+			Flags: item.Flags,
+		})
+	}
+	fuzzer.AddCandidates(candidates)
+	resumed := 0
+	for _, item := range state.Jobs {
+		p, err := fuzzer.target.Deserialize([]byte(item.Prog), prog.NonStrict)
+		if err != nil || !p.OnlyContains(fuzzer.Config.EnabledCalls) ||
+			item.Call < -1 || item.Call >= len(p.Calls) {
+			continue
+		}
+		if fuzzer.resumeJob(item, p) {
+			resumed++
+		}
+	}
+	fuzzer.Logf(0, "resumed %v candidates and %v jobs", len(candidates), resumed)
+}
+
+func (fuzzer *Fuzzer) resumeJob(item StateJob, p *prog.Prog) bool {
+	switch item.Type {
+	case stateJobSmash:
+		fuzzer.startJob(fuzzer.statJobsSmash, &smashJob{
+			exec: fuzzer.smashQueue,
+			p:    p,
+			call: item.Call,
+			skip: item.Progress,
+			info: &JobInfo{
+				Name:  p.String(),
+				Type:  "smash",
+				Calls: []string{p.CallName(item.Call)},
+			},
+		})
+	case stateJobHints:
+		if !fuzzer.Config.Comparisons || item.Call < 0 {
+			return false
+		}
+		fuzzer.startJob(fuzzer.statJobsHints, &hintsJob{
+			exec: fuzzer.smashQueue,
+			p:    p,
+			call: item.Call,
+			info: &JobInfo{
+				Name:  p.String(),
+				Type:  "hints",
+				Calls: []string{p.CallName(item.Call)},
+			},
+		})
+	case stateJobFault:
+		if !fuzzer.Config.FaultInjection || item.Call < 0 {
+			return false
+		}
+		fuzzer.startJob(fuzzer.statJobsFaultInjection, &faultInjectionJob{
+			exec: fuzzer.smashQueue,
+			p:    p,
+			call: item.Call,
+			skip: item.Progress,
+		})
+	default:
+		return false
+	}
+	return true
+}
+
+func (fuzzer *Fuzzer) addTriaged(candidate Candidate) {
+	triaged := candidate.Triaged
+	fuzzer.Cover.AddMaxSignal(triaged.Signal)
+	fuzzer.statTriageSkipped.Add(1)
+	fuzzer.Config.Corpus.Save(corpus.NewInput{
+		Prog:   candidate.Prog,
+		Call:   triaged.Call,
+		Signal: triaged.Signal,
+		Cover:  triaged.Cover,
+	})
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package fuzzer
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateResume(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64Fuzz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newFuzzer := func() *Fuzzer {
+		calls := map[*prog.Syscall]bool{}
+		for _, c := range target.Syscalls {
+			calls[c] = true
+		}
+		return NewFuzzer(ctx, &Config{
+			Corpus:       corpus.NewCorpus(ctx),
+			Coverage:     true,
+			EnabledCalls: calls,
+		}, rand.New(testutil.RandSource(t)), target)
+	}
+	fuzzer := newFuzzer()
+	rnd := rand.New(testutil.RandSource(t))
+	ct := fuzzer.ChoiceTable()
+	triaged := target.Generate(rnd, 5, ct)
+	pending := target.Generate(rnd, 5, ct)
+	fuzzer.AddCandidates([]Candidate{
+		{
+			Prog:  triaged,
+			Flags: ProgFromCorpus | ProgMinimized | ProgSmashed,
+			Triaged: &TriagedInput{
+				Call:   1,
+				Signal: signal.FromRaw([]uint64{1, 2, 3}, 0),
+				Cover:  []uint64{10, 20},
+			},
+		},
+		{
+			Prog:  pending,
+			Flags: ProgFromCorpus | ProgMinimized,
+		},
+	})
+
+	// The triaged candidate must go directly to the corpus.
+	items := fuzzer.Config.Corpus.Items()
+	assert.Len(t, items, 1)
+	assert.Equal(t, triaged.Serialize(), items[0].Prog.Serialize())
+	assert.Equal(t, 1, items[0].Call)
+	assert.Equal(t, 3, fuzzer.Cover.maxSignal.Len())
+	assert.Equal(t, 1, fuzzer.statCandidates.Val())
+
+	state := fuzzer.State()
+	assert.Equal(t, []StateCandidate{{
+		Prog:  string(pending.Serialize()),
+		Flags: ProgFromCorpus | ProgMinimized,
+	}}, state.Candidates)
+	assert.Empty(t, state.Jobs)
+
+	state.Jobs = append(state.Jobs, StateJob{
+		Type:     stateJobSmash,
+		Prog:     string(triaged.Serialize()),
+		Call:     1,
+		Progress: 10,
+	}, StateJob{
+		Type: "unknown",
+		Prog: string(triaged.Serialize()),
+	}, StateJob{
+		Type: stateJobSmash,
+		Prog: "broken program",
+	})
+	resumed := newFuzzer()
+	resumed.Resume(state)
+	assert.Equal(t, 1, resumed.statCandidates.Val())
+	assert.Eventually(t, func() bool {
+		return len(resumed.RunningJobs()) == 1
+	}, time.Minute, 10*time.Millisecond)
+	resumedState := resumed.State()
+	assert.Equal(t, state.Candidates, resumedState.Candidates)
+	assert.Len(t, resumedState.Jobs, 1)
+	assert.Equal(t, stateJobSmash, resumedState.Jobs[0].Type)
+	assert.Equal(t, 1, resumedState.Jobs[0].Call)
+	assert.GreaterOrEqual(t, resumedState.Jobs[0].Progress, 10)
+}
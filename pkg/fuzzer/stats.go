@@ -15,6 +15,7 @@ type Stats struct {
 	Syscalls []SyscallStats
 
 	statCandidates          *stat.Val
+	statTriageSkipped       *stat.Val
 	statNewInputs           *stat.Val
 	statJobs                *stat.Val
 	statJobsTriage          *stat.Val
@@ -47,6 +48,8 @@ func newStats(target *prog.Target) Stats {
 		Syscalls: make([]SyscallStats, len(target.Syscalls)+1),
 		statCandidates: stat.New("candidates", "Number of candidate programs in triage queue",
 			stat.Console, stat.Graph("corpus")),
+		statTriageSkipped: stat.New("skipped triage", "Corpus programs added with the signal saved before restart",
+			stat.NoGraph),
 		statNewInputs: stat.New("new inputs", "Potential untriaged corpus candidates",
 			stat.Graph("corpus")),
 		statJobs: stat.New("fuzzer jobs", "Total running fuzzer jobs", stat.NoGraph),
@@ -4,14 +4,19 @@
 package instance
 
 import (
+	"context"
 	"fmt"
+	"net"
 	"os"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/flatrpc"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/rpcserver"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 	"github.com/google/syzkaller/vm"
@@ -103,7 +108,7 @@ func CreateExecProgInstance(vmPool *vm.Pool, vmIndex int, mgrCfg *mgrconfig.Conf
 }
 
 func (inst *ExecProgInstance) runCommand(command string, duration time.Duration,
-	exitCondition vm.ExitCondition) (*RunResult, error) {
+	exitCondition vm.ExitCondition, extraOpts ...any) (*RunResult, error) {
 	start := time.Now()
 
 	var prefixOutput []byte
@@ -119,7 +124,7 @@ func (inst *ExecProgInstance) runCommand(command string, duration time.Duration,
 		command = inst.StraceBin + filterCalls + ` -s 100 -x -f ` + command
 		prefixOutput = []byte(fmt.Sprintf("%s\n\n<...>\n", command))
 	}
-	opts := []any{exitCondition}
+	opts := append([]any{exitCondition}, extraOpts...)
 	if inst.BeforeContextLen != 0 {
 		opts = append(opts, vm.OutputSize(inst.BeforeContextLen))
 	}
@@ -130,8 +135,10 @@ func (inst *ExecProgInstance) runCommand(command string, duration time.Duration,
 	if rep == nil {
 		inst.Logf(2, "program did not crash")
 	} else {
-		if err := inst.reporter.Symbolize(rep); err != nil {
-			inst.Logf(0, "failed to symbolize report: %v", err)
+		for _, rep1 := range append([]*report.Report{rep}, rep.Secondary...) {
+			if err := inst.reporter.Symbolize(rep1); err != nil {
+				inst.Logf(0, "failed to symbolize report: %v", err)
+			}
 		}
 		inst.Logf(2, "program crashed: %v", rep.Title)
 	}
@@ -154,6 +161,7 @@ type ExecParams struct {
 	// Only one of these will be used, depending on the function.
 	CProg   *prog.Prog
 	SyzProg []byte
+	Journal string // executor session journal file (see rpcserver.Replay)
 
 	Opts     csource.Options
 	Duration time.Duration
@@ -207,6 +215,52 @@ func (inst *ExecProgInstance) RunSyzProg(params ExecParams) (*RunResult, error)
 	return inst.RunSyzProgFile(progFile, params.Duration, params.Opts, params.ExitConditions)
 }
 
+// RunJournal replays the executor session journal in the VM.
+// The VM runs for params.Duration unless the replay fails.
+func (inst *ExecProgInstance) RunJournal(params ExecParams) (*RunResult, error) {
+	host, _, err := net.SplitHostPort(inst.mgrCfg.RPC)
+	if err != nil {
+		return nil, fmt.Errorf("bad manager rpc address: %w", err)
+	}
+	serv, err := flatrpc.ListenSecure(net.JoinHostPort(host, "0"), []byte(inst.mgrCfg.RPCKey))
+	if err != nil {
+		return nil, err
+	}
+	defer serv.Close()
+	fwdAddr, err := inst.VMInstance.Forward(serv.Addr.Port)
+	if err != nil {
+		return nil, &TestError{Title: fmt.Sprintf("failed to setup port forwarding: %v", err)}
+	}
+	vmHost, vmPort, err := net.SplitHostPort(fwdAddr)
+	if err != nil {
+		return nil, fmt.Errorf("bad forwarded address %q: %w", fwdAddr, err)
+	}
+	command, err := ExecutorRunnerCmd(inst.mgrCfg, inst.VMInstance, inst.executorBin, vmHost, vmPort)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var replayed atomic.Bool
+	go serv.Serve(ctx, func(ctx context.Context, conn *flatrpc.Conn) error {
+		if replayed.Swap(true) {
+			// The executor has restarted, there is nothing more to replay.
+			return nil
+		}
+		inst.Logf(2, "replaying journal %v", params.Journal)
+		if err := rpcserver.Replay(ctx, conn, params.Journal); err != nil && ctx.Err() == nil {
+			inst.Logf(0, "journal replay failed: %v", err)
+			cancel()
+			return nil
+		}
+		inst.Logf(2, "journal replay finished")
+		// Keep the connection open until the VM stops, otherwise the executor will fail.
+		<-ctx.Done()
+		return nil
+	})
+	return inst.runCommand(command, params.Duration, SyzExitConditions, vm.StopContext(ctx))
+}
+
 func (inst *ExecProgInstance) Close() {
 	inst.VMInstance.Close()
 }
@@ -0,0 +1,53 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package instance
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/vm"
+)
+
+// ExecutorRunnerCmd returns the command that starts syz-executor runner in the VM.
+// If the RPC key is configured, it's copied to the VM and passed to the runner as a file,
+// so that it does not appear in the logs.
+func ExecutorRunnerCmd(cfg *mgrconfig.Config, inst *vm.Instance, executorBin, host, port string) (string, error) {
+	cmd := fmt.Sprintf("%v runner %v %v %v", executorBin, inst.Index(), host, port)
+	if cfg.RPCKey == "" {
+		return cmd, nil
+	}
+	keyFile, err := writeRPCKey(cfg)
+	if err != nil {
+		return "", err
+	}
+	vmKeyFile, err := inst.Copy(keyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy rpc key: %w", err)
+	}
+	return cmd + " " + vmKeyFile, nil
+}
+
+func writeRPCKey(cfg *mgrconfig.Config) (string, error) {
+	file := filepath.Join(cfg.Workdir, "rpc.key")
+	// The file may be concurrently copied to other VMs, so replace it atomically.
+	tmp, err := os.CreateTemp(cfg.Workdir, "rpc.key.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(cfg.RPCKey)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write rpc key: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return "", fmt.Errorf("failed to write rpc key: %w", err)
+	}
+	return file, nil
+}
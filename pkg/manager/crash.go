@@ -4,6 +4,7 @@
 package manager
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -17,6 +18,9 @@ import (
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/pkg/repro"
 	"github.com/google/syzkaller/prog"
 )
 
@@ -30,6 +34,10 @@ type CrashStore struct {
 const reproFileName = "repro.prog"
 const cReproFileName = "repro.cprog"
 const straceFileName = "strace.log"
+const reliabilityFileName = "repro.reliability"
+
+// Stack trace frames of the first crash of the kind, one per line.
+const stackFileName = "stack"
 
 const MaxReproAttempts = 3
 
@@ -57,6 +65,10 @@ func (cs *CrashStore) SaveCrash(crash *Crash) (bool, error) {
 	if err != nil {
 		return false, fmt.Errorf("failed to write crash: %w", err)
 	}
+	stackFile := filepath.Join(dir, stackFileName)
+	if len(crash.Report.Frames) != 0 && !osutil.IsExist(stackFile) {
+		osutil.WriteFile(stackFile, serializeStack(crash.Report.Frames))
+	}
 
 	// Save up to cs.cfg.MaxCrashLogs reports, overwrite the oldest once we've reached that number.
 	// Newer reports are generally more useful. Overwriting is also needed
@@ -88,11 +100,71 @@ func (cs *CrashStore) SaveCrash(crash *Crash) (bool, error) {
 	writeOrRemove("log", crash.Output)
 	writeOrRemove("tag", []byte(cs.Tag))
 	writeOrRemove("report", crash.Report.Report)
+	writeOrRemove(secondaryPrefix, secondaryReports(crash.Report))
+	writeOrRemove(stackFileName, serializeStack(crash.Report.Frames))
 	writeOrRemove("machineInfo", crash.MachineInfo)
+	var console []byte
+	if crash.ConsoleOffset != 0 {
+		console = []byte(fmt.Sprintf("%v %v\n", crash.InstanceIndex, crash.ConsoleOffset))
+	}
+	writeOrRemove(consolePrefix, console)
+	// The dump of the overwritten crash does not belong to the new crash.
+	dumpFile := filepath.Join(dir, fmt.Sprintf("%v%v", memoryDumpPrefix, oldestI))
+	os.Remove(dumpFile)
+	if crash.MemoryDump != "" && !cs.hasMemoryDump(dir) {
+		// Dumps are large, so keep only one.
+		if err := os.Rename(crash.MemoryDump, dumpFile); err != nil {
+			return first, fmt.Errorf("failed to save memory dump: %w", err)
+		}
+	}
+	journal := filepath.Join(dir, fmt.Sprintf("%v%v", journalPrefix, oldestI))
+	if crash.Journal != "" {
+		// The slot may be overwritten by a newer crash before the reproduction starts,
+		// so the reproduction keeps using crash.Journal (see Crash.RemoveJournal).
+		if err := osutil.CopyFile(crash.Journal, journal); err != nil {
+			return first, fmt.Errorf("failed to save journal: %w", err)
+		}
+	} else {
+		os.Remove(journal)
+	}
 
 	return first, nil
 }
 
+const (
+	memoryDumpPrefix = "vmcore"
+	journalPrefix    = "journal"
+	// Contains VM index and offset in its console log.
+	consolePrefix = "console"
+	// Reports that followed the main one (see report.Report.Secondary).
+	secondaryPrefix = "secondary"
+)
+
+func secondaryReports(rep *report.Report) []byte {
+	var buf bytes.Buffer
+	for _, rep1 := range rep.Secondary {
+		fmt.Fprintf(&buf, "%v\n\n%s\n\n", rep1.Title, rep1.Report)
+	}
+	return buf.Bytes()
+}
+
+func serializeStack(frames []string) []byte {
+	if len(frames) == 0 {
+		return nil
+	}
+	return []byte(strings.Join(frames, "\n") + "\n")
+}
+
+func (cs *CrashStore) hasMemoryDump(dir string) bool {
+	files, _ := osutil.ListDir(dir)
+	for _, f := range files {
+		if strings.HasPrefix(f, memoryDumpPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
 func (cs *CrashStore) HasRepro(title string) bool {
 	return osutil.IsExist(filepath.Join(cs.path(title), reproFileName))
 }
@@ -126,10 +198,11 @@ func (cs *CrashStore) SaveFailedRepro(title string, log []byte) error {
 func (cs *CrashStore) SaveRepro(res *ReproResult, progText, cProgText []byte) error {
 	repro := res.Repro
 	rep := repro.Report
-	dir := cs.path(rep.Title)
+	title := cs.StackTitle(rep.Title, rep.Frames)
+	dir := cs.path(title)
 	osutil.MkdirAll(dir)
 
-	err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(rep.Title+"\n"))
+	err := osutil.WriteFile(filepath.Join(dir, "description"), []byte(title+"\n"))
 	if err != nil {
 		return fmt.Errorf("failed to write crash: %w", err)
 	}
@@ -147,6 +220,9 @@ func (cs *CrashStore) SaveRepro(res *ReproResult, progText, cProgText []byte) er
 	if len(cProgText) > 0 {
 		osutil.WriteFile(filepath.Join(dir, cReproFileName), cProgText)
 	}
+	if reliability := reproReliability(repro); len(reliability) > 0 {
+		osutil.WriteFile(filepath.Join(dir, reliabilityFileName), reliability)
+	}
 	var assetErr error
 	repro.Prog.ForEachAsset(func(name string, typ prog.AssetType, r io.Reader, c *prog.Call) {
 		fileName := filepath.Join(dir, name+".gz")
@@ -173,12 +249,24 @@ func (cs *CrashStore) SaveRepro(res *ReproResult, progText, cProgText []byte) er
 	return nil
 }
 
+func reproReliability(res *repro.Result) []byte {
+	buf := new(bytes.Buffer)
+	if res.SyzReliability != nil {
+		fmt.Fprintf(buf, "syz reproducer: %v\n", res.SyzReliability)
+	}
+	if res.CReliability != nil {
+		fmt.Fprintf(buf, "C reproducer: %v\n", res.CReliability)
+	}
+	return buf.Bytes()
+}
+
 type BugReport struct {
-	Title  string
-	Tag    string
-	Prog   []byte
-	CProg  []byte
-	Report []byte
+	Title       string
+	Tag         string
+	Prog        []byte
+	CProg       []byte
+	Report      []byte
+	Reliability []byte
 }
 
 func (cs *CrashStore) Report(id string) (*BugReport, error) {
@@ -195,6 +283,7 @@ func (cs *CrashStore) Report(id string) (*BugReport, error) {
 	ret.Prog, _ = os.ReadFile(filepath.Join(dir, reproFileName))
 	ret.CProg, _ = os.ReadFile(filepath.Join(dir, cReproFileName))
 	ret.Report, _ = os.ReadFile(filepath.Join(dir, "repro.report"))
+	ret.Reliability, _ = os.ReadFile(filepath.Join(dir, reliabilityFileName))
 	return ret, nil
 }
 
@@ -203,9 +292,18 @@ type CrashInfo struct {
 	Log   string // filename relative to the workdir
 
 	// These fields are only set if full=true.
-	Tag    string
-	Report string // filename relative to workdir
-	Time   time.Time
+	Tag        string
+	Report     string // filename relative to workdir
+	Secondary  string // filename relative to workdir
+	MemoryDump string // filename relative to workdir
+	Journal    string // filename relative to workdir
+	// Position of the crash in the console log (see pkg/consolelog), if any.
+	ConsoleVM     int
+	ConsoleOffset int64
+	// The stack trace is not similar to the stack of the first crash of the bug,
+	// i.e. it's likely a different bug with the same title (see CrashStore.StackTitle).
+	OtherStack bool
+	Time       time.Time
 }
 
 type BugInfo struct {
@@ -218,6 +316,7 @@ type BugInfo struct {
 	StraceFile    string // relative to the workdir
 	ReproAttempts int
 	Crashes       []*CrashInfo
+	Frames        []string // only set if full=true
 }
 
 func (cs *CrashStore) BugInfo(id string, full bool) (*BugInfo, error) {
@@ -261,6 +360,7 @@ func (cs *CrashStore) BugInfo(id string, full bool) (*BugInfo, error) {
 	if !full {
 		return ret, nil
 	}
+	ret.Frames = cs.stack(id)
 	for _, crash := range ret.Crashes {
 		if stat, err := os.Stat(filepath.Join(cs.BaseDir, crash.Log)); err == nil {
 			crash.Time = stat.ModTime()
@@ -271,6 +371,22 @@ func (cs *CrashStore) BugInfo(id string, full bool) (*BugInfo, error) {
 		if osutil.IsExist(filepath.Join(cs.BaseDir, reportFile)) {
 			crash.Report = reportFile
 		}
+		secondaryFile := filepath.Join("crashes", id, fmt.Sprintf("%v%d", secondaryPrefix, crash.Index))
+		if osutil.IsExist(filepath.Join(cs.BaseDir, secondaryFile)) {
+			crash.Secondary = secondaryFile
+		}
+		dumpFile := filepath.Join("crashes", id, fmt.Sprintf("%v%d", memoryDumpPrefix, crash.Index))
+		if osutil.IsExist(filepath.Join(cs.BaseDir, dumpFile)) {
+			crash.MemoryDump = dumpFile
+		}
+		journalFile := filepath.Join("crashes", id, fmt.Sprintf("%v%d", journalPrefix, crash.Index))
+		if osutil.IsExist(filepath.Join(cs.BaseDir, journalFile)) {
+			crash.Journal = journalFile
+		}
+		console, _ := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%v%d", consolePrefix, crash.Index)))
+		fmt.Sscanf(string(console), "%d %d", &crash.ConsoleVM, &crash.ConsoleOffset)
+		stack, _ := os.ReadFile(filepath.Join(dir, fmt.Sprintf("%v%d", stackFileName, crash.Index)))
+		crash.OtherStack = isOtherStack(ret.Frames, strings.Fields(string(stack)))
 	}
 	sort.Slice(ret.Crashes, func(i, j int) bool {
 		return ret.Crashes[i].Time.After(ret.Crashes[j].Time)
@@ -308,6 +424,95 @@ func (cs *CrashStore) BugList() ([]*BugInfo, error) {
 	return ret, nil
 }
 
+// SimilarBug is a bug with a stack trace similar to the one of the given bug.
+type SimilarBug struct {
+	ID     string
+	Title  string
+	Score  float64 // see crash.StackSimilarity
+	Frames []string
+}
+
+// SimilarBugs returns bugs that have stack traces similar to the bug id,
+// the most similar first. Such bugs are likely duplicates.
+func (cs *CrashStore) SimilarBugs(id string) ([]*SimilarBug, error) {
+	frames := cs.stack(id)
+	if len(frames) == 0 {
+		return nil, nil
+	}
+	dirs, err := osutil.ListDir(filepath.Join(cs.BaseDir, "crashes"))
+	if err != nil {
+		return nil, err
+	}
+	var ret []*SimilarBug
+	for _, dir := range dirs {
+		if dir == id {
+			continue
+		}
+		other := cs.stack(dir)
+		score := crash.StackSimilarity(frames, other)
+		if score < crash.MinStackSimilarity {
+			continue
+		}
+		desc, err := os.ReadFile(filepath.Join(cs.BaseDir, "crashes", dir, "description"))
+		if err != nil {
+			continue
+		}
+		ret = append(ret, &SimilarBug{
+			ID:     dir,
+			Title:  strings.TrimSpace(string(desc)),
+			Score:  score,
+			Frames: other,
+		})
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Score != ret[j].Score {
+			return ret[i].Score > ret[j].Score
+		}
+		return ret[i].Title < ret[j].Title
+	})
+	return ret, nil
+}
+
+// Crashes with the same title, but with dissimilar stack traces are likely different bugs.
+// Such crashes are saved as separate bugs with a " (stack N)" title suffix,
+// up to maxStackVariants bugs per title. The rest are saved to the original bug
+// and are marked with CrashInfo.OtherStack.
+const maxStackVariants = 4
+
+// StackTitle returns the title of the bug the crash with the given title
+// and stack trace frames should be saved to: the first of the title variants
+// that has no crashes yet, or has a similar stack trace.
+func (cs *CrashStore) StackTitle(title string, frames []string) string {
+	if len(frames) == 0 {
+		return title
+	}
+	for i := 1; i <= maxStackVariants; i++ {
+		variant := title
+		if i > 1 {
+			variant = fmt.Sprintf("%v (stack %v)", title, i)
+		}
+		id := crashHash(variant)
+		if !osutil.IsExist(filepath.Join(cs.BaseDir, "crashes", id, "description")) ||
+			!isOtherStack(cs.stack(id), frames) {
+			return variant
+		}
+	}
+	return title
+}
+
+func isOtherStack(bugFrames, frames []string) bool {
+	return len(bugFrames) != 0 && len(frames) != 0 &&
+		crash.StackSimilarity(bugFrames, frames) < crash.MinStackSimilarity
+}
+
+func (cs *CrashStore) stack(id string) []string {
+	data, err := os.ReadFile(filepath.Join(cs.BaseDir, "crashes", id, stackFileName))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
 func crashHash(title string) string {
 	sig := hash.Hash([]byte(title))
 	return sig.String()
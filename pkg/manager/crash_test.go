@@ -4,9 +4,15 @@
 package manager
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/report/crash"
 	"github.com/google/syzkaller/pkg/repro"
 	"github.com/google/syzkaller/prog"
 	"github.com/stretchr/testify/assert"
@@ -100,7 +106,9 @@ func TestCrashRepro(t *testing.T) {
 				Title:  "Some title",
 				Report: []byte("Some report"),
 			},
-			Prog: &prog.Prog{},
+			Prog:           &prog.Prog{},
+			SyzReliability: &repro.Reliability{Runs: 5, Crashes: 2, TimeToCrash: 90 * time.Second},
+			CReliability:   &repro.Reliability{Runs: 5},
 		},
 	}, []byte("prog text"), []byte("c prog text"))
 	assert.NoError(t, err)
@@ -112,4 +120,269 @@ func TestCrashRepro(t *testing.T) {
 	assert.Equal(t, []byte("prog text"), report.Prog)
 	assert.Equal(t, []byte("c prog text"), report.CProg)
 	assert.Equal(t, []byte("Some report"), report.Report)
+	assert.Equal(t, "syz reproducer: 2/5 runs, 1m30s to crash\nC reproducer: 0/5 runs\n",
+		string(report.Reliability))
+}
+
+func TestCrashMemoryDump(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	dumps := t.TempDir()
+	for i := 0; i < 3; i++ {
+		dump := filepath.Join(dumps, fmt.Sprint(i))
+		assert.NoError(t, osutil.WriteFile(dump, []byte("dump")))
+		_, err := crashStore.SaveCrash(&Crash{
+			MemoryDump: dump,
+			Report: &report.Report{
+				Title:  "Title A",
+				Output: []byte("ABCD"),
+			},
+		})
+		assert.NoError(t, err)
+		// Only the first dump is moved to the crash dir.
+		assert.Equal(t, i != 0, osutil.IsExist(dump))
+	}
+	info, err := crashStore.BugInfo(crashHash("Title A"), true)
+	assert.NoError(t, err)
+	var saved []string
+	for _, crash := range info.Crashes {
+		if crash.MemoryDump != "" {
+			saved = append(saved, crash.MemoryDump)
+		}
+	}
+	assert.Equal(t, []string{filepath.Join("crashes", crashHash("Title A"), "vmcore0")}, saved)
+}
+
+func TestCrashMemoryDumpOverwrite(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 2,
+	}
+	dir := filepath.Join(crashStore.BaseDir, "crashes", crashHash("Title A"))
+	dumps := t.TempDir()
+	saveCrash := func(i int, withDump bool) {
+		crash := &Crash{
+			Report: &report.Report{
+				Title:  "Title A",
+				Output: []byte("ABCD"),
+			},
+		}
+		if withDump {
+			crash.MemoryDump = filepath.Join(dumps, fmt.Sprint(i))
+			assert.NoError(t, osutil.WriteFile(crash.MemoryDump, []byte(fmt.Sprintf("dump%v", i))))
+		}
+		_, err := crashStore.SaveCrash(crash)
+		assert.NoError(t, err)
+	}
+	// Makes the log in the slot the newest one, so that the other slot is overwritten next.
+	touch := func(slot int, age time.Duration) {
+		file := filepath.Join(dir, fmt.Sprintf("log%v", slot))
+		assert.NoError(t, os.Chtimes(file, time.Now(), time.Now().Add(age)))
+	}
+	saveCrash(0, true)
+	assert.True(t, osutil.IsExist(filepath.Join(dir, "vmcore0")))
+	saveCrash(1, false)
+	touch(1, time.Hour)
+	// The crash in slot 0 is overwritten by a crash without a dump: the stale dump must go away.
+	saveCrash(2, false)
+	assert.False(t, osutil.IsExist(filepath.Join(dir, "vmcore0")))
+	touch(0, 2*time.Hour)
+	// Now there's no dump, so the dump of the next crash is saved.
+	saveCrash(3, true)
+	data, err := os.ReadFile(filepath.Join(dir, "vmcore1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "dump3", string(data))
+}
+
+func TestCrashJournal(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	journals := t.TempDir()
+	for i := 0; i < 3; i++ {
+		crash := &Crash{
+			Report: &report.Report{
+				Title:  "Title A",
+				Output: []byte("ABCD"),
+			},
+		}
+		if i != 1 {
+			crash.Journal = filepath.Join(journals, fmt.Sprint(i))
+			assert.NoError(t, osutil.WriteFile(crash.Journal, []byte("journal")))
+		}
+		journal := crash.Journal
+		_, err := crashStore.SaveCrash(crash)
+		assert.NoError(t, err)
+		if i != 1 {
+			// The crash keeps its own journal for the reproduction.
+			assert.Equal(t, journal, crash.Journal)
+			assert.True(t, osutil.IsExist(crash.Journal))
+			crash.RemoveJournal()
+			assert.False(t, osutil.IsExist(journal))
+		}
+	}
+	info, err := crashStore.BugInfo(crashHash("Title A"), true)
+	assert.NoError(t, err)
+	var saved []string
+	for _, crash := range info.Crashes {
+		if crash.Journal != "" {
+			saved = append(saved, filepath.Base(crash.Journal))
+		}
+	}
+	assert.ElementsMatch(t, []string{"journal0", "journal2"}, saved)
+}
+
+func TestCrashConsoleOffset(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	for i := 0; i < 2; i++ {
+		_, err := crashStore.SaveCrash(&Crash{
+			InstanceIndex: 3,
+			ConsoleOffset: int64(i * 1000),
+			Report: &report.Report{
+				Title:  "Title A",
+				Output: []byte("ABCD"),
+			},
+		})
+		assert.NoError(t, err)
+	}
+	info, err := crashStore.BugInfo(crashHash("Title A"), true)
+	assert.NoError(t, err)
+	offsets := map[int]int64{}
+	for _, crash := range info.Crashes {
+		offsets[crash.Index] = crash.ConsoleOffset
+		if crash.ConsoleOffset != 0 {
+			assert.Equal(t, 3, crash.ConsoleVM)
+		}
+	}
+	assert.Equal(t, map[int]int64{0: 0, 1: 1000}, offsets)
+}
+
+func TestCrashSecondary(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	_, err := crashStore.SaveCrash(&Crash{Report: &report.Report{
+		Title:  "Title A",
+		Output: []byte("ABCD"),
+		Secondary: []*report.Report{
+			{Title: "Title B", Report: []byte("report B")},
+			{Title: "Title C", Report: []byte("report C")},
+		},
+	}})
+	assert.NoError(t, err)
+	_, err = crashStore.SaveCrash(&Crash{Report: &report.Report{
+		Title:  "Title A",
+		Output: []byte("ABCD"),
+	}})
+	assert.NoError(t, err)
+	info, err := crashStore.BugInfo(crashHash("Title A"), true)
+	assert.NoError(t, err)
+	var files []string
+	for _, crash := range info.Crashes {
+		if crash.Secondary != "" {
+			files = append(files, crash.Secondary)
+		}
+	}
+	assert.Len(t, files, 1)
+	data, err := os.ReadFile(filepath.Join(crashStore.BaseDir, files[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, "Title B\n\nreport B\n\nTitle C\n\nreport C\n\n", string(data))
+}
+
+func TestCrashOtherStack(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	stacks := [][]string{
+		{"kfree", "sock_release", "sock_close", "__fput"},
+		{"kfree", "sock_release", "sock_close", "__fput", "task_work_run"},
+		{"memcpy", "ext4_readdir", "iterate_dir"},
+	}
+	for _, frames := range stacks {
+		_, err := crashStore.SaveCrash(&Crash{Report: &report.Report{
+			Title:  "Title A",
+			Output: []byte("ABCD"),
+			Frames: frames,
+		}})
+		assert.NoError(t, err)
+	}
+	info, err := crashStore.BugInfo(crashHash("Title A"), true)
+	assert.NoError(t, err)
+	other := map[int]bool{}
+	for _, crash := range info.Crashes {
+		other[crash.Index] = crash.OtherStack
+	}
+	assert.Equal(t, map[int]bool{0: false, 1: false, 2: true}, other)
+}
+
+func TestCrashStackTitle(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 5,
+	}
+	save := func(frames ...string) string {
+		crash := &Crash{Report: &report.Report{
+			Title:  "Title A",
+			Output: []byte("ABCD"),
+			Frames: frames,
+		}}
+		crash.Title = crashStore.StackTitle(crash.Title, crash.Frames)
+		_, err := crashStore.SaveCrash(crash)
+		assert.NoError(t, err)
+		return crash.Title
+	}
+	assert.Equal(t, "Title A", save("kfree", "sock_release", "sock_close", "__fput"))
+	assert.Equal(t, "Title A", save("kfree", "sock_release", "sock_close", "__fput", "task_work_run"))
+	assert.Equal(t, "Title A (stack 2)", save("memcpy", "ext4_readdir", "iterate_dir"))
+	assert.Equal(t, "Title A (stack 2)", save("memcpy", "ext4_readdir", "iterate_dir"))
+	assert.Equal(t, "Title A", save())
+	assert.Equal(t, "Title A (stack 3)", save("kmalloc", "alloc_skb", "netlink_sendmsg"))
+	assert.Equal(t, "Title A (stack 4)", save("strlen", "getname_flags", "do_sys_open"))
+	// There are too many variants, the rest goes to the original bug.
+	assert.Equal(t, "Title A", save("list_del", "bio_endio", "blk_update_request"))
+}
+
+func TestSimilarBugs(t *testing.T) {
+	crashStore := &CrashStore{
+		BaseDir:      t.TempDir(),
+		MaxCrashLogs: 10,
+	}
+	save := func(title string, frames ...string) {
+		_, err := crashStore.SaveCrash(&Crash{Report: &report.Report{
+			Title:  title,
+			Output: []byte("ABCD"),
+			Frames: frames,
+		}})
+		assert.NoError(t, err)
+	}
+	save("WARNING in foo", "foo", "bar", "baz", "sys_ioctl")
+	// Only the first stack of the bug is remembered.
+	save("WARNING in foo", "qux")
+	save("WARNING in foo_helper", "foo_helper", "foo", "bar", "baz", "sys_ioctl")
+	save("WARNING in qux", "qux", "bar", "baz", "sys_ioctl")
+	save("no stack")
+
+	id := crashHash("WARNING in foo")
+	info, err := crashStore.BugInfo(id, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"foo", "bar", "baz", "sys_ioctl"}, info.Frames)
+
+	similar, err := crashStore.SimilarBugs(id)
+	assert.NoError(t, err)
+	assert.Len(t, similar, 1)
+	assert.Equal(t, "WARNING in foo_helper", similar[0].Title)
+	assert.Equal(t, crashHash("WARNING in foo_helper"), similar[0].ID)
+	assert.Greater(t, similar[0].Score, crash.MinStackSimilarity)
+
+	similar, err = crashStore.SimilarBugs(crashHash("no stack"))
+	assert.NoError(t, err)
+	assert.Empty(t, similar)
 }
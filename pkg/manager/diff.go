@@ -465,7 +465,10 @@ func (kc *kernelContext) runInstance(ctx context.Context, inst *vm.Instance,
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse manager's address")
 	}
-	cmd := fmt.Sprintf("%v runner %v %v %v", executorBin, inst.Index(), host, port)
+	cmd, err := instance.ExecutorRunnerCmd(kc.cfg, inst, executorBin, host, port)
+	if err != nil {
+		return nil, err
+	}
 	_, rep, err := inst.Run(kc.cfg.Timeouts.VMRunningTime, kc.reporter, cmd,
 		vm.ExitTimeout, vm.StopContext(ctx), vm.InjectExecuting(injectExec),
 		vm.EarlyFinishCb(func() {
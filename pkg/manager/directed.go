@@ -0,0 +1,173 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/cover"
+	"github.com/google/syzkaller/pkg/cover/backend"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+)
+
+// DirectedDistances calculates the distance from coverage PCs to the directed fuzzing targets
+// (cfg.Experimental.DirectedTargets). The result is indexed by the PCs as they are reported by KCOV.
+// PCs from which the targets are not reachable are not present in the map.
+func DirectedDistances(source *ReportGeneratorWrapper, cfg *mgrconfig.Config) (map[uint64]uint32, error) {
+	if len(cfg.Experimental.DirectedTargets) == 0 {
+		return nil, nil
+	}
+	rg, err := source.Get()
+	if err != nil {
+		return nil, err
+	}
+	if len(rg.Calls) == 0 {
+		return nil, fmt.Errorf("directed fuzzing: no call graph information for %v", cfg.SysTarget.Arch)
+	}
+	targetPCs := make(map[uint64]bool)
+	for _, target := range cfg.Experimental.DirectedTargets {
+		pcs, err := resolveDirectedTarget(rg, target)
+		if err != nil {
+			return nil, err
+		}
+		if len(pcs) == 0 {
+			return nil, fmt.Errorf("directed target %q does not match any coverage points", target)
+		}
+		for _, pc := range pcs {
+			targetPCs[pc] = true
+		}
+	}
+	distances := targetDistances(rg.Symbols, rg.Calls, targetPCs)
+	ret := make(map[uint64]uint32, len(distances))
+	for pc, dist := range distances {
+		// KCOV will point to the next instruction.
+		ret[backend.NextInstructionPC(cfg.SysTarget, cfg.Type, pc)] = dist
+	}
+	log.Logf(0, "directed fuzzing: %v target PCs, %v PCs can reach them", len(targetPCs), len(ret))
+	return ret, nil
+}
+
+// resolveDirectedTarget returns coverage points of a function ("name") or a source line ("file:line").
+func resolveDirectedTarget(rg *cover.ReportGenerator, target string) ([]uint64, error) {
+	if pos := strings.LastIndexByte(target, ':'); pos > 0 {
+		if line, err := strconv.Atoi(target[pos+1:]); err == nil {
+			return rg.LinePCs(target[:pos], line)
+		}
+	}
+	var pcs []uint64
+	for _, sym := range rg.Symbols {
+		if sym.Name == target {
+			pcs = append(pcs, sym.PCs...)
+		}
+	}
+	return pcs, nil
+}
+
+// The distance of a call relative to the distance between PCs inside of a function.
+const directedCallDistance = 10
+
+// targetDistances implements a simplified version of the AFLGo distance metric.
+// First, we calculate the function-level distance to the target functions in the call graph.
+// Then, inside of each function, the distance of a PC is the distance to the nearest target PC
+// or the nearest call site of a function that leads to the targets. Instead of the real CFG,
+// the intra-procedural distance is approximated by the number of coverage points between
+// the two PCs in the function layout.
+func targetDistances(symbols []*backend.Symbol, calls []backend.Call,
+	targetPCs map[uint64]bool) map[uint64]uint32 {
+	findSymbol := func(pc uint64) *backend.Symbol {
+		idx := sort.Search(len(symbols), func(i int) bool {
+			return pc < symbols[i].End
+		})
+		if idx == len(symbols) || pc < symbols[idx].Start {
+			return nil
+		}
+		return symbols[idx]
+	}
+	type callSite struct {
+		pc     uint64
+		callee *backend.Symbol
+	}
+	byStart := make(map[uint64]*backend.Symbol)
+	for _, sym := range symbols {
+		byStart[sym.Start] = sym
+	}
+	callSites := make(map[*backend.Symbol][]callSite)
+	callers := make(map[*backend.Symbol][]*backend.Symbol)
+	for _, call := range calls {
+		caller, callee := findSymbol(call.PC), byStart[call.Target]
+		if caller == nil || callee == nil {
+			continue
+		}
+		callSites[caller] = append(callSites[caller], callSite{call.PC, callee})
+		callers[callee] = append(callers[callee], caller)
+	}
+
+	// Function-level distances (BFS over the reverse call graph).
+	funcDist := make(map[*backend.Symbol]uint32)
+	var queue []*backend.Symbol
+	for pc := range targetPCs {
+		if sym := findSymbol(pc); sym != nil {
+			if _, ok := funcDist[sym]; !ok {
+				funcDist[sym] = 0
+				queue = append(queue, sym)
+			}
+		}
+	}
+	for len(queue) != 0 {
+		sym := queue[0]
+		queue = queue[1:]
+		for _, caller := range callers[sym] {
+			if _, ok := funcDist[caller]; !ok {
+				funcDist[caller] = funcDist[sym] + 1
+				queue = append(queue, caller)
+			}
+		}
+	}
+
+	// PC-level distances.
+	type anchor struct {
+		idx  int
+		dist uint32
+	}
+	ret := make(map[uint64]uint32)
+	for sym, dist := range funcDist {
+		var anchors []anchor
+		if dist == 0 {
+			for i, pc := range sym.PCs {
+				if targetPCs[pc] {
+					anchors = append(anchors, anchor{i, 0})
+				}
+			}
+		} else {
+			for _, site := range callSites[sym] {
+				calleeDist, ok := funcDist[site.callee]
+				if !ok {
+					continue
+				}
+				idx := sort.Search(len(sym.PCs), func(i int) bool {
+					return sym.PCs[i] >= site.pc
+				})
+				anchors = append(anchors, anchor{idx, (calleeDist + 1) * directedCallDistance})
+			}
+		}
+		for i, pc := range sym.PCs {
+			best := ^uint32(0)
+			for _, a := range anchors {
+				delta := i - a.idx
+				if delta < 0 {
+					delta = -delta
+				}
+				best = min(best, a.dist+uint32(delta))
+			}
+			if len(anchors) != 0 {
+				ret[pc] = best
+			}
+		}
+	}
+	return ret
+}
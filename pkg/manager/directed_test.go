@@ -0,0 +1,48 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"testing"
+
+	"github.com/google/syzkaller/pkg/cover/backend"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTargetDistances(t *testing.T) {
+	symbol := func(name string, start uint64, pcs ...uint64) *backend.Symbol {
+		return &backend.Symbol{
+			ObjectUnit: backend.ObjectUnit{Name: name, PCs: pcs},
+			Start:      start,
+			End:        start + 0x100,
+		}
+	}
+	symbols := []*backend.Symbol{
+		symbol("entry", 0x1000, 0x1010, 0x1020, 0x1030),
+		symbol("middle", 0x2000, 0x2010, 0x2020),
+		symbol("target", 0x3000, 0x3010, 0x3020, 0x3030),
+		symbol("unrelated", 0x4000, 0x4010),
+	}
+	calls := []backend.Call{
+		// entry calls middle after its first coverage point.
+		{PC: 0x1015, Target: 0x2000},
+		// middle calls target at the very beginning.
+		{PC: 0x2005, Target: 0x3000},
+		// target calls unrelated.
+		{PC: 0x3025, Target: 0x4000},
+		// A bogus call in the middle of a function.
+		{PC: 0x4005, Target: 0x2010},
+	}
+	distances := targetDistances(symbols, calls, map[uint64]bool{0x3020: true})
+	assert.Equal(t, map[uint64]uint32{
+		0x3010: 1,
+		0x3020: 0,
+		0x3030: 1,
+		0x2010: 10,
+		0x2020: 11,
+		0x1010: 21,
+		0x1020: 20,
+		0x1030: 21,
+	}, distances)
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/stat"
+)
+
+// ElasticPoolView is the part of vm.Dispatcher used by ElasticPool.
+type ElasticPoolView interface {
+	Total() int
+	Size() int
+	SetSize(size int)
+}
+
+type ElasticConfig struct {
+	// The pool never shrinks below MinVMs and never grows above the pool total.
+	MinVMs int
+	// Max host load average per CPU. The pool is shrunk while the load is higher (0 means no limit).
+	MaxLoad float64
+	// Returns the number of candidate programs that are not yet triaged (may be nil).
+	TriageBacklog func() int
+}
+
+const (
+	// Number of untriaged candidates per additional VM.
+	elasticCandidatesPerVM = 500
+	// The pool shrinks by one VM per period, and only if it did not need to grow for that long.
+	elasticShrinkPeriod = 5 * time.Minute
+	elasticCheckPeriod  = 30 * time.Second
+)
+
+// ElasticPool changes the number of running VMs depending on the demand:
+// the pool has MinVMs for fuzzing plus the VMs reserved for bug reproduction, and grows further
+// while there are untriaged candidates. The pool grows right away, but shrinks gradually,
+// so that short gaps in the demand don't cause VM restarts. If the host is overloaded,
+// the pool is shrunk (down to MinVMs) regardless of the demand.
+type ElasticPool struct {
+	cfg      ElasticConfig
+	pool     ElasticPoolView
+	hostLoad func() (float64, error)
+
+	mu         sync.Mutex
+	reproVMs   int
+	lastGrow   time.Time
+	lastShrink time.Time
+	lastReason string
+}
+
+func NewElasticPool(cfg ElasticConfig, pool ElasticPoolView) (*ElasticPool, error) {
+	if cfg.MinVMs < 1 || cfg.MinVMs > pool.Total() {
+		return nil, fmt.Errorf("elastic min VMs must be in [1, %v], got %v", pool.Total(), cfg.MinVMs)
+	}
+	ep := &ElasticPool{
+		cfg:      cfg,
+		pool:     pool,
+		hostLoad: hostLoadPerCPU,
+	}
+	stat.New("active VMs", "Number of VMs allowed to run by the elastic pool",
+		stat.Graph("elastic pool"), func() int {
+			return pool.Size()
+		})
+	return ep, nil
+}
+
+// SetReproVMs notes the number of VMs needed for bug reproduction.
+func (ep *ElasticPool) SetReproVMs(count int) {
+	ep.mu.Lock()
+	ep.reproVMs = count
+	ep.mu.Unlock()
+	ep.Adjust()
+}
+
+func (ep *ElasticPool) Loop(ctx context.Context) {
+	ticker := time.NewTicker(elasticCheckPeriod)
+	defer ticker.Stop()
+	for {
+		ep.Adjust()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Adjust resizes the pool according to the current demand and the host load.
+func (ep *ElasticPool) Adjust() {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	cur := ep.pool.Size()
+	size, reason := ep.targetLocked(cur, time.Now())
+	if size == cur {
+		return
+	}
+	if reason != ep.lastReason || size > cur {
+		log.Logf(0, "resizing the VM pool from %v to %v: %v", cur, size, reason)
+	}
+	ep.lastReason = reason
+	ep.pool.SetSize(size)
+}
+
+func (ep *ElasticPool) targetLocked(cur int, now time.Time) (int, string) {
+	want := ep.cfg.MinVMs + ep.reproVMs
+	reason := fmt.Sprintf("%v repro VMs", ep.reproVMs)
+	if ep.cfg.TriageBacklog != nil {
+		if backlog := ep.cfg.TriageBacklog(); backlog > 0 {
+			want += (backlog + elasticCandidatesPerVM - 1) / elasticCandidatesPerVM
+			reason += fmt.Sprintf(", %v untriaged candidates", backlog)
+		}
+	}
+	want = max(ep.cfg.MinVMs, min(want, ep.pool.Total()))
+	if ep.cfg.MaxLoad > 0 {
+		load, err := ep.hostLoad()
+		if err != nil {
+			log.Logf(1, "failed to query host load: %v", err)
+		} else if load > ep.cfg.MaxLoad {
+			// Give resources back, one VM at a time, so that the load has time to settle.
+			if now.Sub(ep.lastShrink) < elasticCheckPeriod {
+				return cur, ""
+			}
+			ep.lastShrink = now
+			return max(ep.cfg.MinVMs, min(want, cur-1)),
+				fmt.Sprintf("host load %.2f per CPU is above %.2f", load, ep.cfg.MaxLoad)
+		}
+	}
+	if want >= cur {
+		if want > cur {
+			ep.lastGrow = now
+		}
+		return want, reason
+	}
+	if now.Sub(ep.lastGrow) < elasticShrinkPeriod || now.Sub(ep.lastShrink) < elasticShrinkPeriod {
+		return cur, ""
+	}
+	ep.lastShrink = now
+	return cur - 1, reason
+}
+
+// hostLoadPerCPU returns the 1-minute load average divided by the number of CPUs.
+func hostLoadPerCPU() (float64, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return 0, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected /proc/loadavg contents: %q", data)
+	}
+	load, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, err
+	}
+	return load / float64(runtime.NumCPU()), nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestElasticPool(t *testing.T) {
+	pool := &testElasticPool{total: 10, size: 10}
+	backlog := 0
+	ep, err := NewElasticPool(ElasticConfig{
+		MinVMs:        2,
+		MaxLoad:       1.5,
+		TriageBacklog: func() int { return backlog },
+	}, pool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := 0.5
+	ep.hostLoad = func() (float64, error) { return load, nil }
+	now := time.Now()
+	adjust := func(step time.Duration) int {
+		now = now.Add(step)
+		ep.mu.Lock()
+		defer ep.mu.Unlock()
+		size, _ := ep.targetLocked(pool.size, now)
+		pool.size = size
+		return size
+	}
+
+	// Shrinks gradually once there's nothing to do.
+	assert.Equal(t, 9, adjust(time.Hour))
+	assert.Equal(t, 9, adjust(time.Minute))
+	assert.Equal(t, 8, adjust(elasticShrinkPeriod))
+
+	// Grows right away for repro and triage.
+	pool.size = 2
+	ep.reproVMs = 3
+	assert.Equal(t, 5, adjust(time.Second))
+	backlog = 1001
+	assert.Equal(t, 8, adjust(time.Second))
+	backlog = 100000
+	assert.Equal(t, 10, adjust(time.Second))
+	backlog = 0
+	assert.Equal(t, 10, adjust(time.Minute))
+	assert.Equal(t, 9, adjust(elasticShrinkPeriod))
+	assert.Equal(t, 9, adjust(time.Minute))
+
+	// Shrinks faster if the host is overloaded, but not below the min.
+	load = 2
+	assert.Equal(t, 5, adjust(time.Minute))
+	assert.Equal(t, 5, adjust(time.Second))
+	assert.Equal(t, 4, adjust(time.Minute))
+	ep.reproVMs = 0
+	for i := 0; i < 10; i++ {
+		adjust(time.Minute)
+	}
+	assert.Equal(t, 2, pool.size)
+
+	// Does not grow while the host is overloaded.
+	backlog = 100000
+	assert.Equal(t, 2, adjust(time.Minute))
+	load = 1
+	assert.Equal(t, 10, adjust(time.Minute))
+
+	_, err = NewElasticPool(ElasticConfig{MinVMs: 11}, pool)
+	assert.Error(t, err)
+}
+
+type testElasticPool struct {
+	total int
+	size  int
+}
+
+func (pool *testElasticPool) Total() int {
+	return pool.total
+}
+
+func (pool *testElasticPool) Size() int {
+	return pool.size
+}
+
+func (pool *testElasticPool) SetSize(size int) {
+	pool.size = size
+}
@@ -23,9 +23,11 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/google/syzkaller/pkg/consolelog"
 	"github.com/google/syzkaller/pkg/corpus"
 	"github.com/google/syzkaller/pkg/cover"
 	"github.com/google/syzkaller/pkg/fuzzer"
+	"github.com/google/syzkaller/pkg/fuzzer/queue"
 	"github.com/google/syzkaller/pkg/html/pages"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/mgrconfig"
@@ -61,6 +63,7 @@ type HTTPServer struct {
 	Corpus          atomic.Pointer[corpus.Corpus]
 	Fuzzer          atomic.Pointer[fuzzer.Fuzzer]
 	Cover           atomic.Pointer[CoverageInfo]
+	Tracer          atomic.Pointer[queue.Tracer]
 	EnabledSyscalls atomic.Value // map[*prog.Syscall]bool
 
 	// Internal state.
@@ -107,6 +110,8 @@ func (serv *HTTPServer) Serve(ctx context.Context) error {
 	handle("/debuginput", serv.httpDebugInput)
 	handle("/modules", serv.modulesInfo)
 	handle("/jobs", serv.httpJobs)
+	handle("/traces", serv.httpTraces)
+	handle("/queues", serv.httpQueues)
 	// Browsers like to request this, without special handler this goes to / handler.
 	handle("/favicon.ico", func(w http.ResponseWriter, r *http.Request) {})
 
@@ -173,7 +178,16 @@ func (serv *HTTPServer) httpMain(w http.ResponseWriter, r *http.Request) {
 }
 
 func (serv *HTTPServer) httpConfig(w http.ResponseWriter, r *http.Request) {
-	serv.jsonPage(w, r, "config", serv.Cfg)
+	serv.jsonPage(w, r, "config", redactConfig(serv.Cfg))
+}
+
+// redactConfig returns a copy of the config without secrets that must not be exposed over http.
+func redactConfig(cfg *mgrconfig.Config) *mgrconfig.Config {
+	ret := *cfg
+	if ret.RPCKey != "" {
+		ret.RPCKey = "<redacted>"
+	}
+	return &ret
 }
 
 func (serv *HTTPServer) jsonPage(w http.ResponseWriter, r *http.Request, title string, data any) {
@@ -301,12 +315,31 @@ func (serv *HTTPServer) httpVMs(w http.ResponseWriter, r *http.Request) {
 		if state.Reserved {
 			info.State = "[reserved] " + info.State
 		}
+		if state.Inactive {
+			info.State = "[inactive] " + info.State
+		}
+		if health := state.Health; health != nil {
+			info.Health = fmt.Sprintf("%.2f", health.Score)
+			if health.Quarantined {
+				info.State = "[quarantined] " + info.State
+				info.Health += fmt.Sprintf(", quarantined #%v", health.Quarantines)
+				if left := time.Until(health.QuarantinedUntil); left > 0 {
+					info.Health += fmt.Sprintf(" for %v", left.Round(time.Second))
+				} else {
+					info.Health += ", canary boot"
+				}
+			}
+		}
 		if state.MachineInfo != nil {
 			info.MachineInfo = fmt.Sprintf("/vm?type=machine-info&id=%d", id)
 		}
 		if state.DetailedStatus != nil {
 			info.DetailedStatus = fmt.Sprintf("/vm?type=detailed-status&id=%v", id)
 		}
+		if serv.consoleLogDir(r, id) != "" {
+			info.ConsoleLog = fmt.Sprintf("/vm?type=console&id=%v", id)
+			data.ConsoleLogs = true
+		}
 		data.VMs = append(data.VMs, info)
 	}
 	executeTemplate(w, vmsTemplate, data)
@@ -320,8 +353,12 @@ func (serv *HTTPServer) httpVM(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", ctTextPlain)
-	id, err := strconv.Atoi(r.FormValue("id"))
 	infos := pool.State()
+	if r.FormValue("type") == "grep" {
+		serv.httpConsoleGrep(w, r, len(infos))
+		return
+	}
+	id, err := strconv.Atoi(r.FormValue("id"))
 	if err != nil || id < 0 || id >= len(infos) {
 		http.Error(w, "invalid instance id", http.StatusBadRequest)
 		return
@@ -336,11 +373,182 @@ func (serv *HTTPServer) httpVM(w http.ResponseWriter, r *http.Request) {
 		if info.DetailedStatus != nil {
 			w.Write(info.DetailedStatus())
 		}
+	case "console":
+		serv.httpConsole(w, r, id)
 	default:
 		w.Write([]byte("unknown info type"))
 	}
 }
 
+const (
+	// Default time window for console log views.
+	consoleWindow = 20 * time.Minute
+	// Max number of console log lines to show.
+	consoleLines = 100000
+)
+
+// consoleLogDir returns the console log dir of the VM, or "" if console logs are not enabled.
+// Console logs are only supported for the manager's own pool, pools of the diff fuzzer
+// use different configs.
+func (serv *HTTPServer) consoleLogDir(r *http.Request, id int) string {
+	if serv.Pool == nil || r.FormValue("pool") != "" {
+		return ""
+	}
+	return vm.ConsoleLogDir(serv.Cfg, id)
+}
+
+// httpConsole shows console output of a single VM.
+// Parameters: from/to (RFC3339) and window (duration) select the time window,
+// offset (as stored with crashes) shows the window before the line at the offset and marks it,
+// grep (regexp) shows only the matching lines.
+func (serv *HTTPServer) httpConsole(w http.ResponseWriter, r *http.Request, id int) {
+	dir := serv.consoleLogDir(r, id)
+	if dir == "" {
+		http.Error(w, "console logs are not enabled", http.StatusBadRequest)
+		return
+	}
+	q, err := parseConsoleQuery(r, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	offset := int64(-1)
+	if val := r.FormValue("offset"); val != "" {
+		offset, err = strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad offset: %v", err), http.StatusBadRequest)
+			return
+		}
+		at, err := consolelog.TimeAt(dir, offset)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		window := q.To.Sub(q.From)
+		q.To = at.Add(time.Minute)
+		q.From = q.To.Add(-window)
+	}
+	lines, truncated, err := consolelog.Read(dir, q)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	buf := new(bytes.Buffer)
+	writeConsoleHeader(buf, q, truncated)
+	for _, line := range lines {
+		if offset >= 0 && line.Offset >= offset {
+			fmt.Fprintf(buf, "======== crash was detected here ========\n")
+			offset = -1
+		}
+		fmt.Fprintf(buf, "%v\n", line)
+	}
+	w.Write(buf.Bytes())
+}
+
+// httpConsoleGrep searches console output of all VMs.
+// Parameters: re (regexp), optional window (duration, default: the whole log) and id (only this VM).
+func (serv *HTTPServer) httpConsoleGrep(w http.ResponseWriter, r *http.Request, count int) {
+	if r.FormValue("re") == "" {
+		http.Error(w, "re parameter is required", http.StatusBadRequest)
+		return
+	}
+	q, err := parseConsoleQuery(r, false)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	type vmLine struct {
+		id int
+		*consolelog.Line
+	}
+	var lines []vmLine
+	truncated := false
+	for id := 0; id < count; id++ {
+		if val := r.FormValue("id"); val != "" && val != fmt.Sprint(id) {
+			continue
+		}
+		dir := serv.consoleLogDir(r, id)
+		if dir == "" {
+			http.Error(w, "console logs are not enabled", http.StatusBadRequest)
+			return
+		}
+		res, vmTruncated, err := consolelog.Read(dir, q)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		truncated = truncated || vmTruncated
+		for _, line := range res {
+			lines = append(lines, vmLine{id, line})
+		}
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return lines[i].Time.Before(lines[j].Time)
+	})
+	if len(lines) > q.Limit {
+		lines = lines[len(lines)-q.Limit:]
+		truncated = true
+	}
+	buf := new(bytes.Buffer)
+	writeConsoleHeader(buf, q, truncated)
+	for _, line := range lines {
+		fmt.Fprintf(buf, "vm%v %v\n", line.id, line.Line)
+	}
+	w.Write(buf.Bytes())
+}
+
+func parseConsoleQuery(r *http.Request, bounded bool) (consolelog.Query, error) {
+	q := consolelog.Query{Limit: consoleLines}
+	var err error
+	if val := r.FormValue("to"); val != "" {
+		if q.To, err = time.Parse(time.RFC3339, val); err != nil {
+			return q, fmt.Errorf("bad to: %w", err)
+		}
+	}
+	if val := r.FormValue("from"); val != "" {
+		if q.From, err = time.Parse(time.RFC3339, val); err != nil {
+			return q, fmt.Errorf("bad from: %w", err)
+		}
+	}
+	window := consoleWindow
+	if val := r.FormValue("window"); val != "" {
+		if window, err = time.ParseDuration(val); err != nil || window <= 0 {
+			return q, fmt.Errorf("bad window: %q", val)
+		}
+		bounded = true
+	}
+	if bounded {
+		switch {
+		case !q.From.IsZero() && q.To.IsZero():
+			q.To = q.From.Add(window)
+		case q.From.IsZero():
+			if q.To.IsZero() {
+				q.To = time.Now()
+			}
+			q.From = q.To.Add(-window)
+		}
+	}
+	for _, name := range []string{"grep", "re"} {
+		if val := r.FormValue(name); val != "" {
+			if q.Grep, err = regexp.Compile(val); err != nil {
+				return q, fmt.Errorf("bad %v: %w", name, err)
+			}
+		}
+	}
+	return q, nil
+}
+
+func writeConsoleHeader(buf *bytes.Buffer, q consolelog.Query, truncated bool) {
+	if !q.From.IsZero() {
+		fmt.Fprintf(buf, "console output from %v to %v\n",
+			q.From.UTC().Format(time.RFC3339), q.To.UTC().Format(time.RFC3339))
+	}
+	if truncated {
+		fmt.Fprintf(buf, "only the last %v lines are shown, narrow the query to see the rest\n", q.Limit)
+	}
+	buf.WriteString("\n")
+}
+
 func makeUICrashType(info *BugInfo, startTime time.Time, repros map[string]bool) UICrashType {
 	var crashes []UICrash
 	for _, crash := range info.Crashes {
@@ -378,13 +586,70 @@ func (serv *HTTPServer) httpCrash(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "failed to read crash info", http.StatusInternalServerError)
 		return
 	}
+	similar, err := serv.CrashStore.SimilarBugs(crashID)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to find similar crashes: %v", err), http.StatusInternalServerError)
+		return
+	}
+	similar = similar[:min(len(similar), maxSimilarCrashes)]
 	data := UICrashPage{
 		UIPageHeader: serv.pageHeader(r, info.Title),
 		UICrashType:  makeUICrashType(info, serv.StartTime, nil),
+		Stacks:       sideBySideStacks(info.Frames, similar),
+	}
+	for _, bug := range similar {
+		data.Similar = append(data.Similar, UISimilarCrash{
+			ID:    bug.ID,
+			Title: bug.Title,
+			Score: bug.Score,
+		})
 	}
 	executeTemplate(w, crashTemplate, data)
 }
 
+// Stacks of that many most similar crashes are shown on the crash page.
+const maxSimilarCrashes = 4
+
+// sideBySideStacks returns rows of frames for the table with the bug stack in the first column
+// and the stacks of similar bugs in the next columns.
+func sideBySideStacks(frames []string, similar []*SimilarBug) [][]UIStackFrame {
+	stacks := [][]string{frames}
+	for _, bug := range similar {
+		stacks = append(stacks, bug.Frames)
+	}
+	inStack := func(stack []string, frame string) bool {
+		for _, f := range stack {
+			if f == frame {
+				return true
+			}
+		}
+		return false
+	}
+	var rows [][]UIStackFrame
+	for i := 0; ; i++ {
+		row := make([]UIStackFrame, len(stacks))
+		empty := true
+		for col, stack := range stacks {
+			if i >= len(stack) {
+				continue
+			}
+			empty = false
+			row[col].Name = stack[i]
+			if col == 0 {
+				for _, other := range stacks[1:] {
+					row[col].Common = row[col].Common || inStack(other, stack[i])
+				}
+			} else {
+				row[col].Common = inStack(frames, stack[i])
+			}
+		}
+		if empty {
+			return rows
+		}
+		rows = append(rows, row)
+	}
+}
+
 func (serv *HTTPServer) httpCorpus(w http.ResponseWriter, r *http.Request) {
 	corpus := serv.Corpus.Load()
 	if corpus == nil {
@@ -396,16 +661,24 @@ func (serv *HTTPServer) httpCorpus(w http.ResponseWriter, r *http.Request) {
 		Call:         r.FormValue("call"),
 		RawCover:     serv.Cfg.RawCover,
 	}
+	energy := corpus.Energy()
+	var shares []float64
 	for _, inp := range corpus.Items() {
+		shares = append(shares, energy[inp.Sig])
 		if data.Call != "" && data.Call != inp.StringCall() {
 			continue
 		}
 		data.Inputs = append(data.Inputs, UIInput{
-			Sig:   inp.Sig,
-			Short: inp.Prog.String(),
-			Cover: len(inp.Cover),
+			Sig:        inp.Sig,
+			Short:      inp.Prog.String(),
+			Cover:      len(inp.Cover),
+			Energy:     100 * energy[inp.Sig],
+			Mutations:  inp.Mutations(),
+			Productive: inp.ProductiveMutations(),
 		})
 	}
+	data.Schedule = corpus.PowerSchedule().String()
+	data.Energy = energyDistribution(shares)
 	sort.Slice(data.Inputs, func(i, j int) bool {
 		a, b := data.Inputs[i], data.Inputs[j]
 		if a.Cover != b.Cover {
@@ -416,6 +689,28 @@ func (serv *HTTPServer) httpCorpus(w http.ResponseWriter, r *http.Request) {
 	executeTemplate(w, corpusTemplate, data)
 }
 
+// energyDistribution returns the share of the total energy that goes to
+// the top 1%, 10% and 50% of the corpus programs.
+func energyDistribution(shares []float64) []UIEnergyShare {
+	if len(shares) == 0 {
+		return nil
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(shares)))
+	var ret []UIEnergyShare
+	for _, top := range []int{1, 10, 50} {
+		count := max(1, len(shares)*top/100)
+		sum := 0.0
+		for _, share := range shares[:count] {
+			sum += share
+		}
+		ret = append(ret, UIEnergyShare{
+			Top:    top,
+			Energy: 100 * sum,
+		})
+	}
+	return ret
+}
+
 func (serv *HTTPServer) httpDownloadCorpus(w http.ResponseWriter, r *http.Request) {
 	corpus := filepath.Join(serv.Cfg.Workdir, "corpus.db")
 	file, err := os.Open(corpus)
@@ -650,14 +945,28 @@ func (serv *HTTPServer) httpPrio(w http.ResponseWriter, r *http.Request) {
 		progs = append(progs, inp.Prog)
 	}
 
-	prios := serv.Cfg.Target.CalculatePriorities(progs)
+	var trans *prog.CallTransitions
+	if fuzzer := serv.Fuzzer.Load(); fuzzer != nil {
+		trans = fuzzer.CallTransitions()
+	}
+	prios := serv.Cfg.Target.CalculatePrioritiesWithTransitions(progs, trans)
 
 	data := &UIPrioData{
 		UIPageHeader: serv.pageHeader(r, "syscall priorities"),
 		Call:         callName,
 	}
+	var learned []int32
+	if trans != nil {
+		data.Learned = true
+		data.LearnedEvents = trans.Events()
+		learned = trans.Prios(serv.Cfg.Target, call.ID)
+	}
 	for i, p := range prios[call.ID] {
-		data.Prios = append(data.Prios, UIPrio{serv.Cfg.Target.Syscalls[i].Name, p})
+		prio := UIPrio{Call: serv.Cfg.Target.Syscalls[i].Name, Prio: p}
+		if learned != nil {
+			prio.Learned = learned[i]
+		}
+		data.Prios = append(data.Prios, prio)
 	}
 	sort.Slice(data.Prios, func(i, j int) bool {
 		return data.Prios[i].Prio > data.Prios[j].Prio
@@ -824,6 +1133,9 @@ func (serv *HTTPServer) httpReport(w http.ResponseWriter, r *http.Request) {
 		if len(info.CProg) != 0 {
 			fmt.Fprintf(w, "C reproducer:\n%s\n\n", info.CProg)
 		}
+		if len(info.Reliability) != 0 {
+			fmt.Fprintf(w, "Reproducer reliability:\n%s\n", info.Reliability)
+		}
 	}
 }
 
@@ -939,6 +1251,122 @@ func (serv *HTTPServer) httpJobs(w http.ResponseWriter, r *http.Request) {
 	executeTemplate(w, jobListTemplate, data)
 }
 
+func (serv *HTTPServer) httpQueues(w http.ResponseWriter, r *http.Request) {
+	var fair *queue.WeightedFair
+	if fuzzer := serv.Fuzzer.Load(); fuzzer != nil {
+		fair = fuzzer.ExecShares()
+	}
+	if fair == nil {
+		http.Error(w, "exec shares are not configured", http.StatusInternalServerError)
+		return
+	}
+	if r.Method == http.MethodPost {
+		stage := r.FormValue("stage")
+		weight, err := strconv.ParseFloat(r.FormValue("weight"), 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad weight: %v", err), http.StatusBadRequest)
+			return
+		}
+		minShare, err := strconv.ParseFloat(r.FormValue("min_share"), 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad min share: %v", err), http.StatusBadRequest)
+			return
+		}
+		if err := fair.SetShare(stage, queue.Share{Weight: weight, Min: minShare}); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		log.Logf(0, "exec share of %v set to weight %v, min share %v", stage, weight, minShare)
+		http.Redirect(w, r, "/queues", http.StatusFound)
+		return
+	}
+	data := UIQueuesPage{
+		UIPageHeader: serv.pageHeader(r, "exec shares"),
+	}
+	for _, stage := range fair.Stages() {
+		data.Stages = append(data.Stages, UIQueueStage{
+			Name:     stage.Name,
+			Weight:   stage.Share.Weight,
+			MinShare: stage.Share.Min,
+			Actual:   fmt.Sprintf("%.1f%%", 100*stage.Actual),
+			Requests: stage.Requests,
+			ExecTime: stage.ExecTime.Round(time.Second),
+		})
+	}
+	executeTemplate(w, queuesTemplate, data)
+}
+
+func (serv *HTTPServer) httpTraces(w http.ResponseWriter, r *http.Request) {
+	tracer := serv.Tracer.Load()
+	if tracer == nil {
+		http.Error(w, "request tracing is not enabled", http.StatusInternalServerError)
+		return
+	}
+	summary := tracer.Summary()
+	data := UITracesPage{
+		UIPageHeader: serv.pageHeader(r, "request traces"),
+		Every:        summary.Every,
+		Finished:     summary.Finished,
+		Dropped:      summary.Dropped,
+		Phases:       uiTracePhases(summary.Phases),
+		Sources:      uiTracePhases(summary.Sources),
+	}
+	for status, count := range summary.Statuses {
+		data.Statuses = append(data.Statuses, UITraceStatus{status, count})
+	}
+	sort.Slice(data.Statuses, func(i, j int) bool {
+		return data.Statuses[i].Count > data.Statuses[j].Count
+	})
+	for _, tr := range summary.Recent {
+		var events []string
+		for _, ev := range tr.Events {
+			var attrs []string
+			for _, attr := range ev.Attrs {
+				attrs = append(attrs, fmt.Sprintf("%v=%v", attr.Key, attr.Value))
+			}
+			desc := fmt.Sprintf("+%v %v", ev.Time.Sub(tr.Start).Round(time.Microsecond), ev.Name)
+			if len(attrs) != 0 {
+				desc += fmt.Sprintf("(%v)", strings.Join(attrs, ", "))
+			}
+			events = append(events, desc)
+		}
+		status := tr.Status
+		if tr.Err != nil {
+			status += fmt.Sprintf(": %v", tr.Err)
+		}
+		data.Recent = append(data.Recent, UITrace{
+			Source:   tr.Source,
+			Status:   status,
+			Start:    tr.Start.Format(time.TimeOnly),
+			Duration: tr.Duration.Round(time.Microsecond).String(),
+			Events:   strings.Join(events, " → "),
+		})
+	}
+	executeTemplate(w, tracesTemplate, data)
+}
+
+func uiTracePhases(phases []*queue.TracePhase) []UITracePhase {
+	var total time.Duration
+	for _, phase := range phases {
+		total += phase.Total
+	}
+	var ret []UITracePhase
+	for _, phase := range phases {
+		share := 0.0
+		if total != 0 {
+			share = float64(phase.Total) / float64(total) * 100
+		}
+		ret = append(ret, UITracePhase{
+			Name:  phase.Name,
+			Count: phase.Count,
+			Avg:   phase.Avg().Round(time.Microsecond).String(),
+			Max:   phase.Max.Round(time.Microsecond).String(),
+			Share: fmt.Sprintf("%.1f%%", share),
+		})
+	}
+	return ret
+}
+
 func reproStatus(hasRepro, hasCRepro, reproducing, nonReproducible bool) string {
 	status := ""
 	if hasRepro {
@@ -981,15 +1409,18 @@ type UIDiffTable struct {
 
 type UIVMData struct {
 	UIPageHeader
-	VMs []UIVMInfo
+	VMs         []UIVMInfo
+	ConsoleLogs bool
 }
 
 type UIVMInfo struct {
 	Name           string
 	State          string
 	Since          time.Duration
+	Health         string
 	MachineInfo    string
 	DetailedStatus string
+	ConsoleLog     string
 }
 
 type UISyscallsData struct {
@@ -1000,6 +1431,20 @@ type UISyscallsData struct {
 type UICrashPage struct {
 	UIPageHeader
 	UICrashType
+	Similar []UISimilarCrash
+	// Rows of the stack of this bug and stacks of Similar side by side.
+	Stacks [][]UIStackFrame
+}
+
+type UISimilarCrash struct {
+	ID    string
+	Title string
+	Score float64
+}
+
+type UIStackFrame struct {
+	Name   string
+	Common bool // the frame is present in the stack of this bug and of the other bug(s)
 }
 
 type UICrashType struct {
@@ -1046,13 +1491,23 @@ type UICorpusPage struct {
 	UIPageHeader
 	Call     string
 	RawCover bool
+	Schedule string
+	Energy   []UIEnergyShare
 	Inputs   []UIInput
 }
 
 type UIInput struct {
-	Sig   string
-	Short string
-	Cover int
+	Sig        string
+	Short      string
+	Cover      int
+	Energy     float64 // percent of the total energy
+	Mutations  int
+	Productive int
+}
+
+type UIEnergyShare struct {
+	Top    int     // percent of the programs with the highest energy
+	Energy float64 // percent of the total energy they get
 }
 
 type UIPageHeader struct {
@@ -1105,15 +1560,64 @@ type templType struct {
 
 var templTypes []templType
 
+type UIQueuesPage struct {
+	UIPageHeader
+	Stages []UIQueueStage
+}
+
+type UIQueueStage struct {
+	Name     string
+	Weight   float64
+	MinShare float64
+	Actual   string
+	Requests int
+	ExecTime time.Duration
+}
+
+type UITracesPage struct {
+	UIPageHeader
+	Every    int
+	Finished int
+	Dropped  int
+	Phases   []UITracePhase
+	Sources  []UITracePhase
+	Statuses []UITraceStatus
+	Recent   []UITrace
+}
+
+type UITracePhase struct {
+	Name  string
+	Count int
+	Avg   string
+	Max   string
+	Share string
+}
+
+type UITraceStatus struct {
+	Status string
+	Count  int
+}
+
+type UITrace struct {
+	Source   string
+	Status   string
+	Start    string
+	Duration string
+	Events   string
+}
+
 type UIPrioData struct {
 	UIPageHeader
-	Call  string
-	Prios []UIPrio
+	Call          string
+	Learned       bool
+	LearnedEvents int
+	Prios         []UIPrio
 }
 
 type UIPrio struct {
-	Call string
-	Prio int32
+	Call    string
+	Prio    int32
+	Learned int32
 }
 
 type UIFallbackCoverData struct {
@@ -1166,6 +1670,8 @@ var (
 	fallbackCoverTemplate = createPage("fallback_cover", UIFallbackCoverData{})
 	rawCoverTemplate      = createPage("raw_cover", UIRawCoverPage{})
 	jobListTemplate       = createPage("job_list", UIJobList{})
+	tracesTemplate        = createPage("traces", UITracesPage{})
+	queuesTemplate        = createPage("queues", UIQueuesPage{})
 	textTemplate          = createPage("text", UITextPage{})
 )
 
@@ -4,11 +4,15 @@
 package manager
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestHttpTemplates(t *testing.T) {
@@ -21,3 +25,16 @@ func TestHttpTemplates(t *testing.T) {
 		})
 	}
 }
+
+func TestHttpConfigRedactsRPCKey(t *testing.T) {
+	const key = "0123456789abcdef0123456789abcdef"
+	serv := &HTTPServer{Cfg: &mgrconfig.Config{RPCKey: key}}
+	w := httptest.NewRecorder()
+	serv.httpConfig(w, httptest.NewRequest("GET", "/config?raw=1", nil))
+	assert.NotContains(t, w.Body.String(), key)
+	var cfg mgrconfig.Config
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &cfg))
+	assert.Equal(t, "<redacted>", cfg.RPCKey)
+	// The original config is not changed.
+	assert.Equal(t, key, serv.Cfg.RPCKey)
+}
@@ -7,6 +7,7 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"os"
 	"slices"
 	"sync"
 
@@ -29,6 +30,9 @@ type Crash struct {
 	FromHub       bool // this crash was created based on a repro from syz-hub
 	FromDashboard bool // .. or from dashboard
 	Manual        bool
+	MemoryDump    string // guest memory dump file, if any
+	Journal       string // executor session journal file, if any
+	ConsoleOffset int64  // offset in the console log of the VM at the time of the crash, 0 if none
 	*report.Report
 }
 
@@ -45,6 +49,14 @@ func (c *Crash) FullTitle() string {
 	panic("the crash is expected to have a report")
 }
 
+// RemoveJournal removes the executor session journal of the crash
+// once it's no longer needed for the reproduction.
+func (c *Crash) RemoveJournal() {
+	if c.Journal != "" {
+		os.Remove(c.Journal)
+	}
+}
+
 type ReproManagerView interface {
 	RunRepro(crash *Crash) *ReproResult
 	NeedRepro(crash *Crash) bool
@@ -187,6 +199,7 @@ func (r *ReproLoop) Loop(ctx context.Context) {
 		crash := r.popCrash()
 		for {
 			if crash != nil && !r.mgr.NeedRepro(crash) {
+				crash.RemoveJournal()
 				crash = nil
 				// Now we might not need that many VMs.
 				r.mu.Lock()
@@ -223,6 +236,7 @@ func (r *ReproLoop) Loop(ctx context.Context) {
 			defer wg.Done()
 
 			r.handle(crash)
+			crash.RemoveJournal()
 
 			r.mu.Lock()
 			delete(r.reproducing, title)
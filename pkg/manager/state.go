@@ -0,0 +1,220 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/fuzzer"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/signal"
+)
+
+// FuzzerState persists the fuzzer progress in the workdir, so that a restarted manager
+// does not have to start from scratch:
+//   - the signal of the triaged corpus programs (corpus.signal.db), which lets us skip
+//     the re-triage of the corpus if the kernel has not changed;
+//   - the unfinished fuzzer jobs and candidates (fuzzer_state.json).
+type FuzzerState struct {
+	mu        sync.Mutex
+	signalDB  *db.DB
+	stateFile string
+	// The saved records are only valid for the same kernel build.
+	// If we can't identify the kernel, we don't skip triage.
+	kernelID uint64
+	// Signal length of the records stored in signalDB, used to write only the changed records.
+	saved map[string]uint64
+}
+
+func LoadFuzzerState(cfg *mgrconfig.Config) (*FuzzerState, error) {
+	state := &FuzzerState{
+		stateFile: filepath.Join(cfg.Workdir, "fuzzer_state.json"),
+		kernelID:  kernelID(cfg),
+	}
+	var err error
+	state.signalDB, err = db.Open(filepath.Join(cfg.Workdir, "corpus.signal.db"), true)
+	if err != nil {
+		if state.signalDB == nil {
+			return nil, fmt.Errorf("failed to open corpus signal database: %w", err)
+		}
+		log.Errorf("read %v records from corpus signal database and got error: %v",
+			len(state.signalDB.Records), err)
+	}
+	if state.signalDB.Version != state.kernelID {
+		// The kernel has changed, the saved signal is useless.
+		for key := range state.signalDB.Records {
+			state.signalDB.Delete(key)
+		}
+		if err := state.signalDB.BumpVersion(state.kernelID); err != nil {
+			return nil, fmt.Errorf("failed to reset corpus signal database: %w", err)
+		}
+	}
+	state.saved = make(map[string]uint64, len(state.signalDB.Records))
+	for key, rec := range state.signalDB.Records {
+		state.saved[key] = rec.Seq
+	}
+	return state, nil
+}
+
+// ApplyTriaged attaches the saved triage results to the candidates that don't need
+// any further processing (minimization or smashing), so that they go directly to the corpus.
+// It returns the number of such candidates.
+func (state *FuzzerState) ApplyTriaged(candidates []fuzzer.Candidate) int {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	applied := 0
+	const corpusFlags = fuzzer.ProgFromCorpus | fuzzer.ProgMinimized | fuzzer.ProgSmashed
+	for i := range candidates {
+		candidate := &candidates[i]
+		if state.kernelID == 0 || candidate.Flags != corpusFlags {
+			continue
+		}
+		rec, ok := state.signalDB.Records[hash.String(candidate.Prog.Serialize())]
+		if !ok {
+			continue
+		}
+		triaged, err := deserializeTriaged(rec.Val)
+		if err != nil || triaged.Call >= len(candidate.Prog.Calls) {
+			continue
+		}
+		candidate.Triaged = triaged
+		applied++
+	}
+	// Switch database to the mode when it does not keep records in memory.
+	state.signalDB.DiscardData()
+	return applied
+}
+
+// LoadState returns the fuzzer state saved before the restart.
+// Candidates that are already among the known ones (e.g. corpus programs) are dropped.
+func (state *FuzzerState) LoadState(known []fuzzer.Candidate) (*fuzzer.State, error) {
+	data, err := os.ReadFile(state.stateFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return &fuzzer.State{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	ret := new(fuzzer.State)
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, fmt.Errorf("failed to parse %v: %w", state.stateFile, err)
+	}
+	knownHashes := make(map[string]bool)
+	for _, candidate := range known {
+		knownHashes[hash.String(candidate.Prog.Serialize())] = true
+	}
+	var candidates []fuzzer.StateCandidate
+	for _, candidate := range ret.Candidates {
+		if !knownHashes[hash.String([]byte(candidate.Prog))] {
+			candidates = append(candidates, candidate)
+		}
+	}
+	ret.Candidates = candidates
+	return ret, nil
+}
+
+// Save persists the signal of the corpus items and the fuzzer state.
+// Only new and changed records are appended to the database file, the database compacts
+// the file on load and once stale records make up a noticeable part of it.
+// Records of the programs that are no longer in the corpus are only deleted
+// once the corpus triage is finished, before that the corpus is incomplete.
+func (state *FuzzerState) Save(items []*corpus.Item, fuzzerState *fuzzer.State, triageDone bool) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.kernelID != 0 {
+		present := make(map[string]bool, len(items))
+		for _, item := range items {
+			present[item.Sig] = true
+			// Signal of corpus items only grows, so its length identifies the record version.
+			seq := uint64(len(item.Signal))
+			if saved, ok := state.saved[item.Sig]; ok && saved == seq {
+				continue
+			}
+			state.signalDB.Save(item.Sig, serializeTriaged(item), seq)
+			state.saved[item.Sig] = seq
+		}
+		if triageDone {
+			for key := range state.saved {
+				if !present[key] {
+					state.signalDB.Delete(key)
+					delete(state.saved, key)
+				}
+			}
+		}
+		if err := state.signalDB.Flush(); err != nil {
+			return fmt.Errorf("failed to save corpus signal database: %w", err)
+		}
+	}
+	return osutil.WriteJSON(state.stateFile, fuzzerState)
+}
+
+func serializeTriaged(item *corpus.Item) []byte {
+	data := binary.AppendVarint(nil, int64(item.Call))
+	data = append(data, item.Signal.Serialize()...)
+	data = binary.AppendUvarint(data, uint64(len(item.Cover)))
+	for _, pc := range item.Cover {
+		data = binary.AppendUvarint(data, pc)
+	}
+	return data
+}
+
+func deserializeTriaged(data []byte) (*fuzzer.TriagedInput, error) {
+	call, n := binary.Varint(data)
+	if n <= 0 {
+		return nil, fmt.Errorf("bad call")
+	}
+	ret := &fuzzer.TriagedInput{Call: int(call)}
+	var err error
+	ret.Signal, data, err = signal.Deserialize(data[n:])
+	if err != nil {
+		return nil, err
+	}
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return nil, fmt.Errorf("bad cover size")
+	}
+	data = data[n:]
+	ret.Cover = make([]uint64, count)
+	for i := range ret.Cover {
+		ret.Cover[i], n = binary.Uvarint(data)
+		if n <= 0 {
+			return nil, fmt.Errorf("bad cover")
+		}
+		data = data[n:]
+	}
+	return ret, nil
+}
+
+func kernelID(cfg *mgrconfig.Config) uint64 {
+	var files []string
+	if cfg.KernelObj != "" && cfg.SysTarget.KernelObject != "" {
+		files = append(files, filepath.Join(cfg.KernelObj, cfg.SysTarget.KernelObject))
+	}
+	if cfg.Image != "" {
+		files = append(files, cfg.Image)
+	}
+	var pieces []any
+	for _, file := range files {
+		stat, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		pieces = append(pieces, []byte(file), stat.Size(), stat.ModTime().UnixNano())
+	}
+	if len(pieces) == 0 {
+		return 0
+	}
+	sig := hash.Hash(pieces...)
+	return uint64(sig.Truncate64())
+}
@@ -0,0 +1,133 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package manager
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/corpus"
+	"github.com/google/syzkaller/pkg/fuzzer"
+	"github.com/google/syzkaller/pkg/hash"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/testutil"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuzzerState(t *testing.T) {
+	target, err := prog.GetTarget(targets.TestOS, targets.TestArch64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	cfg := &mgrconfig.Config{
+		Workdir: dir,
+		Image:   filepath.Join(dir, "image"),
+	}
+	if err := os.WriteFile(cfg.Image, []byte("kernel"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rnd := rand.New(testutil.RandSource(t))
+	ct := target.DefaultChoiceTable()
+	var items []*corpus.Item
+	var candidates []fuzzer.Candidate
+	for i := 0; i < 3; i++ {
+		p := target.Generate(rnd, 5, ct)
+		items = append(items, &corpus.Item{
+			Sig:    hash.String(p.Serialize()),
+			Call:   i,
+			Prog:   p,
+			Signal: signal.FromRaw([]uint64{uint64(i), 100}, uint8(i)),
+			Cover:  []uint64{1000, uint64(i)},
+		})
+		candidates = append(candidates, fuzzer.Candidate{
+			Prog:  p,
+			Flags: fuzzer.ProgFromCorpus | fuzzer.ProgMinimized | fuzzer.ProgSmashed,
+		})
+	}
+	// This one still needs to be minimized.
+	candidates[2].Flags &= ^fuzzer.ProgMinimized
+
+	state, err := LoadFuzzerState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, state.ApplyTriaged(candidates))
+	loaded, err := state.LoadState(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &fuzzer.State{}, loaded)
+	saved := &fuzzer.State{
+		Candidates: []fuzzer.StateCandidate{
+			{Prog: string(items[0].Prog.Serialize())},
+			{Prog: "new program"},
+		},
+		Jobs: []fuzzer.StateJob{{Type: "smash", Prog: "program", Call: 1, Progress: 2}},
+	}
+	assert.NoError(t, state.Save(items, saved, false))
+
+	state, err = LoadFuzzerState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2, state.ApplyTriaged(candidates))
+	for i, candidate := range candidates[:2] {
+		assert.Equal(t, &fuzzer.TriagedInput{
+			Call:   items[i].Call,
+			Signal: items[i].Signal,
+			Cover:  items[i].Cover,
+		}, candidate.Triaged)
+	}
+	assert.Nil(t, candidates[2].Triaged)
+	loaded, err = state.LoadState(candidates[:1])
+	assert.NoError(t, err)
+	// The first candidate is already known.
+	assert.Equal(t, saved.Candidates[1:], loaded.Candidates)
+	assert.Equal(t, saved.Jobs, loaded.Jobs)
+
+	// Saving unchanged items does not grow the database.
+	dbFile := filepath.Join(dir, "corpus.signal.db")
+	size := fileSize(t, dbFile)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, state.Save(items, saved, true))
+	}
+	assert.Equal(t, size, fileSize(t, dbFile))
+	// Stale records are compacted away.
+	for i := 0; i < 10; i++ {
+		items[0].Signal = signal.FromRaw([]uint64{0, 100, uint64(1000 + i)}, 0)
+		assert.NoError(t, state.Save(items, saved, true))
+	}
+	assert.NoError(t, state.Save(items[1:], saved, true))
+	state, err = LoadFuzzerState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, state.saved, 2)
+	assert.Less(t, fileSize(t, dbFile), size)
+
+	// The corpus signal is discarded once the kernel changes.
+	if err := os.WriteFile(cfg.Image, []byte("new kernel"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for i := range candidates {
+		candidates[i].Triaged = nil
+	}
+	state, err = LoadFuzzerState(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0, state.ApplyTriaged(candidates))
+}
+
+func fileSize(t *testing.T, file string) int64 {
+	stat, err := os.Stat(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stat.Size()
+}
@@ -18,6 +18,12 @@ type Config struct {
 	HTTP string `json:"http"`
 	// TCP address to serve RPC for fuzzer processes (optional).
 	RPC string `json:"rpc,omitempty"`
+	// Pre-shared key for the RPC connections (optional, at least 16 bytes).
+	// If set, executors must prove that they know the key, the manager must prove the same
+	// to executors, and all RPC traffic is encrypted. Use it when executors run on machines
+	// reachable by others (e.g. "isolated" or "proxyapp" VMs on a shared network).
+	// The key is copied to the VMs as a file and is not passed on the command line.
+	RPCKey string `json:"rpc_key,omitempty"`
 	// Location of a working directory for the syz-manager process. Outputs here include:
 	// - <workdir>/crashes/*: crash output files
 	// - <workdir>/corpus.db: corpus with interesting programs
@@ -140,6 +146,7 @@ type Config struct {
 	// Enables snapshotting mode. In this mode VM is snapshotted and restarted from the snapshot
 	// before executing each test program. This provides better reproducibility and avoids global
 	// accumulated state. Currently only qemu VMs and Linux support this mode.
+	// See also experimental snapshot_vms and snapshot_procs parameters.
 	Snapshot bool `json:"snapshot"`
 
 	// Use KCOV coverage (default: true).
@@ -254,6 +261,126 @@ type Experimental struct {
 	// with an empty Filter, but non-empty weight.
 	// E.g. "focus_areas": [ {"filter": {"files": ["^net"]}, "weight": 10.0}, {"weight": 1.0"} ].
 	FocusAreas []FocusArea `json:"focus_areas,omitempty"`
+
+	// Adaptively re-weight mutation operators (splice, insert call, mutate arg, etc)
+	// based on how often each of them leads to new signal (default: false).
+	AdaptiveMutation bool `json:"adaptive_mutation"`
+
+	// Enable the resource-aware crossover mutation operator that splices corpus programs
+	// at calls that produce/consume compatible resources (default: false).
+	Crossover bool `json:"crossover"`
+
+	// Periodically and on shutdown save unfinished fuzzer jobs and the signal of the triaged
+	// corpus in the workdir and resume from them after restart (default: false).
+	// If the kernel has not changed, corpus programs are not re-triaged.
+	PersistFuzzerState bool `json:"persist_fuzzer_state"`
+
+	// Power schedule that determines how often corpus programs are chosen for mutation (default: exploit).
+	// exploit: proportional to the program signal size;
+	// explore: prefer programs that were mutated less;
+	// fast: prefer programs whose mutants keep finding new signal, penalize heavily mutated ones;
+	// coe: same as fast, but heavily mutated programs that never yielded new signal are cut off.
+	PowerSchedule string `json:"power_schedule"`
+
+	// Directed fuzzing targets: kernel function names (e.g. "tcp_sendmsg") or source lines
+	// (e.g. "net/ipv4/tcp.c:1234"). If set, the fuzzer prefers to mutate programs whose coverage
+	// is closer to the targets in the kernel call graph. Requires kernel_obj.
+	DirectedTargets []string `json:"directed_targets,omitempty"`
+
+	// Trace every N-th fuzzer request through the execution pipeline (default: 0, disabled).
+	// The traces are summarized on the /traces page and exported in the OpenTelemetry
+	// JSON format into workdir/traces.
+	TraceRequests int `json:"trace_requests"`
+
+	// Additional feedback channels besides coverage (default: none).
+	// Programs that reach a new maximum of any of the metrics for a syscall in all of several
+	// re-executions are used as additional mutation seeds. The metrics are noisy, so they
+	// are not mixed into the coverage signal and such programs are not added to the corpus.
+	// Supported channels:
+	// "context_switches": the call blocked on locks/IO more times;
+	// "page_faults": the call caused more page faults.
+	// Currently the metrics are reported only on Linux.
+	Feedback []string `json:"feedback,omitempty"`
+
+	// Learn call-to-call transitions only from programs that give new signal
+	// and blend them into syscall priorities used for program generation/mutation (default: false).
+	// The learned priorities are shown on the /prio page.
+	LearnedPriorities bool `json:"learned_priorities"`
+
+	// Shares of executor time for the fuzzer stages (default: none, the stages are strictly
+	// prioritized in the order: triage candidate, candidate, triage, smash, fuzz).
+	// If set, each stage gets executor time proportional to its weight, but not less than
+	// its min_share (a fraction of the total time), while it has work to do.
+	// The stages that are not mentioned get default shares. Time is measured by the executor.
+	// The shares can be adjusted at runtime on the /queues page.
+	// E.g. "exec_shares": [{"stage": "triage", "weight": 3}, {"stage": "fuzz", "weight": 1, "min_share": 0.1}].
+	ExecShares []ExecShare `json:"exec_shares,omitempty"`
+
+	// Change the number of running VMs at runtime (default: none, all VMs run all the time).
+	// The pool has min_vms VMs for fuzzing plus the VMs needed for bug reproduction,
+	// and grows up to the VM count while there are untriaged corpus candidates.
+	// If max_load is set, the pool shrinks (down to min_vms) while the host 1-minute load average
+	// per CPU is above max_load. The pool shrinks gradually, VMs that reproduce bugs are shut down
+	// only once they finish. Can't be used together with snapshot_vms.
+	// E.g. "elastic_vms": {"min_vms": 4, "max_load": 1.5}.
+	ElasticVMs *ElasticVMs `json:"elastic_vms,omitempty"`
+
+	// Dump guest memory when a VM crashes (default: false). Only supported for qemu VMs.
+	// The first dump for each bug is kept in the crash dir as vmcore file, it can be analyzed
+	// with tools/syz-vmcore (kernel log, tasks, held locks). Dumps are as large as the VM memory.
+	// Not used if the dashboard is configured.
+	DumpGuestMemory bool `json:"dump_guest_memory"`
+
+	// Record sessions with VMs to journals (default: false). A journal contains all messages sent
+	// to the executor with timestamps, and the procs that executed the programs.
+	// The journal of a crashed VM is kept in the crash dir as journal file, and bug reproduction
+	// first replays it with the same timing and proc assignment (tools/syz-replay replays a journal manually).
+	// Journals keep only the last part of the session (up to 64MB).
+	// Not used if the dashboard is configured.
+	ExecutorJournal bool `json:"executor_journal"`
+
+	// Measure how reliably new reproducers trigger the bug and try to make them more reliable
+	// (default: false). The reproducers are rerun several times in parallel, and if they trigger
+	// the bug rarely, they are made more aggressive (repeat, more procs, longer call timeouts, longer runs).
+	// The changes are kept only if they improve the reliability. Takes up to 30 more reproducer runs.
+	StabilizeRepro bool `json:"stabilize_repro"`
+
+	// Track health of VM slots and quarantine the flaky ones (default: false).
+	// The health score drops on boot failures, infrastructure errors (e.g. ssh failures)
+	// and lost connection/no output crashes, but only if the other VMs don't have the same problems.
+	// Quarantined VMs are not booted for a period of time that doubles on every next quarantine,
+	// and need a successful canary boot to be used again. Boot errors and lost connection/no output
+	// crashes of unhealthy VMs are not reported. The health is shown on the /vms page.
+	VMQuarantine bool `json:"vm_quarantine"`
+
+	// Number of VMs that run in snapshot mode (default: 0, all VMs). Requires snapshot.
+	// If less than the VM count, the rest of the VMs fuzz in the normal mode, and the snapshot VMs
+	// execute triage, minimization, hints and smash jobs, which benefit most from reproducible execution.
+	// Bug reproduction takes the normal VMs first. Can't be used together with elastic_vms.
+	SnapshotVMs int `json:"snapshot_vms"`
+
+	// Number of programs executed concurrently after each snapshot restore (default: 1). Requires snapshot.
+	// If a batch of programs crashes the kernel, each program is re-executed alone to find the culprit.
+	SnapshotProcs int `json:"snapshot_procs"`
+
+	// Store console output of all VMs in workdir/console (default: false).
+	// Each line is stored with the time it was received and its source (e.g. console or ssh).
+	// Files are rotated, up to 64MB are kept for each VM. The output of any VM for any time window
+	// can be viewed and searched on the /vms page, and crashes link to the console output
+	// around the time of the crash.
+	ConsoleLogs bool `json:"console_logs"`
+}
+
+type ElasticVMs struct {
+	MinVMs  int     `json:"min_vms"`
+	MaxLoad float64 `json:"max_load"`
+}
+
+type ExecShare struct {
+	// One of: triage candidate, candidate, triage, smash, fuzz.
+	Stage    string  `json:"stage"`
+	Weight   float64 `json:"weight"`
+	MinShare float64 `json:"min_share"`
 }
 
 type FocusArea struct {
@@ -13,6 +13,7 @@ import (
 	"strings"
 
 	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/flatrpc"
 	"github.com/google/syzkaller/pkg/osutil"
 	"github.com/google/syzkaller/pkg/vminfo"
 	"github.com/google/syzkaller/prog"
@@ -174,6 +175,9 @@ func Complete(cfg *Config) error {
 	default:
 		return fmt.Errorf("config param sandbox must contain one of none/setuid/namespace/android")
 	}
+	if cfg.RPCKey != "" && len(cfg.RPCKey) < flatrpc.MinKeySize {
+		return fmt.Errorf("config param rpc_key is too short: need at least %v bytes", flatrpc.MinKeySize)
+	}
 	if err := cfg.checkSSHParams(); err != nil {
 		return err
 	}
@@ -200,11 +204,54 @@ func Complete(cfg *Config) error {
 	if err := cfg.completeFocusAreas(); err != nil {
 		return err
 	}
+	if len(cfg.Experimental.DirectedTargets) != 0 && (!cfg.Cover || cfg.KernelObj == "") {
+		return fmt.Errorf("directed_targets require cover and kernel_obj")
+	}
+	for _, channel := range cfg.Experimental.Feedback {
+		if _, ok := flatrpc.FeedbackChannels[channel]; !ok {
+			return fmt.Errorf("unknown feedback channel %q", channel)
+		}
+	}
+	if cfg.Experimental.TraceRequests < 0 {
+		return fmt.Errorf("bad config param trace_requests: %v, want a non-negative value",
+			cfg.Experimental.TraceRequests)
+	}
+	if err := cfg.checkExecShares(); err != nil {
+		return err
+	}
+	if elastic := cfg.Experimental.ElasticVMs; elastic != nil && (elastic.MinVMs < 1 || elastic.MaxLoad < 0) {
+		return fmt.Errorf("elastic_vms: min_vms must be positive and max_load non-negative")
+	}
+	if err := cfg.checkSnapshot(); err != nil {
+		return err
+	}
 	cfg.initTimeouts()
 	cfg.VMLess = cfg.Type == "none"
 	return nil
 }
 
+func (cfg *Config) checkSnapshot() error {
+	exp := &cfg.Experimental
+	if exp.SnapshotVMs < 0 {
+		return fmt.Errorf("bad config param snapshot_vms: %v, want a non-negative value", exp.SnapshotVMs)
+	}
+	if exp.SnapshotProcs < 0 || exp.SnapshotProcs > int(flatrpc.ConstMaxSnapshotProcs) {
+		return fmt.Errorf("bad config param snapshot_procs: %v, want [0, %v]",
+			exp.SnapshotProcs, flatrpc.ConstMaxSnapshotProcs)
+	}
+	if !cfg.Snapshot && (exp.SnapshotVMs != 0 || exp.SnapshotProcs != 0) {
+		return fmt.Errorf("snapshot_vms and snapshot_procs require snapshot")
+	}
+	if exp.SnapshotVMs != 0 && exp.ElasticVMs != nil {
+		// Snapshot VMs take the last VM indices, and these are shut down first when the pool shrinks.
+		return fmt.Errorf("snapshot_vms can't be used together with elastic_vms")
+	}
+	if cfg.Snapshot && exp.SnapshotProcs == 0 {
+		exp.SnapshotProcs = 1
+	}
+	return nil
+}
+
 func (cfg *Config) completeServices() error {
 	if cfg.HubClient != "" {
 		if err := checkNonEmpty(
@@ -340,6 +387,32 @@ func (cfg *Config) completeBinaries() error {
 	return nil
 }
 
+func (cfg *Config) checkExecShares() error {
+	stages := map[string]bool{}
+	totalMin := 0.0
+	for _, share := range cfg.Experimental.ExecShares {
+		switch share.Stage {
+		case "triage candidate", "candidate", "triage", "smash", "fuzz":
+		default:
+			return fmt.Errorf("unknown exec_shares stage %q, want one of: "+
+				"triage candidate/candidate/triage/smash/fuzz", share.Stage)
+		}
+		if stages[share.Stage] {
+			return fmt.Errorf("duplicate exec_shares stage %q", share.Stage)
+		}
+		stages[share.Stage] = true
+		if share.Weight < 0 || share.MinShare < 0 || share.MinShare > 1 {
+			return fmt.Errorf("exec_shares stage %q: weight must be non-negative and min_share in [0, 1]",
+				share.Stage)
+		}
+		totalMin += share.MinShare
+	}
+	if totalMin > 1 {
+		return fmt.Errorf("exec_shares: total min_share is %v, must not exceed 1", totalMin)
+	}
+	return nil
+}
+
 func (cfg *Config) completeFocusAreas() error {
 	names := map[string]bool{}
 	seenEmptyFilter := false
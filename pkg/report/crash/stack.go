@@ -0,0 +1,104 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package crash
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MinStackSimilarity is the StackSimilarity score starting from which
+// two crashes are considered to be likely the same bug.
+const MinStackSimilarity = 0.6
+
+// Only that many top frames are compared. Deeper frames are mostly generic
+// syscall/workqueue/interrupt machinery that says little about the bug.
+const maxStackFrames = 16
+
+// StackSimilarity compares two stack traces (function names from the top frame down,
+// see report.Report.Frames) and returns a score in the [0, 1] range,
+// where 1 means that the stacks are the same after noise filtering.
+func StackSimilarity(a, b []string) float64 {
+	a, b = cleanStack(a), cleanStack(b)
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	// Weighted longest common subsequence: a match of frames a[i] and b[j] contributes
+	// the average weight of the two positions. This tolerates an added/removed frame
+	// (e.g. a new inline helper), while the top frames still matter most.
+	prev := make([]float64, len(b)+1)
+	cur := make([]float64, len(b)+1)
+	for i := range a {
+		for j := range b {
+			best := max(prev[j+1], cur[j])
+			if a[i] == b[j] {
+				best = max(best, prev[j]+(frameWeight(i)+frameWeight(j))/2)
+			}
+			cur[j+1] = best
+		}
+		prev, cur = cur, prev
+	}
+	total := (stackWeight(len(a)) + stackWeight(len(b))) / 2
+	return min(prev[len(b)]/total, 1)
+}
+
+func frameWeight(pos int) float64 {
+	return 1 / float64(1+pos)
+}
+
+func stackWeight(frames int) float64 {
+	total := 0.0
+	for i := 0; i < frames; i++ {
+		total += frameWeight(i)
+	}
+	return total
+}
+
+// cleanStack normalizes frame names, drops known noise frames and
+// collapses repeated frames (e.g. recursion or several syscall wrappers).
+func cleanStack(frames []string) []string {
+	var ret []string
+	for _, frame := range frames {
+		frame = normalizeFrame(frame)
+		if frame == "" || noiseFrameRe.MatchString(frame) {
+			continue
+		}
+		if len(ret) != 0 && ret[len(ret)-1] == frame {
+			continue
+		}
+		ret = append(ret, frame)
+		if len(ret) == maxStackFrames {
+			break
+		}
+	}
+	return ret
+}
+
+func normalizeFrame(frame string) string {
+	// Compiler-generated clones: foo.isra.0, foo.constprop.0, foo.part.0, foo.cold.
+	if pos := strings.IndexByte(frame, '.'); pos > 0 {
+		frame = frame[:pos]
+	}
+	// Various per-arch syscall wrappers: __x64_sys_foo, __se_sys_foo, __do_sys_foo, etc.
+	return syscallWrapperRe.ReplaceAllString(frame, "sys_")
+}
+
+var (
+	syscallWrapperRe = regexp.MustCompile(`^(?:__(?:x64|ia32|arm64|riscv|s390x?|powerpc|se|do)_)+sys_`)
+
+	// Frames that are present in lots of unrelated crashes: reporting machinery,
+	// syscall/interrupt entry code, generic work execution contexts.
+	noiseFrameRe = regexp.MustCompile(`^(?:` + strings.Join([]string{
+		`dump_stack.*`, `show_stack`, `print_report`, `print_address_description`,
+		`__warn`, `warn_slowpath.*`, `report_bug`, `handle_bug`, `panic`,
+		`.*kasan_report.*`, `kmsan_report`, `ubsan_.*`, `__ubsan_.*`,
+		`exc_.*`, `asm_exc_.*`, `asm_sysvec_.*`, `sysvec_.*`, `do_error_trap`, `do_trap`,
+		`entry_SYSCALL.*`, `do_syscall_.*`, `x64_sys_call`, `syscall_exit_.*`,
+		`invoke_syscall`, `el0_svc.*`, `el0t_.*`, `do_el0_svc`,
+		`ret_from_fork.*`, `kthread`, `worker_thread`, `process_one_work`, `process_scheduled_works`,
+		`__do_softirq`, `handle_softirqs`, `do_softirq.*`, `irq_exit.*`, `__irq_exit_rcu`,
+		`call_timer_fn`, `__run_timers`, `run_timer_softirq`, `expire_timers`,
+		`rcu_core`, `rcu_do_batch`, `do_idle`, `cpu_startup_entry`,
+	}, "|") + `)$`)
+)
@@ -0,0 +1,46 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package crash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackSimilarity(t *testing.T) {
+	base := []string{
+		"ext4_fill_super", "get_tree_bdev", "vfs_get_tree", "path_mount",
+		"__do_sys_mount", "__se_sys_mount", "__x64_sys_mount", "do_syscall_64",
+		"entry_SYSCALL_64_after_hwframe",
+	}
+	assert.Equal(t, 1.0, StackSimilarity(base, base))
+	assert.Equal(t, 0.0, StackSimilarity(base, nil))
+	assert.Equal(t, 0.0, StackSimilarity(nil, nil))
+
+	// Noise frames and syscall wrappers don't matter.
+	assert.Equal(t, 1.0, StackSimilarity(base, []string{
+		"dump_stack_lvl", "ext4_fill_super.isra.0", "get_tree_bdev", "vfs_get_tree",
+		"path_mount", "__ia32_sys_mount", "ret_from_fork",
+	}))
+
+	// An extra/missing frame still looks similar.
+	inlined := []string{"ext4_fill_super", "vfs_get_tree", "path_mount", "__x64_sys_mount"}
+	score := StackSimilarity(base, inlined)
+	assert.Greater(t, score, MinStackSimilarity)
+	assert.Less(t, score, 1.0)
+	assert.Equal(t, score, StackSimilarity(inlined, base))
+	extra := append([]string{"ext4_check_descriptors"}, base...)
+	assert.Greater(t, StackSimilarity(base, extra), MinStackSimilarity)
+
+	// The same syscall, but a different place: not similar.
+	other := []string{"btrfs_fill_super", "btrfs_get_tree", "vfs_get_tree", "path_mount", "__x64_sys_mount"}
+	assert.Less(t, StackSimilarity(base, other), MinStackSimilarity)
+
+	// Nothing in common except for the noise.
+	assert.Equal(t, 0.0, StackSimilarity(
+		[]string{"foo", "do_syscall_64", "entry_SYSCALL_64_after_hwframe"},
+		[]string{"bar", "do_syscall_64", "entry_SYSCALL_64_after_hwframe"},
+	))
+}
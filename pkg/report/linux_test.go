@@ -18,6 +18,7 @@ import (
 	"github.com/google/syzkaller/pkg/symbolizer"
 	"github.com/google/syzkaller/pkg/vminfo"
 	"github.com/google/syzkaller/sys/targets"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestLinuxIgnores(t *testing.T) {
@@ -511,3 +512,78 @@ func testDisassembly(t *testing.T, reporter *Reporter, linuxReporter *linux, tes
 		t.Fatalf("expected:\n%s\ngot:\n%s", output, result)
 	}
 }
+
+func TestLinuxStackTrace(t *testing.T) {
+	reporter, _ := prepareLinuxReporter(t, targets.AMD64)
+	rep := reporter.Parse([]byte(`
+[   42.364412] BUG: KASAN: slab-out-of-bounds in ip6_fragment+0x11c8/0x3730
+[   42.365471] Read of size 840 at addr ffff88000969e798 by task ip6_fragment-oo/3789
+[   42.366696] CPU: 1 PID: 3789 Comm: ip6_fragment-oo Not tainted 4.11.0+ #41
+[   42.368824] Call Trace:
+[   42.369183]  dump_stack+0xb3/0x10b
+[   42.370325]  kasan_report+0x252/0x370
+[   42.371978]  memcpy+0x23/0x50
+[   42.372395]  ip6_fragment+0x11c8/0x3730
+[   42.372395]  ? ip6_forward_finish+0x160/0x160
+[   42.372395]  ip6_finish_output+0x319/0x950
+[   42.390650]  SyS_sendto+0x40/0x50
+[   42.391103]  entry_SYSCALL_64_fastpath+0x1f/0xbe
+[   42.391731] RIP: 0033:0x7fbbb711e383
+[   42.397411] Allocated by task 3789:
+[   42.397702]  save_stack_trace+0x16/0x20
+[   42.398848]  __kmalloc_node_track_caller+0xcb/0x380
+[   42.399654]  __alloc_skb+0xf8/0x580
+[   42.403273]  entry_SYSCALL_64_fastpath+0x1f/0xbe
+`))
+	if rep == nil {
+		t.Fatal("no report")
+	}
+	assert.Equal(t, []string{
+		"ip6_fragment",
+		"ip6_finish_output",
+		"sendto",
+		"entry_SYSCALL_64_fastpath",
+	}, rep.Frames)
+}
+
+func TestLinuxParseChain(t *testing.T) {
+	reporter, _ := prepareLinuxReporter(t, targets.AMD64)
+	output, err := os.ReadFile(filepath.Join("testdata", "linux", "report", "213"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rep := reporter.ParseChain(output)
+	if rep == nil {
+		t.Fatal("no report")
+	}
+	assert.Equal(t, "KASAN: slab-out-of-bounds in rds_cong_queue_updates", rep.Title)
+	// The panic_on_warn panic that follows is corrupted (it's a part of the WARNING report).
+	assert.Len(t, rep.Secondary, 1)
+	assert.Equal(t, "WARNING in rds_cong_queue_updates", rep.Secondary[0].Title)
+	assert.Greater(t, rep.Secondary[0].StartPos, rep.StartPos)
+	assert.Equal(t, []string{
+		"KASAN: slab-out-of-bounds in rds_cong_queue_updates",
+		"WARNING in rds_cong_queue_updates",
+	}, rep.Titles())
+
+	// Repeated reports are not duplicated.
+	rep = reporter.ParseChain(append(append([]byte{}, output...), output...))
+	assert.Len(t, rep.Secondary, 1)
+
+	// Generic reports are not in the chain, even if they come first.
+	var chain []byte
+	for _, file := range []string{"176", "213", "143"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "linux", "report", file))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Skip the test header.
+		chain = append(chain, data[bytes.Index(data, []byte("\n\n"))+2:]...)
+	}
+	assert.Equal(t, "INFO: task hung in do_exit", reporter.Parse(chain).Title)
+	rep = reporter.ParseChain(chain)
+	assert.Equal(t, []string{
+		"KASAN: slab-out-of-bounds in rds_cong_queue_updates",
+		"WARNING in rds_cong_queue_updates",
+	}, rep.Titles())
+}
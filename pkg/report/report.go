@@ -36,6 +36,8 @@ type Reporter struct {
 	impl         reporterImpl
 	suppressions []*regexp.Regexp
 	interests    []*regexp.Regexp
+	stack        *stackParams
+	stackSkipRe  *regexp.Regexp
 }
 
 type Report struct {
@@ -48,6 +50,10 @@ type Report struct {
 	Type crash.Type
 	// The indicative function name.
 	Frame string
+	// Frames contains function names from the first stack trace in the report (top frame first)
+	// with the frames that are always skipped during title extraction filtered out.
+	// Used for similarity-based deduplication (see crash.StackSimilarity).
+	Frames []string
 	// Report contains whole oops text.
 	Report []byte
 	// Output contains whole raw console output as passed to Reporter.Parse.
@@ -71,6 +77,10 @@ type Report struct {
 	MachineInfo []byte
 	// If the crash happened in the context of the syz-executor process, Executor will hold more info.
 	Executor *ExecutorInfo
+	// Secondary contains other distinct non-generic reports that follow this one in the output
+	// (see ParseChain) in the order of appearance. They are assumed to be consequences of this report
+	// (e.g. a KASAN report followed by a BUG).
+	Secondary []*Report
 	// reportPrefixLen is length of additional prefix lines that we added before actual crash report.
 	reportPrefixLen int
 	// symbolized is set if the report is symbolized.
@@ -86,6 +96,21 @@ func (r Report) String() string {
 	return fmt.Sprintf("crash: %v\n%s", r.Title, r.Report)
 }
 
+// Titles returns titles and alt titles of the report and all its secondary reports.
+func (r *Report) Titles() []string {
+	var titles []string
+	dedup := make(map[string]bool)
+	for _, rep := range append([]*Report{r}, r.Secondary...) {
+		for _, title := range append([]string{rep.Title}, rep.AltTitles...) {
+			if title != "" && !dedup[title] {
+				dedup[title] = true
+				titles = append(titles, title)
+			}
+		}
+	}
+	return titles
+}
+
 // unspecifiedType can be used to cancel oops.reportType from oopsFormat.reportType.
 const unspecifiedType = crash.Type("UNSPECIFIED")
 
@@ -148,6 +173,10 @@ func NewReporter(cfg *mgrconfig.Config) (*Reporter, error) {
 		impl:         rep,
 		suppressions: supps,
 		interests:    interests,
+		stack:        stackTraceParams[typ],
+	}
+	if reporter.stack != nil && len(reporter.stack.skipPatterns) != 0 {
+		reporter.stackSkipRe = regexp.MustCompile(strings.Join(reporter.stack.skipPatterns, "|"))
 	}
 	return reporter, nil
 }
@@ -156,6 +185,13 @@ const (
 	corruptedNoFrames = "extracted no frames"
 )
 
+// stackTraceParams are used to extract whole stack traces (see Report.Frames).
+var stackTraceParams = map[string]*stackParams{
+	targets.Linux:   linuxStackParams,
+	targets.Starnix: fuchsiaStackParams,
+	targets.Fuchsia: fuchsiaStackParams,
+}
+
 var ctors = map[string]fn{
 	targets.Linux:   ctorLinux,
 	targets.Starnix: ctorFuchsia,
@@ -215,6 +251,9 @@ func (reporter *Reporter) ParseFrom(output []byte, minReportPos int) *Report {
 	if match := reportFrameRe.FindStringSubmatch(rep.Title); match != nil {
 		rep.Frame = match[1]
 	}
+	if reporter.stack != nil && !rep.Corrupted {
+		rep.Frames = extractStackTrace(reporter.stack, reporter.stackSkipRe, rep.Report)
+	}
 	rep.SkipPos = len(output)
 	if pos := bytes.IndexByte(rep.Output[rep.StartPos:], '\n'); pos != -1 {
 		rep.SkipPos = rep.StartPos + pos
@@ -225,6 +264,65 @@ func (reporter *Reporter) ParseFrom(output []byte, minReportPos int) *Report {
 	return rep
 }
 
+// ParseChain is similar to Parse, but also parses all distinct reports that follow the first one
+// and attaches them to it as Secondary.
+// Generic reports that are usually consequences of any other crash (a kernel panic, a hung task, etc)
+// are dropped from the chain. The primary report is the earliest non-generic one, the rest are
+// assumed to be its consequences: the reports are not analyzed for the actual causal ordering.
+func (reporter *Reporter) ParseChain(output []byte) *Report {
+	return reporter.ParseChainFrom(output, 0)
+}
+
+// Normally there are only few consequent reports (e.g. WARNING -> BUG -> panic),
+// but a kernel can also spew the same report over and over again.
+const maxSecondaryReports = 8
+
+// ParseChainFrom is similar to ParseChain, but starts parsing from minReportPos (see ParseFrom).
+func (reporter *Reporter) ParseChainFrom(output []byte, minReportPos int) *Report {
+	var reports []*Report
+	primary := -1
+	seen := make(map[string]bool)
+	for pos := minReportPos; len(reports) <= maxSecondaryReports; {
+		next := reporter.ParseFrom(output, pos)
+		if next == nil {
+			break
+		}
+		pos = next.SkipPos
+		// Corrupted reports are usually just parts of the previous (non-generic) reports.
+		if len(reports) != 0 && (next.Corrupted && primary != -1 || seen[next.Title]) {
+			continue
+		}
+		for _, title := range next.Titles() {
+			seen[title] = true
+		}
+		if primary == -1 && !isGenericReport(next) {
+			primary = len(reports)
+		}
+		reports = append(reports, next)
+	}
+	if len(reports) == 0 {
+		return nil
+	}
+	primary = max(primary, 0)
+	rep := reports[primary]
+	for _, next := range reports[primary+1:] {
+		if !isGenericReport(next) {
+			rep.Secondary = append(rep.Secondary, next)
+		}
+	}
+	return rep
+}
+
+// isGenericReport returns whether the report is usually a consequence of any other crash
+// rather than a bug on its own, if it follows another report.
+func isGenericReport(rep *Report) bool {
+	switch rep.Type {
+	case crash.Hang, crash.LostConnection, crash.UnexpectedReboot:
+		return true
+	}
+	return strings.HasPrefix(rep.Title, "kernel panic:")
+}
+
 func (reporter *Reporter) ContainsCrash(output []byte) bool {
 	return reporter.impl.ContainsCrash(output)
 }
@@ -604,6 +702,37 @@ func extractStackFrame(params *stackParams, stack *stackFmt, output []byte) ([]s
 	return extractStackFrameImpl(params, output, skipRe, stack.parts2, extractor)
 }
 
+// Enough for deduplication, the rest are mostly generic entry frames.
+const maxStackTraceFrames = 32
+
+// extractStackTrace returns all frames of the first stack trace in the report.
+func extractStackTrace(params *stackParams, skipRe *regexp.Regexp, report []byte) []string {
+	var frames []string
+	inStack := len(params.stackStartRes) == 0
+	for _, ln := range lines(report) {
+		if matchesAny(ln, params.stackStartRes) {
+			if len(frames) != 0 {
+				break
+			}
+			inStack = true
+			continue
+		}
+		if !inStack {
+			continue
+		}
+		for _, re := range params.frameRes {
+			if match := re.FindSubmatch(ln); match != nil {
+				frames = appendStackFrame(frames, match, params, skipRe)
+				break
+			}
+		}
+		if len(frames) >= maxStackTraceFrames {
+			return frames[:maxStackTraceFrames]
+		}
+	}
+	return frames
+}
+
 func lines(text []byte) [][]byte {
 	return bytes.Split(text, []byte("\n"))
 }
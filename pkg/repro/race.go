@@ -0,0 +1,159 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/prog"
+)
+
+// Races frequently don't reproduce with any single program from the log, nor with the programs
+// concatenated: the racing calls were executed concurrently by different procs
+// (or by async threads of the same proc), while the repro executes them one after another.
+//
+// For such crashes we take the calls that appear in the crash report stacks (KCSAN prints both
+// racing accesses, KASAN prints the bad access and where the object was freed), and build programs
+// where these calls run in parallel on threads pinned to different CPUs. If one of them crashes,
+// the normal minimization drops the calls, the async flags and the pinning that are not needed.
+
+// The C reproducer has a fixed pool of 16 threads, so don't run too many calls in parallel.
+const maxRaceCalls = 8
+
+func isRaceCrash(typ crash.Type) bool {
+	return typ == crash.DataRace || typ == crash.KASAN
+}
+
+var syscallFrameRe = regexp.MustCompile(`(?:^|[^a-zA-Z0-9_])_*(?:[a-z0-9]+_)*?sys_([a-z0-9_]+)`)
+
+// reportSyscalls returns names of the syscalls present in the report stacks.
+func reportSyscalls(report []byte) map[string]bool {
+	ret := make(map[string]bool)
+	for _, match := range syscallFrameRe.FindAllSubmatch(report, -1) {
+		ret[string(match[1])] = true
+	}
+	return ret
+}
+
+func (ctx *reproContext) isRaceCall(c *prog.Call) bool {
+	return ctx.raceSyscalls[c.Meta.CallName]
+}
+
+func (ctx *reproContext) extractProgRace(entries []*prog.LogEntry) (*Result, error) {
+	if ctx.fast || !isRaceCrash(ctx.crashType) || len(ctx.raceSyscalls) == 0 {
+		return nil, nil
+	}
+	var racing []*prog.LogEntry
+	for _, ent := range lastEntries(entries) {
+		for _, c := range ent.P.Calls {
+			if ctx.isRaceCall(c) {
+				racing = append(racing, ent)
+				break
+			}
+		}
+	}
+	if len(racing) == 0 {
+		ctx.reproLogf(3, "race: no programs with the racing calls")
+		return nil, nil
+	}
+	// lastEntries returns the most recent programs first, restore the execution order.
+	slices.Reverse(racing)
+	var candidates []*prog.Prog
+	if p := ctx.raceProgDup(racing); p != nil {
+		candidates = append(candidates, p)
+	}
+	if p := ctx.raceProgAsync(racing); p != nil {
+		candidates = append(candidates, p)
+	}
+	opts := ctx.startOpts
+	opts.Threaded = true
+	// Races need time to trigger, so use the largest timeout.
+	duration := ctx.testTimeouts[len(ctx.testTimeouts)-1]
+	for _, p := range candidates {
+		ret, err := ctx.testProg(p, duration, opts, false)
+		if err != nil {
+			return nil, err
+		}
+		if ret.Crashed {
+			ctx.reproLogf(3, "race: successfully extracted reproducer")
+			return &Result{
+				Prog:     p,
+				Duration: duration,
+				Opts:     opts,
+			}, nil
+		}
+	}
+	ctx.reproLogf(3, "race: failed to extract reproducer")
+	return nil, nil
+}
+
+// raceProgDup concatenates the programs and runs an async copy of each racing call
+// in parallel with the call itself. This catches races of a call with itself
+// (e.g. two concurrent close's of the same fd).
+func (ctx *reproContext) raceProgDup(entries []*prog.LogEntry) *prog.Prog {
+	p := concatenate(entries)
+	numRace := 0
+	for _, c := range p.Calls {
+		if ctx.isRaceCall(c) {
+			numRace++
+		}
+	}
+	if numRace > maxRaceCalls {
+		ctx.reproLogf(3, "race: too many racing calls (%v)", numRace)
+		return nil
+	}
+	p, err := prog.DupRaceCalls(p, ctx.isRaceCall)
+	if err != nil {
+		ctx.reproLogf(3, "race: %v", err)
+		return nil
+	}
+	return p
+}
+
+// raceProgAsync concatenates the programs, the racing calls of each program run async,
+// so that they run in parallel with the calls of the next programs.
+// The racing calls of each program are pinned to a separate CPU, like they were
+// executed by different procs when the crash happened.
+func (ctx *reproContext) raceProgAsync(entries []*prog.LogEntry) *prog.Prog {
+	if len(entries) < 2 {
+		return nil
+	}
+	p := concatenate(entries)
+	if len(p.Calls) > prog.MaxCalls {
+		ctx.reproLogf(3, "race: concatenated prog exceeds %d calls", prog.MaxCalls)
+		return nil
+	}
+	pos, numRace := 0, 0
+	for i, ent := range entries {
+		for range ent.P.Calls {
+			c := p.Calls[pos]
+			pos++
+			if !ctx.isRaceCall(c) {
+				continue
+			}
+			c.Props.PinCPU = i + 1
+			if i+1 != len(entries) {
+				c.Props.Async = true
+				numRace++
+			}
+		}
+	}
+	if numRace > maxRaceCalls {
+		ctx.reproLogf(3, "race: too many racing calls (%v)", numRace)
+		return nil
+	}
+	return p
+}
+
+func concatenate(entries []*prog.LogEntry) *prog.Prog {
+	p := &prog.Prog{
+		Target: entries[0].P.Target,
+	}
+	for _, entry := range entries {
+		p.Calls = append(p.Calls, entry.P.Clone().Calls...)
+	}
+	return p
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/google/syzkaller/pkg/bisect/minimize"
@@ -19,6 +20,7 @@ import (
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/report"
 	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/pkg/rpcserver"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 	"github.com/google/syzkaller/vm"
@@ -33,6 +35,11 @@ type Result struct {
 	// Information about the final (non-symbolized) crash that we reproduced.
 	// Can be different from what we started reproducing.
 	Report *report.Report
+	// How often the syz and C reproducers trigger the bug (nil if not measured).
+	SyzReliability *Reliability
+	CReliability   *Reliability
+	// Kernel configs and features the reproducer needs.
+	Requirements *Requirements
 }
 
 type Stats struct {
@@ -43,6 +50,7 @@ type Stats struct {
 	SimplifyProgTime time.Duration
 	ExtractCTime     time.Duration
 	SimplifyCTime    time.Duration
+	StabilizeTime    time.Duration
 }
 
 type reproContext struct {
@@ -54,6 +62,7 @@ type reproContext struct {
 	crashType      crash.Type
 	crashStart     int
 	crashExecutor  *report.ExecutorInfo
+	raceSyscalls   map[string]bool
 	entries        []*prog.LogEntry
 	testTimeouts   []time.Duration
 	startOpts      csource.Options
@@ -62,6 +71,13 @@ type reproContext struct {
 	timeouts       targets.Timeouts
 	observedTitles map[string]bool
 	fast           bool
+	stabilizeRepro bool
+	// Protects stats.Log, report and observedTitles during parallel runs (see measure).
+	mu sync.Mutex
+	// Executor session journal of the crash and the programs executed in the session.
+	journal        string
+	journalEntries []*prog.LogEntry
+	journalLength  time.Duration
 }
 
 // execInterface describes the interfaces needed by pkg/repro.
@@ -78,10 +94,18 @@ type Environment struct {
 	// The Fast repro mode restricts the repro log bisection,
 	// it skips multiple simpifications and C repro generation.
 	Fast bool
+	// Measure how reliably the reproducer triggers the bug and try to make it more reliable
+	// (see Result.SyzReliability/CReliability). This takes up to 30 more runs of the reproducer
+	// in batches of parallel runs. Not done in the Fast mode.
+	Stabilize bool
+	// Executor session journal of the crash (optional, see rpcserver.Journal).
+	// If the replay of the session reproduces the crash, the programs are taken from the journal
+	// rather than from the crash log.
+	Journal string
 }
 
 func Run(ctx context.Context, log []byte, env Environment) (*Result, *Stats, error) {
-	return runInner(ctx, log, env.Config, env.Features, env.Reporter, env.Fast, &poolWrapper{
+	return runInner(ctx, log, env.Journal, env.Config, env.Features, env.Reporter, env.Fast, env.Stabilize, &poolWrapper{
 		cfg:      env.Config,
 		reporter: env.Reporter,
 		pool:     env.Pool,
@@ -90,20 +114,23 @@ func Run(ctx context.Context, log []byte, env Environment) (*Result, *Stats, err
 
 var ErrEmptyCrashLog = errors.New("no programs")
 
-func runInner(ctx context.Context, crashLog []byte, cfg *mgrconfig.Config, features flatrpc.Feature,
-	reporter *report.Reporter, fast bool, exec execInterface) (*Result, *Stats, error) {
+func runInner(ctx context.Context, crashLog []byte, journal string, cfg *mgrconfig.Config,
+	features flatrpc.Feature, reporter *report.Reporter, fast, stabilize bool,
+	exec execInterface) (*Result, *Stats, error) {
 	entries := cfg.Target.ParseLog(crashLog, prog.NonStrict)
-	if len(entries) == 0 {
+	if len(entries) == 0 && journal == "" {
 		return nil, nil, fmt.Errorf("log (%d bytes) parse failed: %w", len(crashLog), ErrEmptyCrashLog)
 	}
 	crashStart := len(crashLog)
 	crashTitle, crashType := "", crash.UnknownType
 	var crashExecutor *report.ExecutorInfo
+	var raceSyscalls map[string]bool
 	if rep := reporter.Parse(crashLog); rep != nil {
 		crashStart = rep.StartPos
 		crashTitle = rep.Title
 		crashType = rep.Type
 		crashExecutor = rep.Executor
+		raceSyscalls = reportSyscalls(rep.Report)
 	}
 	testTimeouts := []time.Duration{
 		max(30*time.Second, 3*cfg.Timeouts.Program), // to catch simpler crashes (i.e. no races and no hangs)
@@ -135,6 +162,7 @@ func runInner(ctx context.Context, crashLog []byte, cfg *mgrconfig.Config, featu
 		crashType:     crashType,
 		crashStart:    crashStart,
 		crashExecutor: crashExecutor,
+		raceSyscalls:  raceSyscalls,
 
 		entries:        entries,
 		testTimeouts:   testTimeouts,
@@ -143,6 +171,10 @@ func runInner(ctx context.Context, crashLog []byte, cfg *mgrconfig.Config, featu
 		timeouts:       cfg.Timeouts,
 		observedTitles: map[string]bool{},
 		fast:           fast,
+		stabilizeRepro: stabilize,
+	}
+	if journal != "" {
+		reproCtx.loadJournal(journal, cfg.Target)
 	}
 	return reproCtx.run()
 }
@@ -170,6 +202,7 @@ func (ctx *reproContext) run() (*Result, *Stats, error) {
 		ctx.reproLogf(3, "final repro crashed as (corrupted=%v):\n%s",
 			ctx.report.Corrupted, ctx.report.Report)
 		res.Report = ctx.report
+		res.Requirements = ProgRequirements(res.Prog, res.Opts, ctx.report.Type)
 	}
 	return res, ctx.stats, nil
 }
@@ -211,21 +244,28 @@ func createStartOptions(cfg *mgrconfig.Config, features flatrpc.Feature,
 }
 
 func (ctx *reproContext) repro() (*Result, error) {
-	// Cut programs that were executed after crash.
-	for i, ent := range ctx.entries {
-		if ent.Start > ctx.crashStart {
-			ctx.entries = ctx.entries[:i]
-			break
-		}
-	}
-
 	reproStart := time.Now()
 	defer func() {
 		ctx.reproLogf(3, "reproducing took %s", time.Since(reproStart))
 		ctx.stats.TotalTime = time.Since(reproStart)
 	}()
 
-	res, err := ctx.extractProg(ctx.entries)
+	entries, err := ctx.replayJournal()
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		// Cut programs that were executed after crash.
+		for i, ent := range ctx.entries {
+			if ent.Start > ctx.crashStart {
+				ctx.entries = ctx.entries[:i]
+				break
+			}
+		}
+		entries = ctx.entries
+	}
+
+	res, err := ctx.extractProg(entries)
 	if err != nil {
 		return nil, err
 	}
@@ -259,10 +299,55 @@ func (ctx *reproContext) repro() (*Result, error) {
 				return nil, err
 			}
 		}
+
+		if ctx.stabilizeRepro {
+			res, err = ctx.stabilize(res)
+			if err != nil {
+				return nil, err
+			}
+		}
 	}
 	return res, nil
 }
 
+func (ctx *reproContext) loadJournal(file string, target *prog.Target) {
+	summary, err := rpcserver.SummarizeJournal(file)
+	if err != nil {
+		ctx.reproLogf(0, "failed to load journal: %v", err)
+		return
+	}
+	ctx.journal = file
+	ctx.journalEntries = target.ParseLog(summary.Log, prog.NonStrict)
+	ctx.journalLength = summary.Duration
+}
+
+// replayJournal replays the recorded executor session with the same timing and proc assignment.
+// Only the trailing window of the session kept in the journal is replayed, up to the crash.
+// If the crash reproduces, it returns all programs executed in the replayed part of the session
+// before the crash, the console log may miss some of them.
+func (ctx *reproContext) replayJournal() ([]*prog.LogEntry, error) {
+	if ctx.journal == "" || len(ctx.journalEntries) == 0 {
+		return nil, nil
+	}
+	duration := ctx.journalLength + ctx.testTimeouts[0]
+	ctx.reproLogf(2, "replaying journal with %v programs (duration=%v)", len(ctx.journalEntries), duration)
+	ret, err := ctx.getVerdict(func() (*instance.RunResult, error) {
+		return ctx.exec.Run(ctx.ctx, instance.ExecParams{
+			Journal:  ctx.journal,
+			Duration: duration,
+		}, ctx.reproLogf)
+	}, false)
+	if err != nil {
+		return nil, err
+	}
+	if !ret.Crashed {
+		ctx.reproLogf(2, "journal replay did not reproduce the crash, falling back to the crash log")
+		return nil, nil
+	}
+	ctx.reproLogf(2, "journal replay reproduced the crash, using programs from the journal")
+	return ctx.journalEntries, nil
+}
+
 func (ctx *reproContext) extractProg(entries []*prog.LogEntry) (*Result, error) {
 	ctx.reproLogf(2, "extracting reproducer from %v programs", len(entries))
 	start := time.Now()
@@ -321,6 +406,16 @@ func (ctx *reproContext) extractProg(entries []*prog.LogEntry) (*Result, error)
 		}
 	}
 
+	// Neither a single program nor a combination of programs crash, maybe it's a race between them.
+	res, err := ctx.extractProgRace(entries)
+	if err != nil {
+		return nil, err
+	}
+	if res != nil {
+		ctx.reproLogf(3, "found race reproducer with %d syscalls", len(res.Prog.Calls))
+		return res, nil
+	}
+
 	ctx.reproLogf(2, "failed to extract reproducer")
 	return nil, nil
 }
@@ -641,18 +736,39 @@ func (ctx *reproContext) getVerdict(callback func() (rep *instance.RunResult, er
 		ctx.reproLogf(2, "not a leak crash: %v", rep.Title)
 		return verdict{false, result.Duration}, nil
 	}
+	// The crash we are after may be preceded by a different report (or followed by one),
+	// so match on all reports in the chain. Generic consequences of any crash
+	// (e.g. a kernel panic or a hung task) are not in the chain (see report.ParseChain).
+	titles := rep.Titles()
+	ctx.mu.Lock()
+	seen := true
 	if strict && len(ctx.observedTitles) > 0 {
-		if !ctx.observedTitles[rep.Title] {
-			ctx.reproLogf(2, "a never seen crash title: %v, ignore", rep.Title)
-			return verdict{false, result.Duration}, nil
-		}
+		seen = ctx.observedAny(titles)
 	} else {
-		ctx.observedTitles[rep.Title] = true
+		for _, title := range titles {
+			ctx.observedTitles[title] = true
+		}
+	}
+	if seen {
+		ctx.report = rep
+	}
+	ctx.mu.Unlock()
+	if !seen {
+		ctx.reproLogf(2, "a never seen crash title: %v, ignore", rep.Title)
+		return verdict{false, result.Duration}, nil
 	}
-	ctx.report = rep
 	return verdict{true, result.Duration}, nil
 }
 
+func (ctx *reproContext) observedAny(titles []string) bool {
+	for _, title := range titles {
+		if ctx.observedTitles[title] {
+			return true
+		}
+	}
+	return false
+}
+
 var ErrNoVMs = errors.New("all VMs failed to boot")
 
 func encodeEntries(entries []*prog.LogEntry) []byte {
@@ -711,7 +827,9 @@ func (ctx *reproContext) reproLogf(level int, format string, args ...interface{}
 	}
 	prefix := fmt.Sprintf("reproducing crash '%v': ", ctx.crashTitle)
 	log.Logf(level, prefix+format, args...)
+	ctx.mu.Lock()
 	ctx.stats.Log = append(ctx.stats.Log, []byte(fmt.Sprintf(format, args...)+"\n")...)
+	ctx.mu.Unlock()
 }
 
 func (ctx *reproContext) bisectProgs(progs []*prog.LogEntry, pred func([]*prog.LogEntry) (bool, error)) (
@@ -772,6 +890,8 @@ func (pw *poolWrapper) Run(ctx context.Context, params instance.ExecParams,
 			typ := "syz"
 			if params.CProg != nil {
 				typ = "C"
+			} else if params.Journal != "" {
+				typ = "journal"
 			}
 			info.Status = fmt.Sprintf("reproducing (%s, %.1f min)", typ, params.Duration.Minutes())
 		})
@@ -783,6 +903,8 @@ func (pw *poolWrapper) Run(ctx context.Context, params instance.ExecParams,
 		}
 		if params.CProg != nil {
 			result, err = ret.RunCProg(params)
+		} else if params.Journal != "" {
+			result, err = ret.RunJournal(params)
 		} else {
 			result, err = ret.RunSyzProg(params)
 		}
@@ -967,7 +1089,7 @@ func (stats *Stats) FullLog() []byte {
 		return nil
 	}
 	return []byte(fmt.Sprintf("Extracting prog: %v\nMinimizing prog: %v\n"+
-		"Simplifying prog options: %v\nExtracting C: %v\nSimplifying C: %v\n\n\n%s",
+		"Simplifying prog options: %v\nExtracting C: %v\nSimplifying C: %v\nStabilizing: %v\n\n\n%s",
 		stats.ExtractProgTime, stats.MinimizeProgTime,
-		stats.SimplifyProgTime, stats.ExtractCTime, stats.SimplifyCTime, stats.Log))
+		stats.SimplifyProgTime, stats.ExtractCTime, stats.SimplifyCTime, stats.StabilizeTime, stats.Log))
 }
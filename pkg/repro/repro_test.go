@@ -8,14 +8,20 @@ import (
 	"fmt"
 	"math/rand"
 	"regexp"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/debugtracer"
 	"github.com/google/syzkaller/pkg/flatrpc"
 	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/kconfig"
 	"github.com/google/syzkaller/pkg/mgrconfig"
 	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/pkg/rpcserver"
 	"github.com/google/syzkaller/pkg/testutil"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
@@ -118,10 +124,21 @@ func (tei *testExecInterface) Run(_ context.Context, params instance.ExecParams,
 	if params.CProg != nil {
 		syzProg = params.CProg.Serialize()
 	}
+	if params.Journal != "" {
+		summary, err := rpcserver.SummarizeJournal(params.Journal)
+		if err != nil {
+			return nil, err
+		}
+		syzProg = summary.Log
+	}
 	return tei.run(syzProg)
 }
 
 func runTestRepro(t *testing.T, log string, exec execInterface) (*Result, *Stats, error) {
+	return runTestReproJournal(t, log, "", exec)
+}
+
+func runTestReproJournal(t *testing.T, log, journal string, exec execInterface) (*Result, *Stats, error) {
 	mgrConfig := &mgrconfig.Config{
 		Derived: mgrconfig.Derived{
 			TargetOS:     targets.Linux,
@@ -138,8 +155,8 @@ func runTestRepro(t *testing.T, log string, exec execInterface) (*Result, *Stats
 	if err != nil {
 		t.Fatal(err)
 	}
-	return runInner(context.Background(), []byte(log), mgrConfig,
-		flatrpc.AllFeatures, reporter, false, exec)
+	return runInner(context.Background(), []byte(log), journal, mgrConfig,
+		flatrpc.AllFeatures, reporter, false, false, exec)
 }
 
 const testReproLog = `
@@ -188,6 +205,38 @@ alarm(0xa)
 	}
 }
 
+// The crash log may lack the programs that caused the crash (e.g. due to lost console output),
+// check that they are taken from the executor session journal.
+func TestJournalRepro(t *testing.T) {
+	journal := rpcserver.JournalFile(t.TempDir(), 0)
+	j, err := rpcserver.CreateJournal(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.Executing(1, 0, []byte("getpid()\npause()\n"))
+	j.Executing(2, 1, []byte("getpid()\ngetuid()\n"))
+	j.Executing(3, 0, []byte("alarm(0xa)\ngetpid()\n"))
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	const crashLog = `
+2015/12/21 12:18:05 executing program 1:
+getpid()
+getuid()
+`
+	result, _, err := runTestReproJournal(t, crashLog, journal, &testExecInterface{
+		run: testExecRunner,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`pause()
+alarm(0xa)
+`, string(result.Prog.Serialize())); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
 // There happen to be transient errors like ssh/scp connection failures.
 // Ensure that the code just retries.
 func TestVMErrorResilience(t *testing.T) {
@@ -258,3 +307,292 @@ alarm(0xa)
 		t.Fatal(diff)
 	}
 }
+
+// The crash happens only when the two close's run in parallel on different CPUs,
+// so no single program or their concatenation reproduces it.
+func TestRaceRepro(t *testing.T) {
+	const execLog = `
+2015/12/21 12:18:05 executing program 1:
+getpid()
+close(0x3)
+2015/12/21 12:18:10 executing program 2:
+getuid()
+close(0x3)
+[   44.377931][    C4] ==================================================================
+[   44.379001][    C4] BUG: KCSAN: data-race in __fput / __fput
+[   44.379966][    C4] 
+[   44.380268][    C4] read to 0xffffffff85a7f140 of 8 bytes by task 1082 on cpu 1:
+[   44.381409][    C4]  __fput+0x57/0xe0
+[   44.381969][    C4]  __x64_sys_close+0x28e/0x510
+[   44.382748][    C4]  do_syscall_64+0x30c/0x590
+[   44.386391][    C4] 
+[   44.386691][    C4] write to 0xffffffff85a7f140 of 8 bytes by task 1083 on cpu 0:
+[   44.387656][    C4]  __fput+0x4f/0xa0
+[   44.388333][    C4]  __x64_sys_close+0x6c/0x90
+[   44.388954][    C4]  do_syscall_64+0xe5/0x190
+[   44.396484][    C4] 
+[   44.396800][    C4] Reported by Kernel Concurrency Sanitizer on:
+[   44.397634][    C4] CPU: 0 PID: 6252 Comm: syz-executor Not tainted 5.3.0+ #3
+[   44.399836][    C4] ==================================================================
+`
+	crashCondition := regexp.MustCompile(`close\(0x3\) \(async, pin_cpu: 2\)\nclose\(0x3\) \(pin_cpu: 1\)`)
+	result, _, err := runTestRepro(t, execLog, &testExecInterface{
+		run: func(log []byte) (*instance.RunResult, error) {
+			ret := &instance.RunResult{}
+			if crashCondition.Match(log) {
+				ret.Report = &report.Report{
+					Title: `KCSAN: data-race in __fput / __fput`,
+				}
+			}
+			return ret, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(`close(0x3) (async, pin_cpu: 2)
+close(0x3) (pin_cpu: 1)
+`, string(result.Prog.Serialize())); diff != "" {
+		t.Fatal(diff)
+	}
+	if !result.CRepro {
+		t.Fatal("expected a C reproducer")
+	}
+}
+
+type flakyExec struct {
+	mu   sync.Mutex
+	runs int
+}
+
+func (fe *flakyExec) Run(_ context.Context, params instance.ExecParams,
+	_ instance.ExecutorLogger) (*instance.RunResult, error) {
+	fe.mu.Lock()
+	defer fe.mu.Unlock()
+	fe.runs++
+	ret := &instance.RunResult{Duration: time.Second}
+	// Without repeat the bug triggers only in every 4-th run.
+	if params.Opts.Repeat || fe.runs%4 == 0 {
+		ret.Report = &report.Report{Title: "some crash"}
+	}
+	return ret, nil
+}
+
+func TestStabilize(t *testing.T) {
+	target, err := prog.GetTarget(targets.Linux, targets.AMD64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte("getpid()\n"), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &reproContext{
+		exec:           &flakyExec{},
+		target:         targets.Get(targets.Linux, targets.AMD64),
+		testTimeouts:   []time.Duration{10 * time.Second, time.Minute},
+		startOpts:      csource.Options{Threaded: true, Repeat: true, Procs: 4},
+		stats:          new(Stats),
+		observedTitles: map[string]bool{"some crash": true},
+		logf:           t.Logf,
+	}
+	res, err := ctx.stabilize(&Result{
+		Prog:     p,
+		Duration: 10 * time.Second,
+		Opts:     csource.Options{Threaded: true, Procs: 1},
+		CRepro:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Opts.Repeat || res.Opts.Procs != 1 || res.Duration != 10*time.Second {
+		t.Fatalf("unexpected repro: %+v", res)
+	}
+	want := &Reliability{Runs: reliabilityRuns, Crashes: reliabilityRuns, TimeToCrash: time.Second}
+	if diff := cmp.Diff(want, res.CReliability); diff != "" {
+		t.Fatal(diff)
+	}
+	if diff := cmp.Diff(want, res.SyzReliability); diff != "" {
+		t.Fatal(diff)
+	}
+
+	// A reliable reproducer is measured only once.
+	exec := &flakyExec{}
+	ctx.exec = exec
+	res, err = ctx.stabilize(&Result{
+		Prog:     p,
+		Duration: 10 * time.Second,
+		Opts:     csource.Options{Threaded: true, Repeat: true, Procs: 1},
+		CRepro:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, res.CReliability); diff != "" {
+		t.Fatal(diff)
+	}
+	if exec.runs != reliabilityRuns || res.SyzReliability != nil {
+		t.Fatalf("got %v runs, syz reliability %v", exec.runs, res.SyzReliability)
+	}
+
+	// Repeat is not enabled if it's not enabled in the start options.
+	ctx.startOpts.Repeat = false
+	res, err = ctx.stabilize(&Result{
+		Prog:     p,
+		Duration: 10 * time.Second,
+		Opts:     csource.Options{Threaded: true, Procs: 1},
+		CRepro:   true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Opts.Repeat || res.Opts.Procs != 1 {
+		t.Fatalf("unexpected repro: %+v", res)
+	}
+}
+
+func TestVerdictTitles(t *testing.T) {
+	ctx := &reproContext{
+		stats:          new(Stats),
+		observedTitles: map[string]bool{"crash A": true},
+		logf:           t.Logf,
+	}
+	crashed := func(rep *report.Report) bool {
+		ret, err := ctx.getVerdict(func() (*instance.RunResult, error) {
+			return &instance.RunResult{Report: rep}, nil
+		}, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ret.Crashed
+	}
+	if !crashed(&report.Report{Title: "crash A"}) {
+		t.Fatalf("the observed title is not accepted")
+	}
+	if !crashed(&report.Report{Title: "crash B", AltTitles: []string{"crash A"}}) {
+		t.Fatalf("the observed alt title is not accepted")
+	}
+	if !crashed(&report.Report{
+		Title:     "crash C",
+		Secondary: []*report.Report{{Title: "crash A"}},
+	}) {
+		t.Fatalf("the observed secondary title is not accepted")
+	}
+	if crashed(&report.Report{
+		Title:     "crash D",
+		Secondary: []*report.Report{{Title: "crash E"}},
+	}) {
+		t.Fatalf("a never seen crash is accepted")
+	}
+}
+
+func TestProgRequirements(t *testing.T) {
+	target, err := prog.GetTarget(targets.Linux, targets.AMD64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`getpid()
+socket$inet6_tcp(0xa, 0x1, 0x0)
+syz_mount_image$ext4(0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0)
+`), prog.NonStrict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := csource.Options{Sandbox: "none", NetInjection: true, USB: true}
+	req := ProgRequirements(p, opts, crash.KASAN)
+	want := &Requirements{
+		Features: flatrpc.FeatureSandboxNone | flatrpc.FeatureNetInjection | flatrpc.FeatureUSBEmulation,
+		Configs:  []string{"BLK_DEV_LOOP", "EXT4_FS", "IPV6", "KASAN", "TUN", "USB_DUMMY_HCD", "USB_RAW_GADGET"},
+	}
+	if diff := cmp.Diff(want, req); diff != "" {
+		t.Fatal(diff)
+	}
+	block := req.Serialize()
+	if diff := cmp.Diff(`# requirements:
+#   features: NetInjection SandboxNone USBEmulation
+#   configs: CONFIG_BLK_DEV_LOOP CONFIG_EXT4_FS CONFIG_IPV6 CONFIG_KASAN CONFIG_TUN CONFIG_USB_DUMMY_HCD CONFIG_USB_RAW_GADGET
+`, string(block)); diff != "" {
+		t.Fatal(diff)
+	}
+	// The block must not break parsing of the reproducer.
+	p1, err := target.Deserialize(append(block, p.Serialize()...), prog.Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p1.Calls) != len(p.Calls) {
+		t.Fatalf("got %v calls, want %v", len(p1.Calls), len(p.Calls))
+	}
+}
+
+func TestRefineConfigs(t *testing.T) {
+	kconf, err := kconfig.ParseData(targets.Get(targets.Linux, targets.AMD64), []byte(`
+mainmenu "test"
+config NET
+	bool "net"
+config IPV6
+	tristate "ipv6"
+	depends on NET
+config TUN
+	tristate "tun"
+	depends on NET
+config KASAN
+	bool "kasan"
+config EXT4_FS
+	tristate "ext4"
+`), "Kconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, err := kconfig.ParseConfigData([]byte("CONFIG_EXT4_FS=y\n"), "base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := kconfig.ParseConfigData([]byte(`
+CONFIG_EXT4_FS=y
+CONFIG_NET=y
+CONFIG_IPV6=y
+CONFIG_TUN=y
+CONFIG_KASAN=y
+`), "full")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		configs []string
+		needs   []string
+		want    []string
+	}{
+		{
+			// TUN is not actually needed, NET is needed by IPV6.
+			configs: []string{"EXT4_FS", "IPV6", "KASAN", "TUN"},
+			needs:   []string{"IPV6", "KASAN"},
+			want:    []string{"EXT4_FS", "IPV6", "KASAN", "NET"},
+		},
+		{
+			// The static analysis missed TUN.
+			configs: []string{"KASAN"},
+			needs:   []string{"KASAN", "TUN"},
+			want:    []string{"KASAN", "NET", "TUN"},
+		},
+	}
+	for i, test := range tests {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			req := &Requirements{Configs: test.configs}
+			pred := func(cf *kconfig.ConfigFile) (bool, error) {
+				for _, cfg := range test.needs {
+					if cf.Value(cfg) == kconfig.No {
+						return false, nil
+					}
+				}
+				return true, nil
+			}
+			err := req.RefineConfigs(kconf, base, full, pred, &debugtracer.TestTracer{T: t})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(test.want, req.Configs); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/debugtracer"
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/google/syzkaller/pkg/kconfig"
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+// Requirements describes what a reproducer needs from the kernel and the execution environment
+// to trigger the bug. Reproducers are frequently run on configs that differ from the one
+// the bug was found on, and then silently fail to reproduce it.
+type Requirements struct {
+	// Executor features the reproducer relies on.
+	Features flatrpc.Feature
+	// Linux kernel configs (names without the CONFIG_ prefix), sorted.
+	Configs []string
+}
+
+// ProgRequirements statically derives the requirements of the reproducer
+// from the syscalls it uses, the options and the type of the crash.
+// The result is conservative: not all of the configs may be actually needed, see RefineConfigs.
+func ProgRequirements(p *prog.Prog, opts csource.Options, typ crash.Type) *Requirements {
+	req := &Requirements{}
+	configs := make(map[string]bool)
+	optsFeatures := []struct {
+		enabled bool
+		feature flatrpc.Feature
+		configs []string
+	}{
+		{opts.Fault, flatrpc.FeatureFault, faultConfigs},
+		{opts.Leak, flatrpc.FeatureLeak, []string{"DEBUG_KMEMLEAK"}},
+		{opts.KCSAN, flatrpc.FeatureKCSAN, []string{"KCSAN"}},
+		{opts.NetInjection, flatrpc.FeatureNetInjection, []string{"TUN"}},
+		{opts.NetDevices, flatrpc.FeatureNetDevices, nil},
+		{opts.DevlinkPCI, flatrpc.FeatureDevlinkPCI, []string{"NETDEVSIM"}},
+		{opts.NicVF, flatrpc.FeatureNicVF, nil},
+		{opts.USB, flatrpc.FeatureUSBEmulation, []string{"USB_RAW_GADGET", "USB_DUMMY_HCD"}},
+		{opts.VhciInjection, flatrpc.FeatureVhciInjection, []string{"BT", "BT_HCIVHCI"}},
+		{opts.Wifi, flatrpc.FeatureWifiEmulation, []string{"MAC80211_HWSIM"}},
+		{opts.IEEE802154, flatrpc.FeatureLRWPANEmulation, []string{"IEEE802154", "MAC802154", "IEEE802154_HWSIM"}},
+		{opts.BinfmtMisc, flatrpc.FeatureBinFmtMisc, []string{"BINFMT_MISC"}},
+		{opts.Swap, flatrpc.FeatureSwap, []string{"SWAP"}},
+	}
+	for _, feat := range optsFeatures {
+		if feat.enabled {
+			req.Features |= feat.feature
+			for _, cfg := range feat.configs {
+				configs[cfg] = true
+			}
+		}
+	}
+	switch opts.Sandbox {
+	case "none":
+		req.Features |= flatrpc.FeatureSandboxNone
+	case "setuid":
+		req.Features |= flatrpc.FeatureSandboxSetuid
+	case "namespace":
+		req.Features |= flatrpc.FeatureSandboxNamespace
+	case "android":
+		req.Features |= flatrpc.FeatureSandboxAndroid
+	}
+	if p.RequiredFeatures().FaultInjection {
+		req.Features |= flatrpc.FeatureFault
+		for _, cfg := range faultConfigs {
+			configs[cfg] = true
+		}
+	}
+	if p.Target.OS != targets.Linux {
+		return req
+	}
+	for _, cfg := range crashTypeConfigs[typ] {
+		configs[cfg] = true
+	}
+	if p.Target.KernelConfigs != nil {
+		for _, c := range p.Calls {
+			for _, cfg := range p.Target.KernelConfigs(c.Meta) {
+				configs[cfg] = true
+			}
+		}
+	}
+	for cfg := range configs {
+		req.Configs = append(req.Configs, cfg)
+	}
+	sort.Strings(req.Configs)
+	return req
+}
+
+// Serialize returns the requirements as a comment block that can be prepended to the syz reproducer.
+func (req *Requirements) Serialize() []byte {
+	if req == nil || req.Features == 0 && len(req.Configs) == 0 {
+		return nil
+	}
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "# requirements:\n")
+	if req.Features != 0 {
+		var features []string
+		for feat, name := range flatrpc.EnumNamesFeature {
+			if req.Features&feat != 0 {
+				features = append(features, name)
+			}
+		}
+		sort.Strings(features)
+		fmt.Fprintf(buf, "#   features: %v\n", strings.Join(features, " "))
+	}
+	if len(req.Configs) != 0 {
+		fmt.Fprintf(buf, "#   configs: CONFIG_%v\n", strings.Join(req.Configs, " CONFIG_"))
+	}
+	return buf.Bytes()
+}
+
+// RefineConfigs drops the statically derived configs that are not needed to trigger the bug,
+// and adds the configs the static analysis has missed.
+// The bug is assumed to reproduce on the full config and not to reproduce on the base config,
+// pred builds a kernel with the given config and checks whether the reproducer still triggers the bug.
+// It's not a part of the reproduction (see Run): that would require building and booting kernels
+// with different configs, so it's done on demand by tools/syz-requirements.
+func (req *Requirements) RefineConfigs(kconf *kconfig.KConfig, base, full *kconfig.ConfigFile,
+	pred func(*kconfig.ConfigFile) (bool, error), dt debugtracer.DebugTracer) error {
+	// First try base + the statically derived configs (with their dependencies):
+	// the minimization has much less work to do if the static analysis is right.
+	static := make(map[string]bool)
+	candidate := base.Clone()
+	for _, cfg := range req.Configs {
+		static[cfg] = true
+		if full.Value(cfg) == kconfig.No || base.Value(cfg) != kconfig.No {
+			continue
+		}
+		candidate.Set(cfg, kconfig.Yes)
+		if m := kconf.Configs[cfg]; m != nil {
+			for dep := range m.DependsOn() {
+				if full.Value(dep) != kconfig.No && base.Value(dep) == kconfig.No {
+					candidate.Set(dep, kconfig.Yes)
+				}
+			}
+		}
+	}
+	ok, err := pred(candidate)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		dt.Log("the statically derived configs are not enough, minimizing the full config")
+		candidate = full
+	}
+	minimized, err := kconf.Minimize(base, candidate, pred, 0, dt)
+	if err != nil {
+		return err
+	}
+	req.Configs = nil
+	for _, cfg := range minimized.Configs {
+		if cfg.Value != kconfig.Yes && cfg.Value != kconfig.Mod {
+			continue
+		}
+		// The configs that are present in base can't be checked by the minimization,
+		// so we keep only the ones the static analysis has pointed to.
+		if base.Value(cfg.Name) == kconfig.No || static[cfg.Name] {
+			req.Configs = append(req.Configs, cfg.Name)
+		}
+	}
+	sort.Strings(req.Configs)
+	return nil
+}
+
+var faultConfigs = []string{
+	"FAULT_INJECTION", "FAULT_INJECTION_DEBUG_FS", "FAULT_INJECTION_USERCOPY",
+	"FAILSLAB", "FAIL_PAGE_ALLOC", "FAIL_FUTEX",
+}
+
+// The tools that detect the bug.
+var crashTypeConfigs = map[crash.Type][]string{
+	crash.KASAN:       {"KASAN"},
+	crash.KMSAN:       {"KMSAN"},
+	crash.DataRace:    {"KCSAN"},
+	crash.UBSAN:       {"UBSAN"},
+	crash.MemoryLeak:  {"DEBUG_KMEMLEAK"},
+	crash.LockdepBug:  {"PROVE_LOCKING"},
+	crash.AtomicSleep: {"DEBUG_ATOMIC_SLEEP"},
+}
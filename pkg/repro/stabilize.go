@@ -0,0 +1,174 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package repro
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Reliability describes how often a reproducer triggers the bug.
+type Reliability struct {
+	Runs    int
+	Crashes int
+	// Average time it took to trigger the bug in the successful runs.
+	TimeToCrash time.Duration
+}
+
+func (r *Reliability) Rate() float64 {
+	if r == nil || r.Runs == 0 {
+		return 0
+	}
+	return float64(r.Crashes) / float64(r.Runs)
+}
+
+func (r *Reliability) String() string {
+	if r.Crashes == 0 {
+		return fmt.Sprintf("%v/%v runs", r.Crashes, r.Runs)
+	}
+	return fmt.Sprintf("%v/%v runs, %v to crash", r.Crashes, r.Runs, r.TimeToCrash.Round(time.Second))
+}
+
+const (
+	// Each reproducer is rerun that many times to measure its reliability.
+	// The runs are done in parallel.
+	reliabilityRuns = 5
+	// Reproducers that trigger the bug less often are worth stabilizing.
+	goodReliability = 0.8
+	// No new measurements are started after that time.
+	maxStabilizeTime = 30 * time.Minute
+)
+
+// stabilize measures how reliable the reproducer is. If it does not trigger the bug reliably,
+// it tries to make the reproducer more aggressive (repeat, more procs, longer call timeouts,
+// run longer), and keeps the changes that improve the reliability.
+func (ctx *reproContext) stabilize(res *Result) (*Result, error) {
+	ctx.reproLogf(2, "measuring reproducer reliability")
+	start := time.Now()
+	defer func() {
+		ctx.stats.StabilizeTime = time.Since(start)
+	}()
+
+	current, err := ctx.measure(res, res.CRepro)
+	if err != nil {
+		return nil, err
+	}
+	if res.CRepro {
+		res.CReliability = current
+	} else {
+		res.SyzReliability = current
+	}
+	if current.Rate() == 1 {
+		// Nothing to improve.
+		return res, nil
+	}
+	for _, stabilize := range stabilizers {
+		if current.Rate() >= goodReliability || time.Since(start) > maxStabilizeTime {
+			break
+		}
+		res1 := *res
+		if !stabilize(ctx, &res1) || !checkOpts(&res1.Opts, ctx.timeouts, res1.Duration) ||
+			res1.Opts.Check(ctx.target.OS) != nil {
+			continue
+		}
+		ret, err := ctx.measure(&res1, res1.CRepro)
+		if err != nil {
+			return nil, err
+		}
+		if ret.Rate() > current.Rate() {
+			res, current = &res1, ret
+		}
+	}
+	if res.CRepro {
+		res.CReliability = current
+		// Also report how the syz reproducer with the final options behaves.
+		if time.Since(start) <= maxStabilizeTime {
+			if res.SyzReliability, err = ctx.measure(res, false); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		res.SyzReliability = current
+	}
+	return res, nil
+}
+
+func (ctx *reproContext) measure(res *Result, cRepro bool) (*Reliability, error) {
+	verdicts := make([]verdict, reliabilityRuns)
+	errs := make([]error, reliabilityRuns)
+	var wg sync.WaitGroup
+	for i := range verdicts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if cRepro {
+				verdicts[i], errs[i] = ctx.testCProg(res.Prog, res.Duration, res.Opts, true)
+			} else {
+				verdicts[i], errs[i] = ctx.testProg(res.Prog, res.Duration, res.Opts, true)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	ret := &Reliability{}
+	var total time.Duration
+	for _, v := range verdicts {
+		ret.Runs++
+		if v.Crashed {
+			ret.Crashes++
+			total += v.Duration
+		}
+	}
+	if ret.Crashes != 0 {
+		ret.TimeToCrash = total / time.Duration(ret.Crashes)
+	}
+	typ := "syz"
+	if cRepro {
+		typ = "C"
+	}
+	ctx.reproLogf(2, "%v reproducer reliability (%+v): %v", typ, res.Opts, ret)
+	return ret, nil
+}
+
+var stabilizers = []func(ctx *reproContext, res *Result) bool{
+	func(ctx *reproContext, res *Result) bool {
+		if res.Opts.Repeat || !ctx.startOpts.Repeat {
+			return false
+		}
+		res.Opts.Repeat = true
+		return true
+	},
+	func(ctx *reproContext, res *Result) bool {
+		// Procs > 1 requires Repeat.
+		if res.Opts.Procs >= ctx.startOpts.Procs || !ctx.startOpts.Repeat {
+			return false
+		}
+		res.Opts.Repeat = true
+		res.Opts.Procs = ctx.startOpts.Procs
+		return true
+	},
+	func(ctx *reproContext, res *Result) bool {
+		// Give blocking calls more time before the next calls are started.
+		// Slowdown scales the call and program timeouts in C reproducers, for syz reproducers
+		// it's taken from the manager config.
+		if !res.CRepro {
+			return false
+		}
+		res.Opts.Slowdown = max(res.Opts.Slowdown, 1) * 2
+		return true
+	},
+	func(ctx *reproContext, res *Result) bool {
+		// Give the reproducer more time to hit the race window.
+		maxDuration := ctx.testTimeouts[len(ctx.testTimeouts)-1]
+		if res.Duration >= maxDuration {
+			return false
+		}
+		res.Duration = min(res.Duration*2, maxDuration)
+		return true
+	},
+}
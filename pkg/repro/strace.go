@@ -75,5 +75,10 @@ func (strace *StraceResult) IsSameBug(repro *Result) bool {
 	if strace == nil || strace.Report == nil || repro.Report == nil {
 		return false
 	}
-	return strace.Report.Title == repro.Report.Title
+	for _, title := range strace.Report.Titles() {
+		if title == repro.Report.Title {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,346 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	flatbuffers "github.com/google/flatbuffers/go"
+	"github.com/google/syzkaller/pkg/log"
+)
+
+// Journal records all messages that the host sends to a VM during one session,
+// and the procs that executed the requests. The session can be replayed later with Replay.
+// Sessions may be long, so only a trailing window of the session is kept: once the file
+// reaches journalMaxSize, it's truncated to the handshake and the newer half of the entries.
+//
+// The journal file starts with journalMagic followed by a sequence of entries.
+// Each entry starts with the kind (1 byte) and the time since the journal creation in ns (8 bytes).
+// Host messages are followed by the flatbuffers message size (4 bytes) and the message.
+// Executing entries are followed by the request id (8 bytes), the proc (4 bytes),
+// the program size (4 bytes) and the program text. All integers are little-endian.
+// Truncated and crash entries have no payload.
+type Journal struct {
+	mu      sync.Mutex
+	name    string
+	file    *os.File
+	w       *bufio.Writer
+	builder *flatbuffers.Builder
+	start   time.Time
+	maxSize int64
+	// Current file size, and the offset and the time of the first entry in the newer half
+	// of the file (0 if the file is not yet half full).
+	size     int64
+	keepOff  int64
+	keepTime time.Duration
+	// Handshake entries that are preserved on truncation.
+	handshake []byte
+	// Set after write errors and after Close.
+	stopped bool
+}
+
+// journalEntry is a decoded journal entry.
+type journalEntry struct {
+	kind journalKind
+	time time.Duration
+	// Flatbuffers message for host messages.
+	msg []byte
+	// These are set for executing entries.
+	id   int64
+	proc int
+	prog []byte
+}
+
+const (
+	journalMagic   = "SYZJRNL1"
+	journalMaxSize = 64 << 20
+)
+
+type journalKind uint8
+
+const (
+	journalConnectReply journalKind = iota + 1
+	journalInfoReply
+	journalHostMessage
+	journalExecuting
+	// Older entries were dropped (except for the handshake) after this point.
+	journalTruncated
+	// The kernel has crashed, no entries follow.
+	journalCrash
+)
+
+// JournalFile returns the journal file for the VM with the given id.
+func JournalFile(dir string, id int) string {
+	return filepath.Join(dir, fmt.Sprintf("journal-%v", id))
+}
+
+func CreateJournal(file string) (*Journal, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create journal: %w", err)
+	}
+	j := &Journal{
+		name:    file,
+		file:    f,
+		w:       bufio.NewWriterSize(f, 1<<20),
+		builder: flatbuffers.NewBuilder(0),
+		start:   time.Now(),
+		maxSize: journalMaxSize,
+		size:    int64(len(journalMagic)),
+	}
+	if _, err := j.w.WriteString(journalMagic); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j, nil
+}
+
+type journalMsg interface {
+	Pack(*flatbuffers.Builder) flatbuffers.UOffsetT
+}
+
+func (j *Journal) message(kind journalKind, msg journalMsg) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.builder.Finish(msg.Pack(j.builder))
+	data := j.builder.FinishedBytes()
+	j.builder.Reset()
+	j.write(kind, binary.LittleEndian.AppendUint32(nil, uint32(len(data))), data)
+}
+
+// Executing notes that the request with the given id has started executing on the proc.
+func (j *Journal) Executing(id int64, proc int, progData []byte) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var hdr []byte
+	hdr = binary.LittleEndian.AppendUint64(hdr, uint64(id))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(proc))
+	hdr = binary.LittleEndian.AppendUint32(hdr, uint32(len(progData)))
+	j.write(journalExecuting, hdr, progData)
+}
+
+// Crashed notes that the kernel has crashed, the replay of the session stops at this point.
+func (j *Journal) Crashed() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.write(journalCrash, nil, nil)
+}
+
+func (j *Journal) write(kind journalKind, hdr, data []byte) {
+	if j.stopped {
+		return
+	}
+	now := time.Since(j.start)
+	if j.keepOff == 0 && j.size >= j.maxSize/2 {
+		j.keepOff, j.keepTime = j.size, now
+	}
+	entry := entryHeader(kind, now)
+	entry = append(entry, hdr...)
+	if kind == journalConnectReply || kind == journalInfoReply {
+		j.handshake = append(append(j.handshake, entry...), data...)
+	}
+	_, err := j.w.Write(entry)
+	if err == nil {
+		_, err = j.w.Write(data)
+	}
+	j.size += int64(len(entry) + len(data))
+	if err == nil && j.size >= j.maxSize {
+		err = j.truncate()
+	}
+	if err != nil {
+		// Don't disturb fuzzing, the journal is only a debugging aid.
+		log.Logf(0, "failed to write journal %v: %v", j.name, err)
+		j.stopped = true
+	}
+}
+
+func entryHeader(kind journalKind, ts time.Duration) []byte {
+	entry := []byte{byte(kind)}
+	return binary.LittleEndian.AppendUint64(entry, uint64(ts))
+}
+
+// truncate replaces the journal file with a new one that contains only the handshake
+// and the newer half of the entries.
+func (j *Journal) truncate() error {
+	if err := j.w.Flush(); err != nil {
+		return err
+	}
+	f, err := os.Create(j.name + ".tmp")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriterSize(f, 1<<20)
+	w.WriteString(journalMagic)
+	w.Write(j.handshake)
+	// The truncation point has the time of the first kept entry,
+	// so the timing of the rest of the session is relative to it.
+	w.Write(entryHeader(journalTruncated, j.keepTime))
+	if _, err = j.file.Seek(j.keepOff, io.SeekStart); err == nil {
+		_, err = io.Copy(w, j.file)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), j.name)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	j.file.Close()
+	j.file, j.w = f, w
+	j.size, j.keepOff = int64(len(journalMagic)+len(j.handshake)+9)+j.size-j.keepOff, 0
+	return nil
+}
+
+func (j *Journal) Close() error {
+	if j == nil {
+		return nil
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	// Signal updates may be sent concurrently with the VM shutdown, ignore them.
+	j.stopped = true
+	err := j.w.Flush()
+	if closeErr := j.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+type journalReader struct {
+	file *os.File
+	r    *bufio.Reader
+}
+
+func openJournal(file string) (*journalReader, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	r := &journalReader{
+		file: f,
+		r:    bufio.NewReaderSize(f, 1<<20),
+	}
+	magic := make([]byte, len(journalMagic))
+	if _, err := io.ReadFull(r.r, magic); err != nil || string(magic) != journalMagic {
+		f.Close()
+		return nil, fmt.Errorf("%v is not a journal file", file)
+	}
+	return r, nil
+}
+
+// next returns the next entry, or io.EOF at the end of the journal.
+func (r *journalReader) next() (*journalEntry, error) {
+	var hdr [9]byte
+	if _, err := io.ReadFull(r.r, hdr[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			err = errors.New("truncated journal entry")
+		}
+		return nil, err
+	}
+	entry := &journalEntry{
+		kind: journalKind(hdr[0]),
+		time: time.Duration(binary.LittleEndian.Uint64(hdr[1:])),
+	}
+	var err error
+	switch entry.kind {
+	case journalConnectReply, journalInfoReply, journalHostMessage:
+		var size [4]byte
+		if _, err = io.ReadFull(r.r, size[:]); err == nil {
+			entry.msg, err = r.read(binary.LittleEndian.Uint32(size[:]))
+		}
+	case journalExecuting:
+		var exec [16]byte
+		if _, err = io.ReadFull(r.r, exec[:]); err == nil {
+			entry.id = int64(binary.LittleEndian.Uint64(exec[0:]))
+			entry.proc = int(int32(binary.LittleEndian.Uint32(exec[8:])))
+			entry.prog, err = r.read(binary.LittleEndian.Uint32(exec[12:]))
+		}
+	case journalTruncated, journalCrash:
+	default:
+		return nil, fmt.Errorf("unknown journal entry kind %v", entry.kind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("truncated journal entry: %w", err)
+	}
+	return entry, nil
+}
+
+func (r *journalReader) read(size uint32) ([]byte, error) {
+	const maxSize = 64 << 20
+	if size > maxSize {
+		return nil, fmt.Errorf("too large entry (%v bytes)", size)
+	}
+	data := make([]byte, size)
+	_, err := io.ReadFull(r.r, data)
+	return data, err
+}
+
+func (r *journalReader) close() {
+	r.file.Close()
+}
+
+// JournalSummary describes a recorded session.
+type JournalSummary struct {
+	// Duration of the session after the handshake (or after the truncation point) till the crash.
+	Duration time.Duration
+	// All programs executed before the crash in the execution order
+	// in the format of the fuzzing log (see prog.ParseLog).
+	Log []byte
+}
+
+func SummarizeJournal(file string) (*JournalSummary, error) {
+	r, err := openJournal(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.close()
+	ret := new(JournalSummary)
+	buf := new(bytes.Buffer)
+	var start, end time.Duration
+	for {
+		entry, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		end = entry.time
+		if entry.kind == journalCrash {
+			break
+		}
+		switch entry.kind {
+		case journalInfoReply, journalTruncated:
+			start = entry.time
+		case journalExecuting:
+			fmt.Fprintf(buf, "%v: executing program %v (id=%v):\n%s\n",
+				entry.time-start, entry.proc, entry.id, entry.prog)
+		}
+	}
+	ret.Duration = end - start
+	ret.Log = buf.Bytes()
+	return ret, nil
+}
@@ -0,0 +1,182 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalSummary(t *testing.T) {
+	file := writeTestJournal(t)
+	summary, err := SummarizeJournal(file)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^.*: executing program 2 \(id=1\):\ngetpid\(\)\n\n`+
+		`.*: executing program 0 \(id=2\):\ngetuid\(\)\n\n`+
+		`.*: executing program 3 \(id=2\):\ngetuid\(\)\n\n$`, string(summary.Log))
+	assert.Positive(t, summary.Duration)
+
+	_, err = SummarizeJournal(filepath.Join(t.TempDir(), "nonexistent"))
+	assert.Error(t, err)
+}
+
+func TestJournalTruncate(t *testing.T) {
+	file := JournalFile(t.TempDir(), 1)
+	j, err := CreateJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.maxSize = 1 << 10
+	j.message(journalConnectReply, &flatrpc.ConnectReply{Procs: 4})
+	j.message(journalInfoReply, &flatrpc.InfoReply{})
+	for i := 0; i < 100; i++ {
+		j.Executing(int64(i), 0, []byte(fmt.Sprintf("getpid() # %v\n", i)))
+	}
+	j.Crashed()
+	j.Executing(100, 0, []byte("getuid()\n"))
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(file)
+	assert.NoError(t, err)
+	assert.Less(t, info.Size(), j.maxSize)
+
+	r, err := openJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.close()
+	var kinds []journalKind
+	var ids []int64
+	for {
+		entry, err := r.next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		kinds = append(kinds, entry.kind)
+		if entry.kind == journalExecuting {
+			ids = append(ids, entry.id)
+		}
+	}
+	// The handshake is preserved, and only the newest programs are kept.
+	assert.Equal(t, []journalKind{journalConnectReply, journalInfoReply, journalTruncated}, kinds[:3])
+	assert.Greater(t, len(ids), 10)
+	assert.Equal(t, int64(100), ids[len(ids)-1])
+	for i := 1; i < len(ids); i++ {
+		assert.Equal(t, ids[i-1]+1, ids[i])
+	}
+
+	// The summary stops at the crash.
+	summary, err := SummarizeJournal(file)
+	assert.NoError(t, err)
+	assert.Contains(t, string(summary.Log), "getpid() # 99\n")
+	assert.NotContains(t, string(summary.Log), "getuid()")
+	assert.NotContains(t, string(summary.Log), "getpid() # 0\n")
+}
+
+func TestReplay(t *testing.T) {
+	file := writeTestJournal(t)
+	hostConn, executorConn := net.Pipe()
+	host := flatrpc.NewConn(hostConn)
+	executor := flatrpc.NewConn(executorConn)
+	defer host.Close()
+	defer executor.Close()
+
+	var received []*flatrpc.HostMessage
+	done := make(chan error)
+	go func() {
+		done <- func() error {
+			if err := flatrpc.Send(executor, &flatrpc.ConnectRequest{Id: 1}); err != nil {
+				return err
+			}
+			reply, err := flatrpc.Recv[*flatrpc.ConnectReplyRaw](executor)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, int32(4), reply.Procs)
+			if err := flatrpc.Send(executor, &flatrpc.InfoRequest{}); err != nil {
+				return err
+			}
+			if _, err := flatrpc.Recv[*flatrpc.InfoReplyRaw](executor); err != nil {
+				return err
+			}
+			for i := 0; i < 3; i++ {
+				msg, err := flatrpc.Recv[*flatrpc.HostMessageRaw](executor)
+				if err != nil {
+					return err
+				}
+				received = append(received, msg)
+				req, ok := msg.Msg.Value.(*flatrpc.ExecRequest)
+				if !ok {
+					continue
+				}
+				err = flatrpc.Send(executor, &flatrpc.ExecutorMessage{
+					Msg: &flatrpc.ExecutorMessages{
+						Type:  flatrpc.ExecutorMessagesRawExecResult,
+						Value: &flatrpc.ExecResult{Id: req.Id},
+					},
+				})
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		}()
+	}()
+	assert.NoError(t, Replay(context.Background(), host, file))
+	assert.NoError(t, <-done)
+	assert.Len(t, received, 3)
+	// The first request is pinned to proc 2.
+	assert.Equal(t, ^uint64(1<<2), received[0].Msg.Value.(*flatrpc.ExecRequest).Avoid)
+	// The second request is pinned to proc 0, even though it was avoided in the recorded session.
+	assert.Equal(t, ^uint64(1<<0), received[1].Msg.Value.(*flatrpc.ExecRequest).Avoid)
+	assert.Equal(t, flatrpc.HostMessagesRawCorpusTriaged, received[2].Msg.Type)
+}
+
+func writeTestJournal(t *testing.T) string {
+	file := JournalFile(t.TempDir(), 1)
+	j, err := CreateJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	execRequest := func(id int64, avoid uint64) *flatrpc.HostMessage {
+		return &flatrpc.HostMessage{
+			Msg: &flatrpc.HostMessages{
+				Type: flatrpc.HostMessagesRawExecRequest,
+				Value: &flatrpc.ExecRequest{
+					Id:       id,
+					Avoid:    avoid,
+					ExecOpts: &flatrpc.ExecOpts{},
+				},
+			},
+		}
+	}
+	j.message(journalConnectReply, &flatrpc.ConnectReply{Procs: 4})
+	j.message(journalInfoReply, &flatrpc.InfoReply{})
+	j.message(journalHostMessage, execRequest(1, 0))
+	j.message(journalHostMessage, execRequest(2, 1<<2|1<<0))
+	j.Executing(1, 2, []byte("getpid()\n"))
+	j.Executing(2, 0, []byte("getuid()\n"))
+	// Retries don't change the proc assignment.
+	j.Executing(2, 3, []byte("getuid()\n"))
+	j.message(journalHostMessage, &flatrpc.HostMessage{
+		Msg: &flatrpc.HostMessages{
+			Type:  flatrpc.HostMessagesRawCorpusTriaged,
+			Value: &flatrpc.CorpusTriaged{},
+		},
+	})
+	if err := j.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
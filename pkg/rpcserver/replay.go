@@ -0,0 +1,227 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/flatrpc"
+)
+
+// Replay replays the session recorded in the journal file to the executor connected over conn.
+// The messages are sent with the same timing relative to the end of the handshake
+// (or to the truncation point if the journal was truncated), and the requests are pinned
+// to the procs that executed them in the recorded session. The replay stops at the crash.
+// Replay returns once all messages are sent and the executor has finished all requests.
+// Requests that hang or crash the kernel never finish, so the caller needs to bound the replay with ctx.
+func Replay(ctx context.Context, conn *flatrpc.Conn, file string) error {
+	procs, err := journalProcs(file)
+	if err != nil {
+		return err
+	}
+	r, err := openJournal(file)
+	if err != nil {
+		return err
+	}
+	defer r.close()
+	if _, err := flatrpc.Recv[*flatrpc.ConnectRequestRaw](conn); err != nil {
+		return err
+	}
+	rp := &replayer{
+		conn:     conn,
+		procs:    procs,
+		pending:  make(map[int64]bool),
+		finished: make(chan struct{}, 1),
+		errc:     make(chan error, 1),
+	}
+	for {
+		entry, err := r.next()
+		if err == io.EOF || err == nil && entry.kind == journalCrash {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := rp.replay(ctx, entry); err != nil {
+			return err
+		}
+	}
+	for rp.numPending() != 0 {
+		if err := rp.wait(ctx, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type replayer struct {
+	conn     *flatrpc.Conn
+	procs    map[int64]int
+	numProcs int
+	// Start of the replayed session aligned with the recorded one.
+	start time.Time
+	// Set if the journal was truncated, the next host message is sent right away
+	// and the start is re-aligned with it.
+	realign bool
+	mu      sync.Mutex
+	// Requests sent to the executor that have not finished yet.
+	pending map[int64]bool
+	// Notified when a pending request finishes.
+	finished chan struct{}
+	errc     chan error
+}
+
+func (rp *replayer) replay(ctx context.Context, entry *journalEntry) error {
+	switch entry.kind {
+	case journalConnectReply:
+		msg, err := flatrpc.Parse[*flatrpc.ConnectReplyRaw](entry.msg)
+		if err != nil {
+			return fmt.Errorf("bad journal message: %w", err)
+		}
+		rp.numProcs = int(msg.Procs)
+		return flatrpc.Send(rp.conn, msg)
+	case journalInfoReply:
+		msg, err := flatrpc.Parse[*flatrpc.InfoReplyRaw](entry.msg)
+		if err != nil {
+			return fmt.Errorf("bad journal message: %w", err)
+		}
+		// The machine check may take different time on the new VM,
+		// so the timing of the rest of the session is relative to this point.
+		if _, err := flatrpc.Recv[*flatrpc.InfoRequestRaw](rp.conn); err != nil {
+			return err
+		}
+		if err := flatrpc.Send(rp.conn, msg); err != nil {
+			return err
+		}
+		rp.start = time.Now().Add(-entry.time)
+		go rp.recvLoop()
+		return nil
+	case journalTruncated:
+		rp.realign = true
+		return nil
+	case journalHostMessage:
+		msg, err := flatrpc.Parse[*flatrpc.HostMessageRaw](entry.msg)
+		if err != nil {
+			return fmt.Errorf("bad journal message: %w", err)
+		}
+		if rp.start.IsZero() {
+			return fmt.Errorf("host message before the handshake in the journal")
+		}
+		if rp.realign {
+			rp.start = time.Now().Add(-entry.time)
+			rp.realign = false
+		}
+		if err := rp.wait(ctx, time.After(time.Until(rp.start.Add(entry.time)))); err != nil {
+			return err
+		}
+		switch val := msg.Msg.Value.(type) {
+		case *flatrpc.ExecRequest:
+			rp.prepare(val)
+		case *flatrpc.ExecRequestBatch:
+			for _, req := range val.Requests {
+				rp.prepare(req)
+			}
+		}
+		return flatrpc.Send(rp.conn, msg)
+	}
+	return nil
+}
+
+func (rp *replayer) prepare(req *flatrpc.ExecRequest) {
+	if proc, ok := rp.procs[req.Id]; ok && proc < min(rp.numProcs, 64) {
+		// Executor runs the request on a proc that is not avoided.
+		req.Avoid = ^(uint64(1) << proc)
+	}
+	rp.mu.Lock()
+	rp.pending[req.Id] = true
+	rp.mu.Unlock()
+}
+
+// wait waits for the timeout channel, or returns an error if the replay has failed.
+// If timeout is nil, it returns after the next finished request.
+func (rp *replayer) wait(ctx context.Context, timeout <-chan time.Time) error {
+	var finished <-chan struct{}
+	if timeout == nil {
+		finished = rp.finished
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-rp.errc:
+		return err
+	case <-timeout:
+		return nil
+	case <-finished:
+		return nil
+	}
+}
+
+func (rp *replayer) numPending() int {
+	rp.mu.Lock()
+	defer rp.mu.Unlock()
+	return len(rp.pending)
+}
+
+// recvLoop receives executor messages in the background, the executor may block otherwise.
+func (rp *replayer) recvLoop() {
+	for {
+		raw, err := flatrpc.Recv[*flatrpc.ExecutorMessageRaw](rp.conn)
+		if err != nil {
+			rp.errc <- err
+			return
+		}
+		if raw.Msg == nil || raw.Msg.Value == nil {
+			rp.errc <- fmt.Errorf("received no message")
+			return
+		}
+		var results []*flatrpc.ExecResult
+		switch val := raw.Msg.Value.(type) {
+		case *flatrpc.ExecResult:
+			results = append(results, val)
+		case *flatrpc.ExecResultBatch:
+			if results, err = val.Unpack(); err != nil {
+				rp.errc <- err
+				return
+			}
+		}
+		if len(results) == 0 {
+			continue
+		}
+		rp.mu.Lock()
+		for _, res := range results {
+			delete(rp.pending, res.Id)
+		}
+		rp.mu.Unlock()
+		select {
+		case rp.finished <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// journalProcs returns procs that executed the requests in the recorded session.
+func journalProcs(file string) (map[int64]int, error) {
+	r, err := openJournal(file)
+	if err != nil {
+		return nil, err
+	}
+	defer r.close()
+	procs := make(map[int64]int)
+	for {
+		entry, err := r.next()
+		if err == io.EOF {
+			return procs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := procs[entry.id]; entry.kind == journalExecuting && !ok {
+			procs[entry.id] = entry.proc
+		}
+	}
+}
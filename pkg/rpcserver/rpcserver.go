@@ -38,13 +38,19 @@ type Config struct {
 	VMArch string
 	VMType string
 	RPC    string
+	// If set, connections are authenticated and encrypted with the pre-shared key.
+	RPCKey []byte
 	VMLess bool
 	// Hash adjacent PCs to form fuzzing feedback signal (otherwise just use coverage PCs as signal).
 	UseCoverEdges bool
 	// Filter signal/comparisons against target kernel text/data ranges.
 	// Disabled for gVisor/Starnix which are not Linux.
-	FilterSignal      bool
+	FilterSignal bool
+	// Additional feedback metrics that executor reports for each call (see fuzzer.Config.Feedback).
+	Feedback          []flatrpc.FeedbackKind
 	PrintMachineCheck bool
+	// If set, sessions with VMs are recorded to journal files in this dir (see JournalFile).
+	JournalDir string
 	// Abort early on syz-executor not replying to requests and print extra debugging information.
 	DebugTimeouts bool
 	Procs         int
@@ -58,9 +64,10 @@ type Config struct {
 
 type RemoteConfig struct {
 	*mgrconfig.Config
-	Manager Manager
-	Stats   Stats
-	Debug   bool
+	Manager    Manager
+	Stats      Stats
+	Debug      bool
+	JournalDir string
 }
 
 //go:generate ../../tools/mockery.sh --name Manager --output ./mocks
@@ -173,12 +180,15 @@ func New(cfg *RemoteConfig) (Server, error) {
 		Stats:  cfg.Stats,
 		VMArch: cfg.TargetVMArch,
 		RPC:    cfg.RPC,
+		RPCKey: []byte(cfg.RPCKey),
 		VMLess: cfg.VMLess,
 		// gVisor coverage is not a trace, so producing edges won't work.
 		UseCoverEdges: cfg.Experimental.CoverEdges && cfg.Type != targets.GVisor,
 		// gVisor/Starnix are not Linux, so filtering against Linux ranges won't work.
 		FilterSignal:      cfg.Type != targets.GVisor && cfg.Type != targets.Starnix,
+		Feedback:          flatrpc.FeedbackKinds(cfg.Experimental.Feedback),
 		PrintMachineCheck: true,
+		JournalDir:        cfg.JournalDir,
 		Procs:             cfg.Procs,
 		Slowdown:          cfg.Timeouts.Slowdown,
 		pcBase:            pcBase,
@@ -211,6 +221,8 @@ func newImpl(cfg *Config, mgr Manager) *server {
 				stat.Rate{}, stat.Graph("executor")),
 			statExecutorRestarts: stat.New("executor restarts",
 				"Number of times executor process was restarted", stat.Rate{}, stat.Graph("executor")),
+			statExecBatchSize: stat.New("exec batch size",
+				"Number of exec requests sent to executor in a single message", stat.Distribution{}),
 			statExecBufferTooSmall: queue.StatExecBufferTooSmall,
 			statExecs:              cfg.Stats.StatExecs,
 			statNoExecRequests:     queue.StatNoExecRequests,
@@ -224,7 +236,7 @@ func (serv *server) Close() error {
 }
 
 func (serv *server) Listen() error {
-	s, err := flatrpc.Listen(serv.cfg.RPC)
+	s, err := flatrpc.ListenSecure(serv.cfg.RPC, serv.cfg.RPCKey)
 	if err != nil {
 		return err
 	}
@@ -518,6 +530,7 @@ func (serv *server) CreateInstance(id int, injectExec chan<- bool, updInfo dispa
 		cover:         serv.cfg.Cover,
 		coverEdges:    serv.cfg.UseCoverEdges,
 		filterSignal:  serv.cfg.FilterSignal,
+		feedback:      serv.cfg.Feedback,
 		debug:         serv.cfg.Debug,
 		debugTimeouts: serv.cfg.DebugTimeouts,
 		sysTarget:     serv.sysTarget,
@@ -533,6 +546,13 @@ func (serv *server) CreateInstance(id int, injectExec chan<- bool, updInfo dispa
 		updInfo:  updInfo,
 		resultCh: make(chan error, 1),
 	}
+	if serv.cfg.JournalDir != "" {
+		journal, err := CreateJournal(JournalFile(serv.cfg.JournalDir, id))
+		if err != nil {
+			log.Logf(0, "%v", err)
+		}
+		runner.journal = journal
+	}
 	serv.mu.Lock()
 	defer serv.mu.Unlock()
 	if serv.runners[id] != nil {
@@ -31,6 +31,7 @@ type Runner struct {
 	cover         bool
 	coverEdges    bool
 	filterSignal  bool
+	feedback      []flatrpc.FeedbackKind
 	debug         bool
 	debugTimeouts bool
 	sysTarget     *targets.Target
@@ -44,6 +45,7 @@ type Runner struct {
 	executing     map[int64]bool
 	hanged        map[int64]bool
 	lastExec      *LastExecuting
+	journal       *Journal // nil if journaling is disabled
 	updInfo       dispatcher.UpdateInfo
 	resultCh      chan error
 
@@ -61,6 +63,7 @@ type runnerStats struct {
 	statExecBufferTooSmall *stat.Val
 	statNoExecRequests     *stat.Val
 	statNoExecDuration     *stat.Val
+	statExecBatchSize      *stat.Val
 }
 
 type handshakeConfig struct {
@@ -104,7 +107,9 @@ func (runner *Runner) Handshake(conn *flatrpc.Conn, cfg *handshakeConfig) (hands
 		RaceFrames:       cfg.RaceFrames,
 		Files:            cfg.Files,
 		Features:         cfg.Features,
+		Feedback:         len(runner.feedback) != 0,
 	}
+	runner.journal.message(journalConnectReply, connectReply)
 	if err := flatrpc.Send(conn, connectReply); err != nil {
 		return handshakeResult{}, err
 	}
@@ -119,6 +124,7 @@ func (runner *Runner) Handshake(conn *flatrpc.Conn, cfg *handshakeConfig) (hands
 	infoReply := &flatrpc.InfoReply{
 		CoverFilter: ret.CovFilter,
 	}
+	runner.journal.message(journalInfoReply, infoReply)
 	if err := flatrpc.Send(conn, infoReply); err != nil {
 		return handshakeResult{}, err
 	}
@@ -174,12 +180,11 @@ func (runner *Runner) ConnectionLoop() error {
 			default:
 			}
 		}
-		for len(runner.requests)-len(runner.executing) < 2*runner.procs {
-			req := runner.source.Next(runner.id)
-			if req == nil {
-				break
-			}
-			if err := runner.sendRequest(req); err != nil {
+		// The executor has up to 2*procs queued requests. To reduce per-message overhead,
+		// we refill the queue only when at least half of it is free (the other half keeps
+		// all procs busy meanwhile), and send all new requests in a single message.
+		if queued := len(runner.requests) - len(runner.executing); queued <= runner.procs {
+			if err := runner.sendRequests(2*runner.procs - queued); err != nil {
 				return err
 			}
 		}
@@ -203,6 +208,8 @@ func (runner *Runner) ConnectionLoop() error {
 			err = runner.handleExecutingMessage(msg)
 		case *flatrpc.ExecResult:
 			err = runner.handleExecResult(msg)
+		case *flatrpc.ExecResultBatch:
+			err = runner.handleExecResultBatch(msg)
 		case *flatrpc.StateResult:
 			buf := new(bytes.Buffer)
 			fmt.Fprintf(buf, "pending requests on the VM:")
@@ -267,10 +274,64 @@ func (runner *Runner) sendStateRequest() error {
 			Value: &flatrpc.StateRequest{},
 		},
 	}
+	return runner.send(msg)
+}
+
+func (runner *Runner) send(msg *flatrpc.HostMessage) error {
+	runner.journal.message(journalHostMessage, msg)
 	return flatrpc.Send(runner.conn, msg)
 }
 
-func (runner *Runner) sendRequest(req *queue.Request) error {
+// Batches are capped to keep messages reasonably small for the executor (program data may be up to 4MB).
+const maxRequestBatchSize = 4 << 20
+
+// sendRequests sends up to n new requests to the executor.
+func (runner *Runner) sendRequests(n int) error {
+	var batch []*flatrpc.ExecRequest
+	batchSize := 0
+	for sent := 0; sent < n; {
+		req := runner.source.Next(runner.id)
+		if req == nil {
+			break
+		}
+		msg := runner.newExecRequest(req)
+		if msg == nil {
+			continue
+		}
+		sent++
+		batch = append(batch, msg)
+		batchSize += len(msg.Data)
+		if batchSize >= maxRequestBatchSize {
+			if err := runner.sendBatch(batch); err != nil {
+				return err
+			}
+			batch, batchSize = nil, 0
+		}
+	}
+	return runner.sendBatch(batch)
+}
+
+func (runner *Runner) sendBatch(reqs []*flatrpc.ExecRequest) error {
+	msg := &flatrpc.HostMessage{
+		Msg: &flatrpc.HostMessages{},
+	}
+	switch len(reqs) {
+	case 0:
+		return nil
+	case 1:
+		msg.Msg.Type = flatrpc.HostMessagesRawExecRequest
+		msg.Msg.Value = reqs[0]
+	default:
+		msg.Msg.Type = flatrpc.HostMessagesRawExecRequestBatch
+		msg.Msg.Value = &flatrpc.ExecRequestBatch{Requests: reqs}
+	}
+	runner.stats.statExecBatchSize.Add(len(reqs))
+	return runner.send(msg)
+}
+
+// newExecRequest registers the request as pending and returns the message to send to the executor.
+// It returns nil if the request has already failed.
+func (runner *Runner) newExecRequest(req *queue.Request) *flatrpc.ExecRequest {
 	if err := req.Validate(); err != nil {
 		panic(err)
 	}
@@ -329,22 +390,17 @@ func (runner *Runner) sendRequest(req *queue.Request) error {
 			avoid |= uint64(1 << id.Proc)
 		}
 	}
-	msg := &flatrpc.HostMessage{
-		Msg: &flatrpc.HostMessages{
-			Type: flatrpc.HostMessagesRawExecRequest,
-			Value: &flatrpc.ExecRequest{
-				Id:        id,
-				Type:      req.Type,
-				Avoid:     avoid,
-				Data:      data,
-				Flags:     flags,
-				ExecOpts:  &opts,
-				AllSignal: allSignal,
-			},
-		},
-	}
 	runner.requests[id] = req
-	return flatrpc.Send(runner.conn, msg)
+	req.TraceEvent("send", "vm", runner.id, "id", id)
+	return &flatrpc.ExecRequest{
+		Id:        id,
+		Type:      req.Type,
+		Avoid:     avoid,
+		Data:      data,
+		Flags:     flags,
+		ExecOpts:  &opts,
+		AllSignal: allSignal,
+	}
 }
 
 func (runner *Runner) handleExecutingMessage(msg *flatrpc.ExecutingMessage) error {
@@ -382,15 +438,33 @@ func (runner *Runner) handleExecutingMessage(msg *flatrpc.ExecutingMessage) erro
 		panic(fmt.Sprintf("unhandled request type %v", req.Type))
 	}
 	runner.lastExec.Note(int(msg.Id), proc, data, osutil.MonotonicNano())
+	runner.journal.Executing(msg.Id, proc, data)
 	select {
 	case runner.injectExec <- true:
 	default:
 	}
 	runner.executing[msg.Id] = true
+	req.TraceEvent("executing", "proc", proc, "try", int(msg.Try))
+	return nil
+}
+
+func (runner *Runner) handleExecResultBatch(batch *flatrpc.ExecResultBatch) error {
+	results, err := batch.Unpack()
+	if err != nil {
+		return err
+	}
+	for _, res := range results {
+		if err := runner.handleExecResult(res); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (runner *Runner) handleExecResult(msg *flatrpc.ExecResult) error {
+	if err := msg.Info.UnpackCover(); err != nil {
+		return err
+	}
 	req := runner.requests[msg.Id]
 	if req == nil {
 		if runner.hanged[msg.Id] {
@@ -521,7 +595,7 @@ func (runner *Runner) SendSignalUpdate(plus []uint64) error {
 			},
 		},
 	}
-	return flatrpc.Send(runner.conn, msg)
+	return runner.send(msg)
 }
 
 func (runner *Runner) SendCorpusTriaged() error {
@@ -531,7 +605,7 @@ func (runner *Runner) SendCorpusTriaged() error {
 			Value: &flatrpc.CorpusTriaged{},
 		},
 	}
-	return flatrpc.Send(runner.conn, msg)
+	return runner.send(msg)
 }
 
 func (runner *Runner) Stop() {
@@ -554,6 +628,12 @@ func (runner *Runner) Shutdown(crashed bool, extraExecs ...report.ExecutorInfo)
 		// Wait for the connection goroutine to finish and stop touching data.
 		<-finished
 	}
+	if crashed {
+		runner.journal.Crashed()
+	}
+	if err := runner.journal.Close(); err != nil {
+		log.Logf(0, "failed to write journal: %v", err)
+	}
 	records := runner.lastExec.Collect()
 	for _, info := range extraExecs {
 		req := runner.requests[int64(info.ExecID)]
@@ -0,0 +1,98 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package signal
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// SetCover selects a subset of corpus that covers all signal elements present in the corpus,
+// each with the highest priority it has in the corpus. Unlike Minimize, it uses the greedy
+// weighted set cover algorithm: on each step it takes the input that covers the largest number
+// of not yet covered elements per unit of cost. cost[i] is the cost of corpus[i] and must be positive.
+// Returns sorted indices of the selected inputs.
+func SetCover(corpus []Context, cost []float64) []int {
+	best := make(map[elemType]prioType)
+	for _, inp := range corpus {
+		for e, p := range inp.Signal {
+			if prev, ok := best[e]; !ok || p > prev {
+				best[e] = p
+			}
+		}
+	}
+	covered := make(map[elemType]bool, len(best))
+	gain := func(idx int) int {
+		n := 0
+		for e, p := range corpus[idx].Signal {
+			if p == best[e] && !covered[e] {
+				n++
+			}
+		}
+		return n
+	}
+	h := new(coverHeap)
+	for i := range corpus {
+		if n := gain(i); n != 0 {
+			h.items = append(h.items, coverItem{idx: i, gain: n, ratio: float64(n) / cost[i]})
+		}
+	}
+	heap.Init(h)
+	var result []int
+	// Gains can only decrease as more elements are covered, so we update the gain
+	// of the top item lazily and take it only if it's still the best after the update.
+	for h.Len() != 0 {
+		top := &h.items[0]
+		n := gain(top.idx)
+		if n == 0 {
+			heap.Pop(h)
+			continue
+		}
+		if n != top.gain {
+			top.gain = n
+			top.ratio = float64(n) / cost[top.idx]
+			heap.Fix(h, 0)
+			continue
+		}
+		idx := top.idx
+		heap.Pop(h)
+		result = append(result, idx)
+		for e, p := range corpus[idx].Signal {
+			if p == best[e] {
+				covered[e] = true
+			}
+		}
+	}
+	sort.Ints(result)
+	return result
+}
+
+type coverItem struct {
+	idx   int
+	gain  int
+	ratio float64
+}
+
+type coverHeap struct {
+	items []coverItem
+}
+
+func (h *coverHeap) Len() int { return len(h.items) }
+
+func (h *coverHeap) Less(i, j int) bool {
+	if h.items[i].ratio != h.items[j].ratio {
+		return h.items[i].ratio > h.items[j].ratio
+	}
+	return h.items[i].idx < h.items[j].idx
+}
+
+func (h *coverHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *coverHeap) Push(x any) { h.items = append(h.items, x.(coverItem)) }
+
+func (h *coverHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
@@ -4,6 +4,11 @@
 // Package signal provides types for working with feedback signal.
 package signal
 
+import (
+	"encoding/binary"
+	"fmt"
+)
+
 type (
 	elemType uint64
 	prioType int8
@@ -183,6 +188,39 @@ func (s Signal) ToRaw() []uint64 {
 	return raw
 }
 
+// Serialize encodes the signal in a compact binary form that can be stored on disk.
+func (s Signal) Serialize() []byte {
+	buf := make([]byte, 0, binary.MaxVarintLen64+len(s)*(binary.MaxVarintLen64+1))
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	for e, p := range s {
+		buf = binary.AppendUvarint(buf, uint64(e))
+		buf = append(buf, byte(p))
+	}
+	return buf
+}
+
+// Deserialize decodes signal encoded with Serialize and returns the unconsumed part of data.
+func Deserialize(data []byte) (Signal, []byte, error) {
+	n, size := binary.Uvarint(data)
+	if size <= 0 || n > uint64(len(data)) {
+		return nil, nil, fmt.Errorf("bad signal length")
+	}
+	data = data[size:]
+	if n == 0 {
+		return nil, data, nil
+	}
+	s := make(Signal, n)
+	for i := uint64(0); i < n; i++ {
+		e, size := binary.Uvarint(data)
+		if size <= 0 || size >= len(data) {
+			return nil, nil, fmt.Errorf("truncated signal element %v", i)
+		}
+		s[elemType(e)] = prioType(data[size])
+		data = data[size+1:]
+	}
+	return s, data, nil
+}
+
 type Context struct {
 	Signal  Signal
 	Context interface{}
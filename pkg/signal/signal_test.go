@@ -25,3 +25,37 @@ func TestIntersectsWith(t *testing.T) {
 	// The other signal has a lower priority.
 	assert.False(t, base.IntersectsWith(FromRaw([]uint64{0, 1, 2}, 0)))
 }
+
+func TestSerialize(t *testing.T) {
+	base := FromRaw([]uint64{0, 1, 1 << 40}, 1)
+	base.Merge(FromRaw([]uint64{2, 3}, 3))
+	data := append(base.Serialize(), 0xaa)
+	s, rest, err := Deserialize(data)
+	assert.NoError(t, err)
+	assert.Equal(t, base, s)
+	assert.Equal(t, []byte{0xaa}, rest)
+
+	s, rest, err = Deserialize(Signal(nil).Serialize())
+	assert.NoError(t, err)
+	assert.True(t, s.Empty())
+	assert.Empty(t, rest)
+
+	_, _, err = Deserialize(data[:len(data)-3])
+	assert.Error(t, err)
+}
+
+func TestSetCover(t *testing.T) {
+	corpus := []Context{
+		{Signal: FromRaw([]uint64{1, 2, 3, 4}, 1)},
+		{Signal: FromRaw([]uint64{1, 2}, 1)},
+		{Signal: FromRaw([]uint64{3, 4}, 1)},
+		{Signal: FromRaw([]uint64{5}, 0)},
+		{Signal: FromRaw([]uint64{5}, 1)},
+		{Signal: FromRaw([]uint64{1}, 0)},
+	}
+	// The first input is the cheapest way to cover 1..4.
+	assert.Equal(t, []int{0, 4}, SetCover(corpus, []float64{1.5, 1, 1, 1, 1, 1}))
+	// Two smaller inputs are cheaper than the big one.
+	assert.Equal(t, []int{1, 2, 4}, SetCover(corpus, []float64{5, 1, 1, 1, 1, 1}))
+	assert.Empty(t, SetCover(nil, nil))
+}
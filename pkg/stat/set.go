@@ -256,6 +256,10 @@ type Val struct {
 	histVal *gohistogram.NumericHistogram
 }
 
+func (v *Val) Name() string {
+	return v.name
+}
+
 func (v *Val) Add(val int) {
 	if v.ext != nil {
 		panic(fmt.Sprintf("stat %v is in external mode", v.name))
@@ -0,0 +1,395 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmcore
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Analyzer extracts kernel state from the guest memory.
+type Analyzer struct {
+	mem    Memory
+	layout Layout
+	offset uint64
+}
+
+type Task struct {
+	Addr      uint64
+	PID       int
+	Comm      string
+	State     string
+	HeldLocks []HeldLock // only with lockdep
+}
+
+type HeldLock struct {
+	Name string
+	IP   uint64 // link-time address of the place the lock was acquired at
+	Func string
+}
+
+const (
+	// Upper limits for the number of list elements to walk, the memory may be corrupted.
+	maxTasks   = 1 << 16
+	maxRecords = 1 << 20
+	maxStrLen  = 256
+)
+
+// NewAnalyzer creates an analyzer for the kernel loaded at the KASLR offset.
+// The offset is verified by checking that init_task is where it's expected to be.
+func NewAnalyzer(mem Memory, layout Layout, kernelOffset uint64) (*Analyzer, error) {
+	a := &Analyzer{
+		mem:    mem,
+		layout: layout,
+		offset: kernelOffset,
+	}
+	initTask, err := a.symbol("init_task")
+	if err != nil {
+		return nil, err
+	}
+	comm, err := a.field(initTask, "task_struct", "comm")
+	if err != nil {
+		return nil, err
+	}
+	name, err := a.str(comm, 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read init_task (wrong kernel offset 0x%x?): %w", kernelOffset, err)
+	}
+	if len(name) < 7 || name[:7] != "swapper" {
+		return nil, fmt.Errorf("init_task has unexpected name %q (wrong vmlinux or kernel offset 0x%x?)",
+			name, kernelOffset)
+	}
+	return a, nil
+}
+
+// Dmesg returns the kernel log from the printk ring buffer (kernels 5.10+).
+func (a *Analyzer) Dmesg() ([]byte, error) {
+	prbAddr, err := a.symbol("prb")
+	if err != nil {
+		return nil, err
+	}
+	prb, err := a.u64(prbAddr)
+	if err != nil {
+		return nil, err
+	}
+	ring := &printkRing{a: a}
+	for _, v := range []struct {
+		field string
+		val   *uint64
+		size  int
+	}{
+		{"desc_ring.count_bits", &ring.countBits, 4},
+		{"desc_ring.descs", &ring.descs, 8},
+		{"desc_ring.infos", &ring.infos, 8},
+		{"desc_ring.head_id", &ring.headID, 8},
+		{"desc_ring.tail_id", &ring.tailID, 8},
+		{"text_data_ring.size_bits", &ring.sizeBits, 4},
+		{"text_data_ring.data", &ring.data, 8},
+	} {
+		addr, err := a.field(prb, "printk_ringbuffer", v.field)
+		if err != nil {
+			return nil, err
+		}
+		if *v.val, err = a.uint(addr, v.size); err != nil {
+			return nil, err
+		}
+	}
+	if ring.countBits >= 32 || ring.sizeBits >= 40 {
+		return nil, fmt.Errorf("corrupted printk ring buffer: count bits %v, size bits %v",
+			ring.countBits, ring.sizeBits)
+	}
+	return ring.read()
+}
+
+type printkRing struct {
+	a         *Analyzer
+	countBits uint64
+	descs     uint64
+	infos     uint64
+	headID    uint64
+	tailID    uint64
+	sizeBits  uint64
+	data      uint64
+}
+
+const (
+	descStateShift  = 62
+	descIDMask      = 1<<descStateShift - 1
+	descCommitted   = 1
+	descFinalized   = 2
+	failedLPos      = 1
+	dataBlockHeader = 8 // the descriptor id stored before the text
+)
+
+func (ring *printkRing) read() ([]byte, error) {
+	a := ring.a
+	descSize, err := a.layout.Size("prb_desc")
+	if err != nil {
+		return nil, err
+	}
+	infoSize, err := a.layout.Size("printk_info")
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[string]uint64)
+	for _, f := range []struct{ typ, field string }{
+		{"prb_desc", "state_var"},
+		{"prb_desc", "text_blk_lpos.begin"},
+		{"prb_desc", "text_blk_lpos.next"},
+		{"printk_info", "ts_nsec"},
+		{"printk_info", "text_len"},
+	} {
+		if offsets[f.field], err = a.layout.Offset(f.typ, f.field); err != nil {
+			return nil, err
+		}
+	}
+	buf := new(bytes.Buffer)
+	for id, n := ring.tailID&descIDMask, 0; n < maxRecords; id, n = (id+1)&descIDMask, n+1 {
+		idx := id & (1<<ring.countBits - 1)
+		desc := ring.descs + idx*descSize
+		info := ring.infos + idx*infoSize
+		state, err := a.u64(desc + offsets["state_var"])
+		if err != nil {
+			return nil, err
+		}
+		if state&descIDMask == id && (state>>descStateShift == descCommitted ||
+			state>>descStateShift == descFinalized) {
+			text, err := ring.text(desc+offsets["text_blk_lpos.begin"], desc+offsets["text_blk_lpos.next"],
+				info+offsets["text_len"])
+			if err != nil {
+				return nil, err
+			}
+			ts, err := a.u64(info + offsets["ts_nsec"])
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(buf, "[%5d.%06d] %s\n", ts/1e9, ts%1e9/1e3, text)
+		}
+		if id == ring.headID&descIDMask {
+			break
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (ring *printkRing) text(beginAddr, nextAddr, lenAddr uint64) ([]byte, error) {
+	a := ring.a
+	begin, err := a.u64(beginAddr)
+	if err != nil {
+		return nil, err
+	}
+	next, err := a.u64(nextAddr)
+	if err != nil {
+		return nil, err
+	}
+	textLen, err := a.uint(lenAddr, 2)
+	if err != nil {
+		return nil, err
+	}
+	if begin&failedLPos != 0 || begin == next {
+		return nil, nil
+	}
+	size := uint64(1) << ring.sizeBits
+	index := func(lpos uint64) uint64 { return lpos & (size - 1) }
+	wraps := func(lpos uint64) uint64 { return lpos >> ring.sizeBits }
+	var start, blockLen uint64
+	switch {
+	case wraps(begin) == wraps(next) && index(begin) < index(next):
+		start, blockLen = index(begin), next-begin
+	case wraps(begin+size) == wraps(next):
+		// The block wrapped around, the data is at the beginning of the buffer.
+		start, blockLen = 0, index(next)
+	default:
+		return nil, fmt.Errorf("corrupted printk record lpos [0x%x, 0x%x)", begin, next)
+	}
+	if blockLen < dataBlockHeader {
+		return nil, fmt.Errorf("corrupted printk record lpos [0x%x, 0x%x)", begin, next)
+	}
+	return a.mem.Read(ring.data+start+dataBlockHeader, int(min(textLen, blockLen-dataBlockHeader)))
+}
+
+// Tasks returns all tasks in the system (as linked in the init_task.tasks list).
+func (a *Analyzer) Tasks() ([]*Task, error) {
+	initTask, err := a.symbol("init_task")
+	if err != nil {
+		return nil, err
+	}
+	tasksOff, err := a.layout.Offset("task_struct", "tasks")
+	if err != nil {
+		return nil, err
+	}
+	var tasks []*Task
+	for addr := initTask; len(tasks) < maxTasks; {
+		task, err := a.task(addr)
+		if err != nil {
+			return tasks, err
+		}
+		tasks = append(tasks, task)
+		next, err := a.u64(addr + tasksOff)
+		if err != nil {
+			return tasks, err
+		}
+		addr = next - tasksOff
+		if addr == initTask {
+			break
+		}
+	}
+	return tasks, nil
+}
+
+func (a *Analyzer) task(addr uint64) (*Task, error) {
+	task := &Task{Addr: addr}
+	pidAddr, err := a.field(addr, "task_struct", "pid")
+	if err != nil {
+		return nil, err
+	}
+	pid, err := a.uint(pidAddr, 4)
+	if err != nil {
+		return nil, err
+	}
+	task.PID = int(int32(pid))
+	commAddr, err := a.field(addr, "task_struct", "comm")
+	if err != nil {
+		return nil, err
+	}
+	if task.Comm, err = a.str(commAddr, 16); err != nil {
+		return nil, err
+	}
+	// The field is called state before 5.14 and has a different size.
+	stateAddr, err := a.field(addr, "task_struct", "__state")
+	stateSize := 4
+	if err != nil {
+		stateAddr, err = a.field(addr, "task_struct", "state")
+		stateSize = 8
+	}
+	if err != nil {
+		return nil, err
+	}
+	state, err := a.uint(stateAddr, stateSize)
+	if err != nil {
+		return nil, err
+	}
+	task.State = taskState(state)
+	if task.HeldLocks, err = a.heldLocks(addr); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// heldLocks returns locks held by the task, or nil if the kernel is built without lockdep.
+func (a *Analyzer) heldLocks(task uint64) ([]HeldLock, error) {
+	depthAddr, err := a.field(task, "task_struct", "lockdep_depth")
+	if err != nil {
+		return nil, nil
+	}
+	depth, err := a.uint(depthAddr, 4)
+	if err != nil {
+		return nil, err
+	}
+	locksAddr, err := a.field(task, "task_struct", "held_locks")
+	if err != nil {
+		return nil, err
+	}
+	lockSize, err := a.layout.Size("held_lock")
+	if err != nil {
+		return nil, err
+	}
+	instanceOff, err := a.layout.Offset("held_lock", "instance")
+	if err != nil {
+		return nil, err
+	}
+	ipOff, err := a.layout.Offset("held_lock", "acquire_ip")
+	if err != nil {
+		return nil, err
+	}
+	nameOff, err := a.layout.Offset("lockdep_map", "name")
+	if err != nil {
+		return nil, err
+	}
+	// MAX_LOCK_DEPTH is 48.
+	if depth > 48 {
+		return nil, fmt.Errorf("corrupted lockdep depth %v", depth)
+	}
+	var locks []HeldLock
+	for i := uint64(0); i < depth; i++ {
+		lock := locksAddr + i*lockSize
+		instance, err := a.u64(lock + instanceOff)
+		if err != nil {
+			return nil, err
+		}
+		ip, err := a.u64(lock + ipOff)
+		if err != nil {
+			return nil, err
+		}
+		held := HeldLock{IP: ip - a.offset}
+		held.Func = a.layout.Func(held.IP)
+		if namePtr, err := a.u64(instance + nameOff); err == nil {
+			held.Name, _ = a.str(namePtr, maxStrLen)
+		}
+		locks = append(locks, held)
+	}
+	return locks, nil
+}
+
+func taskState(state uint64) string {
+	const (
+		taskUninterruptible = 0x2
+		taskNoLoad          = 0x400
+	)
+	if state&(taskUninterruptible|taskNoLoad) == taskUninterruptible|taskNoLoad {
+		return "I"
+	}
+	// The same letters as in task_state_to_char().
+	for i, c := range "SDTtXZP" {
+		if state&(1<<i) != 0 {
+			return string(c)
+		}
+	}
+	return "R"
+}
+
+func (a *Analyzer) symbol(name string) (uint64, error) {
+	addr, err := a.layout.Symbol(name)
+	if err != nil {
+		return 0, err
+	}
+	return addr + a.offset, nil
+}
+
+func (a *Analyzer) field(addr uint64, typ, field string) (uint64, error) {
+	off, err := a.layout.Offset(typ, field)
+	if err != nil {
+		return 0, err
+	}
+	return addr + off, nil
+}
+
+func (a *Analyzer) u64(addr uint64) (uint64, error) {
+	return a.uint(addr, 8)
+}
+
+func (a *Analyzer) uint(addr uint64, size int) (uint64, error) {
+	data, err := a.mem.Read(addr, size)
+	if err != nil {
+		return 0, err
+	}
+	var buf [8]byte
+	copy(buf[:], data)
+	return binary.LittleEndian.Uint64(buf[:]), nil
+}
+
+func (a *Analyzer) str(addr uint64, size int) (string, error) {
+	// Don't cross the page boundary, the next page may be not mapped.
+	const pageSize = 4096
+	size = min(size, pageSize-int(addr%pageSize))
+	data, err := a.mem.Read(addr, size)
+	if err != nil {
+		return "", err
+	}
+	if pos := bytes.IndexByte(data, 0); pos != -1 {
+		data = data[:pos]
+	}
+	return string(data), nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package vmcore extracts kernel state (log, tasks, held locks) from guest memory dumps
+// produced by qemu dump-guest-memory with paging enabled (ELF core with guest virtual addresses).
+// Only 64-bit little-endian kernels are supported.
+package vmcore
+
+import (
+	"bytes"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Memory provides access to the guest virtual memory.
+type Memory interface {
+	Read(addr uint64, size int) ([]byte, error)
+}
+
+// Dump is a guest memory dump in the ELF core format.
+type Dump struct {
+	file     *os.File
+	segments []*elf.Prog
+	info     map[string]string
+}
+
+func OpenDump(file string) (*Dump, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	ef, err := elf.NewFile(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to parse %v: %w", file, err)
+	}
+	if ef.Type != elf.ET_CORE || ef.Class != elf.ELFCLASS64 || ef.Data != elf.ELFDATA2LSB {
+		f.Close()
+		return nil, fmt.Errorf("%v is not a 64-bit little-endian ELF core", file)
+	}
+	dump := &Dump{
+		file: f,
+		info: make(map[string]string),
+	}
+	for _, prog := range ef.Progs {
+		switch prog.Type {
+		case elf.PT_LOAD:
+			dump.segments = append(dump.segments, prog)
+		case elf.PT_NOTE:
+			data := make([]byte, prog.Filesz)
+			if _, err := prog.ReadAt(data, 0); err != nil {
+				f.Close()
+				return nil, fmt.Errorf("failed to read notes: %w", err)
+			}
+			for name, desc := range parseNotes(data) {
+				if name == "VMCOREINFO" {
+					dump.info = parseVMCoreInfo(desc)
+				}
+			}
+		}
+	}
+	if len(dump.segments) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("%v has no memory segments", file)
+	}
+	return dump, nil
+}
+
+func (dump *Dump) Close() error {
+	return dump.file.Close()
+}
+
+// KernelOffset returns the KASLR offset from the VMCOREINFO note.
+// The note is present only if the VM has the vmcoreinfo device.
+func (dump *Dump) KernelOffset() (uint64, bool) {
+	val, ok := dump.info["KERNELOFFSET"]
+	if !ok {
+		return 0, false
+	}
+	offset, err := strconv.ParseUint(val, 16, 64)
+	return offset, err == nil
+}
+
+func (dump *Dump) Read(addr uint64, size int) ([]byte, error) {
+	data := make([]byte, size)
+	for pos := 0; pos < size; {
+		cur := addr + uint64(pos)
+		seg := dump.segment(cur)
+		if seg == nil {
+			return nil, fmt.Errorf("address 0x%x is not in the dump", cur)
+		}
+		off := cur - seg.Vaddr
+		n := min(uint64(size-pos), seg.Memsz-off)
+		if off < seg.Filesz {
+			// The part between filesz and memsz is zero.
+			if _, err := seg.ReadAt(data[pos:pos+int(min(n, seg.Filesz-off))], int64(off)); err != nil {
+				return nil, fmt.Errorf("failed to read 0x%x: %w", cur, err)
+			}
+		}
+		pos += int(n)
+	}
+	return data, nil
+}
+
+func (dump *Dump) segment(addr uint64) *elf.Prog {
+	for _, seg := range dump.segments {
+		if addr >= seg.Vaddr && addr-seg.Vaddr < seg.Memsz {
+			return seg
+		}
+	}
+	return nil
+}
+
+// parseNotes returns descriptors of ELF notes keyed by the note name.
+func parseNotes(data []byte) map[string][]byte {
+	notes := make(map[string][]byte)
+	align := func(v uint32) int { return int((v + 3) &^ 3) }
+	for len(data) >= 12 {
+		namesz := binary.LittleEndian.Uint32(data[0:])
+		descsz := binary.LittleEndian.Uint32(data[4:])
+		data = data[12:]
+		if align(namesz)+align(descsz) > len(data) {
+			break
+		}
+		name := string(bytes.TrimRight(data[:namesz], "\x00"))
+		data = data[align(namesz):]
+		notes[name] = data[:descsz]
+		data = data[align(descsz):]
+	}
+	return notes
+}
+
+// parseVMCoreInfo parses the VMCOREINFO note that consists of KEY=VALUE lines.
+func parseVMCoreInfo(data []byte) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, val, ok := strings.Cut(line, "="); ok {
+			info[key] = val
+		}
+	}
+	return info
+}
@@ -0,0 +1,224 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmcore
+
+import (
+	"debug/dwarf"
+	"debug/elf"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Layout describes kernel symbols and data structures.
+type Layout interface {
+	// Symbol returns the link-time address of the symbol.
+	Symbol(name string) (uint64, error)
+	// Func returns the name of the function that contains the link-time address, or "".
+	Func(addr uint64) string
+	// Offset returns the offset of the field in the struct,
+	// the field can be a dot-separated path for nested structs (e.g. "desc_ring.count_bits").
+	Offset(typ, field string) (uint64, error)
+	// Size returns the size of the struct.
+	Size(typ string) (uint64, error)
+}
+
+// Kernel is Layout based on vmlinux symbols and DWARF debug info.
+type Kernel struct {
+	file    *elf.File
+	dwarf   *dwarf.Data
+	symbols map[string]uint64
+	funcs   []elf.Symbol
+
+	mu      sync.Mutex
+	structs map[string]dwarf.Offset
+	types   map[string]*dwarf.StructType
+}
+
+func OpenKernel(vmlinux string) (*Kernel, error) {
+	file, err := elf.Open(vmlinux)
+	if err != nil {
+		return nil, err
+	}
+	data, err := file.DWARF()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read DWARF from %v: %w", vmlinux, err)
+	}
+	syms, err := file.Symbols()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to read symbols from %v: %w", vmlinux, err)
+	}
+	kernel := &Kernel{
+		file:    file,
+		dwarf:   data,
+		symbols: make(map[string]uint64),
+		types:   make(map[string]*dwarf.StructType),
+	}
+	for _, sym := range syms {
+		if sym.Value == 0 {
+			continue
+		}
+		if _, ok := kernel.symbols[sym.Name]; !ok {
+			kernel.symbols[sym.Name] = sym.Value
+		}
+		if elf.ST_TYPE(sym.Info) == elf.STT_FUNC {
+			kernel.funcs = append(kernel.funcs, sym)
+		}
+	}
+	sort.Slice(kernel.funcs, func(i, j int) bool {
+		return kernel.funcs[i].Value < kernel.funcs[j].Value
+	})
+	return kernel, nil
+}
+
+func (kernel *Kernel) Close() error {
+	return kernel.file.Close()
+}
+
+func (kernel *Kernel) Symbol(name string) (uint64, error) {
+	addr, ok := kernel.symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("no symbol %v", name)
+	}
+	return addr, nil
+}
+
+func (kernel *Kernel) Func(addr uint64) string {
+	idx := sort.Search(len(kernel.funcs), func(i int) bool {
+		return kernel.funcs[i].Value > addr
+	}) - 1
+	if idx < 0 {
+		return ""
+	}
+	fn := kernel.funcs[idx]
+	if fn.Size != 0 && addr-fn.Value >= fn.Size {
+		return ""
+	}
+	return fn.Name
+}
+
+func (kernel *Kernel) Offset(typ, field string) (uint64, error) {
+	st, err := kernel.structType(typ)
+	if err != nil {
+		return 0, err
+	}
+	var offset uint64
+	parts := strings.Split(field, ".")
+	for i, part := range parts {
+		f, off := findField(st, part)
+		if f == nil {
+			return 0, fmt.Errorf("struct %v has no field %v", typ, field)
+		}
+		offset += off
+		if i == len(parts)-1 {
+			break
+		}
+		next, ok := stripTypedefs(f.Type).(*dwarf.StructType)
+		if !ok {
+			return 0, fmt.Errorf("field %v.%v is not a struct", typ, strings.Join(parts[:i+1], "."))
+		}
+		st = next
+	}
+	return offset, nil
+}
+
+func (kernel *Kernel) Size(typ string) (uint64, error) {
+	st, err := kernel.structType(typ)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(st.ByteSize), nil
+}
+
+func (kernel *Kernel) structType(name string) (*dwarf.StructType, error) {
+	kernel.mu.Lock()
+	defer kernel.mu.Unlock()
+	if st := kernel.types[name]; st != nil {
+		return st, nil
+	}
+	if kernel.structs == nil {
+		if err := kernel.indexStructs(); err != nil {
+			return nil, err
+		}
+	}
+	off, ok := kernel.structs[name]
+	if !ok {
+		return nil, fmt.Errorf("no struct %v in debug info", name)
+	}
+	typ, err := kernel.dwarf.Type(off)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := typ.(*dwarf.StructType)
+	if !ok {
+		return nil, fmt.Errorf("%v is not a struct", name)
+	}
+	kernel.types[name] = st
+	return st, nil
+}
+
+// indexStructs finds the first complete definition of each struct,
+// parsing all types right away would take too long for vmlinux.
+func (kernel *Kernel) indexStructs() error {
+	kernel.structs = make(map[string]dwarf.Offset)
+	r := kernel.dwarf.Reader()
+	for {
+		ent, err := r.Next()
+		if err != nil {
+			return fmt.Errorf("failed to read DWARF: %w", err)
+		}
+		if ent == nil {
+			return nil
+		}
+		switch ent.Tag {
+		case dwarf.TagCompileUnit:
+			continue
+		case dwarf.TagStructType:
+			name, _ := ent.Val(dwarf.AttrName).(string)
+			decl, _ := ent.Val(dwarf.AttrDeclaration).(bool)
+			if _, ok := kernel.structs[name]; name != "" && !decl && !ok {
+				kernel.structs[name] = ent.Offset
+			}
+		}
+		if ent.Children {
+			r.SkipChildren()
+		}
+	}
+}
+
+// findField looks for the field also in anonymous struct/union members.
+func findField(st *dwarf.StructType, name string) (*dwarf.StructField, uint64) {
+	for _, f := range st.Field {
+		if f.Name == name {
+			return f, uint64(f.ByteOffset)
+		}
+	}
+	for _, f := range st.Field {
+		if f.Name != "" {
+			continue
+		}
+		if inner, ok := stripTypedefs(f.Type).(*dwarf.StructType); ok {
+			if res, off := findField(inner, name); res != nil {
+				return res, uint64(f.ByteOffset) + off
+			}
+		}
+	}
+	return nil, 0
+}
+
+func stripTypedefs(typ dwarf.Type) dwarf.Type {
+	for {
+		switch t := typ.(type) {
+		case *dwarf.TypedefType:
+			typ = t.Type
+		case *dwarf.QualType:
+			typ = t.Type
+		default:
+			return typ
+		}
+	}
+}
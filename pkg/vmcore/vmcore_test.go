@@ -0,0 +1,247 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vmcore
+
+import (
+	"encoding/binary"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testKernelOffset = 0x1000000
+
+func TestAnalyzerDmesg(t *testing.T) {
+	a, mem := testAnalyzer(t)
+	// A ring with 4 descriptors and 64 bytes of text data.
+	const (
+		prb   = 0xffff000000100000
+		descs = 0xffff000000200000
+		infos = 0xffff000000300000
+		data  = 0xffff000000400000
+	)
+	mem.put64(testLayout.symbols["prb"]+testKernelOffset, prb)
+	mem.put(prb+0, 2, 4) // count bits
+	mem.put64(prb+8, descs)
+	mem.put64(prb+16, infos)
+	mem.put64(prb+24, 6)  // head id
+	mem.put64(prb+32, 3)  // tail id
+	mem.put(prb+48, 6, 4) // size bits
+	mem.put64(prb+56, data)
+	mem.bytes(data, make([]byte, 64))
+	record := func(id, state, begin, next, ts uint64, text string) {
+		idx := id % 4
+		mem.put64(descs+idx*24, state<<62|id)
+		mem.put64(descs+idx*24+8, begin)
+		mem.put64(descs+idx*24+16, next)
+		mem.put64(infos+idx*32+8, ts)
+		mem.put(infos+idx*32+16, uint64(len(text)), 2)
+		if state != 0 {
+			pos := begin % 64
+			if begin/64 != next/64 {
+				pos = 0
+			}
+			mem.put64(data+pos, id)
+			mem.bytes(data+pos+8, []byte(text))
+		}
+	}
+	record(3, descCommitted, 16, 40, 1e9+123456789, "first line")
+	record(4, descFinalized, 40, 56, 2e9, "second")
+	// This record does not fit into the end of the ring, so it's placed at the beginning.
+	record(5, descFinalized, 56, 64+16, 3e9, "wrapped")
+	// Reserved records are not finished yet.
+	record(6, 0, 64+16, 64+32, 4e9, "")
+	out, err := a.Dmesg()
+	assert.NoError(t, err)
+	assert.Equal(t, "[    1.123456] first line\n[    2.000000] second\n[    3.000000] wrapped\n", string(out))
+}
+
+func TestAnalyzerTasks(t *testing.T) {
+	a, mem := testAnalyzer(t)
+	initTask := testLayout.symbols["init_task"] + testKernelOffset
+	const (
+		task1 = 0xffff000000500000
+		task2 = 0xffff000000600000
+		lock  = 0xffff000000700000
+		name  = 0xffff000000800000
+	)
+	task := func(addr, next, pid, state uint64, comm string) {
+		mem.put64(addr+128, next+128)
+		mem.put(addr+16, pid, 4)
+		mem.put(addr+20, state, 4)
+		mem.bytes(addr+24, append([]byte(comm), 0))
+		mem.put(addr+40, 0, 4)
+	}
+	task(initTask, task1, 0, 0, "swapper/0")
+	task(task1, task2, 1, 1, "init")
+	task(task2, initTask, 100, 0x402, "kworker/0:1")
+	mem.put(task1+40, 2, 4)
+	for i := uint64(0); i < 2; i++ {
+		mem.put64(task1+48+i*16, lock+i*8)
+		mem.put64(task1+48+i*16+8, 0xffffffff81000010+i+testKernelOffset)
+		mem.put64(lock+i*8, name)
+	}
+	mem.bytes(name, []byte("&mm->mmap_lock\x00"))
+	tasks, err := a.Tasks()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Task{
+		{Addr: initTask, PID: 0, Comm: "swapper/0", State: "R"},
+		{Addr: task1, PID: 1, Comm: "init", State: "S", HeldLocks: []HeldLock{
+			{Name: "&mm->mmap_lock", IP: 0xffffffff81000010, Func: "some_func"},
+			{Name: "&mm->mmap_lock", IP: 0xffffffff81000011, Func: "some_func"},
+		}},
+		{Addr: task2, PID: 100, Comm: "kworker/0:1", State: "I"},
+	}, tasks)
+}
+
+func TestAnalyzerWrongOffset(t *testing.T) {
+	mem := newTestMemory()
+	mem.bytes(testLayout.symbols["init_task"]+24, []byte("swapper/0"))
+	_, err := NewAnalyzer(mem, testLayout, testKernelOffset)
+	assert.Error(t, err)
+	_, err = NewAnalyzer(mem, testLayout, 0)
+	assert.NoError(t, err)
+}
+
+func TestParseNotes(t *testing.T) {
+	var data []byte
+	note := func(name, desc string) {
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(name)+1))
+		data = binary.LittleEndian.AppendUint32(data, uint32(len(desc)))
+		data = binary.LittleEndian.AppendUint32(data, 0)
+		data = append(data, name...)
+		data = append(data, make([]byte, 4-len(name)%4)...)
+		data = append(data, desc...)
+		data = append(data, make([]byte, (4-len(desc)%4)%4)...)
+	}
+	note("CORE", "regs")
+	note("VMCOREINFO", "OSRELEASE=6.10.0\nKERNELOFFSET=2a000000\n")
+	notes := parseNotes(data)
+	assert.Equal(t, "regs", string(notes["CORE"]))
+	info := parseVMCoreInfo(notes["VMCOREINFO"])
+	assert.Equal(t, map[string]string{"OSRELEASE": "6.10.0", "KERNELOFFSET": "2a000000"}, info)
+}
+
+func testAnalyzer(t *testing.T) (*Analyzer, *testMemory) {
+	mem := newTestMemory()
+	mem.bytes(testLayout.symbols["init_task"]+testKernelOffset+24, []byte("swapper/0"))
+	a, err := NewAnalyzer(mem, testLayout, testKernelOffset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a, mem
+}
+
+var testLayout = &fakeLayout{
+	symbols: map[string]uint64{
+		"init_task": 0xffffffff82000000,
+		"prb":       0xffffffff82100000,
+	},
+	funcs: map[uint64]string{
+		0xffffffff81000000: "some_func",
+	},
+	structs: map[string]uint64{
+		"prb_desc":    24,
+		"printk_info": 32,
+		"held_lock":   16,
+	},
+	offsets: map[string]uint64{
+		"printk_ringbuffer.desc_ring.count_bits":     0,
+		"printk_ringbuffer.desc_ring.descs":          8,
+		"printk_ringbuffer.desc_ring.infos":          16,
+		"printk_ringbuffer.desc_ring.head_id":        24,
+		"printk_ringbuffer.desc_ring.tail_id":        32,
+		"printk_ringbuffer.text_data_ring.size_bits": 48,
+		"printk_ringbuffer.text_data_ring.data":      56,
+		"prb_desc.state_var":                         0,
+		"prb_desc.text_blk_lpos.begin":               8,
+		"prb_desc.text_blk_lpos.next":                16,
+		"printk_info.ts_nsec":                        8,
+		"printk_info.text_len":                       16,
+		"task_struct.tasks":                          128,
+		"task_struct.pid":                            16,
+		"task_struct.__state":                        20,
+		"task_struct.comm":                           24,
+		"task_struct.lockdep_depth":                  40,
+		"task_struct.held_locks":                     48,
+		"held_lock.instance":                         0,
+		"held_lock.acquire_ip":                       8,
+		"lockdep_map.name":                           0,
+	},
+}
+
+type fakeLayout struct {
+	symbols map[string]uint64
+	funcs   map[uint64]string
+	structs map[string]uint64
+	offsets map[string]uint64
+}
+
+func (l *fakeLayout) Symbol(name string) (uint64, error) {
+	addr, ok := l.symbols[name]
+	if !ok {
+		return 0, fmt.Errorf("no symbol %v", name)
+	}
+	return addr, nil
+}
+
+func (l *fakeLayout) Func(addr uint64) string {
+	return l.funcs[addr&^0xfff]
+}
+
+func (l *fakeLayout) Offset(typ, field string) (uint64, error) {
+	off, ok := l.offsets[typ+"."+field]
+	if !ok {
+		return 0, fmt.Errorf("no field %v.%v", typ, field)
+	}
+	return off, nil
+}
+
+func (l *fakeLayout) Size(typ string) (uint64, error) {
+	size, ok := l.structs[typ]
+	if !ok {
+		return 0, fmt.Errorf("no struct %v", typ)
+	}
+	return size, nil
+}
+
+// testMemory is sparse memory where all written pages are readable, the rest is not mapped.
+type testMemory struct {
+	pages map[uint64][]byte
+}
+
+func newTestMemory() *testMemory {
+	return &testMemory{pages: make(map[uint64][]byte)}
+}
+
+func (mem *testMemory) Read(addr uint64, size int) ([]byte, error) {
+	data := make([]byte, size)
+	for i := range data {
+		page := mem.pages[(addr+uint64(i))&^0xfff]
+		if page == nil {
+			return nil, fmt.Errorf("address 0x%x is not mapped", addr+uint64(i))
+		}
+		data[i] = page[(addr+uint64(i))&0xfff]
+	}
+	return data, nil
+}
+
+func (mem *testMemory) bytes(addr uint64, data []byte) {
+	for i, v := range data {
+		a := addr + uint64(i)
+		if mem.pages[a&^0xfff] == nil {
+			mem.pages[a&^0xfff] = make([]byte, 0x1000)
+		}
+		mem.pages[a&^0xfff][a&0xfff] = v
+	}
+}
+
+func (mem *testMemory) put(addr, v uint64, size int) {
+	mem.bytes(addr, binary.LittleEndian.AppendUint64(nil, v)[:size])
+}
+
+func (mem *testMemory) put64(addr, v uint64) {
+	mem.put(addr, v, 8)
+}
@@ -211,6 +211,7 @@ type RequiredFeatures struct {
 	Csums          bool
 	FaultInjection bool
 	Async          bool
+	PinCPU         bool
 }
 
 func (p *Prog) RequiredFeatures() RequiredFeatures {
@@ -232,6 +233,9 @@ func (p *Prog) RequiredFeatures() RequiredFeatures {
 		if c.Props.Async {
 			features.Async = true
 		}
+		if c.Props.PinCPU > 0 {
+			features.PinCPU = true
+		}
 	}
 	return features
 }
@@ -129,3 +129,31 @@ func DupCallCollide(origProg *Prog, rand *rand.Rand) (*Prog, error) {
 	prog.Calls = retCalls
 	return prog, nil
 }
+
+// DupRaceCalls duplicates the calls selected by the callback, marks the duplicates async and
+// inserts each of them right before the original call. The original calls and the duplicates
+// are pinned to different CPUs, so that both run truly in parallel.
+func DupRaceCalls(origProg *Prog, race func(*Call) bool) (*Prog, error) {
+	prog := origProg.Clone()
+	var retCalls []*Call
+	dups := 0
+	for _, c := range prog.Calls {
+		if race(c) {
+			dupCall := cloneCall(c, nil)
+			dupCall.Props.Async = true
+			dupCall.Props.PinCPU = 2
+			c.Props.PinCPU = 1
+			retCalls = append(retCalls, dupCall)
+			dups++
+		}
+		retCalls = append(retCalls, c)
+	}
+	if dups == 0 {
+		return nil, fmt.Errorf("no calls to duplicate")
+	}
+	if dups > maxAsyncPerProg || len(retCalls) > MaxCalls {
+		return nil, fmt.Errorf("too many calls to duplicate: %v", dups)
+	}
+	prog.Calls = retCalls
+	return prog, nil
+}
@@ -206,3 +206,32 @@ dup(r2)
 		}
 	}
 }
+
+func TestDupRaceCalls(t *testing.T) {
+	target, err := GetTarget("linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := target.Deserialize([]byte(`r0 = openat(0xffffffffffffff9c, &AUTO='./file1\x00', 0x42, 0x1ff)
+r1 = dup(r0)
+close(r1)
+close(r0)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raced, err := DupRaceCalls(p, func(c *Call) bool {
+		return c.Meta.CallName == "close"
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `r0 = openat(0xffffffffffffff9c, &(0x7f0000000040)='./file1\x00', 0x42, 0x1ff)
+r1 = dup(r0)
+close(r1) (async, pin_cpu: 2)
+close(r1) (pin_cpu: 1)
+close(r0) (async, pin_cpu: 2)
+close(r0) (pin_cpu: 1)
+`, string(raced.Serialize()))
+
+	_, err = DupRaceCalls(p, func(c *Call) bool { return false })
+	assert.Error(t, err)
+}
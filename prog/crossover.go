@@ -0,0 +1,192 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"sort"
+)
+
+// crossoverPoint is a pair of resources of compatible kinds: dst is produced by a call of the mutated
+// program and src is produced by a call of the donor program and used by some of its subsequent calls.
+type crossoverPoint struct {
+	dst     *ResultArg
+	dstCall int
+	src     *ResultArg
+	srcCall int
+}
+
+// crossover splices calls of donor into p at a resource boundary.
+// It chooses a resource produced by a call in p and a compatible resource produced in donor,
+// and takes the donor calls that consume the donor resource (along with the calls that
+// they depend on) and inserts them after the producer in p. Consumers of the donor resource
+// are rewired to use the resource from p, so that the inserted calls stay connected to p.
+// Sometimes the existing consumers of the resource in p are removed, i.e. the consumer
+// subsequences are swapped (calls from noMutate are never removed). donor is modified in the process.
+func (p *Prog) crossover(r *randGen, donor *Prog, ncalls int, noMutate map[int]bool) bool {
+	points := crossoverPoints(p, donor)
+	if len(points) == 0 {
+		return false
+	}
+	point := points[r.Intn(len(points))]
+	calls := crossoverCalls(donor, point)
+	if len(calls) == 0 {
+		return false
+	}
+	// Rewire the donor consumers to the resource in p.
+	rewired := false
+	for use := range point.src.uses {
+		if !calls[resultArgCall(donor, use)] ||
+			!p.Target.isCompatibleResource(resourceName(use), resourceName(point.dst)) {
+			continue
+		}
+		delete(point.src.uses, use)
+		use.Res = point.dst
+		if point.dst.uses == nil {
+			point.dst.uses = make(map[*ResultArg]bool)
+		}
+		point.dst.uses[use] = true
+		rewired = true
+	}
+	if !rewired {
+		return false
+	}
+	for i := len(donor.Calls) - 1; i >= 0; i-- {
+		if !calls[i] {
+			donor.RemoveCall(i)
+		}
+	}
+	if r.oneOf(2) {
+		// Swap the consumer subsequences: drop the calls in p that consume the resource.
+		for i := len(p.Calls) - 1; i > point.dstCall; i-- {
+			if !noMutate[p.Calls[i].Meta.ID] && callUsesResource(p.Calls[i], point.dst) {
+				p.RemoveCall(i)
+			}
+		}
+	}
+	// Calls after the producer could only be removed above, so its index is still valid.
+	idx := point.dstCall + 1 + r.Intn(len(p.Calls)-point.dstCall)
+	p.Calls = append(p.Calls[:idx], append(donor.Calls, p.Calls[idx:]...)...)
+	for i := len(p.Calls) - 1; i >= ncalls; i-- {
+		p.RemoveCall(i)
+	}
+	return true
+}
+
+func crossoverPoints(p, donor *Prog) []crossoverPoint {
+	var points []crossoverPoint
+	dsts := producedResources(p)
+	for srcCall, c := range donor.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			src, ok := arg.(*ResultArg)
+			if !ok || src.Dir() == DirIn || len(src.uses) == 0 {
+				return
+			}
+			for _, dst := range dsts {
+				if p.Target.isCompatibleResource(resourceName(src), resourceName(dst.arg)) {
+					points = append(points, crossoverPoint{
+						dst:     dst.arg,
+						dstCall: dst.call,
+						src:     src,
+						srcCall: srcCall,
+					})
+				}
+			}
+		})
+	}
+	return points
+}
+
+type producedResource struct {
+	arg  *ResultArg
+	call int
+}
+
+func producedResources(p *Prog) []producedResource {
+	var ret []producedResource
+	for i, c := range p.Calls {
+		ForeachArg(c, func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Dir() != DirIn {
+				ret = append(ret, producedResource{a, i})
+			}
+		})
+	}
+	return ret
+}
+
+// crossoverCalls returns the set of donor calls to splice for the crossover point:
+// all calls that transitively consume point.src, plus calls that they depend on
+// (except for the producer of point.src).
+func crossoverCalls(donor *Prog, point crossoverPoint) map[int]bool {
+	calls := make(map[int]bool)
+	var queue []int
+	add := func(idx int) {
+		if idx != point.srcCall && !calls[idx] {
+			calls[idx] = true
+			queue = append(queue, idx)
+		}
+	}
+	for use := range point.src.uses {
+		add(resultArgCall(donor, use))
+	}
+	// Consumers of the resources produced by the consumers.
+	for len(queue) != 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		ForeachArg(donor.Calls[idx], func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Dir() != DirIn {
+				for use := range a.uses {
+					add(resultArgCall(donor, use))
+				}
+			}
+		})
+	}
+	// Producers of the resources used by the selected calls.
+	for idx := range calls {
+		queue = append(queue, idx)
+	}
+	sort.Ints(queue)
+	for len(queue) != 0 {
+		idx := queue[0]
+		queue = queue[1:]
+		ForeachArg(donor.Calls[idx], func(arg Arg, _ *ArgCtx) {
+			if a, ok := arg.(*ResultArg); ok && a.Res != nil && a.Res != point.src {
+				add(resultArgCall(donor, a.Res))
+			}
+		})
+	}
+	return calls
+}
+
+// resultArgCall returns index of the call in p that contains arg.
+func resultArgCall(p *Prog, arg *ResultArg) int {
+	for i, c := range p.Calls {
+		found := false
+		ForeachArg(c, func(arg1 Arg, ctx *ArgCtx) {
+			if arg1 == arg {
+				found = true
+				ctx.Stop = true
+			}
+		})
+		if found {
+			return i
+		}
+	}
+	panic("result arg is not found in the program")
+}
+
+// callUsesResource returns true if c uses res.
+func callUsesResource(c *Call, res *ResultArg) bool {
+	uses := false
+	ForeachArg(c, func(arg Arg, ctx *ArgCtx) {
+		if a, ok := arg.(*ResultArg); ok && a.Res == res {
+			uses = true
+			ctx.Stop = true
+		}
+	})
+	return uses
+}
+
+func resourceName(arg *ResultArg) string {
+	return arg.Type().(*ResourceType).Desc.Name
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCrossover(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	r := newRand(target, rs)
+	for i := 0; i < iters; i++ {
+		p, err := target.Deserialize([]byte(`
+r0 = test$produce_common()
+test$consume_common(r0)
+`), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		donor, err := target.Deserialize([]byte(`
+test$res2()
+r0 = test$produce_subtype_of_common()
+test$consume_subtype_of_common(r0)
+test$consume_common(r0)
+`), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.crossover(r, donor, 10, nil) {
+			t.Fatalf("crossover failed")
+		}
+		if err := p.validate(); err != nil {
+			t.Fatal(err)
+		}
+		data := string(p.Serialize())
+		// The donor producer is replaced with the producer from p,
+		// the unrelated call is not taken.
+		if !strings.HasPrefix(data, "r0 = test$produce_common()\n") ||
+			!strings.Contains(data, "test$consume_subtype_of_common(r0)\n") ||
+			strings.Contains(data, "produce_subtype_of_common") ||
+			strings.Contains(data, "test$res2") {
+			t.Fatalf("bad crossover result:\n%s", data)
+		}
+	}
+}
+
+func TestCrossoverNoMutate(t *testing.T) {
+	target, rs, iters := initRandomTargetTest(t, "test", "64")
+	r := newRand(target, rs)
+	noMutate := map[int]bool{target.SyscallMap["test$consume_common"].ID: true}
+	for i := 0; i < iters; i++ {
+		p, err := target.Deserialize([]byte(`
+r0 = test$produce_common()
+test$consume_common(r0)
+`), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		donor, err := target.Deserialize([]byte(`
+r0 = test$produce_subtype_of_common()
+test$consume_subtype_of_common(r0)
+`), Strict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.crossover(r, donor, 10, noMutate) {
+			t.Fatalf("crossover failed")
+		}
+		if data := string(p.Serialize()); !strings.Contains(data, "test$consume_common(r0)\n") {
+			t.Fatalf("crossover removed a no-mutate call:\n%s", data)
+		}
+	}
+}
+
+func TestCrossoverRandom(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	r := newRand(target, rs)
+	ok := 0
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		donor := target.Generate(rs, 10, ct)
+		if p.crossover(r, donor, 15, nil) {
+			ok++
+		}
+		if err := p.validate(); err != nil {
+			t.Fatal(err)
+		}
+		if len(p.Calls) > 15 {
+			t.Fatalf("too many calls: %v", len(p.Calls))
+		}
+	}
+	if ok == 0 {
+		t.Fatalf("crossover never succeeded")
+	}
+}
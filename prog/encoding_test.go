@@ -471,7 +471,7 @@ func TestSerializeCallProps(t *testing.T) {
 		},
 		{
 			"serialize0(0x0) (fail_nth: 5)\n",
-			[]CallProps{{5, false, 0}},
+			[]CallProps{{5, false, 0, 0}},
 		},
 		{
 			"serialize0(0x0) (fail_nth)\n",
@@ -483,11 +483,15 @@ func TestSerializeCallProps(t *testing.T) {
 		},
 		{
 			"serialize0(0x0) (async)\n",
-			[]CallProps{{0, true, 0}},
+			[]CallProps{{0, true, 0, 0}},
 		},
 		{
 			"serialize0(0x0) (async, rerun: 10)\n",
-			[]CallProps{{0, true, 10}},
+			[]CallProps{{0, true, 10, 0}},
+		},
+		{
+			"serialize0(0x0) (async, pin_cpu: 2)\n",
+			[]CallProps{{0, true, 0, 2}},
 		},
 	}
 
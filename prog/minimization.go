@@ -281,6 +281,15 @@ func minimizeCallProps(p0 *Prog, callIndex, callIndex0 int, pred minimizePred) *
 		}
 	}
 
+	// Try to drop CPU pinning.
+	if props.PinCPU > 0 {
+		p := p0.Clone()
+		p.Calls[callIndex].Props.PinCPU = 0
+		if pred(p, callIndex0, statMinRemoveProps, "props") {
+			p0 = p
+		}
+	}
+
 	return p0
 }
 
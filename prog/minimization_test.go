@@ -223,6 +223,17 @@ func TestMinimize(t *testing.T) {
 			"pipe2(0x0, 0x0) (rerun: 100)\n",
 			-1,
 		},
+		// Clear unneeded CPU pinning.
+		{
+			"linux", "amd64", MinimizeCorpus,
+			"pipe2(0x0, 0x0) (async, pin_cpu: 2)\n",
+			-1,
+			func(p *Prog, callIndex int) bool {
+				return len(p.Calls) == 1 && p.Calls[0].Meta.Name == "pipe2" && p.Calls[0].Props.Async
+			},
+			"pipe2(0x0, 0x0) (async)\n",
+			-1,
+		},
 		// Undo target.SpecialFileLenghts mutation (reduce file name length).
 		{
 			"test", "64", MinimizeCrash,
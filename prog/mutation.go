@@ -47,18 +47,95 @@ type MutateOpts struct {
 	InsertWeight       int
 	MutateArgWeight    int
 	RemoveCallWeight   int
+	// Crossover is disabled by default (see WithCrossover).
+	CrossoverWeight int
+
+	// Scheduler, if set, overrides the static weights above
+	// and decides which mutation operator to apply next.
+	Scheduler MutationScheduler
+}
+
+// WithCrossover returns opts with the crossover operator enabled.
+// Crossover is a resource-aware version of splice, so it takes half of the splice weight.
+func (o MutateOpts) WithCrossover() MutateOpts {
+	o.SpliceWeight /= 2
+	o.CrossoverWeight = o.SpliceWeight
+	return o
+}
+
+// MutationOp is one of the top-level mutation operators applied by MutateWithOpts.
+type MutationOp int
+
+const (
+	MutationSquash MutationOp = iota
+	MutationSplice
+	MutationInsert
+	MutationArg
+	MutationRemoveCall
+	MutationCrossover
+	MutationOpCount
+)
+
+var mutationOpNames = [MutationOpCount]string{
+	MutationSquash:     "squash",
+	MutationSplice:     "splice",
+	MutationInsert:     "insert",
+	MutationArg:        "mutate arg",
+	MutationRemoveCall: "remove call",
+	MutationCrossover:  "crossover",
+}
+
+func (op MutationOp) String() string {
+	return mutationOpNames[op]
+}
+
+// MutationScheduler allows to plug in a custom (e.g. adaptive) policy for mutation operator selection.
+// It must be safe for concurrent use, since mutations run in parallel.
+type MutationScheduler interface {
+	ChooseMutation(r *rand.Rand) MutationOp
+}
+
+// Weights returns static weights of all mutation operators indexed by MutationOp.
+func (o MutateOpts) Weights() [MutationOpCount]int {
+	return [MutationOpCount]int{
+		MutationSquash:     o.SquashWeight,
+		MutationSplice:     o.SpliceWeight,
+		MutationInsert:     o.InsertWeight,
+		MutationArg:        o.MutateArgWeight,
+		MutationRemoveCall: o.RemoveCallWeight,
+		MutationCrossover:  o.CrossoverWeight,
+	}
+}
+
+// ChooseMutation implements MutationScheduler using the static weights.
+func (o MutateOpts) ChooseMutation(r *rand.Rand) MutationOp {
+	weights := o.Weights()
+	val := r.Intn(o.weight())
+	for op, weight := range weights {
+		val -= weight
+		if val < 0 {
+			return MutationOp(op)
+		}
+	}
+	return MutationOpCount - 1
 }
 
 func (o MutateOpts) weight() int {
-	return o.SquashWeight + o.SpliceWeight + o.InsertWeight + o.MutateArgWeight + o.RemoveCallWeight
+	return o.SquashWeight + o.SpliceWeight + o.InsertWeight + o.MutateArgWeight + o.RemoveCallWeight +
+		o.CrossoverWeight
 }
 
+// MutateWithOpts mutates the program according to opts and returns the list
+// of mutation operators that were successfully applied (in the order of application).
 func (p *Prog) MutateWithOpts(rs rand.Source, ncalls int, ct *ChoiceTable, noMutate map[int]bool,
-	corpus []*Prog, opts MutateOpts) {
+	corpus []*Prog, opts MutateOpts) []MutationOp {
 	if p.isUnsafe {
 		panic("mutation of unsafe programs is not supposed to be done")
 	}
-	totalWeight := opts.weight()
+	var sched MutationScheduler = opts
+	if opts.Scheduler != nil {
+		sched = opts.Scheduler
+	}
 	r := newRand(p.Target, rs)
 	ncalls = max(ncalls, len(p.Calls))
 	ctx := &mutator{
@@ -70,37 +147,37 @@ func (p *Prog) MutateWithOpts(rs rand.Source, ncalls int, ct *ChoiceTable, noMut
 		corpus:   corpus,
 		opts:     opts,
 	}
+	var applied []MutationOp
 	for stop, ok := false, false; !stop; stop = ok && len(p.Calls) != 0 && r.oneOf(opts.ExpectedIterations) {
-		val := r.Intn(totalWeight)
-		val -= opts.SquashWeight
-		if val < 0 {
+		op := sched.ChooseMutation(r.Rand)
+		switch op {
+		case MutationSquash:
 			// Not all calls have anything squashable,
 			// so this has lower priority in reality.
 			ok = ctx.squashAny()
-			continue
-		}
-		val -= opts.SpliceWeight
-		if val < 0 {
+		case MutationSplice:
 			ok = ctx.splice()
-			continue
-		}
-		val -= opts.InsertWeight
-		if val < 0 {
+		case MutationInsert:
 			ok = ctx.insertCall()
-			continue
-		}
-		val -= opts.MutateArgWeight
-		if val < 0 {
+		case MutationArg:
 			ok = ctx.mutateArg()
-			continue
+		case MutationRemoveCall:
+			ok = ctx.removeCall()
+		case MutationCrossover:
+			ok = ctx.crossover()
+		default:
+			panic(fmt.Sprintf("unknown mutation op %v", op))
+		}
+		if ok {
+			applied = append(applied, op)
 		}
-		ok = ctx.removeCall()
 	}
 	p.sanitizeFix()
 	p.debugValidate()
 	if got := len(p.Calls); got < 1 || got > ncalls {
 		panic(fmt.Sprintf("bad number of calls after mutation: %v, want [1, %v]", got, ncalls))
 	}
+	return applied
 }
 
 // Internal state required for performing mutations -- currently this matches
@@ -133,6 +210,17 @@ func (ctx *mutator) splice() bool {
 	return true
 }
 
+// crossover is a resource-aware version of splice: it inserts a subsequence of calls
+// of a random corpus program that is connected to ctx.p via a shared resource (see Prog.crossover).
+func (ctx *mutator) crossover() bool {
+	p, r := ctx.p, ctx.r
+	if len(ctx.corpus) == 0 || len(p.Calls) == 0 || len(p.Calls) >= ctx.ncalls {
+		return false
+	}
+	p0 := ctx.corpus[r.Intn(len(ctx.corpus))]
+	return p.crossover(r, p0.Clone(), ctx.ncalls, ctx.noMutate)
+}
+
 // Picks a random complex pointer and squashes its arguments into an ANY.
 // Subsequently, if the ANY contains blobs, mutates a random blob.
 func (ctx *mutator) squashAny() bool {
@@ -257,6 +257,47 @@ func TestMutateCorpus(t *testing.T) {
 	}
 }
 
+// insertRemoveScheduler only inserts and removes calls.
+type insertRemoveScheduler struct{}
+
+func (insertRemoveScheduler) ChooseMutation(r *rand.Rand) MutationOp {
+	if r.Intn(2) == 0 {
+		return MutationInsert
+	}
+	return MutationRemoveCall
+}
+
+func TestMutationScheduler(t *testing.T) {
+	target, rs, iters := initTest(t)
+	ct := target.DefaultChoiceTable()
+	opts := DefaultMutateOpts
+	opts.Scheduler = insertRemoveScheduler{}
+	for i := 0; i < iters; i++ {
+		p := target.Generate(rs, 10, ct)
+		applied := p.MutateWithOpts(rs, 20, ct, nil, nil, opts)
+		if len(applied) == 0 {
+			t.Fatalf("no mutations were applied")
+		}
+		for _, op := range applied {
+			if op != MutationInsert && op != MutationRemoveCall {
+				t.Fatalf("scheduler did not choose %v, but it was applied", op)
+			}
+		}
+	}
+}
+
+func TestMutateOptsChooseMutation(t *testing.T) {
+	opts := MutateOpts{SpliceWeight: 1, RemoveCallWeight: 1}
+	r := rand.New(testutil.RandSource(t))
+	seen := map[MutationOp]bool{}
+	for i := 0; i < 1000; i++ {
+		seen[opts.ChooseMutation(r)] = true
+	}
+	if len(seen) != 2 || !seen[MutationSplice] || !seen[MutationRemoveCall] {
+		t.Fatalf("unexpected chosen mutations: %v", seen)
+	}
+}
+
 func TestMutateTable(t *testing.T) {
 	tests := [][2]string{
 		// Insert a call.
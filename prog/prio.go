@@ -23,10 +23,18 @@ import (
 // pair of syscalls in a single program in corpus. For example, if socket and
 // connect frequently occur in programs together, we give higher priority to
 // this pair of syscalls.
+// The optional learned component is based on CallTransitions: it gives higher
+// priority to calls that gave new signal right after the given call.
 // Note: the current implementation is very basic, there is no theory behind any
 // constants.
 
 func (target *Target) CalculatePriorities(corpus []*Prog) [][]int32 {
+	return target.CalculatePrioritiesWithTransitions(corpus, nil)
+}
+
+// CalculatePrioritiesWithTransitions is CalculatePriorities that also blends in
+// learned call transitions (if trans is not nil).
+func (target *Target) CalculatePrioritiesWithTransitions(corpus []*Prog, trans *CallTransitions) [][]int32 {
 	static := target.calcStaticPriorities()
 	if len(corpus) != 0 {
 		// Let's just sum the static and dynamic distributions.
@@ -38,6 +46,9 @@ func (target *Target) CalculatePriorities(corpus []*Prog) [][]int32 {
 			}
 		}
 	}
+	if trans != nil {
+		trans.addPrios(static)
+	}
 	return static
 }
 
@@ -205,6 +216,13 @@ type ChoiceTable struct {
 }
 
 func (target *Target) BuildChoiceTable(corpus []*Prog, enabled map[*Syscall]bool) *ChoiceTable {
+	return target.BuildChoiceTableWithTransitions(corpus, enabled, nil)
+}
+
+// BuildChoiceTableWithTransitions is BuildChoiceTable that also takes into account
+// learned call transitions (see CalculatePrioritiesWithTransitions).
+func (target *Target) BuildChoiceTableWithTransitions(corpus []*Prog, enabled map[*Syscall]bool,
+	trans *CallTransitions) *ChoiceTable {
 	if enabled == nil {
 		enabled = make(map[*Syscall]bool)
 		for _, c := range target.Syscalls {
@@ -241,7 +259,7 @@ func (target *Target) BuildChoiceTable(corpus []*Prog, enabled map[*Syscall]bool
 			}
 		}
 	}
-	prios := target.CalculatePriorities(corpus)
+	prios := target.CalculatePrioritiesWithTransitions(corpus, trans)
 	run := make([][]int32, len(target.Syscalls))
 	// ChoiceTable.runs[][] contains cumulated sum of weighted priority numbers.
 	// This helps in quick binary search with biases when generating programs.
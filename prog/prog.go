@@ -59,6 +59,9 @@ type CallProps struct {
 	FailNth int  `key:"fail_nth"`
 	Async   bool `key:"async"`
 	Rerun   int  `key:"rerun"`
+	// If non-zero, the thread executing the call is pinned to CPU PinCPU-1
+	// (modulo the number of online CPUs). Used by race reproducers.
+	PinCPU int `key:"pin_cpu"`
 }
 
 type Call struct {
@@ -47,6 +47,10 @@ type Target struct {
 	// empty string which will omit the comment.
 	AnnotateCall func(c ExecCall) string
 
+	// KernelConfigs returns kernel configs (names without the CONFIG_ prefix) the syscall needs
+	// to work (optional). Used to derive reproducer requirements.
+	KernelConfigs func(meta *Syscall) []string
+
 	// SpecialTypes allows target to do custom generation/mutation for some struct's and union's.
 	// Map key is struct/union name for which custom generation/mutation is required.
 	// Map value is custom generation/mutation function that will be called
@@ -0,0 +1,104 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"math"
+	"sync"
+)
+
+const (
+	// How many preceding calls are credited for new signal of a call.
+	transitionOrder = 3
+	// Number of observations of a call after which its learned transitions get the full weight
+	// (on par with the static and dynamic priorities).
+	transitionFullWeight = 20
+)
+
+// CallTransitions is a model of call-to-call transitions P(next call | previous calls)
+// that is learned only from programs that gave new signal. Unlike the dynamic priorities,
+// which count co-occurrence of calls in all corpus programs, it credits only the calls
+// that preceded the call that actually gave new signal.
+// CallTransitions is safe for concurrent use.
+type CallTransitions struct {
+	mu sync.Mutex
+	// counts[prev][next] is the weighted number of times next gave new signal after prev.
+	counts map[int]map[int]float64
+	// totals[prev] is the sum of counts[prev].
+	totals map[int]float64
+	events int
+}
+
+func NewCallTransitions() *CallTransitions {
+	return &CallTransitions{
+		counts: make(map[int]map[int]float64),
+		totals: make(map[int]float64),
+	}
+}
+
+// Record notes that call idx in p gave new signal.
+// Up to transitionOrder preceding calls are credited, closer calls get larger weight.
+func (ct *CallTransitions) Record(p *Prog, idx int) {
+	if idx < 0 || idx >= len(p.Calls) {
+		return
+	}
+	next := p.Calls[idx].Meta.ID
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.events++
+	for dist := 1; dist <= transitionOrder && idx-dist >= 0; dist++ {
+		prev := p.Calls[idx-dist].Meta.ID
+		row := ct.counts[prev]
+		if row == nil {
+			row = make(map[int]float64)
+			ct.counts[prev] = row
+		}
+		weight := 1 / float64(dist)
+		row[next] += weight
+		ct.totals[prev] += weight
+	}
+}
+
+// Events returns the number of recorded calls that gave new signal.
+func (ct *CallTransitions) Events() int {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	return ct.events
+}
+
+// Prios returns learned priorities of all calls after call prev
+// in the same scale as the other priorities (see normalizePrios).
+// Calls with few observations get proportionally lower total weight.
+func (ct *CallTransitions) Prios(target *Target, prev int) []int32 {
+	prios := make([]int32, len(target.Syscalls))
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	ct.addRow(prev, prios)
+	return prios
+}
+
+// addPrios adds learned priorities to the prios matrix.
+func (ct *CallTransitions) addPrios(prios [][]int32) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+	for prev := range ct.counts {
+		ct.addRow(prev, prios[prev])
+	}
+}
+
+func (ct *CallTransitions) addRow(prev int, dst []int32) {
+	row, total := ct.counts[prev], ct.totals[prev]
+	if total == 0 {
+		return
+	}
+	sum := 0.0
+	for _, cnt := range row {
+		sum += math.Sqrt(cnt)
+	}
+	points := 10 * float64(len(dst)) * min(1, total/transitionFullWeight)
+	for next, cnt := range row {
+		// As with dynamic priorities, use sqrt() to lessen the effect of large counts.
+		dst[next] += int32(points * math.Sqrt(cnt) / sum)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package prog
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCallTransitions(t *testing.T) {
+	target := initTargetTest(t, "test", "64")
+	p, err := target.Deserialize([]byte(`
+r0 = test$produce_common()
+test$consume_common(r0)
+test$consume_subtype_of_common(0x0)
+`), Strict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	produce := target.SyscallMap["test$produce_common"].ID
+	consume := target.SyscallMap["test$consume_common"].ID
+	consumeSubtype := target.SyscallMap["test$consume_subtype_of_common"].ID
+
+	trans := NewCallTransitions()
+	static := target.CalculatePriorities(nil)
+	if prios := target.CalculatePrioritiesWithTransitions(nil, trans); !reflect.DeepEqual(static, prios) {
+		t.Fatalf("empty transitions changed priorities")
+	}
+	for i := 0; i < transitionFullWeight; i++ {
+		trans.Record(p, 2)
+	}
+	if got := trans.Events(); got != transitionFullWeight {
+		t.Fatalf("recorded %v events, want %v", got, transitionFullWeight)
+	}
+	learned := trans.Prios(target, consume)
+	// The only transition from consume_common that gave new signal.
+	if want := int32(10 * len(target.Syscalls)); learned[consumeSubtype] != want {
+		t.Fatalf("learned prio %v, want %v", learned[consumeSubtype], want)
+	}
+	// The more distant call gets credit as well.
+	if learned := trans.Prios(target, produce); learned[consumeSubtype] == 0 {
+		t.Fatalf("no learned prio for the distant call")
+	}
+	// Nothing was learned for the last call.
+	for _, prio := range trans.Prios(target, consumeSubtype) {
+		if prio != 0 {
+			t.Fatalf("unexpected learned prio")
+		}
+	}
+	prios := target.CalculatePrioritiesWithTransitions(nil, trans)
+	if prios[consume][consumeSubtype] != static[consume][consumeSubtype]+learned[consumeSubtype] {
+		t.Fatalf("learned prios are not blended: %v", prios[consume][consumeSubtype])
+	}
+}
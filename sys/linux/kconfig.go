@@ -0,0 +1,130 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package linux
+
+import (
+	"strings"
+
+	"github.com/google/syzkaller/prog"
+)
+
+// Kernel configs required by syscalls, matched by the syscall name prefix.
+var callConfigs = []struct {
+	prefix  string
+	configs []string
+}{
+	{"bpf", []string{"BPF_SYSCALL"}},
+	{"io_uring_", []string{"IO_URING"}},
+	{"syz_io_uring_", []string{"IO_URING"}},
+	{"perf_event_open", []string{"PERF_EVENTS"}},
+	{"userfaultfd", []string{"USERFAULTFD"}},
+	{"fanotify_", []string{"FANOTIFY"}},
+	{"inotify_", []string{"INOTIFY_USER"}},
+	{"memfd_secret", []string{"SECRETMEM"}},
+	{"landlock_", []string{"SECURITY_LANDLOCK"}},
+	{"mq_", []string{"POSIX_MQUEUE"}},
+	{"msg", []string{"SYSVIPC"}},
+	{"sem", []string{"SYSVIPC"}},
+	{"shm", []string{"SYSVIPC"}},
+	{"add_key", []string{"KEYS"}},
+	{"request_key", []string{"KEYS"}},
+	{"keyctl", []string{"KEYS"}},
+	{"quotactl", []string{"QUOTACTL"}},
+	{"openat$kvm", []string{"KVM"}},
+	{"syz_kvm_", []string{"KVM"}},
+	{"syz_usb_", []string{"USB_RAW_GADGET", "USB_DUMMY_HCD"}},
+	{"syz_usbip_server_init", []string{"USBIP_CORE", "USBIP_VHCI_HCD"}},
+	{"syz_80211_", []string{"MAC80211_HWSIM"}},
+	{"syz_emit_vhci", []string{"BT", "BT_HCIVHCI"}},
+	{"syz_fuse_", []string{"FUSE_FS"}},
+	{"syz_mount_image$", []string{"BLK_DEV_LOOP"}},
+	{"socket$alg", []string{"CRYPTO_USER_API"}},
+	{"socket$caif", []string{"CAIF"}},
+	{"socket$can", []string{"CAN"}},
+	{"socket$inet6", []string{"IPV6"}},
+	{"socket$isdn", []string{"MISDN"}},
+	{"socket$kcm", []string{"AF_KCM"}},
+	{"socket$key", []string{"NET_KEY"}},
+	{"socket$l2tp", []string{"L2TP"}},
+	{"socket$packet", []string{"PACKET"}},
+	{"socket$phonet", []string{"PHONET"}},
+	{"socket$pppl2tp", []string{"PPPOL2TP"}},
+	{"socket$pppoe", []string{"PPPOE"}},
+	{"socket$pptp", []string{"PPTP"}},
+	{"socket$qrtr", []string{"QRTR"}},
+	{"socket$rds", []string{"RDS"}},
+	{"socket$rxrpc", []string{"AF_RXRPC"}},
+	{"socket$tipc", []string{"TIPC"}},
+	{"socket$vsock", []string{"VSOCKETS"}},
+	{"socket$xdp", []string{"XDP_SOCKETS"}},
+	{"syz_init_net_socket$802154", []string{"IEEE802154_SOCKET"}},
+	{"syz_init_net_socket$ax25", []string{"AX25"}},
+	{"syz_init_net_socket$bt", []string{"BT"}},
+	{"syz_init_net_socket$llc", []string{"LLC2"}},
+	{"syz_init_net_socket$netrom", []string{"NETROM"}},
+	{"syz_init_net_socket$nfc", []string{"NFC"}},
+	{"syz_init_net_socket$rose", []string{"ROSE"}},
+	{"syz_init_net_socket$x25", []string{"X25"}},
+}
+
+// Filesystem configs for syz_mount_image$fs.
+var fsConfigs = map[string]string{
+	"adfs":     "ADFS_FS",
+	"affs":     "AFFS_FS",
+	"bcachefs": "BCACHEFS_FS",
+	"befs":     "BEFS_FS",
+	"bfs":      "BFS_FS",
+	"btrfs":    "BTRFS_FS",
+	"cramfs":   "CRAMFS",
+	"efs":      "EFS_FS",
+	"erofs":    "EROFS_FS",
+	"exfat":    "EXFAT_FS",
+	"ext4":     "EXT4_FS",
+	"f2fs":     "F2FS_FS",
+	"fuse":     "FUSE_FS",
+	"gfs2":     "GFS2_FS",
+	"gfs2meta": "GFS2_FS",
+	"hfs":      "HFS_FS",
+	"hfsplus":  "HFSPLUS_FS",
+	"hpfs":     "HPFS_FS",
+	"iso9660":  "ISO9660_FS",
+	"jffs2":    "JFFS2_FS",
+	"jfs":      "JFS_FS",
+	"minix":    "MINIX_FS",
+	"msdos":    "MSDOS_FS",
+	"nilfs2":   "NILFS2_FS",
+	"ntfs":     "NTFS_FS",
+	"ntfs3":    "NTFS3_FS",
+	"ocfs2":    "OCFS2_FS",
+	"omfs":     "OMFS_FS",
+	"qnx4":     "QNX4FS_FS",
+	"qnx6":     "QNX6FS_FS",
+	"reiserfs": "REISERFS_FS",
+	"romfs":    "ROMFS_FS",
+	"squashfs": "SQUASHFS",
+	"sysv":     "SYSV_FS",
+	"ubifs":    "UBIFS_FS",
+	"udf":      "UDF_FS",
+	"ufs":      "UFS_FS",
+	"v7":       "SYSV_FS",
+	"vfat":     "VFAT_FS",
+	"vxfs":     "VXFS_FS",
+	"xfs":      "XFS_FS",
+	"zonefs":   "ZONEFS_FS",
+}
+
+func kernelConfigs(meta *prog.Syscall) []string {
+	var ret []string
+	for _, rule := range callConfigs {
+		if strings.HasPrefix(meta.Name, rule.prefix) {
+			ret = append(ret, rule.configs...)
+		}
+	}
+	if meta.CallName == "syz_mount_image" {
+		if _, fs, ok := strings.Cut(meta.Name, "$"); ok && fsConfigs[fs] != "" {
+			ret = append(ret, fsConfigs[fs])
+		}
+	}
+	return ret
+}
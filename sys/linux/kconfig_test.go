@@ -0,0 +1,37 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package linux
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/syzkaller/prog"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func TestKernelConfigs(t *testing.T) {
+	target, err := prog.GetTarget(targets.Linux, targets.AMD64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The tables are not generated from the descriptions, so check that they don't go stale.
+	for _, rule := range callConfigs {
+		found := false
+		for _, meta := range target.Syscalls {
+			if strings.HasPrefix(meta.Name, rule.prefix) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("no syscalls match %q", rule.prefix)
+		}
+	}
+	for fs := range fsConfigs {
+		if target.SyscallMap["syz_mount_image$"+fs] == nil {
+			t.Errorf("no syz_mount_image$%v syscall", fs)
+		}
+	}
+}
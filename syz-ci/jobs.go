@@ -561,6 +561,7 @@ func (jp *JobProcessor) bisect(job *Job, mgrcfg *mgrconfig.Config) error {
 	if res.Report != nil {
 		resp.CrashTitle = res.Report.Title
 		resp.CrashAltTitles = res.Report.AltTitles
+		resp.CrashSecondaryTitles = secondaryTitles(res.Report)
 		resp.CrashReport = res.Report.Report
 		resp.CrashLog = res.Report.Output
 		if len(resp.Commits) != 0 {
@@ -677,12 +678,21 @@ func (jp *JobProcessor) testPatch(job *Job, mgrcfg *mgrconfig.Config) error {
 	if rep != nil {
 		resp.CrashTitle = rep.Title
 		resp.CrashAltTitles = rep.AltTitles
+		resp.CrashSecondaryTitles = secondaryTitles(rep)
 		resp.CrashReport = rep.Report
 	}
 	resp.CrashLog = ret.rawOutput
 	return nil
 }
 
+func secondaryTitles(rep *report.Report) []string {
+	var titles []string
+	for _, rep1 := range rep.Secondary {
+		titles = append(titles, rep1.Title)
+	}
+	return titles
+}
+
 func (jp *JobProcessor) prepareBisectionRepo(mgrcfg *mgrconfig.Config, req *dashapi.JobPollResp) error {
 	if req.MergeBaseRepo == "" {
 		// No need to.
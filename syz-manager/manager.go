@@ -17,6 +17,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"sort"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -32,6 +33,7 @@ import (
 	"github.com/google/syzkaller/pkg/gce"
 	"github.com/google/syzkaller/pkg/ifaceprobe"
 	"github.com/google/syzkaller/pkg/image"
+	"github.com/google/syzkaller/pkg/instance"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/manager"
 	"github.com/google/syzkaller/pkg/mgrconfig"
@@ -63,17 +65,21 @@ type Manager struct {
 	mode            *Mode
 	vmPool          *vm.Pool
 	pool            *vm.Dispatcher
+	elastic         *manager.ElasticPool // nil if the pool size is fixed
 	target          *prog.Target
 	sysTarget       *targets.Target
 	reporter        *report.Reporter
 	crashStore      *manager.CrashStore
+	journalDir      string // "" if executor journals are disabled
 	serv            rpcserver.Server
 	http            *manager.HTTPServer
 	servStats       rpcserver.Stats
 	corpus          *corpus.Corpus
+	powerSchedule   corpus.PowerSchedule
 	corpusDB        *db.DB
 	corpusDBMu      sync.Mutex // for concurrent operations on corpusDB
 	corpusPreload   chan []fuzzer.Candidate
+	fuzzerState     *manager.FuzzerState
 	firstConnect    atomic.Int64 // unix time, or 0 if not connected
 	crashTypes      map[string]bool
 	enabledFeatures flatrpc.Feature
@@ -81,6 +87,7 @@ type Manager struct {
 	reportGenerator *manager.ReportGeneratorWrapper
 	fresh           bool
 	coverFilters    manager.CoverageFilters
+	targetDistances map[uint64]uint32
 
 	dash *dashapi.Dashboard
 	// This is specifically separated from dash, so that we can keep dash = nil when
@@ -242,9 +249,12 @@ func main() {
 }
 
 func RunManager(mode *Mode, cfg *mgrconfig.Config) {
+	schedule, err := corpus.ParsePowerSchedule(cfg.Experimental.PowerSchedule)
+	if err != nil {
+		log.Fatalf("bad config param power_schedule: %v", err)
+	}
 	var vmPool *vm.Pool
 	if !cfg.VMLess {
-		var err error
 		vmPool, err = vm.Create(cfg, *flagDebug)
 		if err != nil {
 			log.Fatalf("%v", err)
@@ -277,6 +287,7 @@ func RunManager(mode *Mode, cfg *mgrconfig.Config) {
 		crashes:            make(chan *manager.Crash, 10),
 		saturatedCalls:     make(map[string]bool),
 		reportGenerator:    manager.ReportGeneratorCache(cfg),
+		powerSchedule:      schedule,
 	}
 	if *flagDebug {
 		mgr.cfg.Procs = 1
@@ -297,11 +308,13 @@ func RunManager(mode *Mode, cfg *mgrconfig.Config) {
 
 	// Create RPC server for fuzzers.
 	mgr.servStats = rpcserver.NewStats()
+	mgr.journalDir = mgr.makeJournalDir()
 	rpcCfg := &rpcserver.RemoteConfig{
-		Config:  mgr.cfg,
-		Manager: mgr,
-		Stats:   mgr.servStats,
-		Debug:   *flagDebug,
+		Config:     mgr.cfg,
+		Manager:    mgr,
+		Stats:      mgr.servStats,
+		Debug:      *flagDebug,
+		JournalDir: mgr.journalDir,
 	}
 	mgr.serv, err = rpcserver.New(rpcCfg)
 	if err != nil {
@@ -354,13 +367,34 @@ func RunManager(mode *Mode, cfg *mgrconfig.Config) {
 		log.Logf(0, "you are supposed to start syz-executor manually as:")
 		log.Logf(0, "syz-executor runner local manager.ip %v", mgr.serv.Port())
 		<-vm.Shutdown
+		mgr.saveFuzzerStateOnShutdown()
 		return
 	}
 	mgr.pool = vm.NewDispatcher(mgr.vmPool, mgr.fuzzerInstance)
+	if mgr.cfg.Experimental.VMQuarantine {
+		mgr.pool.TrackHealth(dispatcher.DefaultHealthConfig)
+	}
 	mgr.http.Pool = mgr.pool
 	mgr.reproLoop = manager.NewReproLoop(mgr, mgr.vmPool.Count()-mgr.cfg.FuzzingVMs, mgr.cfg.DashboardOnlyRepro)
 	mgr.http.ReproLoop = mgr.reproLoop
 	mgr.http.TogglePause = mgr.pool.TogglePause
+	if elasticCfg := mgr.cfg.Experimental.ElasticVMs; elasticCfg != nil {
+		elastic, err := manager.NewElasticPool(manager.ElasticConfig{
+			MinVMs:  elasticCfg.MinVMs,
+			MaxLoad: elasticCfg.MaxLoad,
+			TriageBacklog: func() int {
+				if fuzzer := mgr.fuzzer.Load(); fuzzer != nil {
+					return fuzzer.CandidateBacklog()
+				}
+				return 0
+			},
+		}, mgr.pool)
+		if err != nil {
+			log.Fatal(err)
+		}
+		mgr.elastic = elastic
+		go elastic.Loop(ctx)
+	}
 
 	if mgr.cfg.HTTP != "" {
 		go func() {
@@ -373,6 +407,7 @@ func RunManager(mode *Mode, cfg *mgrconfig.Config) {
 	go mgr.trackUsedFiles()
 	go mgr.processFuzzingResults(ctx)
 	mgr.pool.Loop(ctx)
+	mgr.saveFuzzerStateOnShutdown()
 }
 
 // Exit successfully in special operation modes.
@@ -442,6 +477,8 @@ func (mgr *Manager) processFuzzingResults(ctx context.Context) {
 			needRepro := mgr.saveCrash(crash)
 			if mgr.cfg.Reproduce && needRepro {
 				mgr.reproLoop.Enqueue(crash)
+			} else {
+				crash.RemoveJournal()
 			}
 		case err := <-mgr.pool.BootErrors:
 			crash := mgr.convertBootError(err)
@@ -502,10 +539,12 @@ func reportReproError(err error) {
 
 func (mgr *Manager) RunRepro(crash *manager.Crash) *manager.ReproResult {
 	res, stats, err := repro.Run(context.Background(), crash.Output, repro.Environment{
-		Config:   mgr.cfg,
-		Features: mgr.enabledFeatures,
-		Reporter: mgr.reporter,
-		Pool:     mgr.pool,
+		Config:    mgr.cfg,
+		Features:  mgr.enabledFeatures,
+		Reporter:  mgr.reporter,
+		Pool:      mgr.pool,
+		Journal:   crash.Journal,
+		Stabilize: mgr.cfg.Experimental.StabilizeRepro,
 	})
 	ret := &manager.ReproResult{
 		Crash: crash,
@@ -558,6 +597,12 @@ func (mgr *Manager) preloadCorpus() {
 	}
 	mgr.fresh = info.Fresh
 	mgr.corpusDB = info.CorpusDB
+	if mgr.cfg.Experimental.PersistFuzzerState && mgr.mode == ModeFuzzing {
+		mgr.fuzzerState, err = manager.LoadFuzzerState(mgr.cfg)
+		if err != nil {
+			log.Fatalf("failed to load fuzzer state: %v", err)
+		}
+	}
 	mgr.corpusPreload <- info.Candidates
 }
 
@@ -579,6 +624,10 @@ func (mgr *Manager) loadCorpus(enabledSyscalls map[*prog.Syscall]bool) []fuzzer.
 	resmashed := ret.ResmashSubset()
 	log.Logf(0, "%-24v: %v (%v seeds), %d to be reminimized, %d to be resmashed",
 		"corpus", len(ret.Candidates), ret.SeedCount, reminimized, resmashed)
+	if mgr.fuzzerState != nil {
+		triaged := mgr.fuzzerState.ApplyTriaged(ret.Candidates)
+		log.Logf(0, "%-24v: %v", "already triaged", triaged)
+	}
 	return ret.Candidates
 }
 
@@ -593,12 +642,13 @@ func (mgr *Manager) fuzzerInstance(ctx context.Context, inst *vm.Instance, updIn
 	injectExec := make(chan bool, 10)
 	serv.CreateInstance(inst.Index(), injectExec, updInfo)
 
+	dumpFile := mgr.memoryDumpFile(inst.Index())
 	rep, vmInfo, err := mgr.runInstanceInner(ctx, inst, injectExec, vm.EarlyFinishCb(func() {
 		// Depending on the crash type and kernel config, fuzzing may continue
 		// running for several seconds even after kernel has printed a crash report.
 		// This litters the log and we want to prevent it.
 		serv.StopFuzzing(inst.Index())
-	}))
+	}), vm.DumpMemory(dumpFile))
 	var extraExecs []report.ExecutorInfo
 	if rep != nil && rep.Executor != nil {
 		extraExecs = []report.ExecutorInfo{*rep.Executor}
@@ -611,19 +661,89 @@ func (mgr *Manager) fuzzerInstance(ctx context.Context, inst *vm.Instance, updIn
 		}
 		rep.MachineInfo = machineInfo
 	}
+	if ev := healthEvent(rep, err); !mgr.pool.ReportHealth(inst.Index(), ev) &&
+		ev == dispatcher.HealthSuspiciousCrash {
+		// Most likely it's a problem with the host, not with the kernel.
+		log.Logf(0, "VM %v: ignoring %q on an unhealthy VM", inst.Index(), rep.Title)
+		mgr.statFlakyCrashes.Add(1)
+		rep = nil
+	}
 	if err == nil && rep != nil {
-		mgr.crashes <- &manager.Crash{
+		crash := &manager.Crash{
 			InstanceIndex: inst.Index(),
 			Report:        rep,
 		}
+		if dumpFile != "" && osutil.IsExist(dumpFile) {
+			crash.MemoryDump = dumpFile
+		}
+		crash.Journal = mgr.takeJournal(inst.Index())
+		crash.ConsoleOffset = inst.ConsoleLogOffset()
+		mgr.crashes <- crash
+	} else if dumpFile != "" {
+		os.Remove(dumpFile)
 	}
 	if err != nil {
 		log.Logf(1, "VM %v: failed with error: %v", inst.Index(), err)
 	}
 }
 
+func healthEvent(rep *report.Report, err error) dispatcher.HealthEvent {
+	switch {
+	case err != nil:
+		return dispatcher.HealthInfraError
+	case rep != nil && (rep.Type == crash_pkg.LostConnection ||
+		strings.HasPrefix(rep.Title, "no output from test machine")):
+		return dispatcher.HealthSuspiciousCrash
+	default:
+		return dispatcher.HealthOK
+	}
+}
+
+// memoryDumpFile returns the file to dump guest memory to on crashes, or "" if dumps are disabled.
+func (mgr *Manager) memoryDumpFile(index int) string {
+	if !mgr.cfg.Experimental.DumpGuestMemory || mgr.dash != nil {
+		return ""
+	}
+	dir := filepath.Join(mgr.cfg.Workdir, "dumps")
+	if err := osutil.MkdirAll(dir); err != nil {
+		log.Errorf("failed to create memory dumps dir: %v", err)
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("vmcore-%v-%v", index, time.Now().UnixNano()))
+}
+
+// makeJournalDir returns the dir for executor session journals, or "" if journals are disabled.
+func (mgr *Manager) makeJournalDir() string {
+	// With the dashboard crashes are not saved locally, so there is no use in the journals.
+	if !mgr.cfg.Experimental.ExecutorJournal || mgr.cfg.DashboardAddr != "" && !mgr.cfg.DashboardOnlyRepro {
+		return ""
+	}
+	dir := filepath.Join(mgr.cfg.Workdir, "journals")
+	// Remove the journals of the crashes that were queued for reproduction before a restart.
+	os.RemoveAll(dir)
+	if err := osutil.MkdirAll(dir); err != nil {
+		log.Errorf("failed to create journals dir: %v", err)
+		return ""
+	}
+	return dir
+}
+
+// takeJournal moves the journal of the crashed VM out of the way of the next VM session.
+// The returned file is owned by the crash until it's reproduced (see Crash.RemoveJournal).
+func (mgr *Manager) takeJournal(index int) string {
+	if mgr.journalDir == "" {
+		return ""
+	}
+	file := filepath.Join(mgr.journalDir, fmt.Sprintf("crash-%v-%v", index, time.Now().UnixNano()))
+	if err := os.Rename(rpcserver.JournalFile(mgr.journalDir, index), file); err != nil {
+		log.Logf(0, "failed to save journal: %v", err)
+		return ""
+	}
+	return file
+}
+
 func (mgr *Manager) runInstanceInner(ctx context.Context, inst *vm.Instance, injectExec <-chan bool,
-	finishCb vm.EarlyFinishCb) (*report.Report, []byte, error) {
+	finishCb vm.EarlyFinishCb, dumpMemory vm.DumpMemory) (*report.Report, []byte, error) {
 	fwdAddr, err := inst.Forward(mgr.serv.Port())
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to setup port forwarding: %w", err)
@@ -646,10 +766,13 @@ func (mgr *Manager) runInstanceInner(ctx context.Context, inst *vm.Instance, inj
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to parse manager's address")
 	}
-	cmd := fmt.Sprintf("%v runner %v %v %v", executorBin, inst.Index(), host, port)
+	cmd, err := instance.ExecutorRunnerCmd(mgr.cfg, inst, executorBin, host, port)
+	if err != nil {
+		return nil, nil, err
+	}
 	_, rep, err := inst.Run(mgr.cfg.Timeouts.VMRunningTime, mgr.reporter, cmd,
 		vm.ExitTimeout, vm.StopContext(ctx), vm.InjectExecuting(injectExec),
-		finishCb,
+		finishCb, dumpMemory,
 	)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to run fuzzer: %w", err)
@@ -682,8 +805,14 @@ func (mgr *Manager) emailCrash(crash *manager.Crash) {
 }
 
 func (mgr *Manager) saveCrash(crash *manager.Crash) bool {
-	if err := mgr.reporter.Symbolize(crash.Report); err != nil {
-		log.Errorf("failed to symbolize report: %v", err)
+	if crash.MemoryDump != "" {
+		// SaveCrash moves the dump into the crash dir if it's needed.
+		defer os.Remove(crash.MemoryDump)
+	}
+	for _, rep := range append([]*report.Report{crash.Report}, crash.Secondary...) {
+		if err := mgr.reporter.Symbolize(rep); err != nil {
+			log.Errorf("failed to symbolize report: %v", err)
+		}
 	}
 	if crash.Type == crash_pkg.MemoryLeak {
 		mgr.mu.Lock()
@@ -703,6 +832,9 @@ func (mgr *Manager) saveCrash(crash *manager.Crash) bool {
 		flags += " [suppressed]"
 	}
 	log.Logf(0, "VM %v: crash: %v%v", crash.InstanceIndex, crash.Title, flags)
+	for _, rep := range crash.Secondary {
+		log.Logf(1, "VM %v: followed by: %v", crash.InstanceIndex, rep.Title)
+	}
 
 	if mgr.mode.FailOnCrashes {
 		path := filepath.Join(mgr.cfg.Workdir, "report.json")
@@ -717,6 +849,9 @@ func (mgr *Manager) saveCrash(crash *manager.Crash) bool {
 		// e.g. if there are some spikes in suppressed reports.
 		crash.Title = "suppressed report"
 		mgr.statSuppressed.Add(1)
+	} else if mgr.dash == nil && !crash.Corrupted {
+		// The dashboard does its own bug deduplication.
+		crash.Title = mgr.crashStore.StackTitle(crash.Title, crash.Report.Frames)
 	}
 
 	mgr.statCrashes.Add(1)
@@ -741,8 +876,10 @@ func (mgr *Manager) saveCrash(crash *manager.Crash) bool {
 			Log:         crash.Output,
 			Report:      crash.Report.Report,
 			MachineInfo: crash.MachineInfo,
+			Frames:      crash.Report.Frames,
 		}
 		setGuiltyFiles(dc, crash.Report)
+		setSecondaryReports(dc, crash.Report)
 		resp, err := mgr.dash.ReportCrash(dc)
 		if err != nil {
 			log.Logf(0, "failed to report crash to dashboard: %v", err)
@@ -844,7 +981,7 @@ func (mgr *Manager) saveFailedRepro(rep *report.Report, stats *repro.Stats) {
 func (mgr *Manager) saveRepro(res *manager.ReproResult) {
 	repro := res.Repro
 	opts := fmt.Sprintf("# %+v\n", repro.Opts)
-	progText := repro.Prog.Serialize()
+	progText := append(repro.Requirements.Serialize(), repro.Prog.Serialize()...)
 
 	// Append this repro to repro list to send to hub if it didn't come from hub originally.
 	if !res.Crash.FromHub {
@@ -897,6 +1034,7 @@ func (mgr *Manager) saveRepro(res *manager.ReproResult) {
 			Log:           output,
 			Flags:         crashFlags,
 			Report:        report.Report,
+			Frames:        report.Frames,
 			ReproOpts:     repro.Opts.Serialize(),
 			ReproSyz:      progText,
 			ReproC:        cprogText,
@@ -905,6 +1043,9 @@ func (mgr *Manager) saveRepro(res *manager.ReproResult) {
 			OriginalTitle: res.Crash.Title,
 		}
 		setGuiltyFiles(dc, report)
+		setSecondaryReports(dc, report)
+		dc.ReproSyzReliability = reproReliability(repro.SyzReliability)
+		dc.ReproCReliability = reproReliability(repro.CReliability)
 		if _, err := mgr.dash.ReportCrash(dc); err != nil {
 			log.Logf(0, "failed to report repro to dashboard: %v", err)
 		} else {
@@ -921,6 +1062,9 @@ func (mgr *Manager) saveRepro(res *manager.ReproResult) {
 
 func (mgr *Manager) ResizeReproPool(size int) {
 	mgr.pool.ReserveForRun(size)
+	if mgr.elastic != nil {
+		mgr.elastic.SetReproVMs(size)
+	}
 }
 
 func (mgr *Manager) uploadReproAssets(repro *repro.Result) []dashapi.NewAsset {
@@ -1082,6 +1226,26 @@ func setGuiltyFiles(crash *dashapi.Crash, report *report.Report) {
 	}
 }
 
+func setSecondaryReports(crash *dashapi.Crash, report *report.Report) {
+	for _, rep := range report.Secondary {
+		crash.Secondary = append(crash.Secondary, dashapi.SecondaryReport{
+			Title:  rep.Title,
+			Report: rep.Report,
+		})
+	}
+}
+
+func reproReliability(r *repro.Reliability) *dashapi.ReproReliability {
+	if r == nil {
+		return nil
+	}
+	return &dashapi.ReproReliability{
+		Runs:        r.Runs,
+		Crashes:     r.Crashes,
+		TimeToCrash: r.TimeToCrash,
+	}
+}
+
 func (mgr *Manager) BugFrames() (leaks, races []string) {
 	mgr.mu.Lock()
 	defer mgr.mu.Unlock()
@@ -1125,19 +1289,34 @@ func (mgr *Manager) MachineChecked(features flatrpc.Feature,
 		corpusUpdates := make(chan corpus.NewItemEvent, 128)
 		mgr.corpus = corpus.NewFocusedCorpus(context.Background(),
 			corpusUpdates, mgr.coverFilters.Areas)
+		mgr.corpus.SetPowerSchedule(mgr.powerSchedule)
 		mgr.http.Corpus.Store(mgr.corpus)
 
+		var tracer *queue.Tracer
+		if mgr.cfg.Experimental.TraceRequests != 0 {
+			tracer = queue.NewTracer(mgr.cfg.Experimental.TraceRequests, mgr.cfg.Name)
+			mgr.http.Tracer.Store(tracer)
+			go mgr.traceExporter(tracer)
+		}
 		rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
 		fuzzerObj := fuzzer.NewFuzzer(context.Background(), &fuzzer.Config{
-			Corpus:         mgr.corpus,
-			Snapshot:       mgr.cfg.Snapshot,
-			Coverage:       mgr.cfg.Cover,
-			FaultInjection: features&flatrpc.FeatureFault != 0,
-			Comparisons:    features&flatrpc.FeatureComparisons != 0,
-			Collide:        true,
-			EnabledCalls:   enabledSyscalls,
-			NoMutateCalls:  mgr.cfg.NoMutateCalls,
-			FetchRawCover:  mgr.cfg.RawCover,
+			Corpus:           mgr.corpus,
+			Snapshot:         mgr.snapshotOnly(),
+			SnapshotJobs:     mgr.snapshotMixed(),
+			Coverage:         mgr.cfg.Cover,
+			FaultInjection:   features&flatrpc.FeatureFault != 0,
+			Comparisons:      features&flatrpc.FeatureComparisons != 0,
+			Collide:          true,
+			EnabledCalls:     enabledSyscalls,
+			NoMutateCalls:    mgr.cfg.NoMutateCalls,
+			FetchRawCover:    mgr.cfg.RawCover,
+			AdaptiveMutation: mgr.cfg.Experimental.AdaptiveMutation,
+			TargetDistances:  mgr.targetDistances,
+			Tracer:           tracer,
+			LearnTransitions: mgr.cfg.Experimental.LearnedPriorities,
+			Crossover:        mgr.cfg.Experimental.Crossover,
+			ExecShares:       execShares(mgr.cfg),
+			Feedback:         flatrpc.FeedbackKinds(mgr.cfg.Experimental.Feedback),
 			Logf: func(level int, msg string, args ...interface{}) {
 				if level != 0 {
 					return
@@ -1150,13 +1329,24 @@ func (mgr *Manager) MachineChecked(features flatrpc.Feature,
 				return !mgr.saturatedCalls[call]
 			},
 		}, rnd, mgr.target)
-		fuzzerObj.AddCandidates(candidates)
 		mgr.fuzzer.Store(fuzzerObj)
 		mgr.http.Fuzzer.Store(fuzzerObj)
 
 		go mgr.corpusInputHandler(corpusUpdates)
 		go mgr.corpusMinimization()
 		go mgr.fuzzerLoop(fuzzerObj)
+		// Already triaged candidates are saved to the corpus right away,
+		// so this must go after corpusInputHandler is started.
+		fuzzerObj.AddCandidates(candidates)
+		if mgr.fuzzerState != nil {
+			state, err := mgr.fuzzerState.LoadState(candidates)
+			if err != nil {
+				log.Errorf("failed to load fuzzer state: %v", err)
+			} else {
+				fuzzerObj.Resume(state)
+			}
+			go mgr.fuzzerStateSaver(fuzzerObj)
+		}
 		if mgr.dash != nil {
 			go mgr.dashboardReporter()
 			if mgr.cfg.Reproduce {
@@ -1164,7 +1354,7 @@ func (mgr *Manager) MachineChecked(features flatrpc.Feature,
 			}
 		}
 		source := queue.DefaultOpts(fuzzerObj, opts)
-		if mgr.cfg.Snapshot {
+		if mgr.snapshotOnly() {
 			log.Logf(0, "restarting VMs for snapshot mode")
 			mgr.snapshotSource = queue.Distribute(source)
 			mgr.pool.SetDefault(mgr.snapshotInstance)
@@ -1174,6 +1364,11 @@ func (mgr *Manager) MachineChecked(features flatrpc.Feature,
 				return nil
 			}), nil
 		}
+		if mgr.snapshotMixed() {
+			log.Logf(0, "restarting VMs for mixed snapshot mode (%v snapshot VMs)", mgr.snapshotVMs())
+			mgr.snapshotSource = queue.Distribute(queue.DefaultOpts(fuzzerObj.SnapshotSource(), opts))
+			mgr.pool.SetDefault(mgr.mixedInstance)
+		}
 		return source, nil
 	} else if mgr.mode == ModeCorpusRun {
 		ctx := &corpusRunner{
@@ -1255,6 +1450,80 @@ func (mgr *Manager) corpusMinimization() {
 	}
 }
 
+func (mgr *Manager) fuzzerStateSaver(fuzzerObj *fuzzer.Fuzzer) {
+	for range time.NewTicker(10 * time.Minute).C {
+		mgr.saveFuzzerState(fuzzerObj)
+	}
+}
+
+func (mgr *Manager) saveFuzzerState(fuzzerObj *fuzzer.Fuzzer) {
+	err := mgr.fuzzerState.Save(mgr.corpus.Items(), fuzzerObj.State(),
+		fuzzerObj.CandidateTriageFinished())
+	if err != nil {
+		log.Errorf("failed to save fuzzer state: %v", err)
+	}
+}
+
+// saveFuzzerStateOnShutdown saves the latest fuzzer state, so that a restart
+// does not lose the progress made since the last periodic save.
+func (mgr *Manager) saveFuzzerStateOnShutdown() {
+	fuzzerObj := mgr.fuzzer.Load()
+	if mgr.fuzzerState == nil || fuzzerObj == nil {
+		return
+	}
+	log.Logf(0, "saving fuzzer state...")
+	mgr.saveFuzzerState(fuzzerObj)
+}
+
+// execShares converts the exec_shares config param into per-stage shares for the fuzzer.
+// Returns nil if exec shares are not configured (the stages are strictly prioritized then).
+func execShares(cfg *mgrconfig.Config) map[string]queue.Share {
+	if len(cfg.Experimental.ExecShares) == 0 {
+		return nil
+	}
+	ret := make(map[string]queue.Share)
+	for _, share := range cfg.Experimental.ExecShares {
+		ret[share.Stage] = queue.Share{
+			Weight: share.Weight,
+			Min:    share.MinShare,
+		}
+	}
+	return ret
+}
+
+// traceExporter periodically exports finished request traces into workdir/traces.
+// Only the most recent files are kept.
+func (mgr *Manager) traceExporter(tracer *queue.Tracer) {
+	const keepFiles = 100
+	dir := filepath.Join(mgr.cfg.Workdir, "traces")
+	if err := osutil.MkdirAll(dir); err != nil {
+		log.Errorf("failed to create traces dir: %v", err)
+		return
+	}
+	var files []string
+	for range time.NewTicker(time.Minute).C {
+		buf := new(bytes.Buffer)
+		n, err := tracer.Export(buf)
+		if err != nil {
+			log.Errorf("failed to export traces: %v", err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+		file := filepath.Join(dir, fmt.Sprintf("traces-%v.json", time.Now().Unix()))
+		if err := osutil.WriteFile(file, buf.Bytes()); err != nil {
+			log.Errorf("failed to write traces: %v", err)
+			continue
+		}
+		files = append(files, file)
+		if len(files) > keepFiles {
+			os.Remove(files[0])
+			files = files[1:]
+		}
+	}
+}
+
 func (mgr *Manager) MaxSignal() signal.Signal {
 	if fuzzer := mgr.fuzzer.Load(); fuzzer != nil {
 		return fuzzer.Cover.CopyMaxSignal()
@@ -1264,7 +1533,7 @@ func (mgr *Manager) MaxSignal() signal.Signal {
 
 func (mgr *Manager) fuzzerLoop(fuzzer *fuzzer.Fuzzer) {
 	for ; ; time.Sleep(time.Second / 2) {
-		if mgr.cfg.Cover && !mgr.cfg.Snapshot {
+		if mgr.cfg.Cover && !mgr.snapshotOnly() {
 			// Distribute new max signal over all instances.
 			newSignal := fuzzer.Cover.GrabSignalDelta()
 			log.Logf(3, "distributing %d new signal", len(newSignal))
@@ -1280,7 +1549,7 @@ func (mgr *Manager) fuzzerLoop(fuzzer *fuzzer.Fuzzer) {
 			}
 			mgr.mu.Lock()
 			if mgr.phase == phaseLoadedCorpus {
-				if !mgr.cfg.Snapshot {
+				if !mgr.snapshotOnly() {
 					mgr.serv.TriagedCorpus()
 				}
 				if mgr.cfg.HubClient != "" {
@@ -1444,6 +1713,10 @@ func (mgr *Manager) CoverageFilter(modules []*vminfo.KernelModule) ([]uint64, er
 		return nil, fmt.Errorf("failed to init coverage filter: %w", err)
 	}
 	mgr.coverFilters = filters
+	mgr.targetDistances, err = manager.DirectedDistances(mgr.reportGenerator, mgr.cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate directed fuzzing distances: %w", err)
+	}
 	mgr.http.Cover.Store(&manager.CoverageInfo{
 		Modules:         modules,
 		ReportGenerator: mgr.reportGenerator,
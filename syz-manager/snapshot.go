@@ -8,6 +8,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	flatbuffers "github.com/google/flatbuffers/go"
@@ -15,6 +16,7 @@ import (
 	"github.com/google/syzkaller/pkg/fuzzer/queue"
 	"github.com/google/syzkaller/pkg/log"
 	"github.com/google/syzkaller/pkg/manager"
+	"github.com/google/syzkaller/pkg/report"
 	"github.com/google/syzkaller/vm"
 	"github.com/google/syzkaller/vm/dispatcher"
 )
@@ -33,6 +35,43 @@ func (mgr *Manager) snapshotInstance(ctx context.Context, inst *vm.Instance, upd
 	}
 }
 
+// mixedInstance runs the VM in snapshot mode or in the normal mode depending on the VM index.
+func (mgr *Manager) mixedInstance(ctx context.Context, inst *vm.Instance, updInfo dispatcher.UpdateInfo) {
+	if mgr.snapshotVM(inst.Index()) {
+		mgr.snapshotInstance(ctx, inst, updInfo)
+	} else {
+		mgr.fuzzerInstance(ctx, inst, updInfo)
+	}
+}
+
+// snapshotVMs returns the number of VMs that run in snapshot mode.
+// These are the VMs with the largest indices, since bug reproduction takes the smallest ones first.
+// Elastic VMs shut down the largest indices first, so they are not supported together (see mgrconfig).
+func (mgr *Manager) snapshotVMs() int {
+	if !mgr.cfg.Snapshot {
+		return 0
+	}
+	count := mgr.vmPool.Count()
+	if n := mgr.cfg.Experimental.SnapshotVMs; n != 0 && n < count {
+		return n
+	}
+	return count
+}
+
+func (mgr *Manager) snapshotVM(index int) bool {
+	return index >= mgr.vmPool.Count()-mgr.snapshotVMs()
+}
+
+// snapshotOnly says if all VMs run in snapshot mode.
+func (mgr *Manager) snapshotOnly() bool {
+	return mgr.cfg.Snapshot && mgr.snapshotVMs() == mgr.vmPool.Count()
+}
+
+// snapshotMixed says if snapshot VMs execute fuzzer jobs, and the rest of the VMs fuzz in the normal mode.
+func (mgr *Manager) snapshotMixed() bool {
+	return mgr.snapshotVMs() != 0 && !mgr.snapshotOnly()
+}
+
 func (mgr *Manager) snapshotLoop(ctx context.Context, inst *vm.Instance) error {
 	executor, err := inst.Copy(mgr.cfg.ExecutorBin)
 	if err != nil {
@@ -48,42 +87,111 @@ func (mgr *Manager) snapshotLoop(ctx context.Context, inst *vm.Instance) error {
 	builder := flatbuffers.NewBuilder(0)
 	var envFlags flatrpc.ExecEnv
 	for first := true; ctx.Err() == nil; first = false {
-		mgr.servStats.StatExecs.Add(1)
-		req := mgr.snapshotSource.Next(inst.Index())
+		// Each proc executes one request after each snapshot restore.
+		reqs := make([]*queue.Request, mgr.cfg.Experimental.SnapshotProcs)
+		for i := range reqs {
+			mgr.servStats.StatExecs.Add(1)
+			reqs[i] = mgr.snapshotSource.Next(inst.Index())
+		}
 		if first {
-			envFlags = req.ExecOpts.EnvFlags
+			envFlags = reqs[0].ExecOpts.EnvFlags
 			if err := mgr.snapshotSetup(inst, builder, envFlags); err != nil {
-				req.Done(&queue.Result{Status: queue.Crashed})
+				snapshotCrashed(reqs)
 				return err
 			}
 		}
-		if envFlags != req.ExecOpts.EnvFlags {
-			panic(fmt.Sprintf("request env flags has changed: 0x%x -> 0x%x",
-				envFlags, req.ExecOpts.EnvFlags))
+		for _, req := range reqs {
+			if envFlags != req.ExecOpts.EnvFlags {
+				panic(fmt.Sprintf("request env flags has changed: 0x%x -> 0x%x",
+					envFlags, req.ExecOpts.EnvFlags))
+			}
 		}
 
-		res, output, err := mgr.snapshotRun(inst, builder, req)
+		results, output, err := mgr.snapshotRun(inst, builder, reqs)
 		if err != nil {
-			req.Done(&queue.Result{Status: queue.Crashed})
+			snapshotCrashed(reqs)
 			return err
 		}
 
 		if mgr.reporter.ContainsCrash(output) {
-			res.Status = queue.Crashed
-			rep := mgr.reporter.Parse(output)
-			buf := new(bytes.Buffer)
-			fmt.Fprintf(buf, "program:\n%s\n", req.Prog.Serialize())
-			buf.Write(rep.Output)
-			rep.Output = buf.Bytes()
-			mgr.crashes <- &manager.Crash{Report: rep}
+			if err := mgr.snapshotCrash(inst, builder, reqs, results, output); err != nil {
+				snapshotCrashed(reqs)
+				return err
+			}
+		}
+
+		for i, req := range reqs {
+			req.Done(results[i])
 		}
+	}
+	return nil
+}
+
+func snapshotCrashed(reqs []*queue.Request) {
+	for _, req := range reqs {
+		req.Done(&queue.Result{Status: queue.Crashed})
+	}
+}
 
-		req.Done(res)
+// snapshotCrash handles a kernel crash during execution of the batch of requests.
+// If the batch contains several programs, each of them is re-executed alone to find the culprits.
+// If none of them crashes alone, all of them are considered crashed.
+func (mgr *Manager) snapshotCrash(inst *vm.Instance, builder *flatbuffers.Builder, reqs []*queue.Request,
+	results []*queue.Result, output []byte) error {
+	rep := mgr.reporter.Parse(output)
+	culprits := make([]bool, len(reqs))
+	for i := range reqs {
+		culprits[i] = true
+	}
+	if len(reqs) > 1 {
+		var soloRep *report.Report
+		soloCulprits := make([]bool, len(reqs))
+		for i, req := range reqs {
+			// Execute the request on the same proc, other procs get no requests.
+			solo := make([]*queue.Request, len(reqs))
+			solo[i] = req
+			mgr.servStats.StatExecs.Add(1)
+			_, soloOutput, err := mgr.snapshotRun(inst, builder, solo)
+			if err != nil {
+				return err
+			}
+			if mgr.reporter.ContainsCrash(soloOutput) {
+				soloCulprits[i] = true
+				if soloRep == nil {
+					soloRep = mgr.reporter.Parse(soloOutput)
+				}
+			}
+		}
+		if soloRep != nil {
+			rep, culprits = soloRep, soloCulprits
+		}
+	}
+	buf := new(bytes.Buffer)
+	for i, req := range reqs {
+		if !culprits[i] {
+			continue
+		}
+		results[i].Status = queue.Crashed
+		if len(reqs) == 1 {
+			fmt.Fprintf(buf, "program:\n%s\n", req.Prog.Serialize())
+		} else {
+			fmt.Fprintf(buf, "program (proc %v):\n%s\n", i, req.Prog.Serialize())
+		}
+	}
+	if rep != nil {
+		buf.Write(rep.Output)
+		rep.Output = buf.Bytes()
+		mgr.crashes <- &manager.Crash{
+			InstanceIndex: inst.Index(),
+			ConsoleOffset: inst.ConsoleLogOffset(),
+			Report:        rep,
+		}
 	}
 	return nil
 }
 
 func (mgr *Manager) snapshotSetup(inst *vm.Instance, builder *flatbuffers.Builder, env flatrpc.ExecEnv) error {
+	procs := mgr.cfg.Experimental.SnapshotProcs
 	msg := flatrpc.SnapshotHandshakeT{
 		CoverEdges:       mgr.cfg.Experimental.CoverEdges,
 		Kernel64Bit:      mgr.cfg.SysTarget.PtrSize == 8,
@@ -93,21 +201,63 @@ func (mgr *Manager) snapshotSetup(inst *vm.Instance, builder *flatbuffers.Builde
 		Features:         mgr.enabledFeatures,
 		EnvFlags:         env,
 		SandboxArg:       mgr.cfg.SandboxArg,
+		Procs:            int32(procs),
 	}
 	builder.Reset()
 	builder.Finish(msg.Pack(builder))
-	return inst.SetupSnapshot(builder.FinishedBytes())
+	return inst.SetupSnapshot(procs, builder.FinishedBytes())
+}
+
+// snapshotRun executes the requests concurrently, one per proc. Nil requests leave the proc idle
+// (the corresponding results are nil).
+func (mgr *Manager) snapshotRun(inst *vm.Instance, builder *flatbuffers.Builder, reqs []*queue.Request) (
+	[]*queue.Result, []byte, error) {
+	results := make([]*queue.Result, len(reqs))
+	inputs := make([][]byte, len(reqs))
+	for i, req := range reqs {
+		var err error
+		if req != nil {
+			inputs[i], err = snapshotInput(builder, req)
+		}
+		if _, size := flatrpc.SnapshotInputSlot(len(reqs), i); err == nil && len(inputs[i]) > size {
+			err = fmt.Errorf("program does not fit into the input slot: %v > %v", len(inputs[i]), size)
+		}
+		if err != nil {
+			queue.StatExecBufferTooSmall.Add(1)
+			results[i] = &queue.Result{
+				Status: queue.ExecFailure,
+				Err:    err,
+			}
+			req = nil
+		}
+		if req == nil {
+			// Empty request without the program.
+			builder.Reset()
+			builder.Finish((&flatrpc.SnapshotRequestT{}).Pack(builder))
+			inputs[i] = slices.Clone(builder.FinishedBytes())
+		}
+	}
+
+	start := time.Now()
+	resData, output, err := inst.RunSnapshot(inputs)
+	if err != nil {
+		return nil, nil, err
+	}
+	elapsed := time.Since(start)
+
+	for i, req := range reqs {
+		if req == nil || results[i] != nil {
+			continue
+		}
+		results[i] = snapshotResult(req, resData[i], output, elapsed)
+	}
+	return results, output, nil
 }
 
-func (mgr *Manager) snapshotRun(inst *vm.Instance, builder *flatbuffers.Builder, req *queue.Request) (
-	*queue.Result, []byte, error) {
+func snapshotInput(builder *flatbuffers.Builder, req *queue.Request) ([]byte, error) {
 	progData, err := req.Prog.SerializeForExec()
 	if err != nil {
-		queue.StatExecBufferTooSmall.Add(1)
-		return &queue.Result{
-			Status: queue.ExecFailure,
-			Err:    fmt.Errorf("program serialization failed: %w", err),
-		}, nil, nil
+		return nil, fmt.Errorf("program serialization failed: %w", err)
 	}
 	msg := flatrpc.SnapshotRequestT{
 		ExecFlags: req.ExecOpts.ExecFlags,
@@ -123,14 +273,10 @@ func (mgr *Manager) snapshotRun(inst *vm.Instance, builder *flatbuffers.Builder,
 	}
 	builder.Reset()
 	builder.Finish(msg.Pack(builder))
+	return slices.Clone(builder.FinishedBytes()), nil
+}
 
-	start := time.Now()
-	resData, output, err := inst.RunSnapshot(builder.FinishedBytes())
-	if err != nil {
-		return nil, nil, err
-	}
-	elapsed := time.Since(start)
-
+func snapshotResult(req *queue.Request, resData, output []byte, elapsed time.Duration) *queue.Result {
 	res := parseExecResult(resData)
 	if res.Info != nil {
 		res.Info.Elapsed = uint64(elapsed)
@@ -161,7 +307,7 @@ func (mgr *Manager) snapshotRun(inst *vm.Instance, builder *flatbuffers.Builder,
 	if req.ReturnOutput {
 		ret.Output = output
 	}
-	return ret, output, nil
+	return ret
 }
 
 func parseExecResult(data []byte) *flatrpc.ExecResult {
@@ -16,6 +16,7 @@ type Stats struct {
 	statCrashes       *stat.Val
 	statCrashTypes    *stat.Val
 	statSuppressed    *stat.Val
+	statFlakyCrashes  *stat.Val
 	statUptime        *stat.Val
 	statFuzzingTime   *stat.Val
 	statAvgBootTime   *stat.Val
@@ -29,6 +30,9 @@ func (mgr *Manager) initStats() {
 		stat.Simple, stat.NoGraph)
 	mgr.statSuppressed = stat.New("suppressed", "Total number of suppressed VM crashes",
 		stat.Simple, stat.Graph("crashes"))
+	mgr.statFlakyCrashes = stat.New("flaky VM crashes",
+		"Total number of lost connection/no output crashes ignored on unhealthy VMs",
+		stat.Simple, stat.Graph("crashes"), stat.Prometheus("syz_flaky_vm_crash_total"))
 	mgr.statFuzzingTime = stat.New("fuzzing", "Total fuzzing time in all VMs (seconds)",
 		stat.NoGraph, func(v int, period time.Duration) string { return fmt.Sprintf("%v sec", v/1e9) })
 	mgr.statUptime = stat.New("uptime", "Total uptime (seconds)", stat.Simple, stat.NoGraph,
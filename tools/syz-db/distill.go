@@ -0,0 +1,391 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/db"
+	"github.com/google/syzkaller/pkg/flatrpc"
+	"github.com/google/syzkaller/pkg/fuzzer"
+	"github.com/google/syzkaller/pkg/fuzzer/queue"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/rpcserver"
+	"github.com/google/syzkaller/pkg/signal"
+	"github.com/google/syzkaller/pkg/tool"
+	"github.com/google/syzkaller/pkg/vminfo"
+	"github.com/google/syzkaller/prog"
+)
+
+// Reasons for dropping programs from the distilled corpus.
+const (
+	dropBroken    = "broken"
+	dropFailed    = "failed"
+	dropRedundant = "redundant"
+)
+
+// distillInput is a corpus program along with the signal it produced during replay.
+type distillInput struct {
+	key  string
+	rec  db.Record
+	prog *prog.Prog
+	// Non-empty if the program is not included into the distilled corpus.
+	drop string
+	// The program uses syscalls that are not supported on the target, so it wasn't executed.
+	unsupported bool
+	runs        int
+	elapsed     uint64
+	// Signal that was present in all successful runs.
+	calls []signal.Signal
+	extra signal.Signal
+}
+
+func (inp *distillInput) signal() signal.Signal {
+	var ret signal.Signal
+	for _, sig := range inp.calls {
+		ret.Merge(sig)
+	}
+	ret.Merge(inp.extra)
+	return ret
+}
+
+func distill(args []string, target *prog.Target) {
+	flags := flag.NewFlagSet("distill", flag.ExitOnError)
+	var (
+		flagExecutor   = flags.String("executor", "./syz-executor", "path to executor binary")
+		flagType       = flags.String("type", "", "target VM type")
+		flagProcs      = flags.Int("procs", 2*runtime.NumCPU(), "number of parallel processes to execute programs")
+		flagSandbox    = flags.String("sandbox", "none", "sandbox for fuzzing (none/setuid/namespace/android)")
+		flagSandboxArg = flags.Int("sandbox_arg", 0, "argument for sandbox runner to adjust it via config")
+		flagSlowdown   = flags.Int("slowdown", 1, "execution slowdown caused by emulation/instrumentation")
+		flagCover      = flags.Bool("cover", true, "use coverage signal (otherwise fallback signal is used)")
+		flagRuns       = flags.Int("runs", 1, "execute each program that many times and use only stable signal")
+		flagWeight     = flags.String("weight", "calls", "program cost for set cover (calls/time/uniform)")
+		flagReport     = flags.String("report", "", "write report to the file instead of stdout")
+		flagDebug      = flags.Bool("debug", false, "debug output from executor")
+	)
+	flags.Parse(args)
+	if flags.NArg() != 2 || *flagRuns < 1 {
+		usage()
+	}
+	if target == nil {
+		tool.Failf("distill requires -os and -arch")
+	}
+	if _, ok := distillWeights[*flagWeight]; !ok {
+		tool.Failf("unknown weight %q", *flagWeight)
+	}
+	inDB, err := db.Open(flags.Arg(0), false)
+	if err != nil {
+		tool.Failf("failed to open database: %v", err)
+	}
+	inputs := loadDistillInputs(target, inDB)
+
+	sandbox, err := flatrpc.SandboxToFlags(*flagSandbox)
+	if err != nil {
+		tool.Failf("failed to parse sandbox: %v", err)
+	}
+	env := sandbox
+	if *flagDebug {
+		env |= flatrpc.ExecEnvDebug
+	}
+	if *flagCover {
+		env |= flatrpc.ExecEnvSignal
+	}
+	ctx, done := context.WithCancel(context.Background())
+	replay := &distillReplay{
+		inputs: inputs,
+		runs:   *flagRuns,
+		done:   done,
+		opts: flatrpc.ExecOpts{
+			EnvFlags:   env,
+			ExecFlags:  flatrpc.ExecFlagThreaded | flatrpc.ExecFlagDedupCover,
+			SandboxArg: int64(*flagSandboxArg),
+		},
+	}
+	cfg := &rpcserver.LocalConfig{
+		Config: rpcserver.Config{
+			Config: vminfo.Config{
+				Target:     target,
+				VMType:     *flagType,
+				Features:   flatrpc.AllFeatures,
+				Debug:      *flagDebug,
+				Cover:      *flagCover,
+				Sandbox:    sandbox,
+				SandboxArg: int64(*flagSandboxArg),
+			},
+			Procs:    *flagProcs,
+			Slowdown: *flagSlowdown,
+		},
+		Executor:         *flagExecutor,
+		HandleInterrupts: true,
+		MachineChecked:   replay.machineChecked,
+	}
+	if err := rpcserver.RunLocal(ctx, cfg); err != nil {
+		tool.Fail(err)
+	}
+	if !replay.finished() {
+		tool.Failf("interrupted")
+	}
+
+	var records []db.Record
+	for _, inp := range distillCorpus(inputs, distillWeights[*flagWeight]) {
+		records = append(records, inp.rec)
+	}
+	if err := db.Create(flags.Arg(1), inDB.Version, records); err != nil {
+		tool.Fail(err)
+	}
+	out := os.Stdout
+	if *flagReport != "" {
+		if out, err = os.Create(*flagReport); err != nil {
+			tool.Fail(err)
+		}
+		defer out.Close()
+	}
+	writeDistillReport(out, inputs)
+}
+
+func loadDistillInputs(target *prog.Target, corpus *db.DB) []*distillInput {
+	var inputs []*distillInput
+	for key, rec := range corpus.Records {
+		inp := &distillInput{
+			key: key,
+			rec: rec,
+		}
+		var err error
+		if inp.prog, err = target.Deserialize(rec.Val, prog.NonStrict); err != nil {
+			inp.drop = dropBroken
+		}
+		inputs = append(inputs, inp)
+	}
+	sort.Slice(inputs, func(i, j int) bool {
+		return inputs[i].key < inputs[j].key
+	})
+	return inputs
+}
+
+type distillReplay struct {
+	inputs  []*distillInput
+	runs    int
+	opts    flatrpc.ExecOpts
+	done    func()
+	mu      sync.Mutex
+	pending int
+}
+
+func (replay *distillReplay) machineChecked(features flatrpc.Feature,
+	syscalls map[*prog.Syscall]bool) queue.Source {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+	q := queue.Plain()
+	for _, inp := range replay.inputs {
+		if inp.drop != "" {
+			continue
+		}
+		for _, call := range inp.prog.Calls {
+			if !syscalls[call.Meta] {
+				inp.unsupported = true
+			}
+		}
+		if inp.unsupported {
+			continue
+		}
+		allSignal := []int{-1}
+		for i := range inp.prog.Calls {
+			if i < 64 {
+				allSignal = append(allSignal, i)
+			}
+		}
+		for run := 0; run < replay.runs; run++ {
+			req := &queue.Request{
+				Prog: inp.prog,
+				ExecOpts: flatrpc.ExecOpts{
+					ExecFlags: flatrpc.ExecFlagCollectSignal,
+				},
+				ReturnAllSignal: allSignal,
+			}
+			req.OnDone(func(_ *queue.Request, res *queue.Result) bool {
+				replay.handleResult(inp, res)
+				return true
+			})
+			q.Submit(req)
+			replay.pending++
+		}
+	}
+	log.Logf(0, "replaying %v programs", replay.pending/replay.runs)
+	if replay.pending == 0 {
+		replay.done()
+	}
+	replay.opts.EnvFlags |= csource.FeaturesToFlags(features, nil)
+	return queue.DefaultOpts(queue.Retry(q), replay.opts)
+}
+
+func (replay *distillReplay) handleResult(inp *distillInput, res *queue.Result) {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+	if res.Status == queue.Success && res.Info != nil {
+		calls := make([]signal.Signal, len(inp.prog.Calls))
+		for i, info := range res.Info.Calls {
+			calls[i] = signal.FromRaw(info.Signal, fuzzer.SignalPrio(inp.prog, info, i))
+		}
+		var extra signal.Signal
+		if res.Info.Extra != nil {
+			extra = signal.FromRaw(res.Info.Extra.Signal, 0)
+		}
+		if inp.runs == 0 {
+			inp.calls, inp.extra = calls, extra
+		} else {
+			for i := range calls {
+				inp.calls[i] = inp.calls[i].Intersection(calls[i])
+			}
+			inp.extra = inp.extra.Intersection(extra)
+		}
+		inp.runs++
+		inp.elapsed += res.Info.Elapsed
+	}
+	replay.pending--
+	if replay.pending%1000 == 0 {
+		log.Logf(0, "%v executions left", replay.pending)
+	}
+	if replay.pending == 0 {
+		replay.done()
+	}
+}
+
+func (replay *distillReplay) finished() bool {
+	replay.mu.Lock()
+	defer replay.mu.Unlock()
+	return replay.pending == 0
+}
+
+var distillWeights = map[string]func(inp *distillInput) float64{
+	"calls": func(inp *distillInput) float64 {
+		return float64(len(inp.prog.Calls))
+	},
+	"time": func(inp *distillInput) float64 {
+		// Average execution time in microseconds, +1 to keep it positive.
+		return float64(inp.elapsed/uint64(inp.runs)/1000) + 1
+	},
+	"uniform": func(inp *distillInput) float64 {
+		return 1
+	},
+}
+
+// distillCorpus selects a subset of the replayed inputs that preserves all of their signal
+// and marks the rest as dropped. Programs that were not executed because they use unsupported
+// syscalls are kept as is since we can't judge them. Returns inputs of the distilled corpus.
+func distillCorpus(inputs []*distillInput, weight func(*distillInput) float64) []*distillInput {
+	var executed []*distillInput
+	var corpus []signal.Context
+	var cost []float64
+	for _, inp := range inputs {
+		if inp.drop != "" || inp.unsupported {
+			continue
+		}
+		if inp.runs == 0 {
+			inp.drop = dropFailed
+			continue
+		}
+		executed = append(executed, inp)
+		corpus = append(corpus, signal.Context{Signal: inp.signal()})
+		cost = append(cost, weight(inp))
+	}
+	for _, inp := range executed {
+		inp.drop = dropRedundant
+	}
+	for _, idx := range signal.SetCover(corpus, cost) {
+		executed[idx].drop = ""
+	}
+	var kept []*distillInput
+	for _, inp := range inputs {
+		if inp.drop == "" {
+			kept = append(kept, inp)
+		}
+	}
+	return kept
+}
+
+type distillSyscallLoss struct {
+	name   string
+	before signal.Signal
+	after  signal.Signal
+}
+
+func writeDistillReport(w io.Writer, inputs []*distillInput) {
+	var before, after signal.Signal
+	var kept, unsupported int
+	dropped := make(map[string]int)
+	syscalls := make(map[string]*distillSyscallLoss)
+	for _, inp := range inputs {
+		if inp.drop == "" {
+			kept++
+		} else {
+			dropped[inp.drop]++
+		}
+		if inp.unsupported {
+			unsupported++
+		}
+		sig := inp.signal()
+		before.Merge(sig)
+		if inp.drop == "" {
+			after.Merge(sig)
+		}
+		for i, callSig := range inp.calls {
+			name := inp.prog.Calls[i].Meta.Name
+			loss := syscalls[name]
+			if loss == nil {
+				loss = &distillSyscallLoss{name: name}
+				syscalls[name] = loss
+			}
+			loss.before.Merge(callSig)
+			if inp.drop == "" {
+				loss.after.Merge(callSig)
+			}
+		}
+	}
+	fmt.Fprintf(w, "programs: %v -> %v (%v not executed due to unsupported syscalls)\n",
+		len(inputs), kept, unsupported)
+	fmt.Fprintf(w, "dropped: %v broken, %v failed, %v redundant\n",
+		dropped[dropBroken], dropped[dropFailed], dropped[dropRedundant])
+	fmt.Fprintf(w, "signal: %v -> %v\n", before.Len(), after.Len())
+
+	var losses []*distillSyscallLoss
+	for _, loss := range syscalls {
+		if loss.before.Len() != loss.after.Len() {
+			losses = append(losses, loss)
+		}
+	}
+	sort.Slice(losses, func(i, j int) bool {
+		li := losses[i].before.Len() - losses[i].after.Len()
+		lj := losses[j].before.Len() - losses[j].after.Len()
+		if li != lj {
+			return li > lj
+		}
+		return losses[i].name < losses[j].name
+	})
+	fmt.Fprintf(w, "\nper-syscall signal loss:\n")
+	for _, loss := range losses {
+		fmt.Fprintf(w, "%v: %v -> %v (-%v)\n", loss.name, loss.before.Len(), loss.after.Len(),
+			loss.before.Len()-loss.after.Len())
+	}
+
+	fmt.Fprintf(w, "\ndropped programs:\n")
+	for _, inp := range inputs {
+		if inp.drop == "" {
+			continue
+		}
+		calls := 0
+		if inp.prog != nil {
+			calls = len(inp.prog.Calls)
+		}
+		fmt.Fprintf(w, "%v: %v, %v calls, signal %v\n", inp.key, inp.drop, calls, inp.signal().Len())
+	}
+}
@@ -79,6 +79,8 @@ func main() {
 			usage()
 		}
 		rm(args[1], args[2], target)
+	case "distill":
+		distill(args[1:], target)
 	default:
 		usage()
 	}
@@ -106,6 +108,10 @@ offered:
     syz-db print corpus.db
   remove a syscall from db
     syz-db rm corpus.db syscall_name
+  replay corpus on the local machine and keep only a minimal subset of programs that covers the same signal
+  (see syz-db distill -help for additional flags):
+    syz-db distill [-executor ./syz-executor -procs N -runs N -weight calls/time/uniform -report file] \
+      corpus.db distilled-corpus.db
 `)
 	os.Exit(1)
 }
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"strings"
@@ -12,6 +13,7 @@ import (
 
 	"github.com/google/syzkaller/pkg/db"
 	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/signal"
 	"github.com/google/syzkaller/prog"
 	"github.com/google/syzkaller/sys/targets"
 	"github.com/stretchr/testify/assert"
@@ -50,3 +52,48 @@ func TestDBRemoveMatchLine(t *testing.T) {
 	expected := fmt.Sprintf("%s\n", strings.Join(want, "\n"))
 	assert.Equal(t, expected, string(db1.Records["rm"].Val))
 }
+
+func TestDistillCorpus(t *testing.T) {
+	target, err := prog.GetTarget(targets.Linux, targets.AMD64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := func(key, text string, calls ...[]uint64) *distillInput {
+		p, err := target.Deserialize([]byte(text), prog.NonStrict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		inp := &distillInput{key: key, prog: p, runs: 1}
+		for _, raw := range calls {
+			inp.calls = append(inp.calls, signal.FromRaw(raw, 0))
+		}
+		return inp
+	}
+	inputs := []*distillInput{
+		input("a", "getpid()\ngetuid()\n", []uint64{1, 2}, []uint64{3, 4}),
+		input("b", "getpid()\n", []uint64{1, 2}),
+		input("c", "getuid()\n", []uint64{3}),
+		input("d", "getgid()\n", []uint64{3, 4, 5}),
+		input("e", "getgid()\n"),
+		{key: "f", drop: dropBroken},
+	}
+	inputs[4].runs = 0
+	var kept []string
+	for _, inp := range distillCorpus(inputs, distillWeights["calls"]) {
+		kept = append(kept, inp.key)
+	}
+	assert.Equal(t, []string{"b", "d"}, kept)
+	assert.Equal(t, dropRedundant, inputs[0].drop)
+	assert.Equal(t, dropRedundant, inputs[2].drop)
+	assert.Equal(t, dropFailed, inputs[4].drop)
+
+	buf := new(bytes.Buffer)
+	writeDistillReport(buf, inputs)
+	report := buf.String()
+	assert.Contains(t, report, "programs: 6 -> 2")
+	assert.Contains(t, report, "dropped: 1 broken, 1 failed, 2 redundant")
+	assert.Contains(t, report, "signal: 5 -> 5")
+	// Signal 3 and 4 is now covered only by getgid.
+	assert.Contains(t, report, "getuid: 2 -> 0 (-2)")
+	assert.Contains(t, report, "a: redundant, 2 calls, signal 4")
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-replay replays an executor session journal saved by syz-manager (see executor_journal config option)
+// in a fresh VM with the same timing and proc assignment, and prints the resulting crash, if any.
+// Usage:
+//
+//	syz-replay -config manager.cfg workdir/crashes/HASH/journal0
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/syzkaller/pkg/instance"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/pkg/rpcserver"
+	"github.com/google/syzkaller/pkg/tool"
+	"github.com/google/syzkaller/vm"
+)
+
+var (
+	flagConfig = flag.String("config", "", "manager configuration file (manager.cfg)")
+	flagIndex  = flag.Int("vm", 0, "index of the VM to use")
+	flagWait   = flag.Duration("wait", time.Minute, "how long to wait for a crash after the replay")
+	flagOutput = flag.String("output", "", "where to save the VM output")
+	flagDebug  = flag.Bool("debug", false, "print debug output")
+)
+
+func main() {
+	flag.Parse()
+	if len(flag.Args()) != 1 || *flagConfig == "" {
+		fmt.Fprintf(os.Stderr, "usage: syz-replay -config manager.cfg journal\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	journal := flag.Args()[0]
+	cfg, err := mgrconfig.LoadFile(*flagConfig)
+	if err != nil {
+		tool.Fail(err)
+	}
+	summary, err := rpcserver.SummarizeJournal(journal)
+	if err != nil {
+		tool.Fail(err)
+	}
+	reporter, err := report.NewReporter(cfg)
+	if err != nil {
+		tool.Fail(err)
+	}
+	vmPool, err := vm.Create(cfg, *flagDebug)
+	if err != nil {
+		tool.Fail(err)
+	}
+	defer vmPool.Close()
+	osutil.HandleInterrupts(vm.Shutdown)
+	inst, err := instance.CreateExecProgInstance(vmPool, *flagIndex, cfg, reporter, &instance.OptionalConfig{
+		Logf: func(level int, msg string, args ...interface{}) {
+			log.Logf(level, msg, args...)
+		},
+	})
+	if err != nil {
+		tool.Fail(err)
+	}
+	defer inst.Close()
+	duration := summary.Duration + *flagWait
+	log.Logf(0, "replaying session of %v (%v with the wait time)", summary.Duration, duration)
+	res, err := inst.RunJournal(instance.ExecParams{
+		Journal:  journal,
+		Duration: duration,
+	})
+	if err != nil {
+		tool.Fail(err)
+	}
+	if *flagOutput != "" {
+		if err := osutil.WriteFile(*flagOutput, res.Output); err != nil {
+			tool.Fail(err)
+		}
+	}
+	if res.Report == nil {
+		fmt.Printf("no crash\n")
+		return
+	}
+	fmt.Printf("crashed: %v\n\n%s", res.Report.Title, res.Report.Report)
+}
@@ -21,13 +21,15 @@ import (
 )
 
 var (
-	flagConfig = flag.String("config", "", "manager configuration file (manager.cfg)")
-	flagCount  = flag.Int("count", 0, "number of VMs to use (overrides config count param)")
-	flagDebug  = flag.Bool("debug", false, "print debug output")
-	flagOutput = flag.String("output", filepath.Join(".", "repro.txt"), "output syz repro file (repro.txt)")
-	flagCRepro = flag.String("crepro", filepath.Join(".", "repro.c"), "output c file (repro.c)")
-	flagTitle  = flag.String("title", "", "where to save the title of the reproduced bug")
-	flagStrace = flag.String("strace", "", "output strace log (strace_bin must be set)")
+	flagConfig  = flag.String("config", "", "manager configuration file (manager.cfg)")
+	flagCount   = flag.Int("count", 0, "number of VMs to use (overrides config count param)")
+	flagDebug   = flag.Bool("debug", false, "print debug output")
+	flagOutput  = flag.String("output", filepath.Join(".", "repro.txt"), "output syz repro file (repro.txt)")
+	flagCRepro  = flag.String("crepro", filepath.Join(".", "repro.c"), "output c file (repro.c)")
+	flagTitle   = flag.String("title", "", "where to save the title of the reproduced bug")
+	flagStrace  = flag.String("strace", "", "output strace log (strace_bin must be set)")
+	flagJournal = flag.String("journal", "", "executor session journal of the crash (replayed first)")
+	flagStable  = flag.Bool("stabilize", false, "measure the reproducer reliability and try to improve it")
 )
 
 func main() {
@@ -68,10 +70,12 @@ func main() {
 		defer done()
 
 		res, stats, err := repro.Run(ctx, data, repro.Environment{
-			Config:   cfg,
-			Features: flatrpc.AllFeatures,
-			Reporter: reporter,
-			Pool:     pool,
+			Config:    cfg,
+			Features:  flatrpc.AllFeatures,
+			Reporter:  reporter,
+			Pool:      pool,
+			Journal:   *flagJournal,
+			Stabilize: *flagStable,
 		})
 		if err != nil {
 			log.Logf(0, "reproduction failed: %v", err)
@@ -82,13 +86,21 @@ func main() {
 			fmt.Printf("simplifying prog options: %v\n", stats.SimplifyProgTime)
 			fmt.Printf("extracting C: %v\n", stats.ExtractCTime)
 			fmt.Printf("simplifying C: %v\n", stats.SimplifyCTime)
+			fmt.Printf("stabilizing: %v\n", stats.StabilizeTime)
 		}
 		if res == nil {
 			return
 		}
 
-		fmt.Printf("opts: %+v crepro: %v\n\n", res.Opts, res.CRepro)
-		progSerialized := res.Prog.Serialize()
+		fmt.Printf("opts: %+v crepro: %v\n", res.Opts, res.CRepro)
+		if res.SyzReliability != nil {
+			fmt.Printf("syz reliability: %v\n", res.SyzReliability)
+		}
+		if res.CReliability != nil {
+			fmt.Printf("C reliability: %v\n", res.CReliability)
+		}
+		fmt.Printf("\n")
+		progSerialized := append(res.Requirements.Serialize(), res.Prog.Serialize()...)
 		fmt.Printf("%s\n", progSerialized)
 		if err = osutil.WriteFile(*flagOutput, progSerialized); err == nil {
 			fmt.Printf("program saved to %s\n", *flagOutput)
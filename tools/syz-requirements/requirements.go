@@ -0,0 +1,131 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-requirements prints kernel configs and executor features a reproducer needs to trigger the bug.
+// By default the requirements are derived statically from the syscalls used by the reproducer,
+// its options and the crash type. If a predicate command is specified, the configs are refined
+// by building and testing kernels with smaller configs (see kconfig.Minimize).
+// The predicate is invoked with the path to the candidate .config file and should exit with status 0
+// if the bug reproduces, and with status 1 if it does not.
+// Example use:
+//
+//	$ go run tools/syz-requirements/requirements.go -type KASAN repro.syz
+//	$ go run tools/syz-requirements/requirements.go -type KASAN -sourcedir /src/linux \
+//		-base dashboard/config/linux/upstream-kasan-base.config \
+//		-full dashboard/config/linux/upstream-kasan.config \
+//		-pred ./build-and-test.sh repro.syz
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+
+	"github.com/google/syzkaller/pkg/csource"
+	"github.com/google/syzkaller/pkg/debugtracer"
+	"github.com/google/syzkaller/pkg/kconfig"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report/crash"
+	"github.com/google/syzkaller/pkg/repro"
+	"github.com/google/syzkaller/pkg/tool"
+	"github.com/google/syzkaller/prog"
+	_ "github.com/google/syzkaller/sys"
+	"github.com/google/syzkaller/sys/targets"
+)
+
+func main() {
+	var (
+		flagOS        = flag.String("os", runtime.GOOS, "target os")
+		flagArch      = flag.String("arch", runtime.GOARCH, "target arch")
+		flagOpts      = flag.String("opts", "", "reproducer options (by default taken from the first line of the reproducer)")
+		flagType      = flag.String("type", "", "crash type (e.g. KASAN, LEAK, DATARACE)")
+		flagSourceDir = flag.String("sourcedir", "", "kernel sources dir")
+		flagBase      = flag.String("base", "", "baseline config (the bug does not reproduce)")
+		flagFull      = flag.String("full", "", "full config (the bug reproduces)")
+		flagPred      = flag.String("pred", "", "command that builds and tests a kernel with the given config")
+	)
+	flag.Parse()
+	if flag.NArg() != 1 {
+		tool.Failf("usage: syz-requirements [flags] repro.syz")
+	}
+	target, err := prog.GetTarget(*flagOS, *flagArch)
+	if err != nil {
+		tool.Fail(err)
+	}
+	data, err := os.ReadFile(flag.Arg(0))
+	if err != nil {
+		tool.Fail(err)
+	}
+	p, err := target.Deserialize(data, prog.NonStrict)
+	if err != nil {
+		tool.Failf("failed to deserialize the reproducer: %v", err)
+	}
+	opts, err := reproOpts(*flagOpts, data)
+	if err != nil {
+		tool.Failf("failed to parse reproducer options: %v", err)
+	}
+	req := repro.ProgRequirements(p, opts, crash.Type(*flagType))
+	if *flagPred != "" {
+		if *flagOS != targets.Linux || *flagSourceDir == "" || *flagBase == "" || *flagFull == "" {
+			tool.Failf("-pred requires linux -os, -sourcedir, -base and -full")
+		}
+		refine(req, target, *flagSourceDir, *flagBase, *flagFull, *flagPred)
+	}
+	os.Stdout.Write(req.Serialize())
+}
+
+func reproOpts(flagOpts string, data []byte) (csource.Options, error) {
+	if flagOpts != "" {
+		return csource.DeserializeOptions([]byte(flagOpts))
+	}
+	line, _, _ := bytes.Cut(data, []byte("\n"))
+	if !bytes.HasPrefix(line, []byte("#")) {
+		return csource.Options{}, errors.New("no options in the reproducer, specify -opts")
+	}
+	return csource.DeserializeOptions(bytes.TrimSpace(line[1:]))
+}
+
+func refine(req *repro.Requirements, target *prog.Target, sourceDir, baseFile, fullFile, pred string) {
+	kconf, err := kconfig.Parse(targets.Get(target.OS, target.Arch), filepath.Join(sourceDir, "Kconfig"))
+	if err != nil {
+		tool.Fail(err)
+	}
+	base, err := kconfig.ParseConfig(baseFile)
+	if err != nil {
+		tool.Fail(err)
+	}
+	full, err := kconfig.ParseConfig(fullFile)
+	if err != nil {
+		tool.Fail(err)
+	}
+	dir, err := os.MkdirTemp("", "syz-requirements")
+	if err != nil {
+		tool.Fail(err)
+	}
+	defer os.RemoveAll(dir)
+	configFile := filepath.Join(dir, ".config")
+	predFunc := func(candidate *kconfig.ConfigFile) (bool, error) {
+		if err := osutil.WriteFile(configFile, candidate.Serialize()); err != nil {
+			return false, err
+		}
+		cmd := exec.Command(pred, configFile)
+		cmd.Stdout = os.Stderr
+		cmd.Stderr = os.Stderr
+		err := cmd.Run()
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	dt := &debugtracer.GenericTracer{
+		TraceWriter: os.Stderr,
+	}
+	if err := req.RefineConfigs(kconf, base, full, predFunc, dt); err != nil {
+		tool.Fail(err)
+	}
+}
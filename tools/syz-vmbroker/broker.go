@@ -0,0 +1,538 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-vmbroker boots a pool of VMs and leases them to several syz-manager's over RPC.
+// Managers connect to the broker with the proxyapp VM type, see docs/vm_broker.md for details.
+// Each lease gets a freshly booted instance. Leases are granted according to manager
+// priorities and quotas, and leases of lower priority managers are preempted when
+// a higher priority manager (or a manager that did not get its reserved instances) waits.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/sys/targets"
+	"github.com/google/syzkaller/vm"
+	"github.com/google/syzkaller/vm/dispatcher"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+var (
+	flagConfig = flag.String("config", "", "config file")
+	flagDebug  = flag.Bool("debug", false, "dump VM output to console")
+)
+
+type Config struct {
+	// Address to listen on for manager connections (host:port).
+	RPC     string `json:"rpc"`
+	Workdir string `json:"workdir"`
+	// Location of the disk image, SSH key and user, see the same syz-manager config parameters.
+	Image   string `json:"image"`
+	SSHKey  string `json:"sshkey"`
+	SSHUser string `json:"ssh_user"`
+	// Target OS/arch, e.g. "linux/amd64".
+	Target string `json:"target"`
+	// VM type and type-specific parameters, see the same syz-manager config parameters.
+	// Note: "count" in the VM config determines the size of the shared pool.
+	Type string          `json:"type"`
+	VM   json.RawMessage `json:"vm"`
+	// Slowdown of the VMs, used to scale timeouts (1 by default).
+	Slowdown int `json:"slowdown"`
+	// Managers allowed to lease instances from the broker.
+	Managers []ManagerConfig `json:"managers"`
+}
+
+type ManagerConfig struct {
+	// Name of the manager, must match "manager" in the proxyapp plugin config of the manager.
+	Name string `json:"name"`
+	// Optional secret that the manager must pass as "key" in the plugin config.
+	Key string `json:"key"`
+	// Waiting managers preempt leases of managers with lower priority.
+	Priority int `json:"priority"`
+	// Maximum number of instances leased to the manager at the same time (0 means no limit).
+	Quota int `json:"quota"`
+	// Number of instances guaranteed to the manager: these are never preempted,
+	// and a manager that holds fewer instances can preempt any other manager
+	// that holds more than its reserved number of instances.
+	Reserved int `json:"reserved"`
+}
+
+func main() {
+	flag.Parse()
+	cfg, err := loadConfig(*flagConfig)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pool, err := createPool(cfg, *flagDebug)
+	if err != nil {
+		log.Fatal(err)
+	}
+	broker, err := NewBroker(cfg.Managers, pool.Count())
+	if err != nil {
+		log.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", cfg.RPC)
+	if err != nil {
+		log.Fatalf("failed to listen on %v: %v", cfg.RPC, err)
+	}
+	log.Logf(0, "serving %v VMs on tcp://%v", pool.Count(), ln.Addr())
+	ctx := vm.ShutdownCtx()
+	vms := dispatcher.NewPool[*instance](pool.Count(), pool.Create, broker.ServeInstance)
+	go func() {
+		for err := range vms.BootErrors {
+			log.Logf(0, "failed to boot VM: %v", err)
+		}
+	}()
+	go broker.Serve(ctx, ln)
+	vms.Loop(ctx)
+}
+
+func loadConfig(filename string) (*Config, error) {
+	cfg := &Config{
+		SSHUser:  "root",
+		Slowdown: 1,
+	}
+	if err := config.LoadFile(filename, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.RPC == "" || cfg.Workdir == "" || cfg.Type == "" || cfg.Target == "" {
+		return nil, fmt.Errorf("config params rpc, workdir, type and target must be specified")
+	}
+	if len(cfg.Managers) == 0 {
+		return nil, fmt.Errorf("no managers specified in the config")
+	}
+	if cfg.Slowdown <= 0 {
+		return nil, fmt.Errorf("bad slowdown %v", cfg.Slowdown)
+	}
+	return cfg, nil
+}
+
+// vmPool creates instances of the configured VM type.
+type vmPool struct {
+	impl    vmimpl.Pool
+	workdir string
+}
+
+// instance is a booted VM along with its temp workdir.
+type instance struct {
+	vmimpl.Instance
+	index   int
+	workdir string
+}
+
+func (inst *instance) Close() error {
+	err := inst.Instance.Close()
+	os.RemoveAll(inst.workdir)
+	return err
+}
+
+func createPool(cfg *Config, debug bool) (*vmPool, error) {
+	targetOS, targetArch, ok := strings.Cut(cfg.Target, "/")
+	sysTarget := targets.Get(targetOS, targetArch)
+	if !ok || sysTarget == nil {
+		return nil, fmt.Errorf("unknown target %q", cfg.Target)
+	}
+	typ, ok := vmimpl.Types[cfg.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown instance type %q", cfg.Type)
+	}
+	workdir := osutil.Abs(cfg.Workdir)
+	if err := osutil.MkdirAll(workdir); err != nil {
+		return nil, fmt.Errorf("failed to create workdir: %w", err)
+	}
+	impl, err := typ.Ctor(&vmimpl.Env{
+		Name:     "vmbroker",
+		OS:       sysTarget.OS,
+		Arch:     sysTarget.VMArch,
+		Workdir:  workdir,
+		Image:    osutil.Abs(cfg.Image),
+		SSHKey:   osutil.Abs(cfg.SSHKey),
+		SSHUser:  cfg.SSHUser,
+		Timeouts: sysTarget.Timeouts(cfg.Slowdown),
+		Debug:    debug,
+		Config:   cfg.VM,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &vmPool{impl: impl, workdir: workdir}, nil
+}
+
+func (pool *vmPool) Count() int {
+	return pool.impl.Count()
+}
+
+func (pool *vmPool) Create(index int) (*instance, error) {
+	workdir, err := osutil.ProcessTempDir(pool.workdir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instance temp dir: %w", err)
+	}
+	impl, err := pool.impl.Create(workdir, index)
+	if err != nil {
+		os.RemoveAll(workdir)
+		return nil, err
+	}
+	return &instance{
+		Instance: impl,
+		index:    index,
+		workdir:  workdir,
+	}, nil
+}
+
+var (
+	errReleased  = errors.New("instance was released")
+	errPreempted = errors.New("instance was preempted")
+)
+
+// Broker matches booted instances with the managers waiting for them.
+type Broker struct {
+	mu       sync.Mutex
+	managers map[string]*managerState
+	// Booted instances that are not leased yet.
+	idle []*offer
+	// Pending lease requests.
+	waiters []*waiter
+	leases  map[string]*Lease
+	seq     int
+	count   int
+}
+
+type managerState struct {
+	ManagerConfig
+	// Number of instances currently leased to the manager (including the ones being preempted).
+	leases int
+}
+
+type offer struct {
+	inst     *instance
+	assigned chan *Lease
+}
+
+type waiter struct {
+	mgr   *managerState
+	seq   int
+	ready chan *Lease
+}
+
+// Lease is an instance leased to a manager.
+type Lease struct {
+	ID      string
+	Manager string
+	inst    *instance
+	seq     int
+	started time.Time
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	// Protected by Broker.mu.
+	preempted bool
+
+	mu      sync.Mutex
+	runs    map[string]*run
+	nextRun int
+}
+
+func NewBroker(managers []ManagerConfig, count int) (*Broker, error) {
+	b := &Broker{
+		managers: make(map[string]*managerState),
+		leases:   make(map[string]*Lease),
+		count:    count,
+	}
+	reserved := 0
+	for _, mgr := range managers {
+		if mgr.Name == "" {
+			return nil, fmt.Errorf("empty manager name")
+		}
+		if b.managers[mgr.Name] != nil {
+			return nil, fmt.Errorf("duplicate manager %q", mgr.Name)
+		}
+		if mgr.Quota < 0 || mgr.Reserved < 0 || mgr.Quota != 0 && mgr.Reserved > mgr.Quota {
+			return nil, fmt.Errorf("manager %q: bad quota %v/reserved %v", mgr.Name, mgr.Quota, mgr.Reserved)
+		}
+		reserved += mgr.Reserved
+		b.managers[mgr.Name] = &managerState{ManagerConfig: mgr}
+	}
+	if reserved > count {
+		return nil, fmt.Errorf("managers reserve %v instances, but there are only %v", reserved, count)
+	}
+	return b, nil
+}
+
+// Authenticate checks manager credentials and returns the max number of instances
+// the manager can lease at the same time.
+func (b *Broker) Authenticate(name, key string) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	mgr := b.managers[name]
+	if mgr == nil || mgr.Key != key {
+		return 0, fmt.Errorf("unknown manager %q or wrong key", name)
+	}
+	if mgr.Quota != 0 {
+		return min(mgr.Quota, b.count), nil
+	}
+	return b.count, nil
+}
+
+// ServeInstance is the dispatcher.Runner for booted instances: it offers the instance
+// to the waiting managers and blocks until the lease ends. After that the instance is
+// destroyed and a fresh one is booted by the pool.
+func (b *Broker) ServeInstance(ctx context.Context, inst *instance, updInfo dispatcher.UpdateInfo) {
+	o := &offer{
+		inst:     inst,
+		assigned: make(chan *Lease, 1),
+	}
+	b.mu.Lock()
+	b.idle = append(b.idle, o)
+	b.scheduleLocked()
+	b.mu.Unlock()
+	var lease *Lease
+	select {
+	case lease = <-o.assigned:
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.idle = removeItem(b.idle, o)
+		b.mu.Unlock()
+		// The instance could have been assigned concurrently.
+		select {
+		case lease = <-o.assigned:
+		default:
+			return
+		}
+	}
+	updInfo(func(info *dispatcher.Info) {
+		info.Status = fmt.Sprintf("leased to %v", lease.Manager)
+	})
+	select {
+	case <-lease.ctx.Done():
+	case <-ctx.Done():
+	}
+	b.end(lease)
+}
+
+// Acquire blocks until an instance is leased to the manager or ctx is cancelled.
+func (b *Broker) Acquire(ctx context.Context, name string) (*Lease, error) {
+	b.mu.Lock()
+	mgr := b.managers[name]
+	if mgr == nil {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("unknown manager %q", name)
+	}
+	b.seq++
+	w := &waiter{
+		mgr:   mgr,
+		seq:   b.seq,
+		ready: make(chan *Lease, 1),
+	}
+	b.waiters = append(b.waiters, w)
+	b.scheduleLocked()
+	b.mu.Unlock()
+	select {
+	case lease := <-w.ready:
+		return lease, nil
+	case <-ctx.Done():
+		b.mu.Lock()
+		b.waiters = removeItem(b.waiters, w)
+		b.mu.Unlock()
+		select {
+		case lease := <-w.ready:
+			b.Release(lease)
+		default:
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// Release ends the lease, the instance is destroyed.
+func (b *Broker) Release(lease *Lease) {
+	lease.cancel(errReleased)
+}
+
+// end is called when the instance of the lease is about to be destroyed.
+func (b *Broker) end(lease *Lease) {
+	lease.cancel(errReleased)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.leases[lease.ID] != lease {
+		return
+	}
+	delete(b.leases, lease.ID)
+	b.managers[lease.Manager].leases--
+	log.Logf(0, "lease %v of %v ended after %v: %v", lease.ID, lease.Manager,
+		time.Since(lease.started).Round(time.Second), context.Cause(lease.ctx))
+	// The manager may now be below its quota.
+	b.scheduleLocked()
+}
+
+func (b *Broker) scheduleLocked() {
+	sort.SliceStable(b.waiters, func(i, j int) bool {
+		return b.waiters[i].before(b.waiters[j])
+	})
+	for len(b.idle) != 0 {
+		var w *waiter
+		for _, w1 := range b.waiters {
+			if w1.mgr.canLease() {
+				w = w1
+				break
+			}
+		}
+		if w == nil {
+			break
+		}
+		o := b.idle[0]
+		b.idle = b.idle[1:]
+		b.waiters = removeItem(b.waiters, w)
+		lease := b.newLeaseLocked(o.inst, w.mgr)
+		o.assigned <- lease
+		w.ready <- lease
+	}
+	b.preemptLocked()
+}
+
+func (b *Broker) newLeaseLocked(inst *instance, mgr *managerState) *Lease {
+	b.seq++
+	ctx, cancel := context.WithCancelCause(context.Background())
+	lease := &Lease{
+		ID:      fmt.Sprintf("vm-%v-%v", inst.index, b.seq),
+		Manager: mgr.Name,
+		inst:    inst,
+		seq:     b.seq,
+		started: time.Now(),
+		ctx:     ctx,
+		cancel:  cancel,
+		runs:    make(map[string]*run),
+	}
+	b.leases[lease.ID] = lease
+	mgr.leases++
+	log.Logf(0, "leased %v to %v (%v leases)", lease.ID, mgr.Name, mgr.leases)
+	return lease
+}
+
+// preemptLocked preempts leases for waiters that can't be satisfied with idle instances.
+// The preempted instance is rebooted and then given to the highest priority waiter.
+func (b *Broker) preemptLocked() {
+	// Instances that are booting now or are being preempted will be offered soon,
+	// so the first waiters will get them without preempting anything.
+	soon := b.count - len(b.idle) - len(b.leases)
+	for _, lease := range b.leases {
+		if lease.preempted {
+			soon++
+		}
+	}
+	for _, w := range b.waiters {
+		if !w.mgr.canLease() {
+			continue
+		}
+		if soon > 0 {
+			soon--
+			continue
+		}
+		victim := b.victimLocked(w.mgr)
+		if victim == nil {
+			continue
+		}
+		victim.preempted = true
+		victim.cancel(errPreempted)
+		log.Logf(0, "preempting %v of %v in favor of %v", victim.ID, victim.Manager, w.mgr.Name)
+	}
+}
+
+// victimLocked returns the lease to preempt for the manager, or nil.
+// A lease can be preempted if its manager holds more than its reserved number of instances,
+// and has lower priority than the requesting manager, or the requesting manager holds fewer
+// than its reserved number of instances. Leases of the lowest priority managers are preempted
+// first, and among them the most recent leases (that have done the least work).
+func (b *Broker) victimLocked(mgr *managerState) *Lease {
+	// Number of leases that are already being preempted, per manager.
+	preempted := make(map[string]int)
+	for _, lease := range b.leases {
+		if lease.preempted {
+			preempted[lease.Manager]++
+		}
+	}
+	var victim *Lease
+	for _, lease := range b.leases {
+		owner := b.managers[lease.Manager]
+		if lease.preempted || owner == mgr || owner.leases-preempted[owner.Name] <= owner.Reserved ||
+			owner.Priority >= mgr.Priority && mgr.leases >= mgr.Reserved {
+			continue
+		}
+		if victim == nil {
+			victim = lease
+			continue
+		}
+		victimOwner := b.managers[victim.Manager]
+		if owner.Priority < victimOwner.Priority ||
+			owner.Priority == victimOwner.Priority && lease.seq > victim.seq {
+			victim = lease
+		}
+	}
+	return victim
+}
+
+func (mgr *managerState) canLease() bool {
+	return mgr.Quota == 0 || mgr.leases < mgr.Quota
+}
+
+// before returns true if w should get an instance before w1:
+// managers below their reserved number of instances go first, then higher priority managers,
+// then the requests are served in FIFO order.
+func (w *waiter) before(w1 *waiter) bool {
+	below, below1 := w.mgr.leases < w.mgr.Reserved, w1.mgr.leases < w1.mgr.Reserved
+	if below != below1 {
+		return below
+	}
+	if w.mgr.Priority != w1.mgr.Priority {
+		return w.mgr.Priority > w1.mgr.Priority
+	}
+	return w.seq < w1.seq
+}
+
+// ManagerStatus describes the current state of a manager.
+type ManagerStatus struct {
+	Name    string
+	Leases  int
+	Waiting int
+}
+
+func (b *Broker) Status() []ManagerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var ret []ManagerStatus
+	for _, mgr := range b.managers {
+		status := ManagerStatus{
+			Name:   mgr.Name,
+			Leases: mgr.leases,
+		}
+		for _, w := range b.waiters {
+			if w.mgr == mgr {
+				status.Waiting++
+			}
+		}
+		ret = append(ret, status)
+	}
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].Name < ret[j].Name
+	})
+	return ret
+}
+
+func removeItem[T comparable](list []T, item T) []T {
+	for i, x := range list {
+		if x == item {
+			return append(list[:i], list[i+1:]...)
+		}
+	}
+	return list
+}
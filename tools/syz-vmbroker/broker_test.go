@@ -0,0 +1,252 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/vm/dispatcher"
+	_ "github.com/google/syzkaller/vm/proxyapp"
+	"github.com/google/syzkaller/vm/vmimpl"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBrokerPreemption(t *testing.T) {
+	broker, cancel := startBroker(t, 2, []ManagerConfig{
+		{Name: "low"},
+		{Name: "high", Priority: 1, Quota: 1},
+	})
+	defer cancel()
+	ctx := context.Background()
+	low1, err := broker.Acquire(ctx, "low")
+	assert.NoError(t, err)
+	low2, err := broker.Acquire(ctx, "low")
+	assert.NoError(t, err)
+
+	// The high priority manager preempts the most recent lease.
+	high, err := broker.Acquire(ctx, "high")
+	assert.NoError(t, err)
+	assert.Equal(t, "high", high.Manager)
+	assert.NoError(t, context.Cause(low1.ctx))
+	assert.ErrorIs(t, context.Cause(low2.ctx), errPreempted)
+
+	// The low priority manager can't preempt, so it waits until the high priority manager releases.
+	acquired := make(chan *Lease)
+	go func() {
+		lease, err := broker.Acquire(ctx, "low")
+		assert.NoError(t, err)
+		acquired <- lease
+	}()
+	select {
+	case <-acquired:
+		t.Fatalf("low priority manager preempted a lease")
+	case <-time.After(100 * time.Millisecond):
+	}
+	assert.Equal(t, []ManagerStatus{
+		{Name: "high", Leases: 1},
+		{Name: "low", Leases: 1, Waiting: 1},
+	}, broker.Status())
+	broker.Release(high)
+	low3 := <-acquired
+	assert.Equal(t, "low", low3.Manager)
+	assert.False(t, isPreempted(high))
+}
+
+func TestBrokerReserved(t *testing.T) {
+	broker, cancel := startBroker(t, 2, []ManagerConfig{
+		{Name: "high", Priority: 1},
+		{Name: "reserved", Reserved: 1},
+	})
+	defer cancel()
+	ctx := context.Background()
+	high1, err := broker.Acquire(ctx, "high")
+	assert.NoError(t, err)
+	high2, err := broker.Acquire(ctx, "high")
+	assert.NoError(t, err)
+
+	// The manager gets its reserved instance despite the lower priority.
+	reserved, err := broker.Acquire(ctx, "reserved")
+	assert.NoError(t, err)
+	assert.Equal(t, "reserved", reserved.Manager)
+	assert.False(t, isPreempted(high1))
+	assert.True(t, isPreempted(high2))
+
+	// The reserved instance is not preempted by a higher priority manager.
+	ctx1, cancel1 := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel1()
+	_, err = broker.Acquire(ctx1, "high")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, isPreempted(reserved))
+}
+
+func TestBrokerConfig(t *testing.T) {
+	_, err := NewBroker([]ManagerConfig{{Name: "a", Reserved: 2}, {Name: "b", Reserved: 1}}, 2)
+	assert.Error(t, err)
+	_, err = NewBroker([]ManagerConfig{{Name: "a"}, {Name: "a"}}, 2)
+	assert.Error(t, err)
+	_, err = NewBroker([]ManagerConfig{{Name: "a", Quota: 1, Reserved: 2}}, 2)
+	assert.Error(t, err)
+	broker, err := NewBroker([]ManagerConfig{{Name: "a", Key: "secret", Quota: 5}, {Name: "b"}}, 2)
+	assert.NoError(t, err)
+	_, err = broker.Authenticate("a", "wrong")
+	assert.Error(t, err)
+	_, err = broker.Authenticate("c", "")
+	assert.Error(t, err)
+	count, err := broker.Authenticate("a", "secret")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+// TestBrokerProxyApp tests the broker with the real proxyapp client used by syz-manager.
+func TestBrokerProxyApp(t *testing.T) {
+	broker, cancel := startBroker(t, 1, []ManagerConfig{
+		{Name: "low", Key: "key"},
+		{Name: "high", Priority: 1},
+	})
+	defer cancel()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	go broker.Serve(ctx, ln)
+
+	pool, err := dialBroker(ln.Addr().String(), "low", "key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, pool.Count())
+	inst, err := pool.Create(t.TempDir(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := inst.Copy("/some/file")
+	assert.NoError(t, err)
+	assert.Equal(t, "/vm/file", file)
+	outc, errc, err := inst.Run(time.Hour, nil, "echo hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	output := make(chan string)
+	go func() {
+		var out []byte
+		for {
+			select {
+			case data := <-outc:
+				out = append(out, data...)
+			case err := <-errc:
+				assert.NoError(t, err)
+				// Drain the rest of the output.
+				time.Sleep(100 * time.Millisecond)
+				for len(outc) != 0 {
+					out = append(out, <-outc...)
+				}
+				output <- string(out)
+				return
+			}
+		}
+	}()
+
+	// Preempt the instance by the higher priority manager.
+	high, err := broker.Acquire(ctx, "high")
+	assert.NoError(t, err)
+	out := <-output
+	assert.True(t, strings.HasPrefix(out, "output of echo hello\n"), out)
+	assert.Contains(t, out, "SYZ-EXECUTOR: PREEMPTED")
+	_, err = inst.Forward(1234)
+	assert.ErrorContains(t, err, "preempted")
+	assert.NoError(t, inst.Close())
+	broker.Release(high)
+
+	// Wrong credentials.
+	_, err = dialBroker(ln.Addr().String(), "low", "wrong")
+	assert.Error(t, err)
+}
+
+func isPreempted(lease *Lease) bool {
+	return context.Cause(lease.ctx) == errPreempted
+}
+
+func startBroker(t *testing.T, count int, managers []ManagerConfig) (*Broker, context.CancelFunc) {
+	broker, err := NewBroker(managers, count)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vms := dispatcher.NewPool[*instance](count,
+		func(idx int) (*instance, error) {
+			workdir, err := os.MkdirTemp(t.TempDir(), "vm")
+			if err != nil {
+				return nil, err
+			}
+			return &instance{
+				Instance: &testInstance{closed: make(chan bool)},
+				index:    idx,
+				workdir:  workdir,
+			}, nil
+		},
+		broker.ServeInstance)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan bool)
+	go func() {
+		vms.Loop(ctx)
+		close(done)
+	}()
+	return broker, func() {
+		cancel()
+		<-done
+	}
+}
+
+// dialBroker creates a proxyapp VM pool connected to the broker (as syz-manager does).
+func dialBroker(addr, manager, key string) (vmimpl.Pool, error) {
+	return vmimpl.Types["proxyapp"].Ctor(&vmimpl.Env{
+		Config: []byte(fmt.Sprintf(`{"rpc_server_uri": %q, "security": "none",
+			"config": {"manager": %q, "key": %q}}`, addr, manager, key)),
+	})
+}
+
+type testInstance struct {
+	closed chan bool
+}
+
+func (inst *testInstance) Copy(hostSrc string) (string, error) {
+	return "/vm/" + hostSrc[strings.LastIndexByte(hostSrc, '/')+1:], nil
+}
+
+func (inst *testInstance) Forward(port int) (string, error) {
+	return fmt.Sprintf("localhost:%v", port), nil
+}
+
+func (inst *testInstance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	outc := make(chan []byte, 1)
+	errc := make(chan error, 1)
+	outc <- []byte(fmt.Sprintf("output of %v\n", command))
+	go func() {
+		select {
+		case <-stop:
+			errc <- vmimpl.ErrTimeout
+		case <-inst.closed:
+			errc <- fmt.Errorf("instance closed")
+		}
+	}()
+	return outc, errc, nil
+}
+
+func (inst *testInstance) Diagnose(rep *report.Report) ([]byte, bool) {
+	return nil, false
+}
+
+func (inst *testInstance) Close() error {
+	close(inst.closed)
+	return nil
+}
@@ -0,0 +1,373 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/vm/proxyapp/proxyrpc"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+// ClientConfig is the "config" part of the proxyapp VM config of a manager.
+type ClientConfig struct {
+	Manager string `json:"manager"`
+	Key     string `json:"key"`
+}
+
+const (
+	// Managers enforce their own run timeouts and stop runs with RunStop.
+	runTimeout = 7 * 24 * time.Hour
+	// The same string as the one vm package expects for preempted instances.
+	preemptedMarker = "\nSYZ-EXECUTOR: PREEMPTED\n"
+)
+
+// Serve accepts manager connections, each connection is served by a separate session.
+func (b *Broker) Serve(ctx context.Context, ln net.Listener) {
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Logf(0, "failed to accept a connection: %v", err)
+			continue
+		}
+		go b.serveConn(ctx, conn)
+	}
+}
+
+func (b *Broker) serveConn(ctx context.Context, conn net.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	sess := &session{
+		broker: b,
+		ctx:    ctx,
+		leases: make(map[string]*Lease),
+		logs:   make(chan string, 16),
+	}
+	serv := rpc.NewServer()
+	if err := serv.RegisterName("ProxyVM", sess); err != nil {
+		panic(err)
+	}
+	// ServeCodec waits for all pending calls after the connection is closed,
+	// so the blocking calls need to be cancelled as soon as reading fails.
+	serv.ServeCodec(&sessionCodec{jsonrpc.NewServerCodec(conn), cancel})
+	cancel()
+	sess.close()
+}
+
+type sessionCodec struct {
+	rpc.ServerCodec
+	cancel context.CancelFunc
+}
+
+func (sc *sessionCodec) ReadRequestHeader(r *rpc.Request) error {
+	err := sc.ServerCodec.ReadRequestHeader(r)
+	if err != nil {
+		sc.cancel()
+	}
+	return err
+}
+
+// session serves proxyapp RPC requests of one manager connection.
+type session struct {
+	broker *Broker
+	ctx    context.Context
+	logs   chan string
+
+	mu      sync.Mutex
+	manager string
+	leases  map[string]*Lease
+}
+
+var _ proxyrpc.ProxyAppInterface = (*session)(nil)
+
+func (sess *session) close() {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	for _, lease := range sess.leases {
+		sess.broker.Release(lease)
+	}
+	sess.leases = nil
+	if sess.manager != "" {
+		log.Logf(0, "manager %v disconnected", sess.manager)
+	}
+}
+
+func (sess *session) logf(msg string, args ...any) {
+	select {
+	case sess.logs <- fmt.Sprintf(msg, args...) + "\n":
+	default:
+	}
+}
+
+func (sess *session) CreatePool(in proxyrpc.CreatePoolParams, out *proxyrpc.CreatePoolResult) error {
+	cfg := new(ClientConfig)
+	if err := json.Unmarshal([]byte(in.Param), cfg); err != nil {
+		return fmt.Errorf("failed to parse plugin config: %w", err)
+	}
+	count, err := sess.broker.Authenticate(cfg.Manager, cfg.Key)
+	if err != nil {
+		return err
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	sess.manager = cfg.Manager
+	out.Count = count
+	log.Logf(0, "manager %v connected", cfg.Manager)
+	return nil
+}
+
+func (sess *session) CreateInstance(in proxyrpc.CreateInstanceParams, out *proxyrpc.CreateInstanceResult) error {
+	sess.mu.Lock()
+	manager := sess.manager
+	sess.mu.Unlock()
+	if manager == "" {
+		return fmt.Errorf("the pool is not created")
+	}
+	lease, err := sess.broker.Acquire(sess.ctx, manager)
+	if err != nil {
+		return err
+	}
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	if sess.leases == nil {
+		sess.broker.Release(lease)
+		return fmt.Errorf("the session is closed")
+	}
+	sess.leases[lease.ID] = lease
+	out.ID = lease.ID
+	go func() {
+		<-lease.ctx.Done()
+		if errors.Is(context.Cause(lease.ctx), errPreempted) {
+			sess.logf("instance %v was preempted", lease.ID)
+		}
+	}()
+	return nil
+}
+
+func (sess *session) lease(id string) (*Lease, error) {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	lease := sess.leases[id]
+	if lease == nil {
+		return nil, fmt.Errorf("unknown instance %v", id)
+	}
+	if err := context.Cause(lease.ctx); err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+func (sess *session) Copy(in proxyrpc.CopyParams, out *proxyrpc.CopyResult) error {
+	lease, err := sess.lease(in.ID)
+	if err != nil {
+		return err
+	}
+	hostSrc := in.HostSrc
+	if len(in.Data) != 0 {
+		hostSrc = filepath.Join(lease.inst.workdir, filepath.Base(in.HostSrc))
+		if err := osutil.WriteFile(hostSrc, in.Data); err != nil {
+			return err
+		}
+		if err := os.Chmod(hostSrc, 0755); err != nil {
+			return err
+		}
+	}
+	out.VMFileName, err = lease.inst.Copy(hostSrc)
+	return err
+}
+
+func (sess *session) Forward(in proxyrpc.ForwardParams, out *proxyrpc.ForwardResult) error {
+	lease, err := sess.lease(in.ID)
+	if err != nil {
+		return err
+	}
+	out.ManagerAddress, err = lease.inst.Forward(in.Port)
+	return err
+}
+
+func (sess *session) RunStart(in proxyrpc.RunStartParams, out *proxyrpc.RunStartReply) error {
+	lease, err := sess.lease(in.ID)
+	if err != nil {
+		return err
+	}
+	out.RunID, err = lease.start(in.Command)
+	return err
+}
+
+func (sess *session) RunStop(in proxyrpc.RunStopParams, out *proxyrpc.RunStopReply) error {
+	lease, err := sess.lease(in.ID)
+	if err != nil {
+		// The run was already stopped along with the lease.
+		return nil
+	}
+	r := lease.run(in.RunID)
+	if r == nil {
+		return fmt.Errorf("unknown run %v", in.RunID)
+	}
+	r.stop()
+	return nil
+}
+
+func (sess *session) RunReadProgress(in proxyrpc.RunReadProgressParams, out *proxyrpc.RunReadProgressReply) error {
+	sess.mu.Lock()
+	lease := sess.leases[in.ID]
+	sess.mu.Unlock()
+	if lease == nil {
+		return fmt.Errorf("unknown instance %v", in.ID)
+	}
+	r := lease.run(in.RunID)
+	if r == nil {
+		return fmt.Errorf("unknown run %v", in.RunID)
+	}
+	r.read(sess.ctx, out)
+	return nil
+}
+
+func (sess *session) Diagnose(in proxyrpc.DiagnoseParams, out *proxyrpc.DiagnoseReply) error {
+	lease, err := sess.lease(in.ID)
+	if err != nil {
+		return err
+	}
+	diagnosis, _ := lease.inst.Diagnose(&report.Report{Title: in.ReasonTitle})
+	out.Diagnosis = string(diagnosis)
+	return nil
+}
+
+func (sess *session) Close(in proxyrpc.CloseParams, out *proxyrpc.CloseReply) error {
+	sess.mu.Lock()
+	defer sess.mu.Unlock()
+	lease := sess.leases[in.ID]
+	if lease == nil {
+		return fmt.Errorf("unknown instance %v", in.ID)
+	}
+	delete(sess.leases, in.ID)
+	sess.broker.Release(lease)
+	return nil
+}
+
+func (sess *session) PoolLogs(in proxyrpc.PoolLogsParam, out *proxyrpc.PoolLogsReply) error {
+	select {
+	case msg := <-sess.logs:
+		out.Log = msg
+		return nil
+	case <-sess.ctx.Done():
+		return sess.ctx.Err()
+	}
+}
+
+func (lease *Lease) start(command string) (string, error) {
+	stop := make(chan bool)
+	outc, errc, err := lease.inst.Run(runTimeout, stop, command)
+	if err != nil {
+		return "", err
+	}
+	r := &run{
+		stopc:  stop,
+		update: make(chan struct{}),
+	}
+	lease.mu.Lock()
+	lease.nextRun++
+	id := fmt.Sprint(lease.nextRun)
+	lease.runs[id] = r
+	lease.mu.Unlock()
+	go r.loop(lease.ctx, outc, errc)
+	return id, nil
+}
+
+func (lease *Lease) run(id string) *run {
+	lease.mu.Lock()
+	defer lease.mu.Unlock()
+	return lease.runs[id]
+}
+
+// run is a command running in a leased instance.
+type run struct {
+	stopc    chan bool
+	stopOnce sync.Once
+
+	mu       sync.Mutex
+	output   []byte
+	finished bool
+	// Closed and re-created on every update of output/finished.
+	update chan struct{}
+}
+
+func (r *run) loop(ctx context.Context, outc <-chan []byte, errc <-chan error) {
+	done := ctx.Done()
+	for {
+		select {
+		case out, ok := <-outc:
+			if !ok {
+				outc = nil
+				continue
+			}
+			r.append(out, false)
+		case err := <-errc:
+			if err != nil && err != vmimpl.ErrTimeout {
+				log.Logf(1, "run finished: %v", err)
+			}
+			r.append(nil, true)
+			return
+		case <-done:
+			done = nil
+			if errors.Is(context.Cause(ctx), errPreempted) {
+				r.append([]byte(preemptedMarker), false)
+			}
+			r.stop()
+		}
+	}
+}
+
+func (r *run) append(out []byte, finished bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.output = append(r.output, out...)
+	r.finished = r.finished || finished
+	close(r.update)
+	r.update = make(chan struct{})
+}
+
+func (r *run) stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopc)
+	})
+}
+
+// read waits for new output or for the end of the run.
+func (r *run) read(ctx context.Context, out *proxyrpc.RunReadProgressReply) {
+	r.mu.Lock()
+	for len(r.output) == 0 && !r.finished {
+		update := r.update
+		r.mu.Unlock()
+		select {
+		case <-update:
+		case <-ctx.Done():
+			return
+		}
+		r.mu.Lock()
+	}
+	defer r.mu.Unlock()
+	out.ConsoleOutChunk = string(r.output)
+	out.Finished = r.finished
+	r.output = nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// syz-vmcore prints kernel state from a guest memory dump saved by syz-manager
+// (see dump_guest_memory config option).
+// Usage:
+//
+//	syz-vmcore -vmlinux vmlinux -vmcore workdir/crashes/HASH/vmcore0 [-offset 0x...] [dmesg] [tasks] [locks]
+//
+// By default all sections are printed.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/google/syzkaller/pkg/tool"
+	"github.com/google/syzkaller/pkg/vmcore"
+)
+
+var (
+	flagVmlinux = flag.String("vmlinux", "", "vmlinux file of the dumped kernel")
+	flagVmcore  = flag.String("vmcore", "", "guest memory dump")
+	flagOffset  = flag.String("offset", "", "KASLR offset (hex, taken from the dump by default)")
+)
+
+func main() {
+	flag.Parse()
+	if *flagVmlinux == "" || *flagVmcore == "" {
+		fmt.Fprintf(os.Stderr, "usage: syz-vmcore -vmlinux vmlinux -vmcore vmcore [dmesg] [tasks] [locks]\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	sections := map[string]bool{}
+	for _, arg := range flag.Args() {
+		if arg != "dmesg" && arg != "tasks" && arg != "locks" {
+			tool.Failf("unknown section %q", arg)
+		}
+		sections[arg] = true
+	}
+	if len(sections) == 0 {
+		sections = map[string]bool{"dmesg": true, "tasks": true, "locks": true}
+	}
+	dump, err := vmcore.OpenDump(*flagVmcore)
+	if err != nil {
+		tool.Fail(err)
+	}
+	defer dump.Close()
+	kernel, err := vmcore.OpenKernel(*flagVmlinux)
+	if err != nil {
+		tool.Fail(err)
+	}
+	defer kernel.Close()
+	offset, ok := dump.KernelOffset()
+	if *flagOffset != "" {
+		offset, err = strconv.ParseUint(*flagOffset, 0, 64)
+		if err != nil {
+			tool.Failf("bad -offset: %v", err)
+		}
+	} else if !ok {
+		fmt.Fprintf(os.Stderr, "no KASLR offset in the dump, assuming 0 (add -device vmcoreinfo to qemu args)\n")
+	}
+	analyzer, err := vmcore.NewAnalyzer(dump, kernel, offset)
+	if err != nil {
+		tool.Fail(err)
+	}
+	if sections["dmesg"] {
+		dmesg, err := analyzer.Dmesg()
+		if err != nil {
+			tool.Failf("failed to extract kernel log: %v", err)
+		}
+		fmt.Printf("=== dmesg ===\n%s\n", dmesg)
+	}
+	if !sections["tasks"] && !sections["locks"] {
+		return
+	}
+	tasks, err := analyzer.Tasks()
+	if err != nil {
+		// The list may be corrupted, print what we've got.
+		fmt.Fprintf(os.Stderr, "failed to walk tasks: %v\n", err)
+	}
+	if sections["tasks"] {
+		fmt.Printf("=== tasks ===\n%8v %5v %v\n", "PID", "STATE", "COMM")
+		for _, task := range tasks {
+			fmt.Printf("%8v %5v %v\n", task.PID, task.State, task.Comm)
+		}
+		fmt.Printf("\n")
+	}
+	if sections["locks"] {
+		fmt.Printf("=== held locks ===\n")
+		for _, task := range tasks {
+			if len(task.HeldLocks) == 0 {
+				continue
+			}
+			fmt.Printf("%v/%v:\n", task.Comm, task.PID)
+			for i, lock := range task.HeldLocks {
+				fmt.Printf("  #%v: %v, at: %v (0x%x)\n", i, lock.Name, lock.Func, lock.IP)
+			}
+		}
+	}
+}
@@ -60,13 +60,14 @@ type Pool struct {
 }
 
 type instance struct {
-	cfg      *Config
-	adbBin   string
-	device   string
-	console  string
-	closed   chan bool
-	debug    bool
-	timeouts targets.Timeouts
+	cfg        *Config
+	adbBin     string
+	device     string
+	console    string
+	closed     chan bool
+	debug      bool
+	consoleLog vmimpl.ConsoleLog
+	timeouts   targets.Timeouts
 }
 
 var (
@@ -132,13 +133,14 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		return nil, err
 	}
 	inst := &instance{
-		cfg:      pool.cfg,
-		adbBin:   pool.cfg.Adb,
-		device:   device.Serial,
-		console:  device.Console,
-		closed:   make(chan bool),
-		debug:    pool.env.Debug,
-		timeouts: pool.env.Timeouts,
+		cfg:        pool.cfg,
+		adbBin:     pool.cfg.Adb,
+		device:     device.Serial,
+		console:    device.Console,
+		closed:     make(chan bool),
+		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
+		timeouts:   pool.env.Timeouts,
 	}
 	closeInst := inst
 	defer func() {
@@ -552,6 +554,7 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		tee = os.Stdout
 	}
 	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(inst.consoleLog)
 	merger.Add("console", tty)
 	merger.Add("adb", adbRpipe)
 
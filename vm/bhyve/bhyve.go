@@ -49,6 +49,7 @@ type instance struct {
 	forwardPort int
 	image       string
 	debug       bool
+	consoleLog  vmimpl.ConsoleLog
 	os          string
 	sshkey      string
 	sshuser     string
@@ -87,12 +88,13 @@ func (pool *Pool) Count() int {
 
 func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 	inst := &instance{
-		cfg:     pool.cfg,
-		debug:   pool.env.Debug,
-		os:      pool.env.OS,
-		sshkey:  pool.env.SSHKey,
-		sshuser: pool.env.SSHUser,
-		vmName:  fmt.Sprintf("syzkaller-%v-%v", pool.env.Name, index),
+		cfg:        pool.cfg,
+		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
+		os:         pool.env.OS,
+		sshkey:     pool.env.SSHKey,
+		sshuser:    pool.env.SSHUser,
+		vmName:     fmt.Sprintf("syzkaller-%v-%v", pool.env.Name, index),
 	}
 
 	dataset := inst.cfg.Dataset
@@ -222,6 +224,7 @@ func (inst *instance) Boot() error {
 		tee = os.Stdout
 	}
 	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.SetConsoleLog(inst.consoleLog)
 	inst.merger.Add("console", outr)
 	outr = nil
 
@@ -0,0 +1,80 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package vm
+
+import (
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/google/syzkaller/pkg/consolelog"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/mgrconfig"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+// consoleLogs lazily opens console logs of the VMs in the pool.
+type consoleLogs struct {
+	cfg  *mgrconfig.Config
+	mu   sync.Mutex
+	logs map[int]*consolelog.Log
+}
+
+func newConsoleLogs(cfg *mgrconfig.Config) *consoleLogs {
+	if !cfg.Experimental.ConsoleLogs {
+		return nil
+	}
+	return &consoleLogs{
+		cfg:  cfg,
+		logs: make(map[int]*consolelog.Log),
+	}
+}
+
+// ConsoleLogDir returns the directory with console logs of the VM index,
+// or an empty string if console logs are not enabled.
+func ConsoleLogDir(cfg *mgrconfig.Config, index int) string {
+	if !cfg.Experimental.ConsoleLogs {
+		return ""
+	}
+	return filepath.Join(cfg.Workdir, "console", fmt.Sprint(index))
+}
+
+func (cl *consoleLogs) get(index int) vmimpl.ConsoleLog {
+	l := cl.open(index)
+	if l == nil {
+		// Don't return a typed nil inside of the interface.
+		return nil
+	}
+	return l
+}
+
+func (cl *consoleLogs) open(index int) *consolelog.Log {
+	if cl == nil {
+		return nil
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	if l, ok := cl.logs[index]; ok {
+		return l
+	}
+	l, err := consolelog.Open(ConsoleLogDir(cl.cfg, index))
+	if err != nil {
+		// Don't disturb fuzzing, the log is only a debugging aid.
+		log.Logf(0, "failed to open console log: %v", err)
+	}
+	cl.logs[index] = l
+	return l
+}
+
+func (cl *consoleLogs) close() {
+	if cl == nil {
+		return
+	}
+	cl.mu.Lock()
+	defer cl.mu.Unlock()
+	for index, l := range cl.logs {
+		l.Close()
+		delete(cl.logs, index)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/stat"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type HealthEvent int
+
+const (
+	// The instance did useful work (e.g. fuzzed for the whole run or found a real crash).
+	HealthOK HealthEvent = iota
+	HealthBootFailure
+	// The instance failed for reasons not related to the kernel (e.g. ssh/port forwarding failures).
+	HealthInfraError
+	// A crash that is often caused by a bad host rather than the kernel (e.g. lost connection).
+	HealthSuspiciousCrash
+)
+
+type HealthConfig struct {
+	// The health score is a moving average of the outcomes (1 for OK, 0 for failures),
+	// each outcome moves the score by Weight towards its value.
+	Weight float64
+	// Instances with the score below Threshold are considered unhealthy and are quarantined.
+	Threshold float64
+	// The first quarantine period, it doubles on every next consecutive quarantine up to MaxQuarantine.
+	Quarantine    time.Duration
+	MaxQuarantine time.Duration
+}
+
+var DefaultHealthConfig = HealthConfig{
+	Weight:        0.25,
+	Threshold:     0.3,
+	Quarantine:    10 * time.Minute,
+	MaxQuarantine: 4 * time.Hour,
+}
+
+// HealthInfo is the health state of an instance slot, see TrackHealth.
+type HealthInfo struct {
+	Score float64
+	// Number of consecutive quarantines (reset once the instance becomes healthy again).
+	Quarantines int
+	// If the instance is quarantined, it's not booted until QuarantinedUntil,
+	// and then it's released from quarantine only after a successful canary boot.
+	Quarantined      bool
+	QuarantinedUntil time.Time
+}
+
+type health struct {
+	cfg   HealthConfig
+	slots []HealthInfo
+}
+
+// TrackHealth enables health tracking for the instance slots.
+// An instance slot is unhealthy if its score drops below the threshold due to the failures
+// reported by the pool itself (boot failures) and by the runners (see ReportHealth),
+// but only if other instances are healthy (i.e. the failures are specific to the slot,
+// not to the kernel). Unhealthy instances are quarantined: they are not booted for
+// the quarantine period, and then they need to pass a canary boot. Boot errors of unhealthy
+// instances are not sent to BootErrors. At most half of the instances can be quarantined.
+// Must be called before Loop.
+func (p *Pool[T]) TrackHealth(cfg HealthConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.health = &health{
+		cfg:   cfg,
+		slots: make([]HealthInfo, len(p.instances)),
+	}
+	for i := range p.health.slots {
+		p.health.slots[i].Score = 1
+		idx := i
+		// Registration fails if the metrics are already registered, that's fine.
+		prometheus.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name:        "syz_vm_health",
+			Help:        "Health score of the VM slot (from 0 to 1)",
+			ConstLabels: prometheus.Labels{"vm": fmt.Sprint(idx)},
+		}, func() float64 {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return p.health.slots[idx].Score
+		}))
+	}
+	stat.New("quarantined VMs", "Number of VMs quarantined due to failures unrelated to the kernel",
+		stat.Graph("VMs"), stat.Link("/vms"), stat.Prometheus("syz_vm_quarantined"), func() int {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return p.health.quarantined()
+		})
+}
+
+// ReportHealth records an outcome of a run in the instance slot.
+// Returns whether the slot is considered healthy. If health tracking is disabled, always returns true.
+func (p *Pool[T]) ReportHealth(idx int, ev HealthEvent) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.health == nil {
+		return true
+	}
+	return p.health.record(idx, ev, time.Now())
+}
+
+func (h *health) record(idx int, ev HealthEvent, now time.Time) bool {
+	slot := &h.slots[idx]
+	val := 0.0
+	if ev == HealthOK {
+		val = 1
+	}
+	slot.Score += h.cfg.Weight * (val - slot.Score)
+	if slot.Score > 1-h.cfg.Threshold {
+		slot.Quarantines = 0
+	}
+	healthy := h.healthy(idx)
+	if !healthy && !slot.Quarantined && h.quarantined() < len(h.slots)/2 {
+		h.quarantine(idx, now)
+	}
+	return healthy
+}
+
+// healthy checks that the slot is not quarantined and its score is not much worse than the other slots.
+func (h *health) healthy(idx int) bool {
+	slot := &h.slots[idx]
+	if slot.Quarantined {
+		return false
+	}
+	if slot.Score >= h.cfg.Threshold {
+		return true
+	}
+	total, count := 0.0, 0
+	for i, other := range h.slots {
+		if i != idx && !other.Quarantined {
+			total += other.Score
+			count++
+		}
+	}
+	return count == 0 || total/float64(count) < 2*h.cfg.Threshold
+}
+
+func (h *health) quarantine(idx int, now time.Time) {
+	slot := &h.slots[idx]
+	period := h.cfg.Quarantine << min(slot.Quarantines, 16)
+	period = min(period, h.cfg.MaxQuarantine)
+	slot.Quarantined = true
+	slot.Quarantines++
+	slot.QuarantinedUntil = now.Add(period)
+	log.Logf(0, "VM %v: quarantined for %v (health %.2f, quarantine #%v)",
+		idx, period, slot.Score, slot.Quarantines)
+}
+
+func (h *health) quarantined() int {
+	count := 0
+	for _, slot := range h.slots {
+		if slot.Quarantined {
+			count++
+		}
+	}
+	return count
+}
+
+// waitQuarantine waits until the quarantine period ends.
+// Returns true if the instance is quarantined and needs a canary boot.
+func (p *Pool[T]) waitQuarantine(ctx context.Context, idx int) bool {
+	p.mu.Lock()
+	if p.health == nil || !p.health.slots[idx].Quarantined {
+		p.mu.Unlock()
+		return false
+	}
+	wait := time.Until(p.health.slots[idx].QuarantinedUntil)
+	p.mu.Unlock()
+	if wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+		}
+	}
+	return true
+}
+
+// canaryBoot boots the quarantined instance and releases it from quarantine if it boots.
+func (p *Pool[T]) canaryBoot(inst *poolInstance[T]) {
+	obj, err := p.creator(inst.idx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	slot := &p.health.slots[inst.idx]
+	if err != nil {
+		log.Logf(0, "VM %v: canary boot failed: %v", inst.idx, err)
+		slot.Quarantined = false
+		p.health.quarantine(inst.idx, time.Now())
+		return
+	}
+	obj.Close()
+	log.Logf(0, "VM %v: canary boot succeeded, released from quarantine", inst.idx)
+	slot.Quarantined = false
+	// Give the instance a chance, but quarantine it again soon if it keeps failing.
+	slot.Score = 2 * p.health.cfg.Threshold
+}
+
+// bootFailed records the boot failure and returns whether the error needs to be reported.
+func (p *Pool[T]) bootFailed(idx int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.health == nil {
+		return true
+	}
+	return p.health.record(idx, HealthBootFailure, time.Now())
+}
+
+func (p *Pool[T]) healthInfo(idx int) *HealthInfo {
+	if p.health == nil {
+		return nil
+	}
+	info := p.health.slots[idx]
+	return &info
+}
@@ -5,6 +5,7 @@ package dispatcher
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"sync"
 	"time"
@@ -38,6 +39,10 @@ type Pool[T Instance] struct {
 	cv        *sync.Cond
 	instances []*poolInstance[T]
 	paused    bool
+	// Only instances with smaller indices (and reserved instances) are allowed to run, see SetSize().
+	size int
+	// Nil if health tracking is disabled, see TrackHealth().
+	health *health
 }
 
 func NewPool[T Instance](count int, creator CreateInstance[T], def Runner[T]) *Pool[T] {
@@ -56,6 +61,7 @@ func NewPool[T Instance](count int, creator CreateInstance[T], def Runner[T]) *P
 		creator:    creator,
 		defaultJob: def,
 		instances:  instances,
+		size:       count,
 		jobs:       make(chan Runner[T]),
 		mu:         mu,
 		cv:         sync.NewCond(mu),
@@ -89,15 +95,57 @@ func (p *Pool[T]) TogglePause(paused bool) {
 	}
 }
 
-func (p *Pool[T]) waitUnpaused() {
+// waitActive waits until the instance is allowed to run.
+// Returns false if ctx was cancelled in the meantime.
+func (p *Pool[T]) waitActive(ctx context.Context, inst *poolInstance[T]) bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	for p.paused {
+	for (p.paused || inst.idx >= p.size && !inst.reserved()) && ctx.Err() == nil {
 		p.cv.Wait()
 	}
+	return ctx.Err() == nil
+}
+
+// SetSize changes the number of instances that are allowed to run (from 0 to Total()).
+// Instances with larger indices that run the default runner are shut down.
+// Reserved instances are never shut down (otherwise Run could block forever), so while
+// they are held, more than size instances may run. Reserved instances are taken from
+// the beginning of the pool and released from the end, so they are normally below size.
+func (p *Pool[T]) SetSize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if size < 0 || size > len(p.instances) {
+		panic(fmt.Sprintf("bad pool size %v, total %v", size, len(p.instances)))
+	}
+	if size == p.size {
+		return
+	}
+	log.Logf(1, "pool: resizing from %v to %v instances", p.size, size)
+	for _, inst := range p.instances[size:] {
+		if !inst.reserved() {
+			inst.shutdown()
+		}
+	}
+	p.size = size
+	p.cv.Broadcast()
+}
+
+// Size returns the number of instances that are allowed to run.
+func (p *Pool[T]) Size() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.size
 }
 
 func (p *Pool[T]) Loop(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() {
+		// Wake up the instances that wait in waitActive().
+		p.mu.Lock()
+		p.cv.Broadcast()
+		p.mu.Unlock()
+	})
+	defer stop()
 	var wg sync.WaitGroup
 	wg.Add(len(p.instances))
 	for _, inst := range p.instances {
@@ -113,7 +161,17 @@ func (p *Pool[T]) Loop(ctx context.Context) {
 }
 
 func (p *Pool[T]) runInstance(ctx context.Context, inst *poolInstance[T]) {
-	p.waitUnpaused()
+	if !p.waitActive(ctx, inst) {
+		return
+	}
+	if p.waitQuarantine(ctx, inst.idx) {
+		if ctx.Err() == nil {
+			inst.status(StateBooting)
+			p.canaryBoot(inst)
+			inst.status(StateOffline)
+		}
+		return
+	}
 	ctx, cancel := context.WithCancel(ctx)
 
 	log.Logf(2, "pool: booting instance %d", inst.idx)
@@ -126,7 +184,11 @@ func (p *Pool[T]) runInstance(ctx context.Context, inst *poolInstance[T]) {
 
 	obj, err := p.creator(inst.idx)
 	if err != nil {
-		p.BootErrors <- err
+		if p.bootFailed(inst.idx) {
+			p.BootErrors <- err
+		} else {
+			log.Logf(0, "VM %v: boot failed on an unhealthy instance: %v", inst.idx, err)
+		}
 		return
 	}
 	defer obj.Close()
@@ -180,11 +242,18 @@ func (p *Pool[T]) ReserveForRun(count int) {
 		free[i].reserve(p.jobs)
 	}
 
+	// Release the instances with the largest indices first, they may be beyond the pool size.
 	needFree := len(reserved) - count
 	for i := 0; i < needFree; i++ {
-		log.Logf(2, "pool: releasing instance %d", reserved[i].idx)
-		reserved[i].free(p.defaultJob)
+		inst := reserved[len(reserved)-1-i]
+		log.Logf(2, "pool: releasing instance %d", inst.idx)
+		inst.free(p.defaultJob)
+		if inst.idx >= p.size {
+			inst.shutdown()
+		}
 	}
+	// Wake up the instances beyond the pool size that became reserved.
+	p.cv.Broadcast()
 }
 
 // Run blocks until it has found an instance to execute job and until job has finished.
@@ -206,6 +275,10 @@ type Info struct {
 	Status     string
 	LastUpdate time.Time
 	Reserved   bool
+	// The instance is shut down because the pool was shrunk (see SetSize).
+	Inactive bool
+	// Nil if health tracking is disabled.
+	Health *HealthInfo
 
 	// The optional callbacks.
 	MachineInfo    func() []byte
@@ -219,6 +292,8 @@ func (p *Pool[T]) State() []Info {
 	ret := make([]Info, len(p.instances))
 	for i, inst := range p.instances {
 		ret[i] = inst.getInfo()
+		ret[i].Inactive = i >= p.size && !ret[i].Reserved
+		ret[i].Health = p.healthInfo(i)
 	}
 	return ret
 }
@@ -293,6 +368,13 @@ func (pi *poolInstance[T]) reserve(ch chan Runner[T]) {
 	pi.mu.Unlock()
 }
 
+// shutdown stops the instance (it will be re-created once it's allowed to run).
+func (pi *poolInstance[T]) shutdown() {
+	pi.mu.Lock()
+	defer pi.mu.Unlock()
+	pi.stop()
+}
+
 func (pi *poolInstance[T]) free(job Runner[T]) {
 	pi.mu.Lock()
 	if pi.job != nil {
@@ -5,6 +5,7 @@ package dispatcher
 
 import (
 	"context"
+	"fmt"
 	"runtime"
 	"sync/atomic"
 	"testing"
@@ -237,6 +238,216 @@ func TestPoolPause(t *testing.T) {
 	<-done
 }
 
+func TestPoolResize(t *testing.T) {
+	var defaultCount atomic.Int64
+	mgr := NewPool[*nilInstance](
+		4,
+		func(idx int) (*nilInstance, error) {
+			return &nilInstance{}, nil
+		},
+		func(ctx context.Context, _ *nilInstance, _ UpdateInfo) {
+			defaultCount.Add(1)
+			<-ctx.Done()
+			defaultCount.Add(-1)
+		},
+	)
+	done := make(chan bool)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		mgr.Loop(ctx)
+		close(done)
+	}()
+	waitCount := func(count int64) {
+		for defaultCount.Load() != count {
+			time.Sleep(time.Second / 100)
+		}
+	}
+	waitCount(4)
+
+	mgr.SetSize(2)
+	assert.Equal(t, 2, mgr.Size())
+	waitCount(2)
+	for i, info := range mgr.State() {
+		assert.Equal(t, i >= 2, info.Inactive)
+	}
+
+	// Shrinking does not interrupt the reserved jobs.
+	mgr.ReserveForRun(1)
+	waitCount(1)
+	started, finish := make(chan bool), make(chan bool)
+	var jobErr error
+	jobDone := make(chan bool)
+	go func() {
+		mgr.Run(func(ctx context.Context, _ *nilInstance, _ UpdateInfo) {
+			started <- true
+			<-finish
+			jobErr = ctx.Err()
+		})
+		close(jobDone)
+	}()
+	<-started
+	mgr.SetSize(0)
+	waitCount(0)
+	close(finish)
+	<-jobDone
+	assert.NoError(t, jobErr)
+
+	mgr.SetSize(4)
+	waitCount(3)
+
+	cancel()
+	<-done
+}
+
+func TestPoolResizeReserved(t *testing.T) {
+	var defaultCount atomic.Int64
+	mgr := NewPool[*nilInstance](
+		4,
+		func(idx int) (*nilInstance, error) {
+			return &nilInstance{}, nil
+		},
+		func(ctx context.Context, _ *nilInstance, _ UpdateInfo) {
+			defaultCount.Add(1)
+			<-ctx.Done()
+			defaultCount.Add(-1)
+		},
+	)
+	done := make(chan bool)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		mgr.Loop(ctx)
+		close(done)
+	}()
+	waitCount := func(count int64) {
+		for defaultCount.Load() != count {
+			time.Sleep(time.Second / 100)
+		}
+	}
+	runJobs := func(count int) {
+		started, finish := make(chan bool), make(chan bool)
+		for i := 0; i < count; i++ {
+			go mgr.Run(func(ctx context.Context, _ *nilInstance, _ UpdateInfo) {
+				started <- true
+				<-finish
+			})
+		}
+		// All jobs must start in parallel.
+		for i := 0; i < count; i++ {
+			<-started
+		}
+		close(finish)
+	}
+	waitCount(4)
+
+	// Shrink the pool while the reserved instances are idle.
+	mgr.ReserveForRun(2)
+	mgr.SetSize(0)
+	waitCount(0)
+	for i, info := range mgr.State() {
+		assert.Equal(t, i >= 2, info.Inactive)
+	}
+	runJobs(2)
+
+	// Reserve more instances than the pool size.
+	mgr.SetSize(1)
+	mgr.ReserveForRun(3)
+	waitCount(0)
+	runJobs(3)
+	mgr.ReserveForRun(0)
+	waitCount(1)
+	for i, info := range mgr.State() {
+		assert.Equal(t, i >= 1, info.Inactive)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestPoolHealth(t *testing.T) {
+	var broken atomic.Bool
+	broken.Store(true)
+	mgr := NewPool[*nilInstance](
+		4,
+		func(idx int) (*nilInstance, error) {
+			if idx == 1 && broken.Load() {
+				return nil, fmt.Errorf("boot failed")
+			}
+			return &nilInstance{}, nil
+		},
+		func(ctx context.Context, _ *nilInstance, _ UpdateInfo) {
+			<-ctx.Done()
+		},
+	)
+	cfg := DefaultHealthConfig
+	cfg.Quarantine = time.Second / 10
+	mgr.TrackHealth(cfg)
+	done := make(chan bool)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		mgr.Loop(ctx)
+		close(done)
+	}()
+	// Boot errors are reported until the instance becomes unhealthy.
+	for i := 0; i < 4; i++ {
+		<-mgr.BootErrors
+	}
+	waitHealth := func(quarantined bool) {
+		for mgr.State()[1].Health.Quarantined != quarantined {
+			time.Sleep(time.Second / 100)
+		}
+	}
+	waitHealth(true)
+	assert.Empty(t, mgr.BootErrors)
+	assert.False(t, mgr.ReportHealth(1, HealthOK))
+	assert.True(t, mgr.State()[0].Health.Score == 1)
+	// Canary boots keep failing, so the quarantine gets longer.
+	for mgr.State()[1].Health.Quarantines < 3 {
+		time.Sleep(time.Second / 100)
+	}
+	broken.Store(false)
+	waitHealth(false)
+	assert.Empty(t, mgr.BootErrors)
+	assert.True(t, mgr.ReportHealth(1, HealthOK))
+	cancel()
+	<-done
+}
+
+func TestHealthScore(t *testing.T) {
+	h := &health{
+		cfg:   DefaultHealthConfig,
+		slots: make([]HealthInfo, 4),
+	}
+	for i := range h.slots {
+		h.slots[i].Score = 1
+	}
+	now := time.Now()
+	// Failures that happen on all instances are not instance-specific.
+	for i := 0; i < 20; i++ {
+		for idx := range h.slots {
+			assert.True(t, h.record(idx, HealthSuspiciousCrash, now))
+		}
+	}
+	assert.Equal(t, 0, h.quarantined())
+	for i := 0; i < 20; i++ {
+		for idx := range h.slots {
+			h.record(idx, HealthOK, now)
+		}
+	}
+	// Failures on one instance make it unhealthy.
+	for i := 0; i < 4; i++ {
+		assert.True(t, h.record(2, HealthInfraError, now))
+	}
+	assert.False(t, h.record(2, HealthInfraError, now))
+	assert.True(t, h.slots[2].Quarantined)
+	assert.Equal(t, now.Add(DefaultHealthConfig.Quarantine), h.slots[2].QuarantinedUntil)
+	// At most half of the instances are quarantined.
+	for i := 0; i < 10; i++ {
+		h.record(0, HealthBootFailure, now)
+		h.record(1, HealthBootFailure, now)
+	}
+	assert.Equal(t, 2, h.quarantined())
+}
+
 func makePool(count int) []testInstance {
 	var ret []testInstance
 	for i := 0; i < count; i++ {
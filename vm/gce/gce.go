@@ -74,6 +74,7 @@ type instance struct {
 	cfg            *Config
 	GCE            *gce.Context
 	debug          bool
+	consoleLog     vmimpl.ConsoleLog
 	name           string
 	ip             string
 	gceKey         string // per-instance private ssh key associated with the instance
@@ -220,6 +221,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		env:            pool.env,
 		cfg:            pool.cfg,
 		debug:          pool.env.Debug,
+		consoleLog:     pool.env.ConsoleLog(index),
 		GCE:            pool.GCE,
 		name:           name,
 		ip:             ip,
@@ -306,6 +308,7 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		tee = os.Stdout
 	}
 	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(inst.consoleLog)
 	var decoder func(data []byte) (int, int, []byte)
 	if inst.env.OS == targets.Windows {
 		decoder = kd.Decode
@@ -146,6 +146,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		tee = os.Stdout
 	}
 	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(pool.env.ConsoleLog(index))
 	merger.Add("runsc", rpipe)
 	merger.Add("runsc-goruntime", panicLogReadFD)
 
@@ -0,0 +1,298 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+// Package hostns runs programs directly on the host inside of fresh user/pid/net/mount/ipc/uts
+// namespaces and (optionally) a cgroup, without any hypervisor. The tested kernel is the host kernel,
+// so kernel crashes are out of scope, but executor failures and hangs are detected as usual.
+// This is useful to test descriptions and the fuzzing pipeline end-to-end on developer machines and CI.
+package hostns
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/google/syzkaller/pkg/config"
+	"github.com/google/syzkaller/pkg/log"
+	"github.com/google/syzkaller/pkg/osutil"
+	"github.com/google/syzkaller/pkg/report"
+	"github.com/google/syzkaller/vm/vmimpl"
+)
+
+func init() {
+	vmimpl.Register("hostns", vmimpl.Type{
+		Ctor:       ctor,
+		Overcommit: true,
+	})
+}
+
+type Config struct {
+	Count int `json:"count"` // number of instances to use
+	// Parent cgroup v2 directory for the instances (optional), e.g. "/sys/fs/cgroup/syzkaller".
+	// It must be writable by the current user and have the required controllers enabled
+	// in cgroup.subtree_control. Each instance gets its own child cgroup.
+	Cgroup string `json:"cgroup"`
+	Mem    int    `json:"mem"`  // memory limit per instance in MB (requires cgroup)
+	CPU    int    `json:"cpu"`  // number of CPUs per instance (requires cgroup)
+	Pids   int    `json:"pids"` // max number of processes per instance (requires cgroup)
+}
+
+type Pool struct {
+	env *vmimpl.Env
+	cfg *Config
+}
+
+type instance struct {
+	cfg        *Config
+	debug      bool
+	consoleLog vmimpl.ConsoleLog
+	workdir    string
+	cgroup     string
+	// Opened cgroup directory, used to start processes right in the cgroup.
+	cgroupFD int
+	port     int
+}
+
+func ctor(env *vmimpl.Env) (vmimpl.Pool, error) {
+	cfg := &Config{
+		Count: 1,
+	}
+	if err := config.LoadData(env.Config, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse vm config: %w", err)
+	}
+	if cfg.Count < 1 || cfg.Count > 128 {
+		return nil, fmt.Errorf("invalid config param count: %v, want [1, 128]", cfg.Count)
+	}
+	if cfg.Mem < 0 || cfg.CPU < 0 || cfg.Pids < 0 {
+		return nil, fmt.Errorf("invalid config params mem/cpu/pids: must not be negative")
+	}
+	if cfg.Cgroup == "" && (cfg.Mem != 0 || cfg.CPU != 0 || cfg.Pids != 0) {
+		return nil, fmt.Errorf("config params mem/cpu/pids require cgroup")
+	}
+	if cfg.Cgroup != "" {
+		if !osutil.IsExist(filepath.Join(cfg.Cgroup, "cgroup.subtree_control")) {
+			return nil, fmt.Errorf("cgroup %v is not a cgroup v2 directory", cfg.Cgroup)
+		}
+	}
+	if env.OS != "linux" {
+		return nil, fmt.Errorf("hostns supports only linux, got %v", env.OS)
+	}
+	pool := &Pool{
+		cfg: cfg,
+		env: env,
+	}
+	return pool, nil
+}
+
+func (pool *Pool) Count() int {
+	return pool.cfg.Count
+}
+
+func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
+	inst := &instance{
+		cfg:        pool.cfg,
+		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
+		workdir:    workdir,
+		cgroupFD:   -1,
+	}
+	if pool.cfg.Cgroup != "" {
+		name := fmt.Sprintf("instance-%v", index)
+		if pool.env.Name != "" {
+			name = pool.env.Name + "-" + name
+		}
+		inst.cgroup = filepath.Join(pool.cfg.Cgroup, name)
+		// Kill the previous instance in case it's still running.
+		inst.removeCgroup()
+		if err := inst.createCgroup(); err != nil {
+			inst.Close()
+			return nil, err
+		}
+	}
+	// Check that we can actually create the sandbox (e.g. unprivileged user namespaces may be disabled).
+	cmd, err := inst.command([]string{"true"})
+	if err != nil {
+		inst.Close()
+		return nil, err
+	}
+	if output, err := osutil.Run(time.Minute, cmd); err != nil {
+		inst.Close()
+		return nil, vmimpl.MakeBootError(fmt.Errorf("failed to create namespaces: %w", err), output)
+	}
+	return inst, nil
+}
+
+func (inst *instance) createCgroup() error {
+	if err := os.Mkdir(inst.cgroup, 0755); err != nil {
+		return fmt.Errorf("failed to create cgroup: %w", err)
+	}
+	limits := map[string]string{}
+	if inst.cfg.Mem != 0 {
+		limits["memory.max"] = fmt.Sprint(inst.cfg.Mem << 20)
+		limits["memory.swap.max"] = "0"
+	}
+	if inst.cfg.CPU != 0 {
+		limits["cpu.max"] = fmt.Sprintf("%v 100000", inst.cfg.CPU*100000)
+	}
+	if inst.cfg.Pids != 0 {
+		limits["pids.max"] = fmt.Sprint(inst.cfg.Pids)
+	}
+	for file, val := range limits {
+		if err := osutil.WriteFile(filepath.Join(inst.cgroup, file), []byte(val)); err != nil {
+			return fmt.Errorf("failed to set cgroup limit %v: %w", file, err)
+		}
+	}
+	fd, err := syscall.Open(inst.cgroup, syscall.O_DIRECTORY|syscall.O_RDONLY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open cgroup: %w", err)
+	}
+	inst.cgroupFD = fd
+	return nil
+}
+
+func (inst *instance) removeCgroup() {
+	if !osutil.IsExist(inst.cgroup) {
+		return
+	}
+	// cgroup.kill is supported since Linux 5.14, so also kill the processes one by one.
+	osutil.WriteFile(filepath.Join(inst.cgroup, "cgroup.kill"), []byte("1"))
+	for i := 0; i < 100; i++ {
+		if procs, err := os.ReadFile(filepath.Join(inst.cgroup, "cgroup.procs")); err == nil {
+			for _, pid := range strings.Fields(string(procs)) {
+				var p int
+				fmt.Sscan(pid, &p)
+				syscall.Kill(p, syscall.SIGKILL)
+			}
+		}
+		if err := syscall.Rmdir(inst.cgroup); err == nil || os.IsNotExist(err) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	log.Logf(0, "failed to remove cgroup %v", inst.cgroup)
+}
+
+func (inst *instance) Close() error {
+	if inst.cgroupFD != -1 {
+		syscall.Close(inst.cgroupFD)
+		inst.cgroupFD = -1
+	}
+	if inst.cgroup != "" {
+		inst.removeCgroup()
+	}
+	return nil
+}
+
+func (inst *instance) Forward(port int) (string, error) {
+	if inst.port != 0 {
+		return "", fmt.Errorf("forward port is already setup")
+	}
+	inst.port = port
+	// The sandbox has its own network namespace, so the executor can't connect to the manager.
+	// Instead we pass a connected socket as stdin (see proxy).
+	return "stdin:0", nil
+}
+
+func (inst *instance) Copy(hostSrc string) (string, error) {
+	// The sandbox shares the file system with the host, so we only need to put the file
+	// into the instance workdir to not interfere with other instances.
+	vmDst := filepath.Join(inst.workdir, filepath.Base(hostSrc))
+	if err := osutil.CopyFile(hostSrc, vmDst); err != nil {
+		return "", err
+	}
+	return vmDst, nil
+}
+
+func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command string) (
+	<-chan []byte, <-chan error, error) {
+	cmd, err := inst.command(strings.Fields(command))
+	if err != nil {
+		return nil, nil, err
+	}
+	rpipe, wpipe, err := osutil.LongPipe()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer wpipe.Close()
+	var tee io.Writer
+	if inst.debug {
+		tee = os.Stdout
+	}
+	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(inst.consoleLog)
+	merger.Add("cmd", rpipe)
+	cmd.Stdout = wpipe
+	cmd.Stderr = wpipe
+	proxy, err := inst.proxy()
+	if err != nil {
+		rpipe.Close()
+		return nil, nil, err
+	}
+	if proxy != nil {
+		defer proxy.Close()
+		cmd.Stdin = proxy
+	}
+	if err := cmd.Start(); err != nil {
+		rpipe.Close()
+		return nil, nil, err
+	}
+	// The command is the init process of the pid namespace, so once it's killed,
+	// all other processes in the sandbox are killed as well.
+	return vmimpl.Multiplex(cmd, merger, timeout, vmimpl.MultiplexConfig{
+		Stop:  stop,
+		Debug: inst.debug,
+		Scale: 1,
+	})
+}
+
+// proxy returns a socket connected to the forwarded manager port.
+func (inst *instance) proxy() (*os.File, error) {
+	if inst.port == 0 {
+		return nil, nil
+	}
+	// The sockets must not be inherited by the sandbox (the guest side is passed as stdin),
+	// otherwise the sandbox won't see EOF once the host side is closed.
+	syscall.ForkLock.RLock()
+	socks, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err == nil {
+		syscall.CloseOnExec(socks[0])
+		syscall.CloseOnExec(socks[1])
+	}
+	syscall.ForkLock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	// Make the host side pollable, otherwise Close does not unblock the pending Read below,
+	// and the sandbox does not see EOF when the manager closes the connection.
+	if err := syscall.SetNonblock(socks[0], true); err != nil {
+		syscall.Close(socks[0])
+		syscall.Close(socks[1])
+		return nil, err
+	}
+	hostSock := os.NewFile(uintptr(socks[0]), "host unix proxy")
+	guestSock := os.NewFile(uintptr(socks[1]), "guest unix proxy")
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%v", inst.port))
+	if err != nil {
+		hostSock.Close()
+		guestSock.Close()
+		return nil, err
+	}
+	go func() {
+		io.Copy(hostSock, conn)
+		hostSock.Close()
+	}()
+	go func() {
+		io.Copy(conn, hostSock)
+		conn.Close()
+	}()
+	return guestSock, nil
+}
+
+func (inst *instance) Diagnose(rep *report.Report) ([]byte, bool) {
+	return nil, false
+}
@@ -0,0 +1,38 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+package hostns
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+
+	"github.com/google/syzkaller/pkg/osutil"
+)
+
+// command returns the command that runs args in the sandbox.
+// The command becomes the init process of the new pid namespace, so once it exits or is killed,
+// all other processes in the sandbox are killed as well.
+func (inst *instance) command(args []string) (*exec.Cmd, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+	cmd := osutil.Command(args[0], args[1:]...)
+	cmd.Dir = inst.workdir
+	attr := cmd.SysProcAttr
+	// A new mount namespace owned by a new user namespace does not propagate mounts back to the host.
+	attr.Cloneflags = syscall.CLONE_NEWUSER | syscall.CLONE_NEWPID | syscall.CLONE_NEWNET |
+		syscall.CLONE_NEWNS | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS
+	// The programs run as root in the user namespace that is mapped to the current user,
+	// so they don't get any privileges on the host.
+	attr.UidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getuid(), Size: 1}}
+	attr.GidMappings = []syscall.SysProcIDMap{{ContainerID: 0, HostID: os.Getgid(), Size: 1}}
+	attr.GidMappingsEnableSetgroups = false
+	if inst.cgroupFD != -1 {
+		attr.UseCgroupFD = true
+		attr.CgroupFD = inst.cgroupFD
+	}
+	return cmd, nil
+}
@@ -0,0 +1,15 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build !linux
+
+package hostns
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+func (inst *instance) command(args []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("hostns is supported only on linux")
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 syzkaller project authors. All rights reserved.
+// Use of this source code is governed by Apache 2 LICENSE that can be found in the LICENSE file.
+
+//go:build linux
+
+package hostns
+
+import (
+	"bytes"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/syzkaller/vm/vmimpl"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	vmimpl.WaitForOutputTimeout = 100 * time.Millisecond
+}
+
+func TestRun(t *testing.T) {
+	inst := testInstance(t)
+	// Exit status of successful commands is racy: the command may be killed once its output is closed.
+	output, _ := runCommand(inst, "id -u")
+	assert.Equal(t, "0\n", string(output))
+	// There is only the loopback interface in the new network namespace.
+	output, _ = runCommand(inst, "cat /proc/self/net/dev")
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	assert.Len(t, lines, 3)
+	assert.Contains(t, lines[2], "lo:")
+	_, err := runCommand(inst, "false")
+	assert.Error(t, err)
+	_, err = runCommand(inst, "sleep 1000")
+	assert.Equal(t, vmimpl.ErrTimeout, err)
+}
+
+func TestForward(t *testing.T) {
+	inst := testInstance(t)
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		conn.Write([]byte("hello from manager\n"))
+		conn.Close()
+	}()
+	addr, err := inst.Forward(ln.Addr().(*net.TCPAddr).Port)
+	assert.NoError(t, err)
+	assert.Equal(t, "stdin:0", addr)
+	// cat reads from the connection to the manager.
+	output, _ := runCommand(inst, "cat")
+	assert.Equal(t, "hello from manager\n", string(output))
+}
+
+func testInstance(t *testing.T) vmimpl.Instance {
+	pool, err := ctor(&vmimpl.Env{
+		Name:   "test",
+		OS:     "linux",
+		Config: []byte(`{"count": 1}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inst, err := pool.Create(t.TempDir(), 0)
+	if err != nil {
+		t.Skipf("namespaces are not supported: %v", err)
+	}
+	t.Cleanup(func() { inst.Close() })
+	return inst
+}
+
+func runCommand(inst vmimpl.Instance, command string) ([]byte, error) {
+	outc, errc, err := inst.Run(3*time.Second, nil, command)
+	if err != nil {
+		return nil, err
+	}
+	output := new(bytes.Buffer)
+	for {
+		select {
+		case out := <-outc:
+			output.Write(out)
+		case err := <-errc:
+			// Drain the remaining output.
+			for {
+				select {
+				case out := <-outc:
+					output.Write(out)
+				case <-time.After(100 * time.Millisecond):
+					return output.Bytes(), err
+				}
+			}
+		}
+	}
+}
@@ -53,6 +53,7 @@ type instance struct {
 	index       int
 	closed      chan bool
 	debug       bool
+	consoleLog  vmimpl.ConsoleLog
 	sshUser     string
 	sshKey      string
 	forwardPort int
@@ -104,6 +105,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		index:      index,
 		closed:     make(chan bool),
 		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
 		sshUser:    pool.env.SSHUser,
 		sshKey:     pool.env.SSHKey,
 		timeouts:   pool.env.Timeouts,
@@ -353,6 +355,7 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		tee = os.Stdout
 	}
 	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(inst.consoleLog)
 	merger.Add("dmesg", dmesg)
 	merger.Add("ssh", rpipe)
 
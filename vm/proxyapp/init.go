@@ -31,6 +31,8 @@ func init() {
 		Ctor: func(env *vmimpl.Env) (vmimpl.Pool, error) {
 			return ctor(makeDefaultParams(), env)
 		},
+		// Plugins may take instances away (e.g. syz-vmbroker preempts leases of low priority managers).
+		Preemptible: true,
 	})
 }
 
@@ -163,7 +163,7 @@ func (p *pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		return nil, fmt.Errorf("can't create instance using nil pool")
 	}
 
-	return proxy.CreateInstance(workdir, p.env.Image, index)
+	return proxy.CreateInstance(workdir, p.env.Image, index, p.env.ConsoleLog(index))
 }
 
 // Close is not used now. Its support require wide code changes.
@@ -371,7 +371,8 @@ func (proxy *ProxyApp) CreatePool(config *Config, image string, debug bool) (int
 	return reply.Count, nil
 }
 
-func (proxy *ProxyApp) CreateInstance(workdir, image string, index int) (vmimpl.Instance, error) {
+func (proxy *ProxyApp) CreateInstance(workdir, image string, index int,
+	consoleLog vmimpl.ConsoleLog) (vmimpl.Instance, error) {
 	var reply proxyrpc.CreateInstanceResult
 
 	params := proxyrpc.CreateInstanceParams{
@@ -412,14 +413,16 @@ func (proxy *ProxyApp) CreateInstance(workdir, image string, index int) (vmimpl.
 	}
 
 	return &instance{
-		ProxyApp: proxy,
-		ID:       reply.ID,
+		ProxyApp:   proxy,
+		ID:         reply.ID,
+		consoleLog: consoleLog,
 	}, nil
 }
 
 type instance struct {
 	*ProxyApp
-	ID string
+	ID         string
+	consoleLog vmimpl.ConsoleLog
 }
 
 // Copy copies a hostSrc file into VM and returns file name in VM.
@@ -466,9 +469,10 @@ func (inst *instance) Forward(port int) (string, error) {
 	return reply.ManagerAddress, nil
 }
 
-func buildMerger(names ...string) (*vmimpl.OutputMerger, []io.Writer) {
+func buildMerger(consoleLog vmimpl.ConsoleLog, names ...string) (*vmimpl.OutputMerger, []io.Writer) {
 	var wPipes []io.Writer
 	merger := vmimpl.NewOutputMerger(nil)
+	merger.SetConsoleLog(consoleLog)
 	for _, name := range names {
 		rpipe, wpipe := io.Pipe()
 		wPipes = append(wPipes, wpipe)
@@ -482,7 +486,7 @@ func (inst *instance) Run(
 	stop <-chan bool,
 	command string,
 ) (<-chan []byte, <-chan error, error) {
-	merger, wPipes := buildMerger("stdout", "stderr", "console")
+	merger, wPipes := buildMerger(inst.consoleLog, "stdout", "stderr", "console")
 	receivedStdoutChunks := wPipes[0]
 	receivedStderrChunks := wPipes[1]
 	receivedConsoleChunks := wPipes[2]
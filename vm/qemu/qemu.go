@@ -95,6 +95,7 @@ type instance struct {
 	args        []string
 	image       string
 	debug       bool
+	consoleLog  vmimpl.ConsoleLog
 	os          string
 	workdir     string
 	sshkey      string
@@ -366,6 +367,7 @@ func (pool *Pool) ctor(workdir, sshkey, sshuser string, index int) (*instance, e
 		version:    pool.version,
 		image:      pool.env.Image,
 		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
 		os:         pool.env.OS,
 		timeouts:   pool.env.Timeouts,
 		workdir:    workdir,
@@ -453,6 +455,7 @@ func (inst *instance) boot() error {
 		tee = os.Stdout
 	}
 	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.SetConsoleLog(inst.consoleLog)
 	inst.merger.Add("qemu", inst.rpipe)
 	inst.rpipe = nil
 
@@ -737,6 +740,19 @@ func (inst *instance) Diagnose(rep *report.Report) ([]byte, bool) {
 	return ret, false
 }
 
+func (inst *instance) DumpMemory(file string) error {
+	// With paging the dump contains guest virtual addresses,
+	// which makes it possible to read kernel data structures using only vmlinux.
+	_, err := inst.qmp(&qmpCommand{
+		Execute: "dump-guest-memory",
+		Arguments: map[string]interface{}{
+			"paging":   true,
+			"protocol": "file:" + file,
+		},
+	})
+	return err
+}
+
 func (inst *instance) ssh(args ...string) ([]byte, error) {
 	return osutil.RunCmd(time.Minute*inst.timeouts.Scale, "", "ssh", inst.sshArgs(args...)...)
 }
@@ -25,6 +25,7 @@ type snapshot struct {
 	shmemFD     int
 	shmem       []byte
 	input       []byte
+	output      []byte
 	header      *flatrpc.SnapshotHeaderT
 }
 
@@ -82,7 +83,8 @@ func (inst *instance) snapshotEnable() ([]string, error) {
 	}
 	inst.shmem = shmem
 	inst.input = shmem[:flatrpc.ConstMaxInputSize:flatrpc.ConstMaxInputSize]
-	inst.header = (*flatrpc.SnapshotHeaderT)(unsafe.Pointer(&shmem[flatrpc.ConstMaxInputSize]))
+	inst.output = shmem[flatrpc.ConstMaxInputSize:]
+	inst.header = (*flatrpc.SnapshotHeaderT)(unsafe.Pointer(&inst.output[0]))
 	shmemFile := fmt.Sprintf("/proc/%v/fd/%v", syscall.Getpid(), shmemFD)
 
 	doorbellFD, err := unix.MemfdCreate("syz-qemu-doorbell", 0)
@@ -182,23 +184,39 @@ func (inst *instance) SetupSnapshot(input []byte) error {
 	return nil
 }
 
-func (inst *instance) RunSnapshot(timeout time.Duration, input []byte) (result, output []byte, err error) {
-	copy(inst.input, input)
-	inst.header.OutputOffset = 0
-	inst.header.OutputSize = 0
+func (inst *instance) RunSnapshot(timeout time.Duration, inputs [][]byte) (results [][]byte, output []byte, err error) {
+	procs := len(inputs)
+	headers := make([]*flatrpc.SnapshotHeaderT, procs)
+	for proc, input := range inputs {
+		offset, size := flatrpc.SnapshotInputSlot(procs, proc)
+		if len(input) > size {
+			return nil, nil, fmt.Errorf("input %v is too large: %v > %v", proc, len(input), size)
+		}
+		copy(inst.input[offset:offset+size], input)
+		offset, _ = flatrpc.SnapshotOutputArea(procs, proc)
+		hdr := (*flatrpc.SnapshotHeaderT)(unsafe.Pointer(&inst.output[offset]))
+		hdr.OutputOffset = 0
+		hdr.OutputSize = 0
+		hdr.UpdateState(flatrpc.SnapshotStateExecute)
+		headers[proc] = hdr
+	}
 	inst.header.UpdateState(flatrpc.SnapshotStateExecute)
 	if _, err := inst.hmp("loadvm syz", 0); err != nil {
 		return nil, nil, fmt.Errorf("%w\n%s", err, inst.readOutput())
 	}
 	inst.waitSnapshotStateChange(flatrpc.SnapshotStateExecute, timeout)
-	resStart := int(flatrpc.ConstMaxInputSize) + int(atomic.LoadUint32(&inst.header.OutputOffset))
-	resEnd := resStart + int(atomic.LoadUint32(&inst.header.OutputSize))
-	var res []byte
-	if resEnd <= len(inst.shmem) {
-		res = inst.shmem[resStart:resEnd:resEnd]
+	results = make([][]byte, procs)
+	for proc, hdr := range headers {
+		areaStart, areaSize := flatrpc.SnapshotOutputArea(procs, proc)
+		resStart := int(atomic.LoadUint32(&hdr.OutputOffset))
+		resEnd := resStart + int(atomic.LoadUint32(&hdr.OutputSize))
+		if hdr.LoadState() != flatrpc.SnapshotStateExecute &&
+			resStart >= areaStart && resEnd <= areaStart+areaSize {
+			results[proc] = inst.output[resStart:resEnd:resEnd]
+		}
 	}
 	output = inst.readOutput()
-	return res, output, nil
+	return results, output, nil
 }
 
 func (inst *instance) waitSnapshotStateChange(state flatrpc.SnapshotState, timeout time.Duration) bool {
@@ -7,6 +7,7 @@ package qemu
 
 import (
 	"fmt"
+	"time"
 )
 
 type snapshot struct{}
@@ -28,6 +29,6 @@ func (inst *instance) SetupSnapshot(input []byte) error {
 	return errNotImplemented
 }
 
-func (inst *instance) RunSnapshot(input []byte) (result, output []byte, err error) {
+func (inst *instance) RunSnapshot(timeout time.Duration, inputs [][]byte) (results [][]byte, output []byte, err error) {
 	return nil, nil, errNotImplemented
 }
@@ -52,6 +52,7 @@ type instance struct {
 	cfg         *Config
 	version     string
 	debug       bool
+	consoleLog  vmimpl.ConsoleLog
 	workdir     string
 	port        int
 	forwardPort int
@@ -105,6 +106,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		index:      index,
 		cfg:        pool.cfg,
 		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
 		workdir:    workdir,
 		timeouts:   pool.env.Timeouts,
 	}
@@ -184,6 +186,7 @@ func (inst *instance) boot() error {
 		tee = os.Stdout
 	}
 	inst.merger = vmimpl.NewOutputMerger(tee)
+	inst.merger.SetConsoleLog(inst.consoleLog)
 
 	inst.runFfx(5*time.Minute, "emu", "stop", inst.name)
 
@@ -36,6 +36,7 @@ import (
 	_ "github.com/google/syzkaller/vm/cuttlefish"
 	_ "github.com/google/syzkaller/vm/gce"
 	_ "github.com/google/syzkaller/vm/gvisor"
+	_ "github.com/google/syzkaller/vm/hostns"
 	_ "github.com/google/syzkaller/vm/isolated"
 	_ "github.com/google/syzkaller/vm/proxyapp"
 	_ "github.com/google/syzkaller/vm/qemu"
@@ -54,6 +55,7 @@ type Pool struct {
 	activeCount        int32
 	snapshot           bool
 	hostFuzzer         bool
+	consoleLogs        *consoleLogs
 	statOutputReceived *stat.Val
 }
 
@@ -63,6 +65,7 @@ type Instance struct {
 	workdir       string
 	index         int
 	snapshotSetup bool
+	snapshotProcs int
 	onClose       func()
 }
 
@@ -130,6 +133,10 @@ func Create(cfg *mgrconfig.Config, debug bool) (*Pool, error) {
 		Config:    cfg.VM,
 		KernelSrc: cfg.KernelSrc,
 	}
+	consoleLogs := newConsoleLogs(cfg)
+	if consoleLogs != nil {
+		env.ConsoleLogs = consoleLogs.get
+	}
 	impl, err := typ.Ctor(env)
 	if err != nil {
 		return nil, err
@@ -140,14 +147,15 @@ func Create(cfg *mgrconfig.Config, debug bool) (*Pool, error) {
 		count = 1
 	}
 	return &Pool{
-		impl:       impl,
-		typ:        typ,
-		workdir:    env.Workdir,
-		template:   cfg.WorkdirTemplate,
-		timeouts:   cfg.Timeouts,
-		count:      count,
-		snapshot:   cfg.Snapshot,
-		hostFuzzer: cfg.SysTarget.HostFuzzer,
+		impl:        impl,
+		typ:         typ,
+		workdir:     env.Workdir,
+		template:    cfg.WorkdirTemplate,
+		timeouts:    cfg.Timeouts,
+		count:       count,
+		snapshot:    cfg.Snapshot,
+		hostFuzzer:  cfg.SysTarget.HostFuzzer,
+		consoleLogs: consoleLogs,
 		statOutputReceived: stat.New("vm output", "Bytes of VM console output received",
 			stat.Graph("traffic"), stat.Rate{}, stat.FormatMB),
 	}, nil
@@ -192,6 +200,7 @@ func (pool *Pool) Close() error {
 	if pool.activeCount != 0 {
 		panic("all the instances should be closed before pool.Close()")
 	}
+	defer pool.consoleLogs.close()
 	if closer, ok := pool.impl.(io.Closer); ok {
 		return closer.Close()
 	}
@@ -200,7 +209,8 @@ func (pool *Pool) Close() error {
 
 // SetupSnapshot must be called once before calling RunSnapshot.
 // Input is copied into the VM in an implementation defined way and is interpreted by executor.
-func (inst *Instance) SetupSnapshot(input []byte) error {
+// Procs is the number of inputs executed concurrently on each RunSnapshot call.
+func (inst *Instance) SetupSnapshot(procs int, input []byte) error {
 	impl, ok := inst.impl.(snapshotter)
 	if !ok {
 		return errors.New("this VM type does not support snapshot mode")
@@ -209,14 +219,15 @@ func (inst *Instance) SetupSnapshot(input []byte) error {
 		return fmt.Errorf("SetupSnapshot called twice")
 	}
 	inst.snapshotSetup = true
+	inst.snapshotProcs = procs
 	return impl.SetupSnapshot(input)
 }
 
-// RunSnapshot runs one input in snapshotting mode.
-// Input is copied into the VM in an implementation defined way and is interpreted by executor.
-// Result is the result provided by the executor.
-// Output is the kernel console output during execution of the input.
-func (inst *Instance) RunSnapshot(input []byte) (result, output []byte, err error) {
+// RunSnapshot runs inputs concurrently (one per proc) in snapshotting mode.
+// Inputs are copied into the VM in an implementation defined way and are interpreted by executor.
+// Results are the results provided by the executor for each input.
+// Output is the kernel console output during execution of the inputs.
+func (inst *Instance) RunSnapshot(inputs [][]byte) (results [][]byte, output []byte, err error) {
 	impl, ok := inst.impl.(snapshotter)
 	if !ok {
 		return nil, nil, errors.New("this VM type does not support snapshot mode")
@@ -224,14 +235,17 @@ func (inst *Instance) RunSnapshot(input []byte) (result, output []byte, err erro
 	if !inst.snapshotSetup {
 		return nil, nil, fmt.Errorf("RunSnapshot without SetupSnapshot")
 	}
+	if len(inputs) != inst.snapshotProcs {
+		return nil, nil, fmt.Errorf("RunSnapshot with %v inputs, but %v procs", len(inputs), inst.snapshotProcs)
+	}
 	// Executor has own timeout logic, so use a slightly larger timeout here.
 	timeout := inst.pool.timeouts.Program / 5 * 7
-	return impl.RunSnapshot(timeout, input)
+	return impl.RunSnapshot(timeout, inputs)
 }
 
 type snapshotter interface {
 	SetupSnapshot([]byte) error
-	RunSnapshot(time.Duration, []byte) ([]byte, []byte, error)
+	RunSnapshot(time.Duration, [][]byte) ([][]byte, []byte, error)
 }
 
 func (inst *Instance) Copy(hostSrc string) (string, error) {
@@ -254,6 +268,7 @@ const (
 )
 
 type StopContext context.Context
+type DumpMemory string
 type InjectExecuting <-chan bool
 type OutputSize int
 
@@ -267,12 +282,15 @@ type EarlyFinishCb func()
 //   - StopContext: the context to be used to prematurely stop the command
 //   - ExitCondition: says which exit modes should be considered as errors/OK
 //   - OutputSize: how much output to keep/return
+//   - DumpMemory: the file to dump the guest memory to when a crash is detected
+//     (if supported by the VM type, see vmimpl.MemoryDumper)
 func (inst *Instance) Run(timeout time.Duration, reporter *report.Reporter, command string, opts ...any) (
 	[]byte, *report.Report, error) {
 	exit := ExitNormal
 	var stop <-chan bool
 	var injected <-chan bool
 	var finished func()
+	var dumpFile string
 	outputSize := beforeContextDefault
 	for _, o := range opts {
 		switch opt := o.(type) {
@@ -291,6 +309,8 @@ func (inst *Instance) Run(timeout time.Duration, reporter *report.Reporter, comm
 			injected = (<-chan bool)(opt)
 		case EarlyFinishCb:
 			finished = opt
+		case DumpMemory:
+			dumpFile = string(opt)
 		default:
 			panic(fmt.Sprintf("unknown option %#v", opt))
 		}
@@ -308,6 +328,7 @@ func (inst *Instance) Run(timeout time.Duration, reporter *report.Reporter, comm
 		reporter:        reporter,
 		beforeContext:   outputSize,
 		exit:            exit,
+		dumpFile:        dumpFile,
 		lastExecuteTime: time.Now(),
 	}
 	rep := mon.monitorExecution()
@@ -332,6 +353,12 @@ func (inst *Instance) Index() int {
 	return inst.index
 }
 
+// ConsoleLogOffset returns the current offset in the console log of the VM (see pkg/consolelog),
+// or 0 if console logs are not enabled.
+func (inst *Instance) ConsoleLogOffset() int64 {
+	return inst.pool.consoleLogs.open(inst.index).Offset()
+}
+
 func (inst *Instance) Close() error {
 	err := inst.impl.Close()
 	if retErr := os.RemoveAll(inst.workdir); err == nil {
@@ -357,6 +384,7 @@ type monitor struct {
 	exit            ExitCondition
 	output          []byte
 	beforeContext   int
+	dumpFile        string
 	matchPos        int
 	lastExecuteTime time.Time
 	extractCalled   bool
@@ -461,6 +489,8 @@ func (mon *monitor) extractError(defaultError string) *report.Report {
 	}
 	diagOutput, diagWait := []byte{}, false
 	if defaultError != "" {
+		// Dump memory before diagnose, which may change the kernel state.
+		mon.dumpMemory()
 		diagOutput, diagWait = mon.inst.diagnose(mon.createReport(defaultError))
 	}
 	// Give it some time to finish writing the error message.
@@ -475,6 +505,7 @@ func (mon *monitor) extractError(defaultError string) *report.Report {
 	}
 	if defaultError == "" && mon.reporter.ContainsCrash(mon.output[mon.matchPos:]) {
 		// We did not call Diagnose above because we thought there is no error, so call it now.
+		mon.dumpMemory()
 		diagOutput, diagWait = mon.inst.diagnose(mon.createReport(defaultError))
 		if diagWait {
 			mon.waitForOutput()
@@ -491,8 +522,22 @@ func (mon *monitor) extractError(defaultError string) *report.Report {
 	return rep
 }
 
+func (mon *monitor) dumpMemory() {
+	dumper, ok := mon.inst.impl.(vmimpl.MemoryDumper)
+	if mon.dumpFile == "" || !ok {
+		return
+	}
+	start := time.Now()
+	if err := dumper.DumpMemory(mon.dumpFile); err != nil {
+		log.Logf(0, "VM-%v: failed to dump memory: %v", mon.inst.index, err)
+		os.Remove(mon.dumpFile)
+		return
+	}
+	log.Logf(1, "VM-%v: dumped memory in %v", mon.inst.index, time.Since(start))
+}
+
 func (mon *monitor) createReport(defaultError string) *report.Report {
-	rep := mon.reporter.ParseFrom(mon.output, mon.matchPos)
+	rep := mon.reporter.ParseChainFrom(mon.output, mon.matchPos)
 	if rep == nil {
 		if defaultError == "" {
 			return nil
@@ -513,6 +558,18 @@ func (mon *monitor) createReport(defaultError string) *report.Report {
 	rep.Output = rep.Output[start:end]
 	rep.StartPos -= start
 	rep.EndPos -= start
+	// Secondary reports that don't fit into the saved output are too far to be related.
+	var secondary []*report.Report
+	for _, rep1 := range rep.Secondary {
+		if rep1.EndPos > end {
+			break
+		}
+		rep1.Output = rep.Output
+		rep1.StartPos -= start
+		rep1.EndPos -= start
+		secondary = append(secondary, rep1)
+	}
+	rep.Secondary = secondary
 	return rep
 }
 
@@ -6,6 +6,7 @@ package vm
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"testing"
 	"time"
 
@@ -96,6 +97,7 @@ type Test struct {
 	Body           func(outc chan []byte, errc chan error)
 	BodyExecuting  func(outc chan []byte, errc chan error, inject chan<- bool)
 	Report         *report.Report
+	Secondary      []string // titles of rep.Secondary
 }
 
 // nolint: goconst // "DIAGNOSE\n", "BUG: bad\n" and "other output\n"
@@ -195,6 +197,23 @@ var tests = []*Test{
 			),
 		},
 	},
+	{
+		Name: "kernel-crashes-twice",
+		Body: func(outc chan []byte, errc chan error) {
+			outc <- []byte("BUG: bad\n")
+			time.Sleep(time.Second)
+			outc <- []byte("WARNING: worse\n")
+		},
+		Report: &report.Report{
+			Title: "BUG: bad",
+			Report: []byte(
+				"BUG: bad\n" +
+					"DIAGNOSE\n" +
+					"WARNING: worse\n",
+			),
+		},
+		Secondary: []string{"WARNING: worse"},
+	},
 	{
 		Name: "fuzzer-is-preempted",
 		Body: func(outc chan []byte, errc chan error) {
@@ -425,6 +444,13 @@ func testMonitorExecution(t *testing.T, test *Test) {
 	if test.Report.Type != rep.Type {
 		t.Fatalf("want type %q, got type %q", test.Report.Type, rep.Type)
 	}
+	var secondary []string
+	for _, rep1 := range rep.Secondary {
+		secondary = append(secondary, rep1.Title)
+	}
+	if !reflect.DeepEqual(test.Secondary, secondary) {
+		t.Fatalf("want secondary reports %q, got %q", test.Secondary, secondary)
+	}
 }
 
 func TestVMType(t *testing.T) {
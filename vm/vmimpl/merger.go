@@ -15,9 +15,16 @@ type OutputMerger struct {
 	Err    chan error
 	teeMu  sync.Mutex
 	tee    io.Writer
+	log    ConsoleLog
 	wg     sync.WaitGroup
 }
 
+// ConsoleLog persistently stores output of a VM (see pkg/consolelog).
+type ConsoleLog interface {
+	// Append stores data received from the named source.
+	Append(source string, data []byte)
+}
+
 type MergerError struct {
 	Name string
 	R    io.ReadCloser
@@ -36,6 +43,12 @@ func NewOutputMerger(tee io.Writer) *OutputMerger {
 	}
 }
 
+// SetConsoleLog makes the merger store all complete lines in the log.
+// Must be called before adding any sources.
+func (merger *OutputMerger) SetConsoleLog(log ConsoleLog) {
+	merger.log = log
+}
+
 func (merger *OutputMerger) Wait() {
 	merger.wg.Wait()
 	close(merger.Output)
@@ -50,6 +63,7 @@ func (merger *OutputMerger) AddDecoder(name string, r io.ReadCloser,
 	merger.wg.Add(1)
 	go func() {
 		var pending []byte
+		var logged int // prefix of pending that is already stored in the console log
 		var proto []byte
 		var buf [4 << 10]byte
 		for {
@@ -76,10 +90,15 @@ func (merger *OutputMerger) AddDecoder(name string, r io.ReadCloser,
 						merger.tee.Write(out)
 						merger.teeMu.Unlock()
 					}
+					if merger.log != nil {
+						merger.log.Append(name, pending[logged:pos+1])
+						logged = pos + 1
+					}
 					select {
 					case merger.Output <- append([]byte{}, out...):
 						r := copy(pending, pending[pos+1:])
 						pending = pending[:r]
+						logged = 0
 					default:
 					}
 				}
@@ -92,6 +111,9 @@ func (merger *OutputMerger) AddDecoder(name string, r io.ReadCloser,
 						merger.tee.Write(pending)
 						merger.teeMu.Unlock()
 					}
+					if merger.log != nil {
+						merger.log.Append(name, pending[logged:])
+					}
 					select {
 					case merger.Output <- pending:
 					default:
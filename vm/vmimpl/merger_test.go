@@ -6,7 +6,9 @@ package vmimpl
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
 	"time"
 
@@ -82,3 +84,40 @@ func TestMerger(t *testing.T) {
 		t.Fatalf("bad tee: '%s', want '%s'", got, want)
 	}
 }
+
+type testConsoleLog struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *testConsoleLog) Append(source string, data []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, fmt.Sprintf("%v: %s", source, data))
+}
+
+func TestMergerConsoleLog(t *testing.T) {
+	consoleLog := new(testConsoleLog)
+	merger := NewOutputMerger(nil)
+	merger.SetConsoleLog(consoleLog)
+	rp, wp, err := osutil.LongPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	merger.Add("pipe", rp)
+	// Fill the output channel, so that the merger keeps the pending output.
+	for i := 0; i < cap(merger.Output); i++ {
+		merger.Output <- nil
+	}
+	wp.Write([]byte("111\n222"))
+	time.Sleep(10 * time.Millisecond)
+	wp.Write([]byte("333\n444"))
+	wp.Close()
+	<-merger.Err
+	merger.Wait()
+	// Each line is stored once even though the merger retries sending it.
+	want := []string{"pipe: 111\n", "pipe: 222333\n", "pipe: 444\n"}
+	if got := consoleLog.lines; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("bad console log: %q, want %q", got, want)
+	}
+}
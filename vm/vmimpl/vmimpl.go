@@ -69,6 +69,12 @@ type Infoer interface {
 	Info() ([]byte, error)
 }
 
+// MemoryDumper is an optional interface that can be implemented by Instance.
+type MemoryDumper interface {
+	// DumpMemory writes the guest memory to the file in the ELF core (vmcore) format.
+	DumpMemory(file string) error
+}
+
 // Env contains global constant parameters for a pool of VMs.
 type Env struct {
 	// Unique name
@@ -85,6 +91,17 @@ type Env struct {
 	Debug     bool
 	Config    []byte // json-serialized VM-type-specific config
 	KernelSrc string
+	// ConsoleLogs returns the persistent console log for the VM with the given index.
+	// May be nil if console logs are not enabled.
+	ConsoleLogs func(index int) ConsoleLog
+}
+
+// ConsoleLog returns the console log for the VM index, or nil if console logs are not enabled.
+func (env *Env) ConsoleLog(index int) ConsoleLog {
+	if env.ConsoleLogs == nil {
+		return nil
+	}
+	return env.ConsoleLogs(index)
 }
 
 // BootError is returned by Pool.Create when VM does not boot.
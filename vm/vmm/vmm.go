@@ -111,6 +111,7 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 		vmName:  fmt.Sprintf("%v-%v", pool.env.Name, index),
 		merger:  vmimpl.NewOutputMerger(tee),
 	}
+	inst.merger.SetConsoleLog(pool.env.ConsoleLog(index))
 
 	// Stop the instance from the previous run in case it's still running.
 	// This is racy even with -w flag, start periodically fails with:
@@ -45,6 +45,7 @@ type instance struct {
 	ipAddr      string
 	closed      chan bool
 	debug       bool
+	consoleLog  vmimpl.ConsoleLog
 	sshuser     string
 	sshkey      string
 	forwardPort int
@@ -82,14 +83,15 @@ func (pool *Pool) Create(workdir string, index int) (vmimpl.Instance, error) {
 	sshkey := pool.env.SSHKey
 	sshuser := pool.env.SSHUser
 	inst := &instance{
-		cfg:      pool.cfg,
-		debug:    pool.env.Debug,
-		baseVMX:  pool.cfg.BaseVMX,
-		vmx:      vmx,
-		sshkey:   sshkey,
-		sshuser:  sshuser,
-		closed:   make(chan bool),
-		timeouts: pool.env.Timeouts,
+		cfg:        pool.cfg,
+		debug:      pool.env.Debug,
+		consoleLog: pool.env.ConsoleLog(index),
+		baseVMX:    pool.cfg.BaseVMX,
+		vmx:        vmx,
+		sshkey:     sshkey,
+		sshuser:    sshuser,
+		closed:     make(chan bool),
+		timeouts:   pool.env.Timeouts,
 	}
 	if err := inst.clone(); err != nil {
 		return nil, err
@@ -214,6 +216,7 @@ func (inst *instance) Run(timeout time.Duration, stop <-chan bool, command strin
 		tee = os.Stdout
 	}
 	merger := vmimpl.NewOutputMerger(tee)
+	merger.SetConsoleLog(inst.consoleLog)
 	merger.Add("dmesg", dmesg)
 	merger.Add("ssh", rpipe)
 